@@ -8,6 +8,13 @@ type AuthorizationDto struct {
 	Header   string
 	Username string
 	Role     Role
+	Groups   []string
+}
+
+// TokenClaims are the claims of a valid access token
+type TokenClaims struct {
+	Username string
+	Groups   []string
 }
 
 func ExtractAuthorizationDto(ctx context.Context, key interface{}) (AuthorizationDto, error) {
@@ -16,7 +16,7 @@ type Authenticator interface {
 	FetchAndSetKeySet(ctx context.Context) error
 	IsTokenValid(
 		ctx context.Context, tokenString string, requiredGroup Role,
-	) (valid bool, username string, err error)
+	) (valid bool, claims TokenClaims, err error)
 	GetUserAttributes(ctx context.Context, username string) (UserAttributes, error)
 	GetOrSyncUser(
 		ctx context.Context, authorization AuthorizationDto,
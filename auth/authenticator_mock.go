@@ -13,8 +13,8 @@ func (auth *Mock) FetchAndSetKeySet(_ context.Context) error {
 }
 func (auth *Mock) IsTokenValid(
 	_ context.Context, _ string, _ Role,
-) (valid bool, username string, err error) {
-	return false, "", err
+) (valid bool, claims TokenClaims, err error) {
+	return false, TokenClaims{}, err
 }
 
 func (auth *Mock) GetUserAttributes(
@@ -80,7 +80,7 @@ func (authService *AuthService) FetchAndSetKeySet(ctx context.Context) error {
 // https://docs.aws.amazon.com/cognito/latest/developerguide/amazon-cognito-user-pools-using-tokens-verifying-a-jwt.html#amazon-cognito-user-pools-using-tokens-step-2
 func (authService *AuthService) IsTokenValid(
 	ctx context.Context, tokenString string, requiredGroup auth.Role,
-) (valid bool, username string, err error) {
+) (valid bool, claims auth.TokenClaims, err error) {
 	if authService.cachedKeySet == nil {
 		err = authService.FetchAndSetKeySet(ctx)
 		if err != nil {
@@ -110,7 +110,10 @@ func (authService *AuthService) IsTokenValid(
 	cognitoGroups := token.Claims.(jwt.MapClaims)["cognito:groups"]
 	isValidIssuer := token.Claims.(jwt.MapClaims).VerifyIssuer(authService.CognitoPoolUrl, true)
 	isAccessToken := token.Claims.(jwt.MapClaims)["token_use"] == "access"
-	username = token.Claims.(jwt.MapClaims)["username"].(string)
+	claims = auth.TokenClaims{
+		Username: token.Claims.(jwt.MapClaims)["username"].(string),
+		Groups:   auth.GroupsFromClaim(cognitoGroups),
+	}
 
 	isTokenValid := isValidIssuer && isAccessToken
 
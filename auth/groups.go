@@ -19,3 +19,30 @@ func IsInRequiredGroup(cognitoGroups interface{}, requiredGroup Role) bool {
 		return false
 	}
 }
+
+// GroupsFromClaim returns the names of the groups in the cognito:groups claim of a token
+func GroupsFromClaim(cognitoGroups interface{}) []string {
+	groups := []string{}
+	claimed, ok := cognitoGroups.([]interface{})
+	if !ok {
+		return groups
+	}
+	for _, group := range claimed {
+		if name, isString := group.(string); isString {
+			groups = append(groups, name)
+		}
+	}
+
+	return groups
+}
+
+// RoleFromGroups returns the highest role granted by the groups
+func RoleFromGroups(groups []string) Role {
+	for _, group := range groups {
+		if group == string(RoleAdmin) {
+			return RoleAdmin
+		}
+	}
+
+	return RoleNone
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"api"
+	"api/logger"
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// main computes the perceptual hashes of the images that don't have one yet, from their stored originals
+func main() {
+	log := logger.NewLogger()
+	app, err := api.InitializeApp(log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed initializing app")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err = app.ConnectStorage(ctx); err != nil {
+		log.Fatal().Err(err).Msg("failed connecting app")
+	}
+	defer app.CloseStorage()
+
+	count, err := app.HashBackfill.Run(ctx)
+	if err != nil {
+		log.Error().Err(err).Int("images", count).Msg("backfilling perceptual hashes stopped")
+		return
+	}
+	log.Info().Int("images", count).Msg("backfilled perceptual hashes")
+}
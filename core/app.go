@@ -2,16 +2,31 @@ package core
 
 import (
 	"api/auth"
+	"api/objectstore"
 	"api/storage"
 	"context"
 	"fmt"
 )
 
 type App struct {
-	Config        Config
-	ImagesService *ImagesService
-	Auth          auth.Authenticator
-	storage       storage.Storage
+	Config         Config
+	ImagesService  *ImagesService
+	TagsService    *TagsService
+	UsersService   *UsersService
+	TrashPurger    *TrashPurger
+	UploadsService *UploadsService
+	UploadPurger   *UploadPurger
+	JobsService    *JobsService
+	JobWorker      *JobWorker
+	Transforms     *TransformsService
+	// PlaceholderBackfill is run by the placeholders command, it isn't started with the api
+	PlaceholderBackfill *PlaceholderBackfill
+	// HashBackfill is run by the hashes command, it isn't started with the api
+	HashBackfill *HashBackfill
+	Auth         auth.Authenticator
+	ObjectStore  objectstore.ObjectStore
+	ObjectSigner *objectstore.Signer
+	storage      storage.Storage
 }
 
 func NewApp(
@@ -19,19 +34,47 @@ func NewApp(
 	storage storage.Storage,
 	auth auth.Authenticator,
 	imagesService *ImagesService,
+	tagsService *TagsService,
+	usersService *UsersService,
+	trashPurger *TrashPurger,
+	uploadsService *UploadsService,
+	uploadPurger *UploadPurger,
+	jobsService *JobsService,
+	jobWorker *JobWorker,
+	transforms *TransformsService,
+	placeholderBackfill *PlaceholderBackfill,
+	hashBackfill *HashBackfill,
+	objectStore objectstore.ObjectStore,
+	objectSigner *objectstore.Signer,
 ) *App {
 	return &App{
-		Config:        config,
-		storage:       storage,
-		Auth:          auth,
-		ImagesService: imagesService,
+		Config:              config,
+		storage:             storage,
+		Auth:                auth,
+		ImagesService:       imagesService,
+		TagsService:         tagsService,
+		UsersService:        usersService,
+		TrashPurger:         trashPurger,
+		UploadsService:      uploadsService,
+		UploadPurger:        uploadPurger,
+		JobsService:         jobsService,
+		JobWorker:           jobWorker,
+		Transforms:          transforms,
+		PlaceholderBackfill: placeholderBackfill,
+		HashBackfill:        hashBackfill,
+		ObjectStore:         objectStore,
+		ObjectSigner:        objectSigner,
 	}
 }
 
 func (a *App) Init(initCtx context.Context, ctx context.Context) error {
-	err := a.storage.Connect(initCtx, a.Config.DatabaseUrl)
+	err := a.ConnectStorage(initCtx)
 	if err != nil {
-		return fmt.Errorf("failed connecting to storage: %w", err)
+		return err
+	}
+	err = a.ImagesService.RecoverPendingOperations(initCtx, a.Config.ImagesApiAuthorization)
+	if err != nil {
+		return fmt.Errorf("failed recovering pending operations: %w", err)
 	}
 	err = a.Auth.FetchAndSetKeySet(initCtx)
 	if err != nil {
@@ -41,11 +84,38 @@ func (a *App) Init(initCtx context.Context, ctx context.Context) error {
 	if !a.Config.SqsPostAuthConsumerDisabled {
 		a.Auth.StartConsumingPostAuthAsync(ctx)
 	}
+	if a.Config.TrashPurgeIntervalSec > 0 {
+		a.TrashPurger.StartPurgingAsync(ctx)
+	}
+	a.UploadPurger.StartPurgingAsync(ctx)
+	a.JobWorker.StartProcessingAsync(ctx)
 
 	return nil
 }
 
+// ConnectStorage connects only the storage, commands use it without starting the background tasks of Init
+func (a *App) ConnectStorage(ctx context.Context) error {
+	if err := a.storage.Connect(ctx, a.Config.DatabaseUrl); err != nil {
+		return fmt.Errorf("failed connecting to storage: %w", err)
+	}
+	return nil
+}
+
+// CloseStorage closes the storage connected by ConnectStorage
+func (a *App) CloseStorage() {
+	a.storage.Close()
+}
+
 func (a *App) Shutdown(_ context.Context) error {
+	if err := a.TrashPurger.Shutdown(); err != nil {
+		return err
+	}
+	if err := a.UploadPurger.Shutdown(); err != nil {
+		return err
+	}
+	if err := a.JobWorker.Shutdown(); err != nil {
+		return err
+	}
 	a.storage.Close()
 	if !a.Config.SqsPostAuthConsumerDisabled {
 		return a.Auth.Shutdown()
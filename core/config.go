@@ -1,11 +1,50 @@
 package core
 
 import (
+	"api/image"
 	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// ResizerKind selects the image.Resizer implementation
+type ResizerKind string
+
+const (
+	// ResizerRemote calls the resize api behind ImagesApiDomain
+	ResizerRemote ResizerKind = "remote"
+	// ResizerLocal resizes in process and keeps the files in the object store
+	ResizerLocal ResizerKind = "local"
+)
+
+// DuplicatePolicy is what happens to uploads that look like an existing image
+type DuplicatePolicy string
+
+const (
+	// DuplicatesOff doesn't look for near duplicates of uploads
+	DuplicatesOff DuplicatePolicy = "off"
+	// DuplicatesWarn logs the near duplicates of uploads and returns them along the created image
+	DuplicatesWarn DuplicatePolicy = "warn"
+	// DuplicatesReject refuses uploads that have near duplicates
+	DuplicatesReject DuplicatePolicy = "reject"
+)
+
+// ObjectStoreKind selects the objectstore.ObjectStore implementation
+type ObjectStoreKind string
+
+const (
+	// ObjectStoreFilesystem keeps objects in ImagesLocalDirectory, signed urls are served by this api
+	ObjectStoreFilesystem ObjectStoreKind = "filesystem"
+	// ObjectStoreS3 keeps objects in S3Bucket, S3Endpoint points to S3 compatible servers like MinIO
+	ObjectStoreS3 ObjectStoreKind = "s3"
+)
+
+// maxUploadIntentExpirationMin is the longest expiry of urls presigned by s3, a week
+const maxUploadIntentExpirationMin = 7 * 24 * 60
+
 type Config struct {
 	AwsUserPoolId               string
 	AwsRegion                   string
@@ -16,6 +55,66 @@ type Config struct {
 	SqsPostAuthUrl              string
 	SqsPostAuthIntervalSec      uint
 	SqsPostAuthConsumerDisabled bool
+	// ImagesApiAuthorization is sent to the images api by background tasks, which have no user authorization
+	ImagesApiAuthorization string
+	TrashRetentionHours    uint
+	// TrashPurgeIntervalSec is 0 when the trash is never purged
+	TrashPurgeIntervalSec uint
+	// UploadExpirationHours is how long a resumable upload is kept after it last received a chunk
+	UploadExpirationHours  uint
+	UploadPurgeIntervalSec uint
+	// UploadIntentExpirationMin is how long the signed urls of an upload intent can be used and completed
+	UploadIntentExpirationMin uint
+	ImagesResizer             ResizerKind
+	ImagesLocalDirectory      string
+	// ImagesLocalDomain is returned as the domain of locally resized images, e.g. a server of the object store
+	ImagesLocalDomain string
+	ObjectStore       ObjectStoreKind
+	// ObjectStoreSigningKey signs urls of the filesystem store, a random key is used when it's empty
+	ObjectStoreSigningKey string
+	// ObjectStorePublicUrl is the url this api is reachable at, signed urls of the filesystem store start with it
+	ObjectStorePublicUrl string
+	S3Bucket             string
+	S3Endpoint           string
+	S3ForcePathStyle     bool
+	// SizeProfiles are the sizes uploaded images are resized to, the v1 breakpoints unless configured
+	SizeProfiles []image.Profile
+	// VariantFormats are the formats variants are produced in besides the format of the image, like webp and avif
+	VariantFormats []image.Format
+	// ImagesApiTimeout bounds each request to the images api, unless ImagesApiTimeouts has one for its endpoint
+	ImagesApiTimeout time.Duration
+	// ImagesApiTimeouts are the timeouts by endpoint of the images api, like resize or upload
+	ImagesApiTimeouts map[string]time.Duration
+	// ImagesApiRetries is how often idempotent requests to the images api are retried
+	ImagesApiRetries uint
+	// JobsWorkers process queued jobs in this instance, none are started with 0
+	JobsWorkers         uint
+	JobsPollIntervalSec uint
+	// JobsLeaseSec is how long a job is processed or waits for a callback before it is claimed again
+	JobsLeaseSec uint
+	// JobsCallbackUrl is the url this api is reachable at by the resize api, which then reports resized jobs to it
+	JobsCallbackUrl string
+	// JobsCallbackSecret verifies the HMAC signature of callbacks of the resize api
+	JobsCallbackSecret string
+	// ImagesTransformSecret signs urls of derivatives in arbitrary sizes, the endpoint is disabled when it's empty
+	ImagesTransformSecret string
+	// ImagesTransformSizes are the sizes derivatives can be requested in, the sizes of the profiles unless configured
+	ImagesTransformSizes []image.Dimensions
+	// ImagesTransformConcurrency bounds how many derivatives are rendered at once, others are rejected
+	ImagesTransformConcurrency uint
+	// ImagesTransformMaxAgeSec is how long clients and CDNs cache derivatives
+	ImagesTransformMaxAgeSec uint
+	// ImagesCdnDomain replaces the domain of images in the urls returned to clients, like a CDN in front of it
+	ImagesCdnDomain string
+	// ImagesUrlTemplate is the path of variants in the returned urls, DefaultUrlTemplate unless configured
+	ImagesUrlTemplate string
+	// ImagesStripMetadata are the fields removed from uploaded files and their metadata, gps and serial numbers
+	// unless configured
+	ImagesStripMetadata []image.MetadataField
+	// ImagesDuplicates applies to uploads whose perceptual hash is within ImagesDuplicateDistance of an image
+	ImagesDuplicates DuplicatePolicy
+	// ImagesDuplicateDistance is the most bits of 64 the hashes of near duplicates differ in
+	ImagesDuplicateDistance int
 }
 
 func NewConfigFromEnv() (Config, error) {
@@ -24,6 +123,15 @@ func NewConfigFromEnv() (Config, error) {
 	return c, err
 }
 
+// UploadFormats are the formats of the uploads the configured resizer can resize
+func (c *Config) UploadFormats() []image.Format {
+	if c.ImagesResizer == ResizerLocal {
+		return image.DecodableFormats
+	}
+
+	return image.SupportedFormats
+}
+
 func (c *Config) LoadFromEnvironment() error {
 
 	c.AwsRegion = os.Getenv("AWS_REGION")
@@ -41,11 +149,235 @@ func (c *Config) LoadFromEnvironment() error {
 		return errors.New("missing env DATABASE_URL")
 	}
 
+	switch resizer := ResizerKind(os.Getenv("IMAGES_RESIZER")); resizer {
+	case "", ResizerRemote:
+		c.ImagesResizer = ResizerRemote
+	case ResizerLocal:
+		c.ImagesResizer = ResizerLocal
+	default:
+		return errors.New("invalid env IMAGES_RESIZER of " + string(resizer))
+	}
+
 	c.ImagesApiDomain = os.Getenv("IMAGES_API_DOMAIN")
-	if c.ImagesApiDomain == "" {
+	if c.ImagesApiDomain == "" && c.ImagesResizer == ResizerRemote {
 		return errors.New("missing env IMAGES_API_DOMAIN")
 	}
 
+	c.ImagesLocalDirectory = os.Getenv("IMAGES_LOCAL_DIRECTORY")
+	if c.ImagesLocalDirectory == "" {
+		c.ImagesLocalDirectory = "data/images"
+	}
+	c.ImagesLocalDomain = os.Getenv("IMAGES_LOCAL_DOMAIN")
+
+	switch store := ObjectStoreKind(os.Getenv("OBJECT_STORE")); store {
+	case "", ObjectStoreFilesystem:
+		c.ObjectStore = ObjectStoreFilesystem
+	case ObjectStoreS3:
+		c.ObjectStore = ObjectStoreS3
+	default:
+		return errors.New("invalid env OBJECT_STORE of " + string(store))
+	}
+
+	c.ObjectStoreSigningKey = os.Getenv("OBJECT_STORE_SIGNING_KEY")
+	c.ObjectStorePublicUrl = os.Getenv("OBJECT_STORE_PUBLIC_URL")
+	if c.ObjectStorePublicUrl == "" {
+		c.ObjectStorePublicUrl = "http://localhost:3000"
+	}
+
+	c.S3Bucket = os.Getenv("S3_BUCKET")
+	if c.S3Bucket == "" && c.ObjectStore == ObjectStoreS3 {
+		return errors.New("missing env S3_BUCKET")
+	}
+	c.S3Endpoint = os.Getenv("S3_ENDPOINT")
+	if os.Getenv("S3_FORCE_PATH_STYLE") == "true" {
+		c.S3ForcePathStyle = true
+	}
+
+	c.ImagesApiAuthorization = os.Getenv("IMAGES_API_AUTHORIZATION")
+
+	c.SizeProfiles = image.DefaultProfiles
+	if profiles := os.Getenv("IMAGES_SIZE_PROFILES"); profiles != "" {
+		parsedProfiles, err := image.ParseProfiles(profiles)
+		if err != nil {
+			return fmt.Errorf("invalid env IMAGES_SIZE_PROFILES: %w", err)
+		}
+		c.SizeProfiles = parsedProfiles
+	}
+
+	c.VariantFormats = []image.Format{}
+	if formats := os.Getenv("IMAGES_VARIANT_FORMATS"); formats != "" {
+		for _, value := range strings.Split(formats, ",") {
+			format := image.Format(strings.TrimSpace(value))
+			if !format.IsSupported() {
+				return errors.New("invalid env IMAGES_VARIANT_FORMATS, unsupported format " + string(format))
+			}
+			if c.ImagesResizer == ResizerLocal && !format.IsEncodable() {
+				return errors.New("env IMAGES_VARIANT_FORMATS of " + string(format) + " is not supported by the local resizer")
+			}
+			c.VariantFormats = append(c.VariantFormats, format)
+		}
+	}
+
+	c.ImagesApiTimeout = 30 * time.Second
+	if seconds := os.Getenv("IMAGES_API_TIMEOUT_SEC"); seconds != "" {
+		parsedSeconds, err := strconv.Atoi(seconds)
+		if err != nil {
+			return err
+		}
+		if parsedSeconds <= 0 {
+			return errors.New("env IMAGES_API_TIMEOUT_SEC must be positive")
+		}
+		c.ImagesApiTimeout = time.Duration(parsedSeconds) * time.Second
+	}
+
+	c.ImagesApiTimeouts = map[string]time.Duration{}
+	if timeouts := os.Getenv("IMAGES_API_TIMEOUTS"); timeouts != "" {
+		parsedTimeouts, err := parseTimeouts(timeouts)
+		if err != nil {
+			return fmt.Errorf("invalid env IMAGES_API_TIMEOUTS: %w", err)
+		}
+		c.ImagesApiTimeouts = parsedTimeouts
+	}
+
+	if retries := os.Getenv("IMAGES_API_RETRIES"); retries != "" {
+		parsedRetries, err := strconv.Atoi(retries)
+		if err != nil {
+			return err
+		}
+		if parsedRetries < 0 {
+			return errors.New("env IMAGES_API_RETRIES must not be negative")
+		}
+		c.ImagesApiRetries = uint(parsedRetries)
+	} else {
+		c.ImagesApiRetries = 2
+	}
+
+	if workers := os.Getenv("JOBS_WORKERS"); workers != "" {
+		parsedWorkers, err := strconv.Atoi(workers)
+		if err != nil {
+			return err
+		}
+		if parsedWorkers < 0 {
+			return errors.New("env JOBS_WORKERS must not be negative")
+		}
+		c.JobsWorkers = uint(parsedWorkers)
+	}
+	// workers call the resize api without a user authorization
+	if c.JobsWorkers > 0 && c.ImagesResizer == ResizerRemote && c.ImagesApiAuthorization == "" {
+		return errors.New("missing env IMAGES_API_AUTHORIZATION, required by JOBS_WORKERS with the remote resizer")
+	}
+
+	if seconds := os.Getenv("JOBS_POLL_INTERVAL_SEC"); seconds != "" {
+		parsedSeconds, err := strconv.Atoi(seconds)
+		if err != nil {
+			return err
+		}
+		c.JobsPollIntervalSec = uint(parsedSeconds)
+		if c.JobsPollIntervalSec == 0 {
+			return errors.New("env JOBS_POLL_INTERVAL_SEC must not be 0")
+		}
+	} else {
+		c.JobsPollIntervalSec = 1
+	}
+
+	if seconds := os.Getenv("JOBS_LEASE_SEC"); seconds != "" {
+		parsedSeconds, err := strconv.Atoi(seconds)
+		if err != nil {
+			return err
+		}
+		c.JobsLeaseSec = uint(parsedSeconds)
+		if c.JobsLeaseSec == 0 {
+			return errors.New("env JOBS_LEASE_SEC must not be 0")
+		}
+	} else {
+		c.JobsLeaseSec = 300
+	}
+
+	c.JobsCallbackUrl = os.Getenv("JOBS_CALLBACK_URL")
+	c.JobsCallbackSecret = os.Getenv("JOBS_CALLBACK_SECRET")
+	if c.JobsCallbackUrl != "" && c.JobsCallbackSecret == "" {
+		return errors.New("missing env JOBS_CALLBACK_SECRET, it is required by JOBS_CALLBACK_URL")
+	}
+	if c.JobsCallbackUrl != "" && c.ImagesResizer == ResizerLocal {
+		return errors.New("env JOBS_CALLBACK_URL is not supported by the local resizer")
+	}
+
+	c.ImagesTransformSecret = os.Getenv("IMAGES_TRANSFORM_SECRET")
+
+	c.ImagesTransformSizes = profileSizes(c.SizeProfiles)
+	if sizes := os.Getenv("IMAGES_TRANSFORM_SIZES"); sizes != "" {
+		parsedSizes, err := image.ParseDimensionsList(sizes)
+		if err != nil {
+			return fmt.Errorf("invalid env IMAGES_TRANSFORM_SIZES: %w", err)
+		}
+		c.ImagesTransformSizes = parsedSizes
+	}
+
+	if concurrency := os.Getenv("IMAGES_TRANSFORM_CONCURRENCY"); concurrency != "" {
+		parsedConcurrency, err := strconv.Atoi(concurrency)
+		if err != nil {
+			return err
+		}
+		if parsedConcurrency <= 0 {
+			return errors.New("env IMAGES_TRANSFORM_CONCURRENCY must be positive")
+		}
+		c.ImagesTransformConcurrency = uint(parsedConcurrency)
+	} else {
+		c.ImagesTransformConcurrency = 4
+	}
+
+	if seconds := os.Getenv("IMAGES_TRANSFORM_MAX_AGE_SEC"); seconds != "" {
+		parsedSeconds, err := strconv.Atoi(seconds)
+		if err != nil {
+			return err
+		}
+		if parsedSeconds < 0 {
+			return errors.New("env IMAGES_TRANSFORM_MAX_AGE_SEC must not be negative")
+		}
+		c.ImagesTransformMaxAgeSec = uint(parsedSeconds)
+	} else {
+		c.ImagesTransformMaxAgeSec = 86400
+	}
+
+	c.ImagesCdnDomain = os.Getenv("IMAGES_CDN_DOMAIN")
+	c.ImagesUrlTemplate = os.Getenv("IMAGES_URL_TEMPLATE")
+	if c.ImagesUrlTemplate != "" {
+		if err := validateUrlTemplate(c.ImagesUrlTemplate); err != nil {
+			return fmt.Errorf("invalid env IMAGES_URL_TEMPLATE: %w", err)
+		}
+	}
+
+	c.ImagesStripMetadata = []image.MetadataField{image.GpsField, image.SerialField}
+	if fields := os.Getenv("IMAGES_STRIP_METADATA"); fields != "" {
+		parsedFields, err := image.ParseMetadataFields(fields)
+		if err != nil {
+			return fmt.Errorf("invalid env IMAGES_STRIP_METADATA: %w", err)
+		}
+		c.ImagesStripMetadata = parsedFields
+	}
+
+	switch policy := DuplicatePolicy(os.Getenv("IMAGES_DUPLICATES")); policy {
+	case "", DuplicatesWarn:
+		c.ImagesDuplicates = DuplicatesWarn
+	case DuplicatesOff, DuplicatesReject:
+		c.ImagesDuplicates = policy
+	default:
+		return errors.New("invalid env IMAGES_DUPLICATES of " + string(policy))
+	}
+
+	if distance := os.Getenv("IMAGES_DUPLICATE_DISTANCE"); distance != "" {
+		parsedDistance, err := strconv.Atoi(distance)
+		if err != nil {
+			return err
+		}
+		if parsedDistance < 0 || parsedDistance > 64 {
+			return errors.New("env IMAGES_DUPLICATE_DISTANCE must be between 0 and 64")
+		}
+		c.ImagesDuplicateDistance = parsedDistance
+	} else {
+		c.ImagesDuplicateDistance = 5
+	}
+
 	c.AwsAccessKeyId = os.Getenv("AWS_ACCESS_KEY_ID")
 	if c.AwsAccessKeyId == "" {
 		return errors.New("missing env AWS_ACCESS_KEY_ID")
@@ -78,5 +410,103 @@ func (c *Config) LoadFromEnvironment() error {
 		c.SqsPostAuthConsumerDisabled = true
 	}
 
+	if hours := os.Getenv("TRASH_RETENTION_HOURS"); hours != "" {
+		parsedHours, err := strconv.Atoi(hours)
+		if err != nil {
+			return err
+		}
+		c.TrashRetentionHours = uint(parsedHours)
+	} else {
+		c.TrashRetentionHours = 720
+	}
+
+	if seconds := os.Getenv("TRASH_PURGE_INTERVAL_SEC"); seconds != "" {
+		parsedSeconds, err := strconv.Atoi(seconds)
+		if err != nil {
+			return err
+		}
+		c.TrashPurgeIntervalSec = uint(parsedSeconds)
+	}
+	// purging is opt-in, it deletes the files through the images api without a user authorization
+	if c.TrashPurgeIntervalSec > 0 && c.ImagesApiAuthorization == "" {
+		return errors.New("missing env IMAGES_API_AUTHORIZATION, required to purge the trash")
+	}
+
+	if hours := os.Getenv("UPLOAD_EXPIRATION_HOURS"); hours != "" {
+		parsedHours, err := strconv.Atoi(hours)
+		if err != nil {
+			return err
+		}
+		c.UploadExpirationHours = uint(parsedHours)
+		if c.UploadExpirationHours == 0 {
+			return errors.New("env UPLOAD_EXPIRATION_HOURS must not be 0")
+		}
+	} else {
+		c.UploadExpirationHours = 24
+	}
+
+	if seconds := os.Getenv("UPLOAD_PURGE_INTERVAL_SEC"); seconds != "" {
+		parsedSeconds, err := strconv.Atoi(seconds)
+		if err != nil {
+			return err
+		}
+		c.UploadPurgeIntervalSec = uint(parsedSeconds)
+		if c.UploadPurgeIntervalSec == 0 {
+			return errors.New("env UPLOAD_PURGE_INTERVAL_SEC must not be 0")
+		}
+	} else {
+		c.UploadPurgeIntervalSec = 600
+	}
+
+	if minutes := os.Getenv("UPLOAD_INTENT_EXPIRATION_MIN"); minutes != "" {
+		parsedMinutes, err := strconv.Atoi(minutes)
+		if err != nil {
+			return err
+		}
+		c.UploadIntentExpirationMin = uint(parsedMinutes)
+		if c.UploadIntentExpirationMin == 0 {
+			return errors.New("env UPLOAD_INTENT_EXPIRATION_MIN must not be 0")
+		}
+		// the signed urls expire with the intent
+		if c.UploadIntentExpirationMin > maxUploadIntentExpirationMin {
+			return fmt.Errorf("env UPLOAD_INTENT_EXPIRATION_MIN must not exceed %d", maxUploadIntentExpirationMin)
+		}
+	} else {
+		c.UploadIntentExpirationMin = 60
+	}
+
 	return nil
 }
+
+// parseTimeouts reads comma separated endpoint=seconds pairs, like resize=60,upload=120
+func parseTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	for _, pair := range strings.Split(value, ",") {
+		endpoint, seconds, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || endpoint == "" {
+			return nil, fmt.Errorf("expected endpoint=seconds, got %s", pair)
+		}
+		parsedSeconds, err := strconv.Atoi(seconds)
+		if err != nil || parsedSeconds <= 0 {
+			return nil, fmt.Errorf("timeout of %s has to be a positive number of seconds", endpoint)
+		}
+		timeouts[endpoint] = time.Duration(parsedSeconds) * time.Second
+	}
+
+	return timeouts, nil
+}
+
+// profileSizes are the distinct sizes of the profiles
+func profileSizes(profiles []image.Profile) []image.Dimensions {
+	sizes := make([]image.Dimensions, 0)
+	seen := make(map[image.Dimensions]bool)
+	for _, profile := range profiles {
+		dimensions := image.Dimensions{Width: profile.Width, Height: profile.Height}
+		if !seen[dimensions] {
+			seen[dimensions] = true
+			sizes = append(sizes, dimensions)
+		}
+	}
+
+	return sizes
+}
@@ -0,0 +1,10 @@
+package exception
+
+// Conflict is a request that doesn't apply to the current state of a resource, like an outdated offset
+type Conflict struct {
+	Reason string
+}
+
+func (c Conflict) Error() string {
+	return c.Reason
+}
@@ -0,0 +1,34 @@
+package exception
+
+import "strings"
+
+// FieldError is the reason a field of the request is invalid
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// InvalidFields is an invalid argument of one or more request fields
+type InvalidFields struct {
+	Fields []FieldError
+}
+
+func (invalid *InvalidFields) Add(field, reason string) {
+	invalid.Fields = append(invalid.Fields, FieldError{Field: field, Reason: reason})
+}
+
+// OrNil returns nil when no field was added, so it can be returned as the error of a validation
+func (invalid InvalidFields) OrNil() error {
+	if len(invalid.Fields) == 0 {
+		return nil
+	}
+	return invalid
+}
+
+func (invalid InvalidFields) Error() string {
+	reasons := make([]string, 0, len(invalid.Fields))
+	for _, field := range invalid.Fields {
+		reasons = append(reasons, field.Field+": "+field.Reason)
+	}
+	return strings.Join(reasons, ", ")
+}
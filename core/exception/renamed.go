@@ -0,0 +1,10 @@
+package exception
+
+// Renamed is returned when an image is looked up by a former name, Name is the current one
+type Renamed struct {
+	Name string
+}
+
+func (r Renamed) Error() string {
+	return "Image was renamed to " + r.Name
+}
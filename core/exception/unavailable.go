@@ -0,0 +1,16 @@
+package exception
+
+// Unavailable is a request that can't be served right now but may succeed when it is retried, like under load or
+// while a service this api depends on can't be reached. Err is the failure of that service, if any.
+type Unavailable struct {
+	Reason string
+	Err    error
+}
+
+func (u Unavailable) Error() string {
+	return u.Reason
+}
+
+func (u Unavailable) Unwrap() error {
+	return u.Err
+}
@@ -0,0 +1,29 @@
+package exception
+
+// BadGateway is a service this api depends on that rejected or failed a request, which is not the fault of the client
+type BadGateway struct {
+	Reason string
+	Err    error
+}
+
+func (b BadGateway) Error() string {
+	return b.Reason
+}
+
+func (b BadGateway) Unwrap() error {
+	return b.Err
+}
+
+// GatewayTimeout is a service this api depends on that didn't respond in time
+type GatewayTimeout struct {
+	Reason string
+	Err    error
+}
+
+func (g GatewayTimeout) Error() string {
+	return g.Reason
+}
+
+func (g GatewayTimeout) Unwrap() error {
+	return g.Err
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"api/image"
+	"api/objectstore"
+	"api/storage"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+)
+
+// HashBackfill computes the perceptual hashes of images created before they were hashed at upload, or whose hash was
+// cleared by a rollback, so they are found as near duplicates. They are hashed from the originals as the cropped
+// files aren't kept, originals cropped differently may look further apart than their uploads would.
+type HashBackfill struct {
+	imagesRepository storage.ImagesRepository
+	originals        originalReader
+	hasher           image.PerceptualHasher
+	logger           *zerolog.Logger
+}
+
+func NewHashBackfill(
+	config Config,
+	imagesRepository storage.ImagesRepository,
+	store objectstore.ObjectStore,
+	hasher image.PerceptualHasher,
+	logger *zerolog.Logger,
+) *HashBackfill {
+	return &HashBackfill{
+		imagesRepository: imagesRepository,
+		originals:        newOriginalReader(config, store),
+		hasher:           hasher,
+		logger:           logger,
+	}
+}
+
+// Run backfills every image without perceptual hash and returns how many were backfilled. Images that fail, like
+// originals in formats that can't be decoded, are logged and skipped.
+func (backfill *HashBackfill) Run(ctx context.Context) (int, error) {
+	count := 0
+	afterId := ""
+	for {
+		images, err := backfill.imagesRepository.GetWithoutHash(ctx, afterId, backfillBatchSize)
+		if err != nil {
+			return count, fmt.Errorf("failed fetching images without perceptual hash: %w", err)
+		}
+
+		for _, img := range images {
+			afterId = img.Id
+			if err = backfill.backfillOne(ctx, img); err != nil {
+				if ctx.Err() != nil {
+					return count, ctx.Err()
+				}
+				backfill.logger.Warn().Err(err).Str("image", img.Id).Msg("failed backfilling perceptual hash")
+				continue
+			}
+			count++
+		}
+
+		if len(images) < backfillBatchSize {
+			return count, nil
+		}
+	}
+}
+
+func (backfill *HashBackfill) backfillOne(ctx context.Context, img storage.Image) error {
+	original, err := backfill.originals.open(ctx, img)
+	if err != nil {
+		return err
+	}
+	defer original.Close()
+
+	hash, err := backfill.hasher.Hash(ctx, io.LimitReader(original, maxOriginalBytes))
+	if err != nil {
+		return fmt.Errorf("failed computing perceptual hash: %w", err)
+	}
+	img.PerceptualHash = hash.String()
+
+	return backfill.imagesRepository.SetPerceptualHash(ctx, img)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"api/image"
+	"api/objectstore"
+	"api/objectstore/filesystem"
+	"api/storage"
+	"context"
+	"github.com/rs/zerolog"
+	"io"
+	"strings"
+	"testing"
+)
+
+type hashRepo struct {
+	storage.ImageRepoMock
+	images storage.ImageList
+}
+
+func (repo *hashRepo) GetWithoutHash(_ context.Context, afterId string, limit int) (storage.ImageList, error) {
+	images := storage.ImageList{}
+	for _, img := range repo.images {
+		if img.PerceptualHash == "" && img.Id > afterId && len(images) < limit {
+			images = append(images, img)
+		}
+	}
+	return images, nil
+}
+
+func (repo *hashRepo) SetPerceptualHash(_ context.Context, updated storage.Image) error {
+	for i, img := range repo.images {
+		if img.Id == updated.Id {
+			repo.images[i] = updated
+		}
+	}
+	return nil
+}
+
+// lengthHasher uses the length of the file as its hash
+type lengthHasher struct{}
+
+func (hasher lengthHasher) Hash(_ context.Context, r io.Reader) (image.PerceptualHash, error) {
+	content, err := io.ReadAll(r)
+	return image.PerceptualHash(len(content)), err
+}
+
+func TestHashBackfill_Run(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+	store, err := filesystem.NewStore(t.TempDir(), objectstore.NewSigner([]byte("secret"), "http://localhost:3000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &hashRepo{}
+	for _, name := range []string{"a-plane", "b-missing", "c-glider"} {
+		img := storage.Image{Id: "id-" + name, Name: name, Original: "images/" + name + ".jpg"}
+		repo.images = append(repo.images, img)
+		if name == "b-missing" {
+			continue
+		}
+		if err = store.Put(ctx, img.Original, strings.NewReader(name), int64(len(name)), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo.images = append(repo.images, storage.Image{Id: "id-d-done", PerceptualHash: "00ff00ff00ff00ff"})
+
+	backfill := NewHashBackfill(Config{ImagesResizer: ResizerLocal}, repo, store, lengthHasher{}, &logger)
+	count, err := backfill.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 backfilled images, got %d", count)
+	}
+
+	expected := map[string]string{
+		"id-a-plane":   "0000000000000007",
+		"id-b-missing": "",
+		"id-c-glider":  "0000000000000008",
+		"id-d-done":    "00ff00ff00ff00ff",
+	}
+	for _, img := range repo.images {
+		if img.PerceptualHash != expected[img.Id] {
+			t.Fatalf("Expected the perceptual hash %q of %s, got %q", expected[img.Id], img.Id, img.PerceptualHash)
+		}
+	}
+}
@@ -10,20 +10,38 @@ import (
 type ImagesService struct {
 	resizeApi        image.Resizer
 	imagesRepository storage.ImagesRepository
+	revisions        storage.ImageRevisionRepository
+	operations       storage.PendingOperationRepository
+	placeholders     image.PlaceholderGenerator
+	metadata         *image.MetadataProcessor
+	duplicates       *DuplicateDetector
 	authenticator    auth.Authenticator
+	urls             *UrlBuilder
 	logger           *zerolog.Logger
 }
 
 func NewImagesService(
 	resizeApi image.Resizer,
 	imagesRepository storage.ImagesRepository,
+	revisions storage.ImageRevisionRepository,
+	operations storage.PendingOperationRepository,
+	placeholders image.PlaceholderGenerator,
+	metadata *image.MetadataProcessor,
+	duplicates *DuplicateDetector,
 	authenticator auth.Authenticator,
+	urls *UrlBuilder,
 	logger *zerolog.Logger,
 ) *ImagesService {
 	return &ImagesService{
-		resizeApi:        resizeApi,
+		resizeApi:        exceptionResizer{resizer: resizeApi},
 		imagesRepository: imagesRepository,
+		revisions:        revisions,
+		operations:       operations,
+		placeholders:     placeholders,
+		metadata:         metadata,
+		duplicates:       duplicates,
 		authenticator:    authenticator,
+		urls:             urls,
 		logger:           logger,
 	}
 }
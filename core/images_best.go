@@ -0,0 +1,48 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/image"
+	"api/storage"
+	"context"
+)
+
+// BestVariant is the file of a size in the format a client prefers
+type BestVariant struct {
+	Url    string              `json:"url"`
+	Format storage.ImageFormat `json:"format"`
+	Width  int                 `json:"width"`
+	Height int                 `json:"height"`
+}
+
+// GetBest picks the format of the size of the image by the Accept header of the client, among the format of the
+// image and the other formats the size was produced in
+func (service *ImagesService) GetBest(ctx context.Context, imageId, size, accept string) (BestVariant, error) {
+	if size == "" {
+		return BestVariant{}, exception.InvalidArgument{Reason: "Missing size"}
+	}
+
+	img, err := service.GetOne(ctx, imageId)
+	if err != nil {
+		return BestVariant{}, err
+	}
+
+	dimensions, ok := img.Sizes.Variants[size]
+	if size == storage.OriginalSize {
+		dimensions, ok = img.Sizes.Original, true
+	}
+	if !ok {
+		return BestVariant{}, exception.NotFound{Msg: "Size not found"}
+	}
+	available := img.Formats.ToVariantFormats().Of(image.Format(img.Format), size)
+	format := storage.ImageFormat(image.NegotiateFormat(accept, available, image.Format(img.Format)))
+
+	location, _ := service.urls.Url(img, size, format)
+
+	return BestVariant{
+		Url:    location,
+		Format: format,
+		Width:  dimensions.Width,
+		Height: dimensions.Height,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/storage"
+	"context"
+	"errors"
+	"testing"
+)
+
+const (
+	bestImageId = "6f1e2d3c-4b5a-4978-8a6b-5c4d3e2f1a0b"
+	bestPath    = "https://cdn.example.com/images/my-plane"
+)
+
+// multiFormatImage has webp and avif variants of some sizes
+func multiFormatImage() *memoryImageRepo {
+	return &memoryImageRepo{image: storage.Image{
+		Id:       bestImageId,
+		Name:     "my-plane",
+		Format:   storage.JpgFormat,
+		Original: "images/my-plane.jpg",
+		Domain:   "cdn.example.com",
+		Path:     "images",
+		Sizes: storage.ImageSizes{
+			Original: storage.Dimensions{Width: 1600, Height: 1200},
+			Variants: map[string]storage.Dimensions{
+				"s": {Width: 300, Height: 225},
+				"m": {Width: 500, Height: 375},
+			},
+		},
+		Formats: storage.ImageVariantFormats{
+			storage.WebpFormat: {"s", "m"},
+			storage.AvifFormat: {"m"},
+		},
+	}}
+}
+
+func TestImagesService_GetBest(t *testing.T) {
+	service := newTestImagesService(testImagesDeps{images: multiFormatImage()})
+	accept := "image/avif,image/webp,*/*"
+
+	data := []struct {
+		size     string
+		accept   string
+		expected BestVariant
+	}{
+		{
+			size:     "m",
+			accept:   accept,
+			expected: BestVariant{Url: bestPath + "_500x375.avif", Format: "avif", Width: 500, Height: 375},
+		},
+		{
+			size:     "s",
+			accept:   accept,
+			expected: BestVariant{Url: bestPath + "_300x225.webp", Format: "webp", Width: 300, Height: 225},
+		},
+		{
+			size:     "s",
+			accept:   "image/jpeg",
+			expected: BestVariant{Url: bestPath + "_300x225.jpg", Format: "jpg", Width: 300, Height: 225},
+		},
+		{
+			size:     storage.OriginalSize,
+			accept:   accept,
+			expected: BestVariant{Url: bestPath + ".jpg", Format: "jpg", Width: 1600, Height: 1200},
+		},
+	}
+	for _, d := range data {
+		best, err := service.GetBest(context.Background(), bestImageId, d.size, d.accept)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if best != d.expected {
+			t.Errorf("Expected %+v for size %s, got %+v", d.expected, d.size, best)
+		}
+	}
+
+	var notFound exception.NotFound
+	if _, err := service.GetBest(context.Background(), bestImageId, "xl", accept); !errors.As(err, &notFound) {
+		t.Fatalf("Expected exception.NotFound for a missing size, got %v", err)
+	}
+}
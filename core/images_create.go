@@ -5,10 +5,11 @@ import (
 	"api/core/exception"
 	"api/image"
 	"api/storage"
+	"bytes"
 	"context"
 	"fmt"
 	"golang.org/x/sync/errgroup"
-	"mime/multipart"
+	"io"
 )
 
 func (service *ImagesService) getMultipleSignUrls(
@@ -51,33 +52,31 @@ func (service *ImagesService) getMultipleSignUrls(
 
 func (service *ImagesService) uploadBothFiles(
 	ctx context.Context,
-	originalSignedUrl string,
-	croppedSignedUrl string,
+	originalSigned image.SignedResponse,
+	croppedSigned image.SignedResponse,
 	format image.Format,
-	original *multipart.FileHeader,
-	cropped *multipart.FileHeader,
+	original image.Upload,
+	cropped image.Upload,
 ) error {
 	g := new(errgroup.Group)
 
 	g.Go(func() error {
 		service.logger.Info().
-			Str("signedUrl", originalSignedUrl).
+			Str("signedUrl", originalSigned.SignedUrl).
 			Msg("uploading original file")
 
-		return service.resizeApi.UploadFile(ctx, originalSignedUrl, format, original)
+		return service.resizeApi.UploadFile(ctx, originalSigned, format, original.Body, original.Size)
 	})
 
 	g.Go(func() error {
 		service.logger.Info().
-			Str("croppedSignedUrl", originalSignedUrl).
+			Str("croppedSignedUrl", croppedSigned.SignedUrl).
 			Msg("uploading cropped file")
 
-		return service.resizeApi.UploadFile(ctx, croppedSignedUrl, format, cropped)
+		return service.resizeApi.UploadFile(ctx, croppedSigned, format, cropped.Body, cropped.Size)
 	})
 
-	err := g.Wait()
-
-	return err
+	return g.Wait()
 }
 
 func (service *ImagesService) UploadAndResize(
@@ -85,14 +84,12 @@ func (service *ImagesService) UploadAndResize(
 	authorization auth.AuthorizationDto,
 	imageName string,
 	format image.Format,
-	originalFile *multipart.FileHeader,
-	croppedFile *multipart.FileHeader,
+	originalFile image.Upload,
+	croppedFile image.Upload,
 ) (storage.Image, error) {
-	seoImageName := FormatForSeo(imageName)
-	if seoImageName == "" {
-		return storage.Image{}, exception.InvalidArgument{
-			Reason: fmt.Sprintf("Invalid image name of %s", imageName),
-		}
+	seoImageName, err := service.newImageName(ctx, imageName)
+	if err != nil {
+		return storage.Image{}, err
 	}
 
 	currentUser, err := service.authenticator.GetOrSyncUser(ctx, authorization)
@@ -100,60 +97,368 @@ func (service *ImagesService) UploadAndResize(
 		return storage.Image{}, err
 	}
 
+	operation := storage.PendingOperation{
+		Kind: storage.PendingOperationUpload,
+		Payload: storage.PendingOperationPayload{
+			Image: storage.Image{Name: seoImageName, AuthorId: currentUser.Id},
+		},
+	}
+
+	return service.runOperation(ctx, operation, func(sg *saga, operation *storage.PendingOperation) (storage.Image, error) {
+		err := service.uploadAndResizeSteps(ctx, sg, operation, authorization.Header, format, originalFile, croppedFile)
+		if err != nil {
+			return storage.Image{}, err
+		}
+
+		created, err := service.saveNewImage(ctx, operation)
+		created.Duplicates = operation.Payload.Image.Duplicates
+		return created, err
+	})
+}
+
+// jobImage is the new image of the author with what was computed when the files of the job were uploaded
+func jobImage(authorId string, seoImageName string, payload storage.JobPayload) storage.Image {
+	img := storage.Image{
+		Name:           seoImageName,
+		AuthorId:       authorId,
+		Metadata:       payload.Metadata,
+		PerceptualHash: payload.PerceptualHash,
+	}
+
+	return withPlaceholder(img, image.Placeholder{
+		BlurHash:      payload.BlurHash,
+		Lqip:          payload.Lqip,
+		DominantColor: payload.DominantColor,
+	})
+}
+
+// createFromUploads creates the image of the author under the new seo name from the files the job uploaded, unless
+// it's rejected as a near duplicate of an image created since. The uploaded files are kept when it fails, the job
+// either retries or deletes them. Background tasks pass the authorization of the api.
+func (service *ImagesService) createFromUploads(
+	ctx context.Context,
+	authHeader string,
+	authorId string,
+	seoImageName string,
+	payload storage.JobPayload,
+) (storage.Image, error) {
+	operation := storage.PendingOperation{
+		Kind: storage.PendingOperationUpload,
+		Payload: storage.PendingOperationPayload{
+			Image:         jobImage(authorId, seoImageName, payload),
+			UploadedFiles: []string{payload.OriginalFile, payload.CroppedFile},
+		},
+	}
+
+	return service.runOperation(ctx, operation, func(sg *saga, operation *storage.PendingOperation) (storage.Image, error) {
+		if _, err := service.duplicates.check(ctx, operation.Payload.Image); err != nil {
+			return storage.Image{}, err
+		}
+
+		err := service.resizeSteps(ctx, sg, operation, authHeader, payload.OriginalFile, payload.CroppedFile)
+		if err != nil {
+			return storage.Image{}, err
+		}
+
+		return service.saveNewImage(ctx, operation)
+	})
+}
+
+// createFromResized saves the image the resize api reported through a callback for the job, the resized and
+// uploaded files are deleted when it can't be saved or is rejected as a near duplicate of an image created since
+func (service *ImagesService) createFromResized(
+	ctx context.Context,
+	authHeader string,
+	authorId string,
+	payload storage.JobPayload,
+	res image.ResizeResponse,
+) (storage.Image, error) {
+	seoImageName := FormatForSeo(payload.Name)
+	uploadedFiles := []string{payload.OriginalFile, payload.CroppedFile}
+	resized := withResizeResponse(jobImage(authorId, seoImageName, payload), seoImageName, res)
+	operation := storage.PendingOperation{
+		Kind: storage.PendingOperationUpload,
+		Payload: storage.PendingOperationPayload{
+			Image:         resized,
+			UploadedFiles: uploadedFiles,
+			Resized:       true,
+		},
+	}
+
+	return service.runOperation(ctx, operation, func(sg *saga, operation *storage.PendingOperation) (storage.Image, error) {
+		sg.addCompensation("delete uploaded files", func(ctx context.Context) error {
+			return service.resizeApi.DeleteUploads(ctx, authHeader, image.DeleteUploadsRequest{FileNames: uploadedFiles})
+		})
+		sg.addCompensation("delete resized files", func(ctx context.Context) error {
+			return service.deleteFiles(ctx, authHeader, resized)
+		})
+		if _, err := service.duplicates.check(ctx, operation.Payload.Image); err != nil {
+			return storage.Image{}, err
+		}
+
+		return service.saveNewImage(ctx, operation)
+	})
+}
+
+// uploadFiles processes the metadata of both files and uploads them to new signed urls, the payload of the job
+// creating the image also gets the placeholder and the perceptual hash of the cropped file. The files are deleted
+// when an upload fails or when they are rejected as a near duplicate.
+func (service *ImagesService) uploadFiles(
+	ctx context.Context,
+	authHeader string,
+	imageName string,
+	format image.Format,
+	originalFile image.Upload,
+	croppedFile image.Upload,
+) (storage.JobPayload, error) {
+	originalFile, croppedFile, metadata, err := service.processMetadata(format, originalFile, croppedFile)
+	if err != nil {
+		return storage.JobPayload{}, err
+	}
+
+	originalSigned, croppedSigned, err := service.getMultipleSignUrls(ctx, authHeader, format)
+	if err != nil {
+		return storage.JobPayload{}, fmt.Errorf("error creating multiple sign urls: %w", err)
+	}
+
+	cropped := newCappedBuffer(maxComputedFileBytes)
+	croppedFile.Body = io.TeeReader(croppedFile.Body, cropped)
+	err = service.uploadBothFiles(
+		ctx,
+		originalSigned,
+		croppedSigned,
+		format,
+		originalFile,
+		croppedFile,
+	)
+	if err != nil {
+		service.deleteUploads(ctx, authHeader, originalSigned.FileName, croppedSigned.FileName)
+		return storage.JobPayload{}, fmt.Errorf("error uploading files: %w", err)
+	}
+	img := service.computePlaceholder(ctx, storage.Image{Name: imageName}, cropped)
+	if !cropped.isOverflowed {
+		img = service.duplicates.hash(ctx, img, cropped.reader())
+	}
+	// rejected near duplicates aren't queued
+	if _, err = service.duplicates.check(ctx, img); err != nil {
+		service.deleteUploads(ctx, authHeader, originalSigned.FileName, croppedSigned.FileName)
+		return storage.JobPayload{}, err
+	}
+
+	return storage.JobPayload{
+		Name:           imageName,
+		Format:         string(format),
+		OriginalFile:   originalSigned.FileName,
+		CroppedFile:    croppedSigned.FileName,
+		Metadata:       metadata,
+		BlurHash:       img.BlurHash,
+		Lqip:           img.Lqip,
+		DominantColor:  img.DominantColor,
+		PerceptualHash: img.PerceptualHash,
+	}, nil
+}
+
+// deleteUploads deletes files that were uploaded for an image that won't be created, failures are only logged
+func (service *ImagesService) deleteUploads(ctx context.Context, authHeader string, fileNames ...string) {
+	uploads := image.DeleteUploadsRequest{FileNames: fileNames}
+	if err := service.resizeApi.DeleteUploads(ctx, authHeader, uploads); err != nil {
+		service.logger.Warn().Msgf("failed deleting uploaded files: %s", err.Error())
+	}
+}
+
+// processMetadata turns both files upright and strips the configured metadata from them. The metadata is read from
+// the original, the cropped file may have lost it. The original is read first, as both may be read from one stream.
+func (service *ImagesService) processMetadata(
+	format image.Format,
+	originalFile image.Upload,
+	croppedFile image.Upload,
+) (image.Upload, image.Upload, *storage.ImageMetadata, error) {
+	originalFile, metadata, err := service.metadata.Process(format, originalFile)
+	if err != nil {
+		err = exception.InvalidArgument{Reason: "Invalid original file: " + err.Error()}
+		return image.Upload{}, image.Upload{}, nil, err
+	}
+	croppedFile, _, err = service.metadata.Process(format, croppedFile)
+	if err != nil {
+		err = exception.InvalidArgument{Reason: "Invalid cropped file: " + err.Error()}
+		return image.Upload{}, image.Upload{}, nil, err
+	}
+
+	return originalFile, croppedFile, convertMetadata(metadata), nil
+}
+
+// newImageName formats the name of a new image for seo, the formatted name must not be taken yet
+func (service *ImagesService) newImageName(ctx context.Context, imageName string) (string, error) {
+	seoImageName := FormatForSeo(imageName)
+	if seoImageName == "" {
+		return "", exception.InvalidArgument{
+			Reason: fmt.Sprintf("Invalid image name of %s", imageName),
+		}
+	}
+
 	isNameTaken, err := service.imagesRepository.DoesImageExist(ctx, seoImageName)
 	if err != nil {
-		return storage.Image{}, exception.InvalidArgument{
+		return "", exception.InvalidArgument{
 			Reason: fmt.Sprintf("Image '%s' already exists", seoImageName),
 		}
 	}
 	if isNameTaken {
-		return storage.Image{}, exception.InvalidArgument{
+		return "", exception.InvalidArgument{
 			Reason: fmt.Sprintf("Image name: '%s' already exists, please use another", seoImageName),
 		}
 	}
 
-	originalSigned, croppedSigned, err := service.getMultipleSignUrls(ctx, authorization.Header, format)
+	return seoImageName, nil
+}
+
+func (service *ImagesService) saveNewImage(
+	ctx context.Context, operation *storage.PendingOperation,
+) (storage.Image, error) {
+	createdImg, err := service.imagesRepository.Create(ctx, operation.Payload.Image)
+	if err != nil {
+		return storage.Image{}, fmt.Errorf("err saving new image to database: %w", err)
+	}
+
+	return createdImg, nil
+}
+
+// uploadAndResizeSteps uploads both files and resizes them under the name of the operation image, which receives
+// the resized variants and the ids of its near duplicates. Each step adds its undo action to the saga.
+func (service *ImagesService) uploadAndResizeSteps(
+	ctx context.Context,
+	sg *saga,
+	operation *storage.PendingOperation,
+	authHeader string,
+	format image.Format,
+	originalFile image.Upload,
+	croppedFile image.Upload,
+) error {
+	originalFile, croppedFile, metadata, err := service.processMetadata(format, originalFile, croppedFile)
 	if err != nil {
-		return storage.Image{},
-			fmt.Errorf("error creating multiple sign urls: %w", err)
+		return err
 	}
+	operation.Payload.Image.Metadata = metadata
 
+	originalSigned, croppedSigned, err := service.getMultipleSignUrls(ctx, authHeader, format)
+	if err != nil {
+		return fmt.Errorf("error creating multiple sign urls: %w", err)
+	}
+
+	uploads := image.DeleteUploadsRequest{FileNames: []string{originalSigned.FileName, croppedSigned.FileName}}
+	sg.addCompensation("delete uploaded files", func(ctx context.Context) error {
+		return service.resizeApi.DeleteUploads(ctx, authHeader, uploads)
+	})
+	operation.Payload.UploadedFiles = uploads.FileNames
+	if err = service.saveProgress(ctx, operation); err != nil {
+		return err
+	}
+
+	// The cropped file is kept as it's uploaded, so the placeholder and the hash are computed without reading it again
+	cropped := newCappedBuffer(maxComputedFileBytes)
+	croppedFile.Body = io.TeeReader(croppedFile.Body, cropped)
 	err = service.uploadBothFiles(
 		ctx,
-		originalSigned.SignedUrl,
-		croppedSigned.SignedUrl,
+		originalSigned,
+		croppedSigned,
 		format,
 		originalFile,
 		croppedFile,
 	)
 	if err != nil {
-		return storage.Image{}, fmt.Errorf("error uploading files: %w", err)
+		return fmt.Errorf("error uploading files: %w", err)
+	}
+	operation.Payload.Image = service.computePlaceholder(ctx, operation.Payload.Image, cropped)
+	if !cropped.isOverflowed {
+		operation.Payload.Image = service.duplicates.hash(ctx, operation.Payload.Image, cropped.reader())
+	}
+	// rejected near duplicates aren't resized
+	duplicates, err := service.duplicates.check(ctx, operation.Payload.Image)
+	if err != nil {
+		return err
 	}
+	operation.Payload.Image.Duplicates = duplicates
+
+	return service.resizeSteps(ctx, sg, operation, authHeader, originalSigned.FileName, croppedSigned.FileName)
+}
 
+// resizeSteps resizes both uploaded files under the name of the operation image, which receives the resized
+// variants
+func (service *ImagesService) resizeSteps(
+	ctx context.Context,
+	sg *saga,
+	operation *storage.PendingOperation,
+	authHeader string,
+	originalFileName string,
+	croppedFileName string,
+) error {
+	imageName := operation.Payload.Image.Name
 	resizeRequest := image.ResizeRequest{
-		Name:             seoImageName,
-		FilePath:         croppedSigned.FileName,
-		OriginalFilePath: originalSigned.FileName,
+		Name:             imageName,
+		FilePath:         croppedFileName,
+		OriginalFilePath: originalFileName,
 	}
-	res, err := service.resizeApi.Resize(ctx, authorization.Header, resizeRequest)
+	res, err := service.resizeApi.Resize(ctx, authHeader, resizeRequest)
 	if err != nil {
-		return storage.Image{}, fmt.Errorf("error resizing: %w", err)
+		return fmt.Errorf("error resizing: %w", err)
 	}
 
-	newImage := storage.Image{
-		Name:     res.Name,
-		Format:   storage.ImageFormat(res.Format),
-		Original: res.Original,
-		Domain:   res.Domain,
-		Path:     res.Path,
-		Sizes:    convertImageSizesToStorageSizes(res.Sizes),
-		AuthorId: currentUser.Id,
+	resized := withResizeResponse(operation.Payload.Image, imageName, res)
+	sg.addCompensation("delete resized files", func(ctx context.Context) error {
+		return service.deleteFiles(ctx, authHeader, resized)
+	})
+	operation.Payload.Image = resized
+	operation.Payload.Resized = true
+
+	return service.saveProgress(ctx, operation)
+}
+
+// computePlaceholder sets the placeholder of the image computed from the kept file. Images are kept without one
+// when it fails, like for formats that can't be decoded or files too large to be kept, so they can still be uploaded.
+func (service *ImagesService) computePlaceholder(
+	ctx context.Context, img storage.Image, file *cappedBuffer,
+) storage.Image {
+	if file.isOverflowed {
+		service.logger.Warn().Str("name", img.Name).Msg("cropped file is too large to compute its placeholder")
+		return withPlaceholder(img, image.Placeholder{})
 	}
 
-	createdImg, err := service.imagesRepository.Create(ctx, newImage)
+	placeholder, err := service.placeholders.Generate(ctx, file.reader())
 	if err != nil {
-		return storage.Image{}, fmt.Errorf("err saving new image to database: %w", err)
+		service.logger.Warn().Err(err).Str("name", img.Name).Msg("failed computing placeholder")
 	}
 
-	return createdImg, nil
+	return withPlaceholder(img, placeholder)
+}
+
+// maxComputedFileBytes bounds the cropped file kept in memory while it's uploaded to compute its placeholder and hash
+const maxComputedFileBytes = 16 * 1024 * 1024
+
+// cappedBuffer keeps what is written to it up to its limit. Writes past the limit succeed so the upload it tees
+// continues, but the buffer is dropped and marked overflowed.
+type cappedBuffer struct {
+	buf          bytes.Buffer
+	limit        int
+	isOverflowed bool
+}
+
+func newCappedBuffer(limit int) *cappedBuffer {
+	return &cappedBuffer{limit: limit}
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.isOverflowed {
+		return len(p), nil
+	}
+	if b.buf.Len()+len(p) > b.limit {
+		b.isOverflowed = true
+		b.buf = bytes.Buffer{}
+		return len(p), nil
+	}
+
+	return b.buf.Write(p)
+}
+
+// reader reads the kept bytes from their start, it can be called again
+func (b *cappedBuffer) reader() io.Reader {
+	return bytes.NewReader(b.buf.Bytes())
 }
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"api/auth"
+	"api/image"
+	"api/storage"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	goimage "image"
+	"image/jpeg"
+	"io"
+	"testing"
+)
+
+func TestImagesService_UploadAndResize_Compensation(t *testing.T) {
+	data := []struct {
+		testName               string
+		resizer                *recordingResizer
+		repo                   storage.ImagesRepository
+		expectedDeletedUploads int
+		expectedDeleted        int
+	}{
+		{
+			testName:               "Resize fails",
+			resizer:                &recordingResizer{failResize: true},
+			repo:                   storage.ImageRepoMock{},
+			expectedDeletedUploads: 1,
+			expectedDeleted:        0,
+		},
+		{
+			testName:               "Saving to database fails",
+			resizer:                &recordingResizer{},
+			repo:                   &memoryImageRepo{createErr: errStep},
+			expectedDeletedUploads: 1,
+			expectedDeleted:        1,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			service := newTestImagesService(testImagesDeps{resizer: d.resizer, images: d.repo})
+
+			_, err := service.UploadAndResize(
+				context.Background(),
+				auth.AuthorizationDto{},
+				"my plane",
+				image.PngFormat,
+				pngUpload("plane"),
+				pngUpload("plane"),
+			)
+			if !errors.Is(err, errStep) {
+				t.Fatalf("expected the step error, got %v", err)
+			}
+			if d.resizer.deletedUploads != d.expectedDeletedUploads {
+				t.Fatalf("expected %d deleted uploads, got %d", d.expectedDeletedUploads, d.resizer.deletedUploads)
+			}
+			if d.resizer.deleted != d.expectedDeleted {
+				t.Fatalf("expected %d deleted variants, got %d", d.expectedDeleted, d.resizer.deleted)
+			}
+		})
+	}
+}
+
+// contentPlaceholders uses the content of the file as its blurhash
+type contentPlaceholders struct {
+	fail bool
+}
+
+func (generator contentPlaceholders) Generate(_ context.Context, r io.Reader) (image.Placeholder, error) {
+	if generator.fail {
+		return image.Placeholder{}, errStep
+	}
+	content, err := io.ReadAll(r)
+	return image.Placeholder{BlurHash: string(content), DominantColor: "#000000"}, err
+}
+
+func TestImagesService_UploadAndResize_Placeholder(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		repo := &memoryImageRepo{}
+		service := newTestImagesService(testImagesDeps{images: repo, placeholders: contentPlaceholders{fail: fail}})
+
+		img, err := service.UploadAndResize(
+			context.Background(),
+			auth.AuthorizationDto{},
+			"my plane",
+			image.PngFormat,
+			pngUpload("original"),
+			pngUpload("cropped"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := string(testPng("cropped"))
+		if fail {
+			expected = ""
+		}
+		if img.BlurHash != expected || repo.created.BlurHash != expected {
+			t.Fatalf("Expected the blurhash %q of the cropped file to be saved, got %q", expected, repo.created.BlurHash)
+		}
+	}
+}
+
+// gpsJpeg encodes a jpg with an exif segment naming the camera and the coordinates 45°N 4°W
+func gpsJpeg(t *testing.T) []byte {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, goimage.NewRGBA(goimage.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	order := binary.BigEndian
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	entry := func(tag uint16, kind uint16, count uint32, value uint32) {
+		tiff = order.AppendUint16(tiff, tag)
+		tiff = order.AppendUint16(tiff, kind)
+		tiff = order.AppendUint32(tiff, count)
+		tiff = order.AppendUint32(tiff, value)
+	}
+	// main ifd at 8 with the make at 38 and the gps ifd at 44, whose rationals are at 98 and 122
+	tiff = order.AppendUint16(tiff, 2)
+	entry(0x010F, 2, 6, 38)
+	entry(0x8825, 4, 1, 44)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, "Canon\x00"...)
+	tiff = order.AppendUint16(tiff, 4)
+	entry(0x0001, 2, 2, 'N'<<24)
+	entry(0x0002, 5, 3, 98)
+	entry(0x0003, 2, 2, 'W'<<24)
+	entry(0x0004, 5, 3, 122)
+	tiff = append(tiff, 0, 0, 0, 0)
+	for _, degrees := range []uint32{45, 4} {
+		for _, value := range []uint32{degrees, 1, 0, 1, 0, 1} {
+			tiff = order.AppendUint32(tiff, value)
+		}
+	}
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	file := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	file = order.AppendUint16(file, uint16(len(segment)+2))
+	file = append(file, segment...)
+	return append(file, encoded.Bytes()[2:]...)
+}
+
+func TestImagesService_UploadAndResize_Metadata(t *testing.T) {
+	file := gpsJpeg(t)
+
+	data := []struct {
+		testName string
+		strip    []image.MetadataField
+		isGps    bool
+	}{
+		{testName: "Metadata is kept", strip: nil, isGps: true},
+		{testName: "Gps is stripped", strip: []image.MetadataField{image.GpsField}, isGps: false},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			resizer := &recordingResizer{}
+			repo := &memoryImageRepo{}
+			service := newTestImagesService(testImagesDeps{resizer: resizer, images: repo, strip: d.strip})
+
+			img, err := service.UploadAndResize(
+				context.Background(),
+				auth.AuthorizationDto{},
+				"my plane",
+				image.JpgFormat,
+				image.Upload{Body: bytes.NewReader(file), Size: int64(len(file))},
+				image.Upload{Body: bytes.NewReader(file), Size: int64(len(file))},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			metadata := repo.created.Metadata
+			if metadata == nil || metadata.Camera != "Canon" || img.Metadata != metadata {
+				t.Fatalf("Expected the metadata of the original to be saved, got %+v", metadata)
+			}
+			if (metadata.Gps != nil) != d.isGps {
+				t.Fatalf("Expected coordinates %v, got %+v", d.isGps, metadata.Gps)
+			}
+			if d.isGps && (metadata.Gps.Latitude != 45 || metadata.Gps.Longitude != -4) {
+				t.Fatalf("Unexpected coordinates %+v", metadata.Gps)
+			}
+			for _, uploaded := range resizer.uploaded {
+				// the gps ifd and its rationals take 102 bytes
+				if d.isGps && !bytes.Equal(uploaded, file) || !d.isGps && len(uploaded) > len(file)-102 {
+					t.Fatalf("Unexpected upload of %d bytes from a file of %d bytes", len(uploaded), len(file))
+				}
+			}
+			if len(resizer.uploaded) != 2 {
+				t.Fatalf("Expected both files to be uploaded, got %d", len(resizer.uploaded))
+			}
+		})
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	buffer := newCappedBuffer(8)
+	for _, chunk := range []string{"crop", "ped", "!"} {
+		if n, err := buffer.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Expected the write to succeed, got %d %v", n, err)
+		}
+	}
+	if content, _ := io.ReadAll(buffer.reader()); buffer.isOverflowed || string(content) != "cropped!" {
+		t.Fatalf("Expected the content up to the limit to be kept, got %q", content)
+	}
+
+	if n, err := buffer.Write([]byte("?")); err != nil || n != 1 {
+		t.Fatalf("Expected writes past the limit to succeed, got %d %v", n, err)
+	}
+	if content, _ := io.ReadAll(buffer.reader()); !buffer.isOverflowed || len(content) != 0 {
+		t.Fatalf("Expected the content past the limit to be dropped, got %q", content)
+	}
+}
@@ -3,12 +3,12 @@ package core
 import (
 	"api/auth"
 	"api/core/exception"
-	"api/image"
 	"api/storage"
 	"context"
 	"github.com/google/uuid"
 )
 
+// DeleteOne moves the image to the trash, its files are removed once it's purged
 func (service *ImagesService) DeleteOne(
 	ctx context.Context,
 	auth auth.AuthorizationDto,
@@ -27,23 +27,5 @@ func (service *ImagesService) DeleteOne(
 		return exception.Forbidden{}
 	}
 
-	img, err := service.imagesRepository.GetOne(ctx, imageId)
-	if err != nil {
-		return err
-	}
-
-	deleteRequest := image.DeleteRequest{
-		Name:       img.Name,
-		Format:     image.Format(img.Format),
-		Dimensions: convertStorageSizesToDimensions(img.Sizes),
-	}
-	if err = service.resizeApi.Delete(ctx, auth.Header, deleteRequest); err != nil {
-		service.logger.Error().Msg("failed deleting image " + imageId)
-		return err
-	}
-	if err = service.resizeApi.Invalidate(ctx, auth.Header, deleteRequest); err != nil {
-		service.logger.Error().Msgf("failed invalidating image %s: %s", imageId, err.Error())
-	}
-
-	return service.imagesRepository.DeleteOne(ctx, parsedId.String())
+	return service.imagesRepository.Trash(ctx, parsedId.String())
 }
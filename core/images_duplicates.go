@@ -0,0 +1,129 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/image"
+	"api/storage"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"io"
+	"strings"
+)
+
+const (
+	// duplicatesLimit bounds the near duplicates reported for an upload
+	duplicatesLimit = 5
+	// similarImagesLimit is how many similar images are listed unless requested otherwise
+	similarImagesLimit    = 20
+	maxSimilarImagesLimit = 100
+)
+
+// DuplicateDetector hashes uploads perceptually and looks for the images they are near duplicates of
+type DuplicateDetector struct {
+	hasher           image.PerceptualHasher
+	imagesRepository storage.ImagesRepository
+	policy           DuplicatePolicy
+	maxDistance      int
+	logger           *zerolog.Logger
+}
+
+func NewDuplicateDetector(
+	config Config,
+	hasher image.PerceptualHasher,
+	imagesRepository storage.ImagesRepository,
+	logger *zerolog.Logger,
+) *DuplicateDetector {
+	return &DuplicateDetector{
+		hasher:           hasher,
+		imagesRepository: imagesRepository,
+		policy:           config.ImagesDuplicates,
+		maxDistance:      config.ImagesDuplicateDistance,
+		logger:           logger,
+	}
+}
+
+// hash sets the perceptual hash of the image computed from the file. Images are kept without one when it fails,
+// like for formats that can't be decoded, and are never reported as duplicates.
+func (detector *DuplicateDetector) hash(ctx context.Context, img storage.Image, file io.Reader) storage.Image {
+	hash, err := detector.hasher.Hash(ctx, file)
+	if err != nil {
+		detector.logger.Warn().Err(err).Str("name", img.Name).Msg("failed computing perceptual hash")
+		img.PerceptualHash = ""
+		return img
+	}
+
+	img.PerceptualHash = hash.String()
+	return img
+}
+
+// check applies the policy to the near duplicates of the uploaded image, rejected uploads fail with
+// exception.Conflict and otherwise the ids of the duplicates are returned
+func (detector *DuplicateDetector) check(ctx context.Context, img storage.Image) ([]string, error) {
+	if detector.policy == DuplicatesOff || img.PerceptualHash == "" {
+		return nil, nil
+	}
+
+	duplicates, err := detector.imagesRepository.GetSimilar(
+		ctx, img.PerceptualHash, img.Id, detector.maxDistance, duplicatesLimit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed looking for duplicates: %w", err)
+	}
+	if len(duplicates) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(duplicates))
+	names := make([]string, len(duplicates))
+	for i, duplicate := range duplicates {
+		ids[i] = duplicate.Image.Id
+		names[i] = duplicate.Image.Name
+	}
+	if detector.policy == DuplicatesReject {
+		return nil, exception.Conflict{
+			Reason: fmt.Sprintf("Image '%s' looks like %s", img.Name, strings.Join(names, ", ")),
+		}
+	}
+	detector.logger.Warn().Str("name", img.Name).Strs("duplicates", names).Msg("uploaded a near duplicate")
+
+	return ids, nil
+}
+
+// GetSimilar lists the near duplicates of the image nearest first, images without a perceptual hash have none
+func (service *ImagesService) GetSimilar(
+	ctx context.Context, imageId string, limit int,
+) (storage.SimilarImageList, error) {
+	if _, err := uuid.Parse(imageId); err != nil {
+		return storage.SimilarImageList{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+	if limit <= 0 {
+		limit = similarImagesLimit
+	}
+	if limit > maxSimilarImagesLimit {
+		return storage.SimilarImageList{}, exception.InvalidArgument{
+			Reason: fmt.Sprintf("Limit should be at most %d", maxSimilarImagesLimit),
+		}
+	}
+
+	img, err := service.imagesRepository.GetOne(ctx, imageId)
+	if err != nil {
+		return storage.SimilarImageList{}, err
+	}
+	if img.PerceptualHash == "" {
+		return storage.SimilarImageList{}, nil
+	}
+
+	similar, err := service.imagesRepository.GetSimilar(
+		ctx, img.PerceptualHash, img.Id, service.duplicates.maxDistance, limit,
+	)
+	if err != nil {
+		return storage.SimilarImageList{}, fmt.Errorf("failed getting similar images: %w", err)
+	}
+	for i := range similar {
+		similar[i].Image = service.urls.WithUrls(similar[i].Image)
+	}
+
+	return similar, nil
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/image"
+	"api/storage"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// similarRepo finds the same near duplicate for any hash and records what it was asked for
+type similarRepo struct {
+	memoryImageRepo
+	searched     bool
+	maxDistance  int
+	limit        int
+	excludedId   string
+	searchedHash string
+}
+
+func (repo *similarRepo) GetSimilar(
+	_ context.Context, hash string, excludedId string, maxDistance int, limit int,
+) (storage.SimilarImageList, error) {
+	repo.searched = true
+	repo.searchedHash, repo.excludedId, repo.maxDistance, repo.limit = hash, excludedId, maxDistance, limit
+	duplicate := storage.Image{Id: "9a5ab8f5-3f0c-4f4e-9d3f-5c2a3b1e7d21", Name: "my other plane"}
+	return storage.SimilarImageList{{Image: duplicate, Distance: 2}}, nil
+}
+
+func TestImagesService_UploadAndResize_Duplicates(t *testing.T) {
+	data := []struct {
+		testName               string
+		policy                 DuplicatePolicy
+		isSearched             bool
+		isRejected             bool
+		expectedDeletedUploads int
+	}{
+		{testName: "Duplicates are off", policy: DuplicatesOff},
+		{testName: "Duplicates are returned", policy: DuplicatesWarn, isSearched: true},
+		{testName: "Duplicates are rejected", policy: DuplicatesReject, isSearched: true, isRejected: true,
+			expectedDeletedUploads: 1},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			resizer := &recordingResizer{}
+			repo := &similarRepo{}
+			config := Config{ImagesDuplicates: d.policy, ImagesDuplicateDistance: 5}
+			service := newTestImagesService(testImagesDeps{resizer: resizer, images: repo, config: config})
+
+			img, err := service.UploadAndResize(
+				context.Background(),
+				auth.AuthorizationDto{},
+				"my plane",
+				image.PngFormat,
+				pngUpload("original"),
+				pngUpload("cropped"),
+			)
+			if repo.searched != d.isSearched {
+				t.Fatalf("Expected searching for duplicates %v, got %v", d.isSearched, repo.searched)
+			}
+			if d.isSearched && (repo.searchedHash != "0000000000000000" || repo.maxDistance != 5) {
+				t.Fatalf("Unexpected search of hash %q within %d", repo.searchedHash, repo.maxDistance)
+			}
+			// rejected uploads are never resized, so there are no resized files to delete
+			if resizer.deleted != 0 || resizer.deletedUploads != d.expectedDeletedUploads {
+				t.Fatalf("Expected %d deleted uploads, got %d and %d deleted variants", d.expectedDeletedUploads,
+					resizer.deletedUploads, resizer.deleted)
+			}
+
+			if d.isRejected {
+				var conflict exception.Conflict
+				if !errors.As(err, &conflict) || !strings.Contains(conflict.Reason, "my other plane") {
+					t.Fatalf("Expected a conflict naming the duplicate, got %v", err)
+				}
+				if repo.created.Name != "" {
+					t.Fatal("Expected the rejected image not to be saved")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if repo.created.PerceptualHash != "0000000000000000" {
+				t.Fatalf("Expected the perceptual hash to be saved, got %q", repo.created.PerceptualHash)
+			}
+			if d.isSearched != (len(img.Duplicates) == 1) {
+				t.Fatalf("Unexpected duplicates %v", img.Duplicates)
+			}
+		})
+	}
+}
+
+func TestImagesService_GetSimilar(t *testing.T) {
+	imageId := createdImageId
+	newService := func(repo *similarRepo) *ImagesService {
+		config := Config{ImagesDuplicates: DuplicatesWarn, ImagesDuplicateDistance: 5}
+		return newTestImagesService(testImagesDeps{images: repo, config: config})
+	}
+
+	repo := &similarRepo{memoryImageRepo: memoryImageRepo{
+		image: storage.Image{Id: imageId, PerceptualHash: "00ff00ff00ff00ff"},
+	}}
+	similar, err := newService(repo).GetSimilar(context.Background(), imageId, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(similar) != 1 || similar[0].Distance != 2 {
+		t.Fatalf("Unexpected similar images %+v", similar)
+	}
+	if repo.searchedHash != "00ff00ff00ff00ff" || repo.excludedId != imageId || repo.limit != similarImagesLimit {
+		t.Fatalf("Unexpected search of hash %q excluding %q limited to %d", repo.searchedHash, repo.excludedId, repo.limit)
+	}
+
+	repo = &similarRepo{memoryImageRepo: memoryImageRepo{image: storage.Image{Id: imageId}}}
+	similar, err = newService(repo).GetSimilar(context.Background(), imageId, 10)
+	if err != nil || len(similar) != 0 || repo.searched {
+		t.Fatalf("Expected an image without hash to have no similar images, got %+v and error %v", similar, err)
+	}
+
+	var invalid exception.InvalidArgument
+	if _, err = newService(repo).GetSimilar(context.Background(), "not-a-uuid", 0); !errors.As(err, &invalid) {
+		t.Fatalf("Expected an invalid uuid to be rejected, got %v", err)
+	}
+	if _, err = newService(repo).GetSimilar(context.Background(), imageId, 101); !errors.As(err, &invalid) {
+		t.Fatalf("Expected a limit over %d to be rejected, got %v", maxSimilarImagesLimit, err)
+	}
+}
@@ -1,22 +1,144 @@
 package core
 
 import (
+	"api/auth"
 	"api/core/exception"
+	"api/image"
 	"api/storage"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 )
 
+// Get returns a page of images with cursors pointing to the neighbouring pages. The total count is queried only
+// when requested as it requires a full scan of the matched rows.
 func (service *ImagesService) Get(
-	ctx context.Context, limit, offset int, order storage.Order,
-) (storage.ImageList, error) {
-	images, err := service.imagesRepository.Get(ctx, limit, offset, order)
+	ctx context.Context, filter storage.ImageFilter, pagination storage.Pagination, includeTotal bool,
+) (storage.ImagePage, error) {
+	if err := validateImageFilter(filter); err != nil {
+		return storage.ImagePage{}, err
+	}
+	if filter.Tag != "" {
+		filter.Tag = NormalizeTagValue(filter.Tag)
+	}
+
+	limit := pagination.Limit
+	// One more is fetched to know if there is a following page
+	pagination.Limit++
+
+	images, err := service.imagesRepository.Get(ctx, filter, pagination)
+	if err != nil {
+		return storage.ImagePage{}, fmt.Errorf("failed fetching images: %w", err)
+	}
+
+	page := toImagePage(images, limit, pagination)
+	page.Items = service.urls.withListUrls(page.Items)
+
+	if includeTotal {
+		total, countErr := service.imagesRepository.Count(ctx, filter)
+		if countErr != nil {
+			return storage.ImagePage{}, fmt.Errorf("failed counting images: %w", countErr)
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+func validateImageFilter(filter storage.ImageFilter) error {
+	if filter.Format != "" && !filter.Format.IsSupported() {
+		return exception.InvalidArgument{Reason: fmt.Sprintf("Unsupported format %s", filter.Format)}
+	}
+	if filter.HasSize != "" && !image.IsValidProfileName(filter.HasSize) {
+		return exception.InvalidArgument{Reason: fmt.Sprintf("Invalid size profile %s", filter.HasSize)}
+	}
+	if filter.MinWidth < 0 || filter.MinHeight < 0 {
+		return exception.InvalidArgument{Reason: "Minimum dimensions can't be negative"}
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && filter.CreatedAfter.After(*filter.CreatedBefore) {
+		return exception.InvalidArgument{Reason: "createdAfter has to be before createdBefore"}
+	}
+	if filter.UpdatedAfter != nil && filter.UpdatedBefore != nil && filter.UpdatedAfter.After(*filter.UpdatedBefore) {
+		return exception.InvalidArgument{Reason: "updatedAfter has to be before updatedBefore"}
+	}
+
+	return nil
+}
+
+// GetByAuthor returns a page of the images uploaded by the user
+func (service *ImagesService) GetByAuthor(
+	ctx context.Context, authorId string, pagination storage.Pagination, includeTotal bool,
+) (storage.ImagePage, error) {
+	parsedAuthorId, err := uuid.Parse(authorId)
+	if err != nil {
+		return storage.ImagePage{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	return service.Get(ctx, storage.ImageFilter{AuthorId: parsedAuthorId.String()}, pagination, includeTotal)
+}
+
+// GetOwn returns a page of the images uploaded by the authorized user
+func (service *ImagesService) GetOwn(
+	ctx context.Context, authorization auth.AuthorizationDto, pagination storage.Pagination, includeTotal bool,
+) (storage.ImagePage, error) {
+	user, err := service.authenticator.GetOrSyncUser(ctx, authorization)
 	if err != nil {
-		return storage.ImageList{}, fmt.Errorf("failed fetching images: %w", err)
+		return storage.ImagePage{}, err
 	}
 
-	return images, nil
+	return service.Get(ctx, storage.ImageFilter{AuthorId: user.Id}, pagination, includeTotal)
+}
+
+// toImagePage trims the extra fetched image and sets the cursors of the neighbouring pages
+func toImagePage(images storage.ImageList, limit int, pagination storage.Pagination) storage.ImagePage {
+	backward := pagination.Cursor != nil && pagination.Cursor.Backward
+	hasMore := len(images) > limit
+	if hasMore {
+		if backward {
+			images = images[len(images)-limit:]
+		} else {
+			images = images[:limit]
+		}
+	}
+
+	page := storage.ImagePage{Items: images}
+	if len(images) == 0 {
+		return page
+	}
+
+	hasNext := hasMore
+	hasPrev := pagination.Cursor != nil || pagination.Offset > 0
+	if backward {
+		hasNext = true
+		hasPrev = hasMore
+	}
+
+	if hasNext {
+		page.NextCursor = imageCursor(images[len(images)-1], pagination.Sort, false)
+	}
+	if hasPrev {
+		page.PrevCursor = imageCursor(images[0], pagination.Sort, true)
+	}
+
+	return page
+}
+
+func imageCursor(img storage.Image, sort storage.ImageSort, backward bool) *string {
+	if img.CreatedAt == nil {
+		return nil
+	}
+	if sort == "" {
+		sort = storage.ImageSortCreatedAt
+	}
+
+	cursor := storage.Cursor{CreatedAt: *img.CreatedAt, Id: img.Id, Backward: backward, Sort: sort}
+	if sort != storage.ImageSortCreatedAt {
+		cursor.Key = sort.Key(img)
+	}
+
+	encoded := cursor.Encode()
+	return &encoded
 }
 
 func (service *ImagesService) GetOne(ctx context.Context, imageId string) (storage.Image, error) {
@@ -30,5 +152,30 @@ func (service *ImagesService) GetOne(ctx context.Context, imageId string) (stora
 		return storage.Image{}, err
 	}
 
-	return image, nil
+	return service.urls.WithUrls(image), nil
+}
+
+// GetOneByName finds the image by its SEO name. Former names of renamed images result in exception.Renamed holding
+// the current name, so the old links can be redirected.
+func (service *ImagesService) GetOneByName(ctx context.Context, name string) (storage.Image, error) {
+	if name == "" || FormatForSeo(name) != name {
+		return storage.Image{}, exception.InvalidArgument{Reason: "Invalid image name"}
+	}
+
+	image, err := service.imagesRepository.GetOneByName(ctx, name)
+	if err == nil {
+		return service.urls.WithUrls(image), nil
+	}
+
+	var notFound storage.NotFound
+	if !errors.As(err, &notFound) {
+		return storage.Image{}, fmt.Errorf("failed fetching image by name: %w", err)
+	}
+
+	currentName, err := service.imagesRepository.GetCurrentName(ctx, name)
+	if err != nil {
+		return storage.Image{}, err
+	}
+
+	return storage.Image{}, exception.Renamed{Name: currentName}
 }
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/image"
+	"api/storage"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newImagesForPage(count int) storage.ImageList {
+	images := storage.ImageList{}
+	start := time.Date(2021, 8, 20, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		createdAt := start.Add(-time.Duration(i) * time.Minute)
+		images = append(images, storage.Image{
+			Id:        string(rune('a' + i)),
+			CreatedAt: &createdAt,
+		})
+	}
+	return images
+}
+
+func TestToImagePage(t *testing.T) {
+	data := []struct {
+		testName    string
+		images      storage.ImageList
+		pagination  storage.Pagination
+		expectedLen int
+		expectNext  bool
+		expectPrev  bool
+		expectFirst string
+	}{
+		{
+			testName:    "First page with more",
+			images:      newImagesForPage(3),
+			pagination:  storage.Pagination{Limit: 3},
+			expectedLen: 2,
+			expectNext:  true,
+			expectPrev:  false,
+			expectFirst: "a",
+		},
+		{
+			testName:    "Last page by offset",
+			images:      newImagesForPage(2),
+			pagination:  storage.Pagination{Limit: 3, Offset: 2},
+			expectedLen: 2,
+			expectNext:  false,
+			expectPrev:  true,
+			expectFirst: "a",
+		},
+		{
+			testName:    "Forward cursor",
+			images:      newImagesForPage(1),
+			pagination:  storage.Pagination{Limit: 3, Cursor: &storage.Cursor{Id: "x"}},
+			expectedLen: 1,
+			expectNext:  false,
+			expectPrev:  true,
+			expectFirst: "a",
+		},
+		{
+			testName:    "Backward cursor with more",
+			images:      newImagesForPage(3),
+			pagination:  storage.Pagination{Limit: 3, Cursor: &storage.Cursor{Id: "x", Backward: true}},
+			expectedLen: 2,
+			expectNext:  true,
+			expectPrev:  true,
+			expectFirst: "b",
+		},
+		{
+			testName:    "Empty",
+			images:      storage.ImageList{},
+			pagination:  storage.Pagination{Limit: 3},
+			expectedLen: 0,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			page := toImagePage(d.images, d.pagination.Limit-1, d.pagination)
+			if len(page.Items) != d.expectedLen {
+				t.Fatalf("Expected %d items, got %d", d.expectedLen, len(page.Items))
+			}
+			if (page.NextCursor != nil) != d.expectNext {
+				t.Fatalf("Expected next cursor presence to be %v", d.expectNext)
+			}
+			if (page.PrevCursor != nil) != d.expectPrev {
+				t.Fatalf("Expected prev cursor presence to be %v", d.expectPrev)
+			}
+			if d.expectedLen > 0 && page.Items[0].Id != d.expectFirst {
+				t.Fatalf("Expected first item %s, got %s", d.expectFirst, page.Items[0].Id)
+			}
+		})
+	}
+}
+
+func TestImagesService_GetByAuthor(t *testing.T) {
+	service := newTestImagesService(testImagesDeps{resizer: image.Mock{}})
+
+	_, err := service.GetByAuthor(context.Background(), "not-a-uuid", storage.Pagination{Limit: 10}, false)
+	var invalidArgument exception.InvalidArgument
+	if !errors.As(err, &invalidArgument) {
+		t.Fatalf("Expected invalid argument, got %v", err)
+	}
+}
+
+func TestValidateImageFilter(t *testing.T) {
+	before := time.Date(2021, 8, 20, 10, 0, 0, 0, time.UTC)
+	after := before.Add(time.Hour)
+
+	data := []struct {
+		testName  string
+		filter    storage.ImageFilter
+		expectErr bool
+	}{
+		{testName: "Empty", filter: storage.ImageFilter{}},
+		{testName: "Supported format", filter: storage.ImageFilter{Format: storage.WebpFormat, HasSize: "xl"}},
+		{testName: "Unsupported format", filter: storage.ImageFilter{Format: "gif"}, expectErr: true},
+		{testName: "Unknown size", filter: storage.ImageFilter{HasSize: "original"}, expectErr: true},
+		{testName: "Negative width", filter: storage.ImageFilter{MinWidth: -1}, expectErr: true},
+		{
+			testName:  "Inverted created range",
+			filter:    storage.ImageFilter{CreatedAfter: &after, CreatedBefore: &before},
+			expectErr: true,
+		},
+		{
+			testName:  "Inverted updated range",
+			filter:    storage.ImageFilter{UpdatedAfter: &after, UpdatedBefore: &before},
+			expectErr: true,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			err := validateImageFilter(d.filter)
+			var invalidArgument exception.InvalidArgument
+			if d.expectErr != errors.As(err, &invalidArgument) {
+				t.Fatalf("Expected invalid argument to be %v, got %v", d.expectErr, err)
+			}
+		})
+	}
+}
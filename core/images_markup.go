@@ -0,0 +1,151 @@
+package core
+
+import (
+	"api/image"
+	"api/storage"
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// defaultMarkupSizes lets browsers assume the image spans the viewport when the layout isn't known
+const defaultMarkupSizes = "100vw"
+
+// Markup is responsive html of an image, browsers pick the file of a srcset by the width the image is rendered at
+type Markup struct {
+	Src    string `json:"src"`
+	Srcset string `json:"srcset"`
+	Sizes  string `json:"sizes"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	// Picture has a source for each other format the variants are available in, followed by the img fallback
+	Picture string `json:"picture"`
+}
+
+type srcsetCandidate struct {
+	url   string
+	width int
+}
+
+// GetMarkup returns the srcset of the original and the variants of the image with width descriptors, and the
+// picture element offering the variants in the other formats too. Sizes is the sizes attribute, the alt text is
+// escaped like it.
+func (service *ImagesService) GetMarkup(ctx context.Context, imageId, sizes, alt string) (Markup, error) {
+	if sizes == "" {
+		sizes = defaultMarkupSizes
+	}
+
+	img, err := service.GetOne(ctx, imageId)
+	if err != nil {
+		return Markup{}, err
+	}
+
+	names := append([]string{storage.OriginalSize}, img.Sizes.VariantNames()...)
+	candidates := service.srcsetCandidates(img, img.Format, names)
+	markup := Markup{
+		Src:    service.fallbackSrc(img, candidates),
+		Srcset: srcset(candidates),
+		Sizes:  sizes,
+		Width:  img.Sizes.Original.Width,
+		Height: img.Sizes.Original.Height,
+	}
+
+	var picture strings.Builder
+	picture.WriteString("<picture>\n")
+	for _, format := range otherFormats(img) {
+		variants := service.srcsetCandidates(img, format, img.Formats[format])
+		if len(variants) == 0 {
+			continue
+		}
+		fmt.Fprintf(
+			&picture,
+			"  <source type=\"%s\" srcset=\"%s\" sizes=\"%s\">\n",
+			format.ToImageContentType(), html.EscapeString(srcset(variants)), html.EscapeString(sizes),
+		)
+	}
+	fmt.Fprintf(
+		&picture,
+		"  <img src=\"%s\" srcset=\"%s\" sizes=\"%s\" width=\"%d\" height=\"%d\" alt=\"%s\""+
+			" loading=\"lazy\" decoding=\"async\">\n",
+		html.EscapeString(markup.Src),
+		html.EscapeString(markup.Srcset),
+		html.EscapeString(sizes),
+		markup.Width,
+		markup.Height,
+		html.EscapeString(alt),
+	)
+	picture.WriteString("</picture>")
+	markup.Picture = picture.String()
+
+	return markup, nil
+}
+
+// srcsetCandidates are the files of the sizes in the format ordered by width, sizes of the same width are listed once
+func (service *ImagesService) srcsetCandidates(
+	img storage.Image, format storage.ImageFormat, names []string,
+) []srcsetCandidate {
+	candidates := make([]srcsetCandidate, 0, len(names))
+	widths := make(map[int]bool)
+	for _, name := range names {
+		if name == storage.OriginalSize && format != img.Format {
+			// Originals are only stored in the format of the image
+			continue
+		}
+		dimensions, ok := img.Sizes.Variants[name]
+		if name == storage.OriginalSize {
+			dimensions, ok = img.Sizes.Original, true
+		}
+		if !ok || dimensions.Width <= 0 || widths[dimensions.Width] {
+			continue
+		}
+		location, _ := service.urls.Url(img, name, format)
+		candidates = append(candidates, srcsetCandidate{url: location, width: dimensions.Width})
+		widths[dimensions.Width] = true
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].width < candidates[j].width
+	})
+
+	return candidates
+}
+
+// fallbackSrc is the largest variant for browsers without srcset support, the original when there are none
+func (service *ImagesService) fallbackSrc(img storage.Image, candidates []srcsetCandidate) string {
+	original, _ := service.urls.Url(img, storage.OriginalSize, img.Format)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if candidates[i].url != original {
+			return candidates[i].url
+		}
+	}
+
+	return original
+}
+
+func srcset(candidates []srcsetCandidate) string {
+	entries := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		entries = append(entries, fmt.Sprintf("%s %dw", candidate.url, candidate.width))
+	}
+
+	return strings.Join(entries, ", ")
+}
+
+// otherFormats lists the formats besides the one of the image that variants were produced in, best compressing first
+func otherFormats(img storage.Image) []storage.ImageFormat {
+	formats := make([]image.Format, 0, len(img.Formats))
+	for format := range img.Formats {
+		if format != img.Format {
+			formats = append(formats, image.Format(format))
+		}
+	}
+	image.SortByPreference(formats)
+
+	others := make([]storage.ImageFormat, 0, len(formats))
+	for _, format := range formats {
+		others = append(others, storage.ImageFormat(format))
+	}
+
+	return others
+}
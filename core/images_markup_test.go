@@ -0,0 +1,34 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestImagesService_GetMarkup(t *testing.T) {
+	service := newTestImagesService(testImagesDeps{images: multiFormatImage()})
+
+	markup, err := service.GetMarkup(context.Background(), bestImageId, "", `"plane" & sky`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcset := bestPath + "_300x225.jpg 300w, " + bestPath + "_500x375.jpg 500w, " + bestPath + ".jpg 1600w"
+	expected := Markup{
+		Src:    bestPath + "_500x375.jpg",
+		Srcset: srcset,
+		Sizes:  defaultMarkupSizes,
+		Width:  1600,
+		Height: 1200,
+		Picture: "<picture>\n" +
+			`  <source type="image/avif" srcset="` + bestPath + `_500x375.avif 500w" sizes="100vw">` + "\n" +
+			`  <source type="image/webp" srcset="` + bestPath + "_300x225.webp 300w, " + bestPath +
+			`_500x375.webp 500w" sizes="100vw">` + "\n" +
+			`  <img src="` + bestPath + `_500x375.jpg" srcset="` + srcset + `" sizes="100vw" width="1600" ` +
+			`height="1200" alt="&#34;plane&#34; &amp; sky" loading="lazy" decoding="async">` + "\n" +
+			"</picture>",
+	}
+	if markup != expected {
+		t.Fatalf("Expected markup %+v, got %+v", expected, markup)
+	}
+}
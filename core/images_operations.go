@@ -0,0 +1,142 @@
+package core
+
+import (
+	"api/image"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// pendingOperationTimeout is longer than any operation takes, older pending operations were interrupted
+const pendingOperationTimeout = 10 * time.Minute
+
+var errNoServiceAuthorization = errors.New("missing authorization for the images api")
+
+// operationSteps run the steps of the pending operation, progress is saved to its payload
+type operationSteps func(sg *saga, operation *storage.PendingOperation) (storage.Image, error)
+
+// runOperation records the operation, runs its steps and removes the record once they either succeed or are fully
+// compensated. When compensation fails the record is kept for RecoverPendingOperations.
+func (service *ImagesService) runOperation(
+	ctx context.Context, operation storage.PendingOperation, steps operationSteps,
+) (storage.Image, error) {
+	created, err := service.operations.Create(ctx, operation)
+	if err != nil {
+		return storage.Image{}, fmt.Errorf("failed recording pending operation: %w", err)
+	}
+
+	sg := newSaga(service.logger)
+	img, err := steps(sg, &created)
+	if err != nil {
+		if compensateErr := sg.compensate(); compensateErr != nil {
+			service.logger.Error().
+				Err(compensateErr).
+				Str("operationId", created.Id).
+				Msg("failed compensating, the operation is left for recovery")
+			return storage.Image{}, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), compensationTimeout)
+	defer cancel()
+	if deleteErr := service.operations.DeleteOne(ctx, created.Id); deleteErr != nil {
+		service.logger.Error().Err(deleteErr).Str("operationId", created.Id).Msg("failed removing pending operation")
+	}
+
+	return service.urls.WithUrls(img), err
+}
+
+func (service *ImagesService) saveProgress(ctx context.Context, operation *storage.PendingOperation) error {
+	if err := service.operations.UpdatePayload(ctx, operation.Id, operation.Payload); err != nil {
+		return fmt.Errorf("failed saving progress of pending operation: %w", err)
+	}
+	return nil
+}
+
+// RecoverPendingOperations finishes interrupted operations whose files were already resized and rolls back the
+// rest. The images api is called with the authorization header, operations that fail to recover are kept.
+func (service *ImagesService) RecoverPendingOperations(ctx context.Context, authorizationHeader string) error {
+	operations, err := service.operations.GetStartedBefore(ctx, time.Now().Add(-pendingOperationTimeout))
+	if err != nil {
+		return fmt.Errorf("failed fetching pending operations: %w", err)
+	}
+
+	for _, operation := range operations {
+		if err = service.recoverOperation(ctx, authorizationHeader, operation); err != nil {
+			service.logger.Error().Err(err).Str("operationId", operation.Id).Msg("failed recovering pending operation")
+			continue
+		}
+		if err = service.operations.DeleteOne(ctx, operation.Id); err != nil {
+			return fmt.Errorf("failed removing recovered operation: %w", err)
+		}
+		service.logger.Info().Str("operationId", operation.Id).Msgf("recovered %s operation", operation.Kind)
+	}
+
+	return nil
+}
+
+func (service *ImagesService) recoverOperation(
+	ctx context.Context, authorizationHeader string, operation storage.PendingOperation,
+) error {
+	payload := operation.Payload
+
+	if !payload.Resized {
+		return service.rollbackOperation(ctx, authorizationHeader, payload)
+	}
+
+	if operation.Kind == storage.PendingOperationUpload {
+		exists, err := service.imagesRepository.DoesImageExist(ctx, payload.Image.Name)
+		if err != nil || exists {
+			return err
+		}
+		_, err = service.imagesRepository.Create(ctx, payload.Image)
+		return err
+	}
+
+	if payload.Archive != nil {
+		_, err := service.revisions.Create(ctx, *payload.Archive)
+		if err != nil && !errors.Is(err, storage.ErrDuplicate) {
+			return err
+		}
+	}
+	if payload.RestoredRevision != 0 {
+		err := service.revisions.DeleteOne(ctx, payload.Image.Id, payload.RestoredRevision)
+		if err != nil && !errors.As(err, &storage.NotFound{}) {
+			return err
+		}
+	}
+
+	return service.imagesRepository.UpdateOne(ctx, payload.Image)
+}
+
+// rollbackOperation moves archived files back to the previous image and removes the uploaded files
+func (service *ImagesService) rollbackOperation(
+	ctx context.Context, authorizationHeader string, payload storage.PendingOperationPayload,
+) error {
+	if payload.Archive == nil && len(payload.UploadedFiles) == 0 {
+		return nil
+	}
+	if authorizationHeader == "" {
+		return errNoServiceAuthorization
+	}
+
+	if payload.Archive != nil && payload.Previous != nil {
+		archived := storage.Image{
+			Name:    revisionName(payload.Archive.ImageId, payload.Archive.Revision),
+			Format:  payload.Archive.Format,
+			Sizes:   payload.Archive.Sizes,
+			Formats: payload.Archive.Formats,
+		}
+		if _, err := service.renameRemote(ctx, authorizationHeader, archived, payload.Previous.Name); err != nil {
+			return err
+		}
+	}
+	if len(payload.UploadedFiles) == 0 {
+		return nil
+	}
+
+	request := image.DeleteUploadsRequest{FileNames: payload.UploadedFiles}
+	return service.resizeApi.DeleteUploads(ctx, authorizationHeader, request)
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/image"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+// revisionName is the name the files of a revision are stored under, it can't collide with the SEO name of an image
+func revisionName(imageId string, revision int) string {
+	return fmt.Sprintf("revisions/%s/%d", imageId, revision)
+}
+
+func (service *ImagesService) GetRevisions(ctx context.Context, imageId string) (storage.ImageRevisionList, error) {
+	img, err := service.GetOne(ctx, imageId)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.revisions.Get(ctx, img.Id)
+}
+
+func (service *ImagesService) GetRevision(
+	ctx context.Context, imageId string, revision int,
+) (storage.ImageRevision, error) {
+	img, err := service.GetOne(ctx, imageId)
+	if err != nil {
+		return storage.ImageRevision{}, err
+	}
+
+	return service.revisions.GetOne(ctx, img.Id, revision)
+}
+
+// Rollback moves the files of the revision back to the image, the current files are archived as a new revision
+func (service *ImagesService) Rollback(
+	ctx context.Context,
+	authorization auth.AuthorizationDto,
+	imageId string,
+	revision int,
+) (storage.Image, error) {
+	parsedId, err := uuid.Parse(imageId)
+	if err != nil {
+		return storage.Image{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	user, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return storage.Image{}, err
+	}
+	if user.Role != storage.AuthRoleAdmin {
+		return storage.Image{}, exception.Forbidden{}
+	}
+
+	img, err := service.imagesRepository.GetOne(ctx, parsedId.String())
+	if err != nil {
+		return storage.Image{}, err
+	}
+	restored, err := service.revisions.GetOne(ctx, img.Id, revision)
+	if err != nil {
+		return storage.Image{}, err
+	}
+
+	operation := storage.PendingOperation{
+		Kind:    storage.PendingOperationRollback,
+		ImageId: &img.Id,
+		Payload: storage.PendingOperationPayload{Image: img, Previous: &img, RestoredRevision: revision},
+	}
+
+	return service.runOperation(ctx, operation, func(sg *saga, operation *storage.PendingOperation) (storage.Image, error) {
+		if err := service.archiveSteps(ctx, sg, operation, authorization.Header); err != nil {
+			return storage.Image{}, err
+		}
+		if err := service.restoreSteps(ctx, sg, operation, authorization.Header, restored); err != nil {
+			return storage.Image{}, err
+		}
+		if err := service.saveRevisionSteps(ctx, sg, operation); err != nil {
+			return storage.Image{}, err
+		}
+
+		if err := service.revisions.DeleteOne(ctx, img.Id, revision); err != nil {
+			return storage.Image{}, fmt.Errorf("failed removing restored revision: %w", err)
+		}
+		sg.addCompensation("recreate restored revision", func(ctx context.Context) error {
+			_, createErr := service.revisions.Create(ctx, restored)
+			return createErr
+		})
+
+		if err := service.imagesRepository.UpdateOne(ctx, operation.Payload.Image); err != nil {
+			return storage.Image{}, fmt.Errorf("failed saving rolled back image: %w", err)
+		}
+		service.invalidate(ctx, authorization.Header, img)
+
+		return service.imagesRepository.GetOne(ctx, img.Id)
+	})
+}
+
+// archiveSteps moves the files of the previous image to a new revision, which is set as the operation archive
+func (service *ImagesService) archiveSteps(
+	ctx context.Context,
+	sg *saga,
+	operation *storage.PendingOperation,
+	authHeader string,
+) error {
+	previous := *operation.Payload.Previous
+
+	number, err := service.revisions.NextRevision(ctx, previous.Id)
+	if err != nil {
+		return fmt.Errorf("failed numbering revision: %w", err)
+	}
+
+	archiveName := revisionName(previous.Id, number)
+	res, err := service.renameRemote(ctx, authHeader, previous, archiveName)
+	if err != nil {
+		return fmt.Errorf("error archiving files: %w", err)
+	}
+
+	archived := withResizeResponse(previous, archiveName, res)
+	sg.addCompensation("restore archived files", func(ctx context.Context) error {
+		_, renameErr := service.renameRemote(ctx, authHeader, archived, previous.Name)
+		return renameErr
+	})
+
+	revision := storage.ImageRevision{
+		ImageId:       previous.Id,
+		Revision:      number,
+		Name:          previous.Name,
+		Format:        archived.Format,
+		Original:      archived.Original,
+		Domain:        archived.Domain,
+		Path:          archived.Path,
+		Sizes:         archived.Sizes,
+		Formats:       archived.Formats,
+		BlurHash:      previous.BlurHash,
+		Lqip:          previous.Lqip,
+		DominantColor: previous.DominantColor,
+	}
+	if previous.AuthorId != "" {
+		revision.AuthorId = &previous.AuthorId
+	}
+	operation.Payload.Archive = &revision
+
+	return service.saveProgress(ctx, operation)
+}
+
+// restoreSteps moves the files of the revision back under the name of the operation image
+func (service *ImagesService) restoreSteps(
+	ctx context.Context,
+	sg *saga,
+	operation *storage.PendingOperation,
+	authHeader string,
+	restored storage.ImageRevision,
+) error {
+	imageName := operation.Payload.Image.Name
+	stored := storage.Image{
+		Name:    revisionName(restored.ImageId, restored.Revision),
+		Format:  restored.Format,
+		Sizes:   restored.Sizes,
+		Formats: restored.Formats,
+	}
+
+	res, err := service.renameRemote(ctx, authHeader, stored, imageName)
+	if err != nil {
+		return fmt.Errorf("error restoring revision files: %w", err)
+	}
+
+	// The placeholder of the restored files is kept with the revision, the backfill computes it for revisions archived
+	// before it was. Their metadata and perceptual hash are lost.
+	rolledBack := withPlaceholder(withResizeResponse(operation.Payload.Image, imageName, res), image.Placeholder{
+		BlurHash:      restored.BlurHash,
+		Lqip:          restored.Lqip,
+		DominantColor: restored.DominantColor,
+	})
+	rolledBack.Metadata = nil
+	rolledBack.PerceptualHash = ""
+	sg.addCompensation("archive restored files", func(ctx context.Context) error {
+		_, renameErr := service.renameRemote(ctx, authHeader, rolledBack, stored.Name)
+		return renameErr
+	})
+	operation.Payload.Image = rolledBack
+	operation.Payload.Resized = true
+
+	return service.saveProgress(ctx, operation)
+}
+
+// saveRevisionSteps records the operation archive as a revision of the image
+func (service *ImagesService) saveRevisionSteps(
+	ctx context.Context,
+	sg *saga,
+	operation *storage.PendingOperation,
+) error {
+	archive := *operation.Payload.Archive
+	if _, err := service.revisions.Create(ctx, archive); err != nil {
+		return fmt.Errorf("failed saving revision: %w", err)
+	}
+	sg.addCompensation("remove revision", func(ctx context.Context) error {
+		return service.revisions.DeleteOne(ctx, archive.ImageId, archive.Revision)
+	})
+
+	return nil
+}
+
+// renameRemote moves every size variant of the image to the new name
+func (service *ImagesService) renameRemote(
+	ctx context.Context, authHeader string, img storage.Image, newName string,
+) (image.ResizeResponse, error) {
+	request := image.RenameRequest{
+		Name:    img.Name,
+		NewName: newName,
+		Format:  image.Format(img.Format),
+		SizeMap: img.Sizes.ToSizes(),
+		Formats: img.Formats.ToVariantFormats(),
+	}
+
+	return service.resizeApi.Rename(ctx, authHeader, request)
+}
+
+// deleteRevisionFiles removes the files of every revision of the image
+func (service *ImagesService) deleteRevisionFiles(ctx context.Context, authHeader string, imageId string) error {
+	revisions, err := service.revisions.Get(ctx, imageId)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, revision := range revisions {
+		stored := storage.Image{
+			Name:    revisionName(revision.ImageId, revision.Revision),
+			Format:  revision.Format,
+			Sizes:   revision.Sizes,
+			Formats: revision.Formats,
+		}
+		if err = service.deleteFiles(ctx, authHeader, stored); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
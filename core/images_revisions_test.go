@@ -0,0 +1,118 @@
+package core
+
+import (
+	"api/auth"
+	"api/image"
+	"api/storage"
+	"context"
+	"errors"
+	"testing"
+)
+
+const revisionsImageId = "3c47d736-6c4e-4a1c-a04b-3744cc30b263"
+
+// revisedImage is the image whose files are archived, with a placeholder
+func revisedImage() *memoryImageRepo {
+	return &memoryImageRepo{image: storage.Image{
+		Id: revisionsImageId, Name: "my-plane", Format: "jpg", BlurHash: "LEHV6nWB2yk8", Lqip: "data:,",
+	}}
+}
+
+// placeholderRevisionRepo has a revision with a placeholder and records the archived ones
+type placeholderRevisionRepo struct {
+	storage.ImageRevisionRepoMock
+	created []storage.ImageRevision
+}
+
+func (repo *placeholderRevisionRepo) GetOne(
+	_ context.Context, imageId string, revision int,
+) (storage.ImageRevision, error) {
+	return storage.ImageRevision{
+		ImageId:       imageId,
+		Revision:      revision,
+		Name:          "my-plane",
+		Format:        "jpg",
+		BlurHash:      "L6PZfSi_.AyE",
+		DominantColor: "#aabbcc",
+	}, nil
+}
+
+func (repo *placeholderRevisionRepo) Create(
+	_ context.Context, revision storage.ImageRevision,
+) (storage.ImageRevision, error) {
+	repo.created = append(repo.created, revision)
+	return revision, nil
+}
+
+type adminAuthenticator struct {
+	auth.Mock
+}
+
+func (a *adminAuthenticator) GetOrSyncUser(_ context.Context, _ auth.AuthorizationDto) (storage.User, error) {
+	return storage.User{Role: storage.AuthRoleAdmin}, nil
+}
+
+func TestImagesService_Update_ArchivesPreviousFiles(t *testing.T) {
+	update := func(service *ImagesService) error {
+		original, cropped := pngUpload("plane"), pngUpload("plane")
+		_, err := service.Update(
+			context.Background(),
+			revisionsImageId,
+			auth.AuthorizationDto{},
+			"",
+			image.PngFormat,
+			&original,
+			&cropped,
+		)
+		return err
+	}
+
+	t.Run("Archived before resizing", func(t *testing.T) {
+		resizer := &recordingResizer{}
+		if err := update(newTestImagesService(testImagesDeps{resizer: resizer, images: revisedImage()})); err != nil {
+			t.Fatal(err)
+		}
+		if len(resizer.renames) != 1 {
+			t.Fatalf("expected 1 rename, got %d", len(resizer.renames))
+		}
+		archive := resizer.renames[0]
+		if archive.Name != "my-plane" || archive.NewName != revisionName(revisionsImageId, 1) {
+			t.Fatalf("expected files to be archived as the first revision, got %+v", archive)
+		}
+	})
+
+	t.Run("Archive restored when resizing fails", func(t *testing.T) {
+		resizer := &recordingResizer{failResize: true}
+		err := update(newTestImagesService(testImagesDeps{resizer: resizer, images: revisedImage()}))
+		if !errors.Is(err, errStep) {
+			t.Fatalf("expected the resize error, got %v", err)
+		}
+		if len(resizer.renames) != 2 {
+			t.Fatalf("expected the archive to be renamed back, got %+v", resizer.renames)
+		}
+		restore := resizer.renames[1]
+		if restore.Name != revisionName(revisionsImageId, 1) || restore.NewName != "my-plane" {
+			t.Fatalf("expected files to be restored from the archive, got %+v", restore)
+		}
+	})
+}
+
+func TestImagesService_Rollback_KeepsPlaceholders(t *testing.T) {
+	images := revisedImage()
+	revisions := &placeholderRevisionRepo{}
+	service := newTestImagesService(testImagesDeps{
+		images: images, revisions: revisions, authenticator: &adminAuthenticator{},
+	})
+
+	if _, err := service.Rollback(context.Background(), auth.AuthorizationDto{}, revisionsImageId, 1); err != nil {
+		t.Fatal(err)
+	}
+	updated := images.updated
+	if updated.BlurHash != "L6PZfSi_.AyE" || updated.DominantColor != "#aabbcc" || updated.Lqip != "" {
+		t.Fatalf("expected the placeholder of the revision to be restored, got %+v", updated)
+	}
+	created := revisions.created
+	if len(created) != 1 || created[0].BlurHash != "LEHV6nWB2yk8" || created[0].Lqip != "data:," {
+		t.Fatalf("expected the placeholder of the current files to be archived, got %+v", created)
+	}
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/storage"
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	searchQueryMaxLength = 200
+	suggestionsLimit     = 10
+)
+
+func (service *ImagesService) Search(
+	ctx context.Context, query string, limit, offset int,
+) (storage.ImageSearchResultList, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || len(query) > searchQueryMaxLength {
+		return storage.ImageSearchResultList{}, exception.InvalidArgument{
+			Reason: fmt.Sprintf("Search query should be between 1 and %d characters", searchQueryMaxLength),
+		}
+	}
+
+	results, err := service.imagesRepository.Search(ctx, query, limit, offset)
+	if err != nil {
+		return storage.ImageSearchResultList{}, fmt.Errorf("failed searching images: %w", err)
+	}
+	for i := range results {
+		results[i].Image = service.urls.WithUrls(results[i].Image)
+	}
+
+	return results, nil
+}
+
+// Suggest returns autocomplete suggestions of image names and tags for the typed prefix
+func (service *ImagesService) Suggest(ctx context.Context, prefix string) (storage.SuggestionList, error) {
+	prefix = NormalizeTagValue(prefix)
+	if prefix == "" || len(prefix) > searchQueryMaxLength {
+		return storage.SuggestionList{}, exception.InvalidArgument{
+			Reason: fmt.Sprintf("Search query should be between 1 and %d characters", searchQueryMaxLength),
+		}
+	}
+
+	suggestions, err := service.imagesRepository.Suggest(ctx, prefix, suggestionsLimit)
+	if err != nil {
+		return storage.SuggestionList{}, fmt.Errorf("failed fetching suggestions: %w", err)
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"api/auth"
+	"api/image"
+	"api/storage"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/rs/zerolog"
+	"hash/crc32"
+	"io"
+	"sync"
+)
+
+var errStep = errors.New("step failed")
+
+// createdImageId is the id memoryImageRepo gives to the images created in it
+const createdImageId = "3d1c5b7a-9e2f-4a6b-8c0d-1e2f3a4b5c6d"
+
+// testImagesDeps are the dependencies of the images service under test, the ones left out are mocks
+type testImagesDeps struct {
+	resizer       image.Resizer
+	images        storage.ImagesRepository
+	revisions     storage.ImageRevisionRepository
+	placeholders  image.PlaceholderGenerator
+	strip         []image.MetadataField
+	authenticator auth.Authenticator
+	// config sets the duplicates policy, the duplicates are searched in the images repository
+	config Config
+}
+
+func newTestImagesService(deps testImagesDeps) *ImagesService {
+	logger := zerolog.Nop()
+	if deps.resizer == nil {
+		deps.resizer = &recordingResizer{}
+	}
+	if deps.images == nil {
+		deps.images = storage.ImageRepoMock{}
+	}
+	if deps.revisions == nil {
+		deps.revisions = storage.ImageRevisionRepoMock{}
+	}
+	if deps.placeholders == nil {
+		deps.placeholders = image.PlaceholderMock{}
+	}
+	if deps.authenticator == nil {
+		deps.authenticator = &auth.Mock{}
+	}
+
+	return NewImagesService(
+		deps.resizer,
+		deps.images,
+		deps.revisions,
+		storage.PendingOperationRepoMock{},
+		deps.placeholders,
+		image.NewMetadataProcessor(deps.strip),
+		NewDuplicateDetector(deps.config, image.PerceptualHasherMock{}, deps.images, &logger),
+		deps.authenticator,
+		NewUrlBuilder(deps.config),
+		&logger,
+	)
+}
+
+// testPng is a png holding only a text chunk with the content, uploads have to be files of their format for their
+// metadata to be processed
+func testPng(content string) []byte {
+	chunk := append([]byte("tEXtComment\x00"), content...)
+	file := []byte("\x89PNG\r\n\x1a\n")
+	file = binary.BigEndian.AppendUint32(file, uint32(len(chunk)-4))
+	file = append(file, chunk...)
+	file = binary.BigEndian.AppendUint32(file, crc32.ChecksumIEEE(chunk))
+	return file
+}
+
+func pngUpload(content string) image.Upload {
+	file := testPng(content)
+	return image.Upload{Body: bytes.NewReader(file), Size: int64(len(file))}
+}
+
+// recordingResizer records the files it's asked to upload, rename and delete. Uploaded files are read, so what
+// tees them sees their whole content.
+type recordingResizer struct {
+	image.Mock
+	failResize bool
+	// failDelete is the name of the image whose files fail to be deleted
+	failDelete string
+
+	mu             sync.Mutex
+	uploaded       [][]byte
+	renames        []image.RenameRequest
+	deletedUploads int
+	deleted        int
+}
+
+func (r *recordingResizer) UploadFile(
+	_ context.Context, _ image.SignedResponse, _ image.Format, body io.Reader, _ int64,
+) error {
+	content, err := io.ReadAll(body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.uploaded = append(r.uploaded, content)
+	return err
+}
+
+func (r *recordingResizer) Resize(
+	_ context.Context, _ string, request image.ResizeRequest,
+) (image.ResizeResponse, error) {
+	if r.failResize {
+		return image.ResizeResponse{}, errStep
+	}
+	return image.ResizeResponse{Name: request.Name, Format: image.JpgFormat}, nil
+}
+
+func (r *recordingResizer) Rename(
+	_ context.Context, _ string, request image.RenameRequest,
+) (image.ResizeResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.renames = append(r.renames, request)
+	return image.ResizeResponse{Name: request.NewName, Format: request.Format}, nil
+}
+
+func (r *recordingResizer) DeleteUploads(_ context.Context, _ string, _ image.DeleteUploadsRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deletedUploads++
+	return nil
+}
+
+func (r *recordingResizer) Delete(_ context.Context, _ string, request image.DeleteRequest) error {
+	if r.failDelete != "" && request.Name == r.failDelete {
+		return errStep
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted++
+	return nil
+}
+
+// memoryImageRepo holds a single image and records the images saved to it
+type memoryImageRepo struct {
+	storage.ImageRepoMock
+	image       storage.Image
+	isNameTaken bool
+	createErr   error
+	created     storage.Image
+	updated     storage.Image
+}
+
+func (repo *memoryImageRepo) GetOne(_ context.Context, imageId string) (storage.Image, error) {
+	if imageId != repo.image.Id {
+		return storage.Image{}, storage.NotFound{Msg: "Image not found by id " + imageId}
+	}
+	return repo.image, nil
+}
+
+func (repo *memoryImageRepo) GetOneByName(_ context.Context, name string) (storage.Image, error) {
+	if name != repo.image.Name {
+		return storage.Image{}, storage.NotFound{Msg: "Image not found by name " + name}
+	}
+	return repo.image, nil
+}
+
+func (repo *memoryImageRepo) DoesImageExist(_ context.Context, _ string) (bool, error) {
+	return repo.isNameTaken, nil
+}
+
+func (repo *memoryImageRepo) Create(_ context.Context, img storage.Image) (storage.Image, error) {
+	if repo.createErr != nil {
+		return storage.Image{}, repo.createErr
+	}
+	img.Id = createdImageId
+	repo.created = img
+	return img, nil
+}
+
+func (repo *memoryImageRepo) UpdateOne(_ context.Context, img storage.Image) error {
+	repo.updated = img
+	return nil
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/image"
+	"api/storage"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// purgeBatchSize limits the images purged in a single run, the rest is purged in the following runs
+const purgeBatchSize = 100
+
+// GetTrash returns a page of images in the trash
+func (service *ImagesService) GetTrash(
+	ctx context.Context, pagination storage.Pagination, includeTotal bool,
+) (storage.ImagePage, error) {
+	return service.Get(ctx, storage.ImageFilter{Trashed: true}, pagination, includeTotal)
+}
+
+func (service *ImagesService) Restore(
+	ctx context.Context,
+	auth auth.AuthorizationDto,
+	imageId string,
+) (storage.Image, error) {
+	parsedId, err := uuid.Parse(imageId)
+	if err != nil {
+		return storage.Image{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	user, err := service.authenticator.GetOrSyncUser(ctx, auth)
+	if err != nil {
+		return storage.Image{}, err
+	}
+	if user.Role != storage.AuthRoleAdmin {
+		return storage.Image{}, exception.Forbidden{}
+	}
+
+	restored, err := service.imagesRepository.Restore(ctx, parsedId.String())
+	if err != nil {
+		return storage.Image{}, err
+	}
+
+	return service.urls.WithUrls(restored), nil
+}
+
+// PurgeTrash permanently deletes the images that were in the trash longer than the retention, along with their
+// files. Images whose files fail to be deleted are kept for the next run.
+func (service *ImagesService) PurgeTrash(
+	ctx context.Context, authorizationHeader string, retention time.Duration,
+) (int, error) {
+	if authorizationHeader == "" {
+		return 0, errNoServiceAuthorization
+	}
+
+	images, err := service.imagesRepository.GetTrashedBefore(ctx, time.Now().Add(-retention), purgeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed fetching trashed images: %w", err)
+	}
+
+	purged := 0
+	for _, img := range images {
+		deleteRequest := image.DeleteRequest{
+			Name:       img.Name,
+			Format:     image.Format(img.Format),
+			Dimensions: img.Sizes.ToSizes().GetAllDimensions(),
+			Formats:    img.Formats.ToVariantFormats().Others(),
+		}
+		if err = service.resizeApi.Delete(ctx, authorizationHeader, deleteRequest); err != nil {
+			service.logger.Error().Err(err).Msgf("failed deleting files of trashed image %s", img.Id)
+			continue
+		}
+		if err = service.deleteRevisionFiles(ctx, authorizationHeader, img.Id); err != nil {
+			service.logger.Error().Err(err).Msgf("failed deleting revision files of trashed image %s", img.Id)
+			continue
+		}
+		if err = service.resizeApi.Invalidate(ctx, authorizationHeader, deleteRequest); err != nil {
+			service.logger.Error().Msgf("failed invalidating image %s: %s", img.Id, err.Error())
+		}
+
+		if err = service.imagesRepository.DeleteOne(ctx, img.Id); err != nil {
+			return purged, fmt.Errorf("failed deleting trashed image %s: %w", img.Id, err)
+		}
+		purged++
+	}
+
+	return purged, nil
+}
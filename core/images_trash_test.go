@@ -0,0 +1,50 @@
+package core
+
+import (
+	"api/storage"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type trashRepo struct {
+	storage.ImageRepoMock
+	trashed storage.ImageList
+	deleted []string
+}
+
+func (repo *trashRepo) GetTrashedBefore(_ context.Context, _ time.Time, _ int) (storage.ImageList, error) {
+	return repo.trashed, nil
+}
+
+func (repo *trashRepo) DeleteOne(_ context.Context, imageId string) error {
+	repo.deleted = append(repo.deleted, imageId)
+	return nil
+}
+
+func TestImagesService_PurgeTrash(t *testing.T) {
+	repo := &trashRepo{trashed: storage.ImageList{
+		{Id: "first", Name: "first-image"},
+		{Id: "second", Name: "second-image"},
+	}}
+	service := newTestImagesService(testImagesDeps{
+		resizer: &recordingResizer{failDelete: "first-image"}, images: repo,
+	})
+
+	purged, err := service.PurgeTrash(context.Background(), "Bearer token", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged image, got %d", purged)
+	}
+	if len(repo.deleted) != 1 || repo.deleted[0] != "second" {
+		t.Fatalf("expected only the image with deleted files to be removed, got %v", repo.deleted)
+	}
+
+	_, err = service.PurgeTrash(context.Background(), "", time.Hour)
+	if !errors.Is(err, errNoServiceAuthorization) {
+		t.Fatalf("expected missing authorization error, got %v", err)
+	}
+}
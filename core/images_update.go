@@ -7,186 +7,210 @@ import (
 	"api/storage"
 	"context"
 	"fmt"
-	"mime/multipart"
+	"github.com/google/uuid"
 )
 
+// Update renames the image, replaces its files or does both. Empty imageName keeps the current name and missing
+// files keep the current variants, at least one of them has to change. Replaced files are checked for near
+// duplicates like uploads.
 func (service *ImagesService) Update(
 	ctx context.Context,
 	imageId string,
 	authorization auth.AuthorizationDto,
 	imageName string,
 	format image.Format,
-	originalFile *multipart.FileHeader,
-	croppedFile *multipart.FileHeader,
+	originalFile *image.Upload,
+	croppedFile *image.Upload,
 ) (storage.Image, error) {
-	isFileUpload := string(format) != "" && originalFile != nil && croppedFile != nil
+	parsedId, err := uuid.Parse(imageId)
+	if err != nil {
+		return storage.Image{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	if (originalFile == nil) != (croppedFile == nil) {
+		return storage.Image{}, exception.InvalidArgument{
+			Reason: "Expected both originalFile and croppedFile to replace the image",
+		}
+	}
+	isFileUpload := originalFile != nil && croppedFile != nil
+	if isFileUpload && !format.IsSupported() {
+		return storage.Image{}, exception.InvalidArgument{
+			Reason: fmt.Sprintf("Unsupported format %s", format),
+		}
+	}
 	if !isFileUpload && imageName == "" {
 		return storage.Image{}, exception.InvalidArgument{
 			Reason: "Expected at least a name change or a file change, got all empty",
 		}
 	}
 
-	_, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	_, err = service.authenticator.GetOrSyncUser(ctx, authorization)
 	if err != nil {
 		return storage.Image{}, err
 	}
 
-	img, err := service.imagesRepository.GetOne(ctx, imageId)
+	img, err := service.imagesRepository.GetOne(ctx, parsedId.String())
 	if err != nil {
 		return storage.Image{}, err
 	}
 
-	if isFileUpload && imageName != "" {
-		return service.updateImageAndName(
-			ctx, authorization.Header, imageName, format, img, originalFile, croppedFile,
-		)
-	} else if imageName == "" {
-		return service.updateImageOnly(
-			ctx, authorization, imageId, format, originalFile, croppedFile,
-		)
+	newName := img.Name
+	if imageName != "" {
+		newName, err = service.validateNewName(ctx, img, imageName)
+		if err != nil {
+			return storage.Image{}, err
+		}
 	}
+	isRename := newName != img.Name
 
-	_, err = service.updateNameOnly(ctx, authorization.Header, img, imageName)
-	if err != nil {
-		return storage.Image{}, err
+	if !isFileUpload && !isRename {
+		return img, nil
 	}
 
-	return service.imagesRepository.SetNameById(ctx, imageId, imageName)
-}
-
-func (service *ImagesService) updateNameOnly(
-	ctx context.Context,
-	authHeader string,
-	img storage.Image,
-	newImageName string,
-) (image.ResizeResponse, error) {
-	request := image.RenameRequest{
-		Name:    img.Name,
-		NewName: newImageName,
-		Format:  image.Format(img.Format),
-		SizeMap: fromStorageImageSizesToImageSizes(img.Sizes),
+	kind := storage.PendingOperationRename
+	if isFileUpload {
+		kind = storage.PendingOperationReplace
 	}
-
-	response, err := service.resizeApi.Rename(ctx, authHeader, request)
-	if err != nil {
-		return image.ResizeResponse{}, err
+	operation := storage.PendingOperation{
+		Kind:    kind,
+		ImageId: &img.Id,
+		Payload: storage.PendingOperationPayload{Image: img, Previous: &img},
 	}
+	operation.Payload.Image.Name = newName
 
-	return response, nil
-}
+	return service.runOperation(ctx, operation, func(sg *saga, operation *storage.PendingOperation) (storage.Image, error) {
+		var err error
+		if isFileUpload {
+			err = service.replaceSteps(ctx, sg, operation, authorization.Header, format, *originalFile, *croppedFile)
+		} else {
+			err = service.renameSteps(ctx, sg, operation, authorization.Header)
+		}
+		if err != nil {
+			return storage.Image{}, err
+		}
 
-func (service *ImagesService) updateImageOnly(
-	ctx context.Context,
-	authDto auth.AuthorizationDto,
-	imageId string,
-	format image.Format,
-	originalFile *multipart.FileHeader,
-	croppedFile *multipart.FileHeader,
-) (storage.Image, error) {
-	img, err := service.imagesRepository.GetOne(ctx, imageId)
-	if err != nil {
-		return storage.Image{}, err
-	}
+		if err = service.imagesRepository.UpdateOne(ctx, operation.Payload.Image); err != nil {
+			return storage.Image{}, fmt.Errorf("failed saving updated image: %w", err)
+		}
+		service.invalidate(ctx, authorization.Header, img)
 
-	originalSignedUrl, croppedSignedUrl, err := service.
-		getMultipleSignUrls(ctx, authDto.Header, format)
-	if err != nil {
-		return storage.Image{}, err
-	}
+		updated, err := service.imagesRepository.GetOne(ctx, img.Id)
+		updated.Duplicates = operation.Payload.Image.Duplicates
+		return updated, err
+	})
+}
 
-	request := image.DeleteRequest{
-		Name:       img.Name,
-		Format:     format,
-		Dimensions: convertStorageSizesToDimensions(img.Sizes),
+// validateNewName returns the SEO form of the name, it must not be taken by another image
+func (service *ImagesService) validateNewName(
+	ctx context.Context, img storage.Image, imageName string,
+) (string, error) {
+	seoImageName := FormatForSeo(imageName)
+	if seoImageName == "" {
+		return "", exception.InvalidArgument{
+			Reason: fmt.Sprintf("Invalid image name of %s", imageName),
+		}
 	}
-	err = service.resizeApi.Delete(ctx, authDto.Header, request)
-	if err != nil {
-		return storage.Image{}, err
+	if seoImageName == img.Name {
+		return seoImageName, nil
 	}
 
-	err = service.uploadBothFiles(
-		ctx,
-		originalSignedUrl.SignedUrl,
-		croppedSignedUrl.SignedUrl,
-		format,
-		originalFile,
-		croppedFile,
-	)
+	isNameTaken, err := service.imagesRepository.DoesImageExist(ctx, seoImageName)
 	if err != nil {
-		return storage.Image{}, err
+		return "", err
 	}
-
-	imageResizeRequest := image.ResizeRequest{
-		Name:             img.Name,
-		FilePath:         croppedSignedUrl.FileName,
-		OriginalFilePath: originalSignedUrl.FileName,
+	if isNameTaken {
+		return "", exception.InvalidArgument{
+			Reason: fmt.Sprintf("Image name: '%s' already exists, please use another", seoImageName),
+		}
 	}
-	_, err = service.resizeApi.Resize(ctx, authDto.Header, imageResizeRequest)
+
+	return seoImageName, nil
+}
+
+// renameSteps moves every size variant of the previous image to the name of the operation image
+func (service *ImagesService) renameSteps(
+	ctx context.Context,
+	sg *saga,
+	operation *storage.PendingOperation,
+	authHeader string,
+) error {
+	previous := *operation.Payload.Previous
+	newImageName := operation.Payload.Image.Name
+
+	res, err := service.renameRemote(ctx, authHeader, previous, newImageName)
 	if err != nil {
-		return storage.Image{}, err
+		return fmt.Errorf("error renaming: %w", err)
 	}
 
-	return img, nil
+	renamed := withResizeResponse(operation.Payload.Image, newImageName, res)
+	sg.addCompensation("rename back", func(ctx context.Context) error {
+		_, renameErr := service.renameRemote(ctx, authHeader, renamed, previous.Name)
+		return renameErr
+	})
+	operation.Payload.Image = renamed
+	operation.Payload.Resized = true
+
+	return service.saveProgress(ctx, operation)
 }
 
-func (service *ImagesService) updateImageAndName(
+// replaceSteps archives the previous files as a revision before the new files are uploaded and resized, so they
+// never overwrite each other
+func (service *ImagesService) replaceSteps(
 	ctx context.Context,
+	sg *saga,
+	operation *storage.PendingOperation,
 	authHeader string,
-	imageName string,
 	format image.Format,
-	img storage.Image,
-	originalFile *multipart.FileHeader,
-	croppedFile *multipart.FileHeader,
-) (storage.Image, error) {
-	seoImageName := FormatForSeo(imageName)
-	if seoImageName == "" {
-		return storage.Image{}, exception.InvalidArgument{
-			Reason: fmt.Sprintf("Invalid image name of %s", imageName),
-		}
+	originalFile image.Upload,
+	croppedFile image.Upload,
+) error {
+	if err := service.archiveSteps(ctx, sg, operation, authHeader); err != nil {
+		return err
 	}
-
-	original, cropped, err := service.getMultipleSignUrls(ctx, authHeader, format)
+	err := service.uploadAndResizeSteps(ctx, sg, operation, authHeader, format, originalFile, croppedFile)
 	if err != nil {
-		return storage.Image{}, err
+		return err
 	}
 
-	if err = service.uploadBothFiles(
-		ctx, original.SignedUrl, cropped.SignedUrl, format, originalFile, croppedFile,
-	); err != nil {
-		return storage.Image{}, err
-	}
+	return service.saveRevisionSteps(ctx, sg, operation)
+}
 
+func (service *ImagesService) deleteFiles(ctx context.Context, authHeader string, img storage.Image) error {
 	request := image.DeleteRequest{
-		Name:       imageName,
-		Format:     format,
-		Dimensions: convertStorageSizesToDimensions(img.Sizes),
+		Name:       img.Name,
+		Format:     image.Format(img.Format),
+		Dimensions: img.Sizes.ToSizes().GetAllDimensions(),
+		Formats:    img.Formats.ToVariantFormats().Others(),
 	}
-	if err = service.resizeApi.Delete(ctx, authHeader, request); err != nil {
-		return storage.Image{}, err
+	if err := service.resizeApi.Delete(ctx, authHeader, request); err != nil {
+		return fmt.Errorf("error deleting files: %w", err)
 	}
 
-	resizeRequest := image.ResizeRequest{
-		Name:             imageName,
-		FilePath:         cropped.FileName,
-		OriginalFilePath: original.FileName,
-	}
-	res, err := service.resizeApi.Resize(ctx, authHeader, resizeRequest)
-	if err != nil {
-		return storage.Image{}, err
-	}
+	return nil
+}
 
-	newImage := storage.Image{
-		Name:     res.Name,
-		Format:   storage.ImageFormat(res.Format),
-		Original: res.Original,
-		Domain:   res.Domain,
-		Path:     res.Path,
-		Sizes:    convertImageSizesToStorageSizes(res.Sizes),
-		AuthorId: img.AuthorId,
+// invalidate clears the previous variants from the CDN, failures are only logged as the update already happened
+func (service *ImagesService) invalidate(ctx context.Context, authHeader string, img storage.Image) {
+	request := image.DeleteRequest{
+		Name:       img.Name,
+		Format:     image.Format(img.Format),
+		Dimensions: img.Sizes.ToSizes().GetAllDimensions(),
+		Formats:    img.Formats.ToVariantFormats().Others(),
 	}
-	if err = service.imagesRepository.UpdateOne(ctx, newImage); err != nil {
-		return storage.Image{}, err
+	if err := service.resizeApi.Invalidate(ctx, authHeader, request); err != nil {
+		service.logger.Error().Msgf("failed invalidating image %s: %s", img.Id, err.Error())
 	}
+}
 
-	return newImage, nil
+// withResizeResponse applies the variants described by the resize api to the image
+func withResizeResponse(img storage.Image, imageName string, res image.ResizeResponse) storage.Image {
+	img.Name = imageName
+	img.Format = storage.ImageFormat(res.Format)
+	img.Original = res.Original
+	img.Domain = res.Domain
+	img.Path = res.Path
+	img.Sizes = storage.NewImageSizes(res.Sizes)
+	img.Formats = storage.NewImageVariantFormats(res.Formats)
+
+	return img
 }
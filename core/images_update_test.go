@@ -0,0 +1,66 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/image"
+	"api/storage"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestImagesService_Update_InvalidArguments(t *testing.T) {
+	service := newTestImagesService(testImagesDeps{resizer: image.Mock{}})
+	imageId := "3c47d736-6c4e-4a1c-a04b-3744cc30b263"
+	file := &image.Upload{Body: strings.NewReader("plane"), Size: 5}
+
+	data := []struct {
+		testName string
+		imageId  string
+		name     string
+		format   image.Format
+		original *image.Upload
+		cropped  *image.Upload
+	}{
+		{testName: "Invalid id", imageId: "not-uuid", name: "new-name"},
+		{testName: "Nothing to change", imageId: imageId},
+		{testName: "Only one file", imageId: imageId, format: image.JpgFormat, original: file},
+		{testName: "Files without format", imageId: imageId, original: file, cropped: file},
+		{testName: "Name without SEO characters", imageId: imageId, name: "!!!"},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			_, err := service.Update(
+				context.Background(), d.imageId, auth.AuthorizationDto{}, d.name, d.format, d.original, d.cropped,
+			)
+			if !errors.As(err, &exception.InvalidArgument{}) {
+				t.Fatalf("expected InvalidArgument, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWithResizeResponse(t *testing.T) {
+	img := storage.Image{Id: "id", Name: "old-name", Format: "png", AuthorId: "author"}
+	res := image.ResizeResponse{
+		Format:   image.JpgFormat,
+		Original: "images/new-name.jpg",
+		Domain:   "https://cdn.example.com",
+		Path:     "images",
+		Sizes:    image.Sizes{Original: image.Dimensions{Width: 300, Height: 200}},
+	}
+
+	updated := withResizeResponse(img, "new-name", res)
+	if updated.Id != "id" || updated.AuthorId != "author" {
+		t.Fatalf("expected id and author to be kept, got %+v", updated)
+	}
+	if updated.Name != "new-name" || updated.Format != "jpg" || updated.Original != res.Original {
+		t.Fatalf("expected resize response to be applied, got %+v", updated)
+	}
+	if updated.Sizes.Original.Width != 300 {
+		t.Fatalf("expected sizes to be applied, got %+v", updated.Sizes)
+	}
+}
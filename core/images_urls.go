@@ -0,0 +1,114 @@
+package core
+
+import (
+	"api/storage"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// DefaultUrlTemplate is the path of variants next to the original, like images/my-plane_300x200.jpg. Templates
+// can also use the {size} name of the variant.
+const DefaultUrlTemplate = "{path}/{name}_{width}x{height}.{format}"
+
+// validateUrlTemplate requires the name of the image and either the name or the dimensions of the variant, so the
+// variants of an image don't share a path
+func validateUrlTemplate(template string) error {
+	if !strings.Contains(template, "{name}") {
+		return errors.New("template must contain {name}")
+	}
+	hasDimensions := strings.Contains(template, "{width}") && strings.Contains(template, "{height}")
+	if !strings.Contains(template, "{size}") && !hasDimensions {
+		return errors.New("template must contain {size} or both {width} and {height}")
+	}
+
+	return nil
+}
+
+// imageUrl joins the domain of an image and the path of one of its files, domains without a scheme use https
+func imageUrl(domain string, filePath string) string {
+	domain = strings.TrimSuffix(domain, "/")
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+
+	return domain + "/" + strings.TrimPrefix(filePath, "/")
+}
+
+// originalUrl is where the original is stored, at the domain of the image and not behind a CDN
+func originalUrl(img storage.Image) string {
+	return imageUrl(img.Domain, img.Original)
+}
+
+// UrlBuilder resolves the absolute urls of the files of images. Variant paths follow the template and every file
+// is served from the CDN domain when one is configured, otherwise from the domain of the image.
+type UrlBuilder struct {
+	domain   string
+	template string
+}
+
+func NewUrlBuilder(config Config) *UrlBuilder {
+	template := config.ImagesUrlTemplate
+	if template == "" {
+		template = DefaultUrlTemplate
+	}
+
+	return &UrlBuilder{
+		domain:   config.ImagesCdnDomain,
+		template: template,
+	}
+}
+
+// Url returns the url of the size of the image in the format, ok is false when the image has no such size.
+// The original keeps its stored path whatever the format.
+func (builder *UrlBuilder) Url(img storage.Image, size string, format storage.ImageFormat) (string, bool) {
+	domain := builder.domain
+	if domain == "" {
+		domain = img.Domain
+	}
+	if size == storage.OriginalSize {
+		return imageUrl(domain, img.Original), true
+	}
+
+	dimensions, ok := img.Sizes.Variants[size]
+	if !ok {
+		return "", false
+	}
+	replacer := strings.NewReplacer(
+		"{path}", img.Path,
+		"{name}", img.Name,
+		"{size}", size,
+		"{width}", strconv.Itoa(dimensions.Width),
+		"{height}", strconv.Itoa(dimensions.Height),
+		"{format}", string(format),
+	)
+
+	return imageUrl(domain, replacer.Replace(builder.template)), true
+}
+
+// Urls maps the original and the variants of the image to their urls in the format of the image
+func (builder *UrlBuilder) Urls(img storage.Image) map[string]string {
+	urls := make(map[string]string, len(img.Sizes.Variants)+1)
+	urls[storage.OriginalSize], _ = builder.Url(img, storage.OriginalSize, img.Format)
+	for size := range img.Sizes.Variants {
+		urls[size], _ = builder.Url(img, size, img.Format)
+	}
+
+	return urls
+}
+
+// WithUrls sets the urls of the image, images without an id like empty results are returned as they are
+func (builder *UrlBuilder) WithUrls(img storage.Image) storage.Image {
+	if img.Id == "" {
+		return img
+	}
+	img.Urls = builder.Urls(img)
+	return img
+}
+
+func (builder *UrlBuilder) withListUrls(images storage.ImageList) storage.ImageList {
+	for i := range images {
+		images[i] = builder.WithUrls(images[i])
+	}
+	return images
+}
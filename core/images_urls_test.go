@@ -0,0 +1,63 @@
+package core
+
+import (
+	"api/storage"
+	"reflect"
+	"testing"
+)
+
+func TestUrlBuilder_Urls(t *testing.T) {
+	img := multiFormatImage().image
+
+	data := []struct {
+		config   Config
+		expected map[string]string
+	}{
+		{
+			config: Config{},
+			expected: map[string]string{
+				storage.OriginalSize: bestPath + ".jpg",
+				"s":                  bestPath + "_300x225.jpg",
+				"m":                  bestPath + "_500x375.jpg",
+			},
+		},
+		{
+			config: Config{ImagesCdnDomain: "http://cdn.test/", ImagesUrlTemplate: "{size}/{name}-{width}.{format}"},
+			expected: map[string]string{
+				storage.OriginalSize: "http://cdn.test/images/my-plane.jpg",
+				"s":                  "http://cdn.test/s/my-plane-300.jpg",
+				"m":                  "http://cdn.test/m/my-plane-500.jpg",
+			},
+		},
+	}
+	for _, d := range data {
+		if urls := NewUrlBuilder(d.config).WithUrls(img).Urls; !reflect.DeepEqual(urls, d.expected) {
+			t.Fatalf("Expected urls %v, got %v", d.expected, urls)
+		}
+	}
+
+	if urls := NewUrlBuilder(Config{}).WithUrls(storage.Image{}).Urls; urls != nil {
+		t.Fatalf("Expected no urls for an empty image, got %v", urls)
+	}
+}
+
+func TestValidateUrlTemplate(t *testing.T) {
+	data := []struct {
+		template string
+		isValid  bool
+	}{
+		{template: DefaultUrlTemplate, isValid: true},
+		{template: "{path}/{size}/{name}.{format}", isValid: true},
+		{template: "{path}/{name}.{format}"},
+		{template: "{path}/{name}_{width}.{format}"},
+		{template: "{path}/{size}.{format}"},
+	}
+
+	for _, d := range data {
+		t.Run(d.template, func(t *testing.T) {
+			if err := validateUrlTemplate(d.template); (err == nil) != d.isValid {
+				t.Fatalf("Expected valid to be %v, got %v", d.isValid, err)
+			}
+		})
+	}
+}
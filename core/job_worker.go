@@ -0,0 +1,82 @@
+package core
+
+import (
+	"api/pkg/concurrency"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+)
+
+// JobWorker runs a pool of workers processing queued jobs, idle workers poll for new jobs
+type JobWorker struct {
+	config      Config
+	jobsService *JobsService
+	closed      chan error
+	cancel      context.CancelFunc
+	logger      *zerolog.Logger
+}
+
+func NewJobWorker(config Config, jobsService *JobsService, logger *zerolog.Logger) *JobWorker {
+	return &JobWorker{
+		config:      config,
+		jobsService: jobsService,
+		closed:      make(chan error),
+		logger:      logger,
+	}
+}
+
+func (worker *JobWorker) StartProcessing(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			isProcessed, err := worker.jobsService.ProcessNext(ctx)
+			if err != nil {
+				worker.logger.Error().Msgf("error processing job: %s", err.Error())
+			}
+			if isProcessed && err == nil {
+				continue
+			}
+
+			err = concurrency.SleepSecondsWithContext(ctx, worker.config.JobsPollIntervalSec)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// StartProcessingAsync starts JobsWorkers workers, with none the jobs are left to other instances
+func (worker *JobWorker) StartProcessingAsync(ctx context.Context) {
+	if worker.config.JobsWorkers == 0 {
+		return
+	}
+	worker.logger.Info().Msgf("Started %d job workers", worker.config.JobsWorkers)
+
+	derivedCtx, cancel := context.WithCancel(ctx)
+	worker.cancel = cancel
+	for i := uint(0); i < worker.config.JobsWorkers; i++ {
+		go func() {
+			worker.closed <- worker.StartProcessing(derivedCtx)
+		}()
+	}
+}
+
+// Shutdown waits for the workers to stop, it does nothing when no worker was started
+func (worker *JobWorker) Shutdown() error {
+	if worker.cancel == nil {
+		return nil
+	}
+	worker.logger.Info().Msg("Shutting down job workers")
+	worker.cancel()
+
+	var errs []error
+	for i := uint(0); i < worker.config.JobsWorkers; i++ {
+		if err := <-worker.closed; err != nil && !errors.Is(err, context.Canceled) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/image"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"strings"
+	"time"
+)
+
+// JobsPath serves the status of jobs, the resize api reports resized jobs to its callback path
+const JobsPath = "/api/v1/jobs"
+
+// maxJobAttempts bounds how often a job is claimed, a job is only claimed again once its lease expired
+const maxJobAttempts = 3
+
+// JobsService creates images in the background. The files are uploaded by the request queueing the job, workers
+// resize them either synchronously or by requesting a resize that the resize api reports through a callback.
+type JobsService struct {
+	config        Config
+	jobs          storage.JobRepository
+	imagesService *ImagesService
+	authenticator auth.Authenticator
+	logger        *zerolog.Logger
+}
+
+func NewJobsService(
+	config Config,
+	jobs storage.JobRepository,
+	imagesService *ImagesService,
+	authenticator auth.Authenticator,
+	logger *zerolog.Logger,
+) *JobsService {
+	return &JobsService{
+		config:        config,
+		jobs:          jobs,
+		imagesService: imagesService,
+		authenticator: authenticator,
+		logger:        logger,
+	}
+}
+
+func (service *JobsService) lockedUntil() time.Time {
+	return time.Now().Add(time.Duration(service.config.JobsLeaseSec) * time.Second)
+}
+
+// UploadAndEnqueue uploads both files and queues the job creating the image from them
+func (service *JobsService) UploadAndEnqueue(
+	ctx context.Context,
+	authorization auth.AuthorizationDto,
+	imageName string,
+	format image.Format,
+	originalFile image.Upload,
+	croppedFile image.Upload,
+) (storage.Job, error) {
+	if _, err := service.imagesService.newImageName(ctx, imageName); err != nil {
+		return storage.Job{}, err
+	}
+
+	currentUser, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return storage.Job{}, err
+	}
+
+	payload, err := service.imagesService.uploadFiles(
+		ctx, authorization.Header, imageName, format, originalFile, croppedFile,
+	)
+	if err != nil {
+		return storage.Job{}, err
+	}
+
+	job, err := service.jobs.Create(ctx, storage.Job{AuthorId: currentUser.Id, Payload: payload})
+	if err != nil {
+		service.imagesService.deleteUploads(ctx, authorization.Header, payload.OriginalFile, payload.CroppedFile)
+		return storage.Job{}, fmt.Errorf("failed queueing job: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetOne hides jobs of other users
+func (service *JobsService) GetOne(
+	ctx context.Context, authorization auth.AuthorizationDto, jobId string,
+) (storage.Job, error) {
+	if _, err := uuid.Parse(jobId); err != nil {
+		return storage.Job{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	currentUser, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return storage.Job{}, err
+	}
+
+	job, err := service.jobs.GetOne(ctx, jobId)
+	if err != nil {
+		return storage.Job{}, err
+	}
+	if job.AuthorId != currentUser.Id {
+		return storage.Job{}, exception.NotFound{Msg: "Job not found by id " + jobId}
+	}
+
+	return job, nil
+}
+
+// ProcessNext claims and processes the next job, false is returned when there was none
+func (service *JobsService) ProcessNext(ctx context.Context) (bool, error) {
+	job, isClaimed, err := service.jobs.ClaimNext(ctx, service.lockedUntil())
+	if err != nil || !isClaimed {
+		return false, err
+	}
+
+	return true, service.process(ctx, job)
+}
+
+func (service *JobsService) process(ctx context.Context, job storage.Job) error {
+	authHeader := service.config.ImagesApiAuthorization
+	if job.Attempts > maxJobAttempts {
+		service.fail(ctx, job, fmt.Sprintf("gave up after %d attempts", maxJobAttempts))
+		return nil
+	}
+
+	// the name may have been taken since the job was queued
+	seoImageName, err := service.imagesService.newImageName(ctx, job.Payload.Name)
+	if err != nil {
+		service.fail(ctx, job, err.Error())
+		return nil
+	}
+	if service.config.JobsCallbackUrl != "" {
+		return service.requestResize(ctx, job, seoImageName)
+	}
+
+	img, err := service.imagesService.createFromUploads(ctx, authHeader, job.AuthorId, seoImageName, job.Payload)
+	if image.IsRetryable(err) {
+		// the job keeps its uploaded files and stays running, to be retried once the lease expired
+		return fmt.Errorf("failed creating image of job %s: %w", job.Id, err)
+	}
+	if err != nil {
+		service.fail(ctx, job, err.Error())
+		return nil
+	}
+
+	return service.jobs.Succeed(uncancelable{ctx}, job.Id, img.Id)
+}
+
+// requestResize lets the job wait for the callback of the resize api. The job waits before the resize is requested,
+// so a callback never finds it running. Requests that may succeed when sent again set it running again, to be retried
+// once the lease expired.
+func (service *JobsService) requestResize(ctx context.Context, job storage.Job, seoImageName string) error {
+	if err := service.jobs.Wait(ctx, job.Id, service.lockedUntil()); err != nil {
+		return err
+	}
+
+	err := service.imagesService.resizeApi.RequestResize(ctx, service.config.ImagesApiAuthorization, image.ResizeRequest{
+		Name:             seoImageName,
+		FilePath:         job.Payload.CroppedFile,
+		OriginalFilePath: job.Payload.OriginalFile,
+		CallbackUrl:      strings.TrimSuffix(service.config.JobsCallbackUrl, "/") + JobsPath + "/" + job.Id + "/callback",
+	})
+	if image.IsRetryable(err) {
+		// a callback may have resumed the job already if the request was resized anyway
+		if _, resumeErr := service.jobs.Resume(uncancelable{ctx}, job.Id, service.lockedUntil()); resumeErr != nil {
+			service.logger.Error().Str("jobId", job.Id).Msgf("failed resuming job: %s", resumeErr.Error())
+		}
+		return fmt.Errorf("failed requesting resize of job %s: %w", job.Id, err)
+	}
+	if err != nil {
+		service.fail(ctx, job, err.Error())
+		return nil
+	}
+
+	return nil
+}
+
+// Complete saves the image the resize api reported for the waiting job
+func (service *JobsService) Complete(ctx context.Context, jobId string, callback image.ResizeCallback) error {
+	if _, err := uuid.Parse(jobId); err != nil {
+		return exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	job, err := service.jobs.GetOne(ctx, jobId)
+	if err != nil {
+		return err
+	}
+	isResumed, err := service.jobs.Resume(ctx, job.Id, service.lockedUntil())
+	if err != nil {
+		return err
+	}
+	if !isResumed {
+		return exception.Conflict{Reason: fmt.Sprintf("Job %s is not waiting for a callback", job.Id)}
+	}
+
+	if callback.Resized == nil {
+		reason := callback.Error
+		if reason == "" {
+			reason = "the resize api reported no resized files"
+		}
+		service.fail(ctx, job, "failed resizing: "+reason)
+		return nil
+	}
+
+	img, err := service.imagesService.createFromResized(
+		ctx, service.config.ImagesApiAuthorization, job.AuthorId, job.Payload, *callback.Resized,
+	)
+	if err != nil {
+		// the resized and uploaded files are deleted by the failed operation
+		service.markFailed(ctx, job.Id, err.Error())
+		return nil
+	}
+
+	return service.jobs.Succeed(uncancelable{ctx}, job.Id, img.Id)
+}
+
+// fail marks the job failed and deletes its uploaded files
+func (service *JobsService) fail(ctx context.Context, job storage.Job, reason string) {
+	service.markFailed(ctx, job.Id, reason)
+	service.imagesService.deleteUploads(
+		uncancelable{ctx}, service.config.ImagesApiAuthorization, job.Payload.OriginalFile, job.Payload.CroppedFile,
+	)
+}
+
+func (service *JobsService) markFailed(ctx context.Context, jobId string, reason string) {
+	service.logger.Warn().Str("jobId", jobId).Msgf("job failed: %s", reason)
+
+	err := service.jobs.Fail(uncancelable{ctx}, jobId, reason)
+	var notFound storage.NotFound
+	if err != nil && !errors.As(err, &notFound) {
+		service.logger.Error().Str("jobId", jobId).Msgf("failed marking job failed: %s", err.Error())
+	}
+}
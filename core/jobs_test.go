@@ -0,0 +1,324 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/image"
+	"api/storage"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"testing"
+	"time"
+)
+
+type memoryJobRepo struct {
+	storage.JobRepoMock
+	jobs map[string]storage.Job
+}
+
+func (repo *memoryJobRepo) Create(_ context.Context, job storage.Job) (storage.Job, error) {
+	job.Id = testJobId
+	job.Status = storage.JobQueued
+	repo.jobs[job.Id] = job
+	return job, nil
+}
+
+func (repo *memoryJobRepo) GetOne(_ context.Context, jobId string) (storage.Job, error) {
+	job, ok := repo.jobs[jobId]
+	if !ok {
+		return storage.Job{}, storage.NotFound{Msg: "Job not found by id " + jobId}
+	}
+	return job, nil
+}
+
+func (repo *memoryJobRepo) ClaimNext(_ context.Context, lockedUntil time.Time) (storage.Job, bool, error) {
+	for id, job := range repo.jobs {
+		if job.Status == storage.JobQueued {
+			job.Status = storage.JobRunning
+			job.Attempts++
+			job.LockedUntil = &lockedUntil
+			repo.jobs[id] = job
+			return job, true, nil
+		}
+	}
+	return storage.Job{}, false, nil
+}
+
+func (repo *memoryJobRepo) setStatus(jobId string, from []storage.JobStatus, to storage.JobStatus) bool {
+	job := repo.jobs[jobId]
+	for _, status := range from {
+		if job.Status == status {
+			job.Status = to
+			repo.jobs[jobId] = job
+			return true
+		}
+	}
+	return false
+}
+
+func (repo *memoryJobRepo) Wait(_ context.Context, jobId string, _ time.Time) error {
+	repo.setStatus(jobId, []storage.JobStatus{storage.JobRunning}, storage.JobWaiting)
+	return nil
+}
+
+func (repo *memoryJobRepo) Resume(_ context.Context, jobId string, _ time.Time) (bool, error) {
+	return repo.setStatus(jobId, []storage.JobStatus{storage.JobWaiting}, storage.JobRunning), nil
+}
+
+func (repo *memoryJobRepo) Succeed(_ context.Context, jobId string, imageId string) error {
+	repo.setStatus(jobId, []storage.JobStatus{storage.JobRunning, storage.JobWaiting}, storage.JobSucceeded)
+	job := repo.jobs[jobId]
+	job.ImageId = &imageId
+	repo.jobs[jobId] = job
+	return nil
+}
+
+func (repo *memoryJobRepo) Fail(_ context.Context, jobId string, reason string) error {
+	repo.setStatus(jobId, []storage.JobStatus{storage.JobRunning, storage.JobWaiting}, storage.JobFailed)
+	job := repo.jobs[jobId]
+	job.Error = &reason
+	repo.jobs[jobId] = job
+	return nil
+}
+
+// requestingResizer records the status of the job when its resize is requested
+type requestingResizer struct {
+	recordingResizer
+	repo            *memoryJobRepo
+	err             error
+	requestedStatus storage.JobStatus
+}
+
+func (r *requestingResizer) RequestResize(_ context.Context, _ string, _ image.ResizeRequest) error {
+	r.requestedStatus = r.repo.jobs[testJobId].Status
+	return r.err
+}
+
+const testJobId = "2f6d8b1a-9c4e-4b7d-a3f0-8e5c1d7b9a4f"
+
+// unavailableResizer fails every resize as if the resize api was unavailable
+type unavailableResizer struct {
+	recordingResizer
+}
+
+func (r *unavailableResizer) Resize(_ context.Context, _ string, _ image.ResizeRequest) (image.ResizeResponse, error) {
+	return image.ResizeResponse{}, &image.Unavailable{}
+}
+
+func newTestJobsService(
+	config Config, resizer image.Resizer, images storage.ImagesRepository,
+) (*JobsService, *memoryJobRepo) {
+	logger := zerolog.Nop()
+	imagesService := newTestImagesService(testImagesDeps{resizer: resizer, images: images, config: config})
+	repo := &memoryJobRepo{jobs: map[string]storage.Job{
+		testJobId: {
+			Id:      testJobId,
+			Status:  storage.JobQueued,
+			Payload: storage.JobPayload{Name: "my plane", Format: "png", OriginalFile: "o.png", CroppedFile: "c.png"},
+		},
+	}}
+	config.JobsLeaseSec = 300
+
+	return NewJobsService(config, repo, imagesService, &auth.Mock{}, &logger), repo
+}
+
+func TestJobsService_ProcessNext(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Resized", func(t *testing.T) {
+		service, repo := newTestJobsService(Config{}, &recordingResizer{}, storage.ImageRepoMock{})
+		isProcessed, err := service.ProcessNext(ctx)
+		if err != nil || !isProcessed {
+			t.Fatalf("Expected the job to be processed, got %v", err)
+		}
+		if job := repo.jobs[testJobId]; job.Status != storage.JobSucceeded {
+			t.Fatalf("Expected the job to succeed, got %+v", job)
+		}
+
+		if isProcessed, err = service.ProcessNext(ctx); err != nil || isProcessed {
+			t.Fatalf("Expected no job to be left, got %v", err)
+		}
+	})
+
+	t.Run("Placeholder of the upload", func(t *testing.T) {
+		images := &memoryImageRepo{}
+		service, repo := newTestJobsService(Config{}, &recordingResizer{}, images)
+		job := repo.jobs[testJobId]
+		job.Payload.BlurHash = "LEHV6nWB2yk8"
+		job.Payload.DominantColor = "#aabbcc"
+		repo.jobs[testJobId] = job
+
+		if _, err := service.ProcessNext(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if images.created.BlurHash != "LEHV6nWB2yk8" || images.created.DominantColor != "#aabbcc" {
+			t.Fatalf("Expected the placeholder computed with the upload to be saved, got %+v", images.created)
+		}
+	})
+
+	t.Run("Near duplicate created since", func(t *testing.T) {
+		resizer := &recordingResizer{}
+		images := &similarRepo{}
+		service, repo := newTestJobsService(Config{ImagesDuplicates: DuplicatesReject}, resizer, images)
+		job := repo.jobs[testJobId]
+		job.Payload.PerceptualHash = "00ff00ff00ff00ff"
+		repo.jobs[testJobId] = job
+
+		if _, err := service.ProcessNext(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if job = repo.jobs[testJobId]; job.Status != storage.JobFailed || resizer.deletedUploads != 1 {
+			t.Fatalf("Expected the job to fail and delete its uploads, got %+v", job)
+		}
+		if images.searchedHash != "00ff00ff00ff00ff" || images.created.Name != "" || resizer.deleted != 0 {
+			t.Fatalf("Expected the near duplicate to be rejected before it was resized, got %+v", images.created)
+		}
+	})
+
+	t.Run("Resize failed", func(t *testing.T) {
+		resizer := &recordingResizer{failResize: true}
+		service, repo := newTestJobsService(Config{}, resizer, storage.ImageRepoMock{})
+		if _, err := service.ProcessNext(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if job := repo.jobs[testJobId]; job.Status != storage.JobFailed || resizer.deletedUploads != 1 {
+			t.Fatalf("Expected the job to fail and delete its uploads, got %+v", job)
+		}
+	})
+
+	t.Run("Resize unavailable", func(t *testing.T) {
+		resizer := &unavailableResizer{}
+		service, repo := newTestJobsService(Config{}, resizer, storage.ImageRepoMock{})
+		if _, err := service.ProcessNext(ctx); err == nil {
+			t.Fatal("Expected the unavailable resize api to be retried")
+		}
+		if job := repo.jobs[testJobId]; job.Status != storage.JobRunning || resizer.deletedUploads != 0 {
+			t.Fatalf("Expected the job to keep its uploads and run again once its lease expired, got %+v", job)
+		}
+	})
+
+	t.Run("Name taken", func(t *testing.T) {
+		resizer := &recordingResizer{}
+		service, repo := newTestJobsService(Config{}, resizer, &memoryImageRepo{isNameTaken: true})
+		if _, err := service.ProcessNext(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if job := repo.jobs[testJobId]; job.Status != storage.JobFailed || resizer.deletedUploads != 1 {
+			t.Fatalf("Expected the job to fail and delete its uploads, got %+v", job)
+		}
+	})
+
+	t.Run("Callback", func(t *testing.T) {
+		resizer := &recordingResizer{}
+		service, repo := newTestJobsService(Config{JobsCallbackUrl: "http://api"}, resizer, storage.ImageRepoMock{})
+		if _, err := service.ProcessNext(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if job := repo.jobs[testJobId]; job.Status != storage.JobWaiting {
+			t.Fatalf("Expected the job to wait for the callback, got %+v", job)
+		}
+
+		err := service.Complete(ctx, testJobId, image.ResizeCallback{Error: "unsupported file"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job := repo.jobs[testJobId]; job.Status != storage.JobFailed || resizer.deletedUploads != 1 {
+			t.Fatalf("Expected the job to fail and delete its uploads, got %+v", job)
+		}
+
+		var conflict exception.Conflict
+		err = service.Complete(ctx, testJobId, image.ResizeCallback{Resized: &image.ResizeResponse{}})
+		if !errors.As(err, &conflict) {
+			t.Fatalf("Expected a second callback to conflict, got %v", err)
+		}
+	})
+
+	t.Run("Callback of a near duplicate created since", func(t *testing.T) {
+		resizer := &recordingResizer{}
+		images := &similarRepo{}
+		config := Config{JobsCallbackUrl: "http://api", ImagesDuplicates: DuplicatesReject}
+		service, repo := newTestJobsService(config, resizer, images)
+		job := repo.jobs[testJobId]
+		job.Payload.PerceptualHash = "00ff00ff00ff00ff"
+		job.Status = storage.JobWaiting
+		repo.jobs[testJobId] = job
+
+		err := service.Complete(ctx, testJobId, image.ResizeCallback{Resized: &image.ResizeResponse{Name: "my-plane"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job = repo.jobs[testJobId]; job.Status != storage.JobFailed || images.created.Name != "" {
+			t.Fatalf("Expected the near duplicate to fail its job, got %+v", job)
+		}
+		if resizer.deleted != 1 || resizer.deletedUploads != 1 {
+			t.Fatalf("Expected the resized and uploaded files to be deleted, got %d and %d",
+				resizer.deleted, resizer.deletedUploads)
+		}
+	})
+
+	t.Run("Callback request failed", func(t *testing.T) {
+		resizer := &requestingResizer{err: &image.Unavailable{}}
+		service, repo := newTestJobsService(Config{JobsCallbackUrl: "http://api"}, resizer, storage.ImageRepoMock{})
+		resizer.repo = repo
+		if _, err := service.ProcessNext(ctx); err == nil {
+			t.Fatal("Expected the unavailable resize api to be retried")
+		}
+		if resizer.requestedStatus != storage.JobWaiting {
+			t.Fatalf("Expected the job to wait before the resize was requested, got %s", resizer.requestedStatus)
+		}
+		if job := repo.jobs[testJobId]; job.Status != storage.JobRunning || resizer.deletedUploads != 0 {
+			t.Fatalf("Expected the job to run again until its lease expired, got %+v", job)
+		}
+	})
+}
+
+func TestJobsService_UploadAndEnqueue_Placeholder(t *testing.T) {
+	logger := zerolog.Nop()
+	imagesService := newTestImagesService(testImagesDeps{placeholders: contentPlaceholders{}})
+	service := NewJobsService(Config{}, storage.JobRepoMock{}, imagesService, &auth.Mock{}, &logger)
+
+	job, err := service.UploadAndEnqueue(
+		context.Background(),
+		auth.AuthorizationDto{},
+		"my plane",
+		image.PngFormat,
+		pngUpload("original"),
+		pngUpload("cropped"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := job.Payload
+	if payload.BlurHash != string(testPng("cropped")) || payload.Name != "my plane" || payload.Format != "png" {
+		t.Fatalf("Expected the placeholder of the cropped file to be queued with the job, got %+v", job.Payload)
+	}
+	if payload.PerceptualHash != "0000000000000000" {
+		t.Fatalf("Expected the perceptual hash of the cropped file to be queued with the job, got %+v", job.Payload)
+	}
+}
+
+func TestJobsService_UploadAndEnqueue_Duplicates(t *testing.T) {
+	logger := zerolog.Nop()
+	resizer := &recordingResizer{}
+	config := Config{ImagesDuplicates: DuplicatesReject}
+	imagesService := newTestImagesService(testImagesDeps{resizer: resizer, images: &similarRepo{}, config: config})
+	jobs := &memoryJobRepo{jobs: map[string]storage.Job{}}
+	service := NewJobsService(config, jobs, imagesService, &auth.Mock{}, &logger)
+
+	_, err := service.UploadAndEnqueue(
+		context.Background(),
+		auth.AuthorizationDto{},
+		"my plane",
+		image.PngFormat,
+		pngUpload("original"),
+		pngUpload("cropped"),
+	)
+	var conflict exception.Conflict
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected a near duplicate to be rejected, got %v", err)
+	}
+	if len(jobs.jobs) != 0 || resizer.deletedUploads != 1 {
+		t.Fatalf("Expected the rejected upload to be deleted instead of queued, got %+v", jobs.jobs)
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"api/image"
+	"api/objectstore"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxOriginalBytes bounds originals that are read to be transformed or to compute placeholders
+const maxOriginalBytes = 50 * 1024 * 1024
+
+// originalReader opens the stored originals of images
+type originalReader struct {
+	config Config
+	store  objectstore.ObjectStore
+	client *http.Client
+}
+
+func newOriginalReader(config Config, store objectstore.ObjectStore) originalReader {
+	return originalReader{
+		config: config,
+		store:  store,
+		client: &http.Client{
+			Timeout: config.ImagesApiTimeout,
+		},
+	}
+}
+
+// open reads the original from the object store of the local resizer, or downloads it from its domain
+func (reader originalReader) open(ctx context.Context, img storage.Image) (io.ReadCloser, error) {
+	if reader.config.ImagesResizer == ResizerLocal {
+		object, err := reader.store.Get(ctx, img.Original)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading original: %w", err)
+		}
+		return object.Body, nil
+	}
+
+	location := originalUrl(img)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := reader.client.Do(req)
+	if err != nil {
+		return nil, image.NewTransportError(
+			image.RequestError{Url: location, Message: "failed downloading original", Err: err}, "",
+		)
+	}
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		_ = res.Body.Close()
+		return nil, image.NewStatusError(
+			image.RequestError{
+				Url:        location,
+				StatusCode: res.StatusCode,
+				Message:    fmt.Sprintf("Body: %s", body),
+				Err:        errors.New("failed downloading original"),
+			},
+			string(body),
+		)
+	}
+
+	return res.Body, nil
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"api/image"
+	"api/objectstore"
+	"api/storage"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+)
+
+// backfillBatchSize limits the images loaded at once by the placeholder and hash backfills
+const backfillBatchSize = 50
+
+// PlaceholderBackfill computes the placeholders of images created before they were computed at upload, or whose
+// placeholders were cleared by a rollback. They are computed from the originals as the cropped files aren't kept.
+type PlaceholderBackfill struct {
+	imagesRepository storage.ImagesRepository
+	originals        originalReader
+	placeholders     image.PlaceholderGenerator
+	logger           *zerolog.Logger
+}
+
+func NewPlaceholderBackfill(
+	config Config,
+	imagesRepository storage.ImagesRepository,
+	store objectstore.ObjectStore,
+	placeholders image.PlaceholderGenerator,
+	logger *zerolog.Logger,
+) *PlaceholderBackfill {
+	return &PlaceholderBackfill{
+		imagesRepository: imagesRepository,
+		originals:        newOriginalReader(config, store),
+		placeholders:     placeholders,
+		logger:           logger,
+	}
+}
+
+// Run backfills every image without placeholders and returns how many were backfilled. Images that fail, like
+// originals in formats that can't be decoded, are logged and skipped.
+func (backfill *PlaceholderBackfill) Run(ctx context.Context) (int, error) {
+	count := 0
+	afterId := ""
+	for {
+		images, err := backfill.imagesRepository.GetWithoutPlaceholder(ctx, afterId, backfillBatchSize)
+		if err != nil {
+			return count, fmt.Errorf("failed fetching images without placeholder: %w", err)
+		}
+
+		for _, img := range images {
+			afterId = img.Id
+			if err = backfill.backfillOne(ctx, img); err != nil {
+				if ctx.Err() != nil {
+					return count, ctx.Err()
+				}
+				backfill.logger.Warn().Err(err).Str("image", img.Id).Msg("failed backfilling placeholder")
+				continue
+			}
+			count++
+		}
+
+		if len(images) < backfillBatchSize {
+			return count, nil
+		}
+	}
+}
+
+func (backfill *PlaceholderBackfill) backfillOne(ctx context.Context, img storage.Image) error {
+	original, err := backfill.originals.open(ctx, img)
+	if err != nil {
+		return err
+	}
+	defer original.Close()
+
+	placeholder, err := backfill.placeholders.Generate(ctx, io.LimitReader(original, maxOriginalBytes))
+	if err != nil {
+		return fmt.Errorf("failed computing placeholder: %w", err)
+	}
+
+	return backfill.imagesRepository.SetPlaceholder(ctx, withPlaceholder(img, placeholder))
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"api/objectstore"
+	"api/objectstore/filesystem"
+	"api/storage"
+	"context"
+	"github.com/rs/zerolog"
+	"strings"
+	"testing"
+)
+
+type placeholderRepo struct {
+	storage.ImageRepoMock
+	images storage.ImageList
+}
+
+func (repo *placeholderRepo) GetWithoutPlaceholder(
+	_ context.Context, afterId string, limit int,
+) (storage.ImageList, error) {
+	images := storage.ImageList{}
+	for _, img := range repo.images {
+		if img.BlurHash == "" && img.Id > afterId && len(images) < limit {
+			images = append(images, img)
+		}
+	}
+	return images, nil
+}
+
+func (repo *placeholderRepo) SetPlaceholder(_ context.Context, updated storage.Image) error {
+	for i, img := range repo.images {
+		if img.Id == updated.Id {
+			repo.images[i] = updated
+		}
+	}
+	return nil
+}
+
+func TestPlaceholderBackfill_Run(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+	store, err := filesystem.NewStore(t.TempDir(), objectstore.NewSigner([]byte("secret"), "http://localhost:3000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &placeholderRepo{}
+	for _, name := range []string{"a-plane", "b-missing", "c-plane"} {
+		img := storage.Image{Id: "id-" + name, Name: name, Original: "images/" + name + ".jpg"}
+		repo.images = append(repo.images, img)
+		if name == "b-missing" {
+			continue
+		}
+		if err = store.Put(ctx, img.Original, strings.NewReader(name), int64(len(name)), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo.images = append(repo.images, storage.Image{Id: "id-d-done", BlurHash: "d-done"})
+
+	backfill := NewPlaceholderBackfill(
+		Config{ImagesResizer: ResizerLocal}, repo, store, contentPlaceholders{}, &logger,
+	)
+	count, err := backfill.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 backfilled images, got %d", count)
+	}
+	for _, img := range repo.images {
+		expected := strings.TrimPrefix(img.Id, "id-")
+		if img.Name == "b-missing" {
+			expected = ""
+		}
+		if img.BlurHash != expected {
+			t.Fatalf("Expected the blurhash %q of %s, got %q", expected, img.Id, img.BlurHash)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/image"
+	"context"
+	"errors"
+	"io"
+)
+
+// exceptionResizer reports the failures of the images api as exceptions, the failure is kept as their cause
+type exceptionResizer struct {
+	resizer image.Resizer
+}
+
+// toException leaves errors that aren't failed requests of the images api as they are
+func toException(err error) error {
+	var timeout *image.Timeout
+	var unavailable *image.Unavailable
+	var serverError *image.ServerError
+	var badRequest *image.BadRequest
+	var forbidden *image.Forbidden
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &timeout):
+		return exception.GatewayTimeout{Reason: "Image service timed out", Err: err}
+	case errors.As(err, &unavailable):
+		return exception.Unavailable{Reason: "Image service unavailable", Err: err}
+	// the images api rejected a request of this api, which is not the fault of the client
+	case errors.As(err, &serverError), errors.As(err, &badRequest), errors.As(err, &forbidden):
+		return exception.BadGateway{Reason: "Image service failed", Err: err}
+	}
+
+	return err
+}
+
+func (r exceptionResizer) FetchSignedUrl(
+	ctx context.Context, authorization string, format image.Format,
+) (image.SignedResponse, error) {
+	signed, err := r.resizer.FetchSignedUrl(ctx, authorization, format)
+	return signed, toException(err)
+}
+
+func (r exceptionResizer) UploadFile(
+	ctx context.Context, signed image.SignedResponse, format image.Format, body io.Reader, size int64,
+) error {
+	return toException(r.resizer.UploadFile(ctx, signed, format, body, size))
+}
+
+func (r exceptionResizer) Resize(
+	ctx context.Context, authorizationHeader string, request image.ResizeRequest,
+) (image.ResizeResponse, error) {
+	res, err := r.resizer.Resize(ctx, authorizationHeader, request)
+	return res, toException(err)
+}
+
+func (r exceptionResizer) RequestResize(
+	ctx context.Context, authorizationHeader string, request image.ResizeRequest,
+) error {
+	return toException(r.resizer.RequestResize(ctx, authorizationHeader, request))
+}
+
+func (r exceptionResizer) Rename(
+	ctx context.Context, authorizationHeader string, request image.RenameRequest,
+) (image.ResizeResponse, error) {
+	res, err := r.resizer.Rename(ctx, authorizationHeader, request)
+	return res, toException(err)
+}
+
+func (r exceptionResizer) Invalidate(
+	ctx context.Context, authorizationHeader string, request image.DeleteRequest,
+) error {
+	return toException(r.resizer.Invalidate(ctx, authorizationHeader, request))
+}
+
+func (r exceptionResizer) Delete(ctx context.Context, authorizationHeader string, request image.DeleteRequest) error {
+	return toException(r.resizer.Delete(ctx, authorizationHeader, request))
+}
+
+func (r exceptionResizer) DeleteUploads(
+	ctx context.Context, authorizationHeader string, request image.DeleteUploadsRequest,
+) error {
+	return toException(r.resizer.DeleteUploads(ctx, authorizationHeader, request))
+}
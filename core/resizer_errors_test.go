@@ -0,0 +1,51 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/image"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestToException(t *testing.T) {
+	requestError := image.RequestError{Url: "http://images/resize", Message: "failed resizing"}
+	data := []struct {
+		testName string
+		err      error
+		expected func(err error) bool
+	}{
+		{
+			testName: "Timeout",
+			err:      &image.Timeout{RequestError: requestError},
+			expected: func(err error) bool { return errors.As(err, &exception.GatewayTimeout{}) },
+		},
+		{
+			testName: "Unavailable",
+			err:      &image.Unavailable{RequestError: requestError},
+			expected: func(err error) bool { return errors.As(err, &exception.Unavailable{}) },
+		},
+		{
+			testName: "Rejected request",
+			err:      image.NewStatusError(image.RequestError{StatusCode: http.StatusBadRequest}, ""),
+			expected: func(err error) bool { return errors.As(err, &exception.BadGateway{}) },
+		},
+		{
+			testName: "Other error",
+			err:      image.ErrCallbackUnsupported,
+			expected: func(err error) bool { return err == image.ErrCallbackUnsupported },
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			err := toException(d.err)
+			if !d.expected(err) {
+				t.Fatalf("Unexpected exception %T: %v", err, err)
+			}
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %v to keep its cause", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// compensationTimeout bounds the undo actions, they run on a new context as the request one might be cancelled
+const compensationTimeout = 30 * time.Second
+
+type compensation struct {
+	name string
+	undo func(ctx context.Context) error
+}
+
+// saga collects undo actions of completed steps, on failure they are run in reverse order
+type saga struct {
+	compensations []compensation
+	logger        *zerolog.Logger
+}
+
+func newSaga(logger *zerolog.Logger) *saga {
+	return &saga{logger: logger}
+}
+
+func (s *saga) addCompensation(name string, undo func(ctx context.Context) error) {
+	s.compensations = append(s.compensations, compensation{name: name, undo: undo})
+}
+
+// compensate runs every undo action even when some of them fail, the failures are returned joined
+func (s *saga) compensate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), compensationTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(s.compensations) - 1; i >= 0; i-- {
+		c := s.compensations[i]
+		s.logger.Info().Msgf("compensating: %s", c.name)
+
+		if err := c.undo(ctx); err != nil {
+			s.logger.Error().Err(err).Msgf("failed compensating: %s", c.name)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"testing"
+)
+
+func TestSaga_Compensate(t *testing.T) {
+	logger := zerolog.Nop()
+	sg := newSaga(&logger)
+
+	var order []string
+	failure := errors.New("failed")
+
+	sg.addCompensation("first", func(_ context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	sg.addCompensation("second", func(_ context.Context) error {
+		order = append(order, "second")
+		return failure
+	})
+	sg.addCompensation("third", func(_ context.Context) error {
+		order = append(order, "third")
+		return nil
+	})
+
+	err := sg.compensate()
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the failure to be returned, got %v", err)
+	}
+
+	expected := []string{"third", "second", "first"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, order)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"api/auth"
+	"api/storage"
+	"github.com/rs/zerolog"
+	"strings"
+)
+
+const (
+	tagValueMinLength = 1
+	tagValueMaxLength = 255
+	// tagHierarchyMaxDepth protects from walking endlessly up the parent chain
+	tagHierarchyMaxDepth = 32
+)
+
+type TagsService struct {
+	tagsRepository   storage.TagRepository
+	imagesRepository storage.ImagesRepository
+	authenticator    auth.Authenticator
+	urls             *UrlBuilder
+	logger           *zerolog.Logger
+}
+
+func NewTagsService(
+	tagsRepository storage.TagRepository,
+	imagesRepository storage.ImagesRepository,
+	authenticator auth.Authenticator,
+	urls *UrlBuilder,
+	logger *zerolog.Logger,
+) *TagsService {
+	return &TagsService{
+		tagsRepository:   tagsRepository,
+		imagesRepository: imagesRepository,
+		authenticator:    authenticator,
+		urls:             urls,
+		logger:           logger,
+	}
+}
+
+// TagDto holds the tag changes, nil fields are left untouched on update while an empty ParentId or
+// CanonicalId clears the existing value
+type TagDto struct {
+	Value       *string `json:"value"`
+	ParentId    *string `json:"parentId"`
+	CanonicalId *string `json:"canonicalId"`
+}
+
+// NormalizeTagValue lower cases the value and collapses the whitespace, example:
+// from: "  World   War 2 "
+// to: "world war 2"
+func NormalizeTagValue(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+func isValidTagValue(value string) bool {
+	return len(value) >= tagValueMinLength && len(value) <= tagValueMaxLength
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+func (service *TagsService) Create(
+	ctx context.Context, authorization auth.AuthorizationDto, dto TagDto,
+) (storage.Tag, error) {
+	if dto.Value == nil {
+		return storage.Tag{}, exception.InvalidArgument{Reason: "Missing tag value"}
+	}
+	value := NormalizeTagValue(*dto.Value)
+	if !isValidTagValue(value) {
+		return storage.Tag{}, exception.InvalidArgument{
+			Reason: fmt.Sprintf("Tag value should be between %d and %d characters", tagValueMinLength, tagValueMaxLength),
+		}
+	}
+
+	user, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return storage.Tag{}, err
+	}
+
+	tag := storage.Tag{Value: value, AuthorId: &user.Id}
+	if err = service.applyRelations(ctx, &tag, dto); err != nil {
+		return storage.Tag{}, err
+	}
+
+	created, err := service.tagsRepository.Create(ctx, tag)
+	if err != nil {
+		if errors.Is(err, storage.ErrDuplicate) {
+			return storage.Tag{}, exception.InvalidArgument{
+				Reason: fmt.Sprintf("Tag '%s' already exists", value),
+			}
+		}
+		return storage.Tag{}, err
+	}
+
+	return created, nil
+}
+
+// applyRelations validates and sets the parent and canonical tag from the dto
+func (service *TagsService) applyRelations(ctx context.Context, tag *storage.Tag, dto TagDto) error {
+	if dto.ParentId != nil {
+		if *dto.ParentId == "" {
+			tag.ParentId = nil
+		} else {
+			parent, err := service.findRelatedTag(ctx, *dto.ParentId)
+			if err != nil {
+				return err
+			}
+			if err = service.validateParent(ctx, tag.Id, parent); err != nil {
+				return err
+			}
+			tag.ParentId = &parent.Id
+		}
+	}
+
+	if dto.CanonicalId != nil {
+		if *dto.CanonicalId == "" {
+			tag.CanonicalId = nil
+		} else {
+			canonical, err := service.findRelatedTag(ctx, *dto.CanonicalId)
+			if err != nil {
+				return err
+			}
+			if canonical.Id == tag.Id {
+				return exception.InvalidArgument{Reason: "Tag can't be a synonym of itself"}
+			}
+			if canonical.IsSynonym() {
+				return exception.InvalidArgument{
+					Reason: fmt.Sprintf("Tag '%s' is a synonym, use its canonical tag instead", canonical.Value),
+				}
+			}
+			if err = service.validateNoSynonyms(ctx, *tag); err != nil {
+				return err
+			}
+			tag.CanonicalId = &canonical.Id
+		}
+	}
+
+	return nil
+}
+
+// validateNoSynonyms ensures the tag has no synonyms before it becomes a synonym itself, so synonyms never chain
+func (service *TagsService) validateNoSynonyms(ctx context.Context, tag storage.Tag) error {
+	if tag.Id == "" || tag.IsSynonym() {
+		return nil
+	}
+
+	hasSynonyms, err := service.tagsRepository.HasSynonyms(ctx, tag.Id)
+	if err != nil {
+		return err
+	}
+	if hasSynonyms {
+		return exception.InvalidArgument{
+			Reason: fmt.Sprintf("Tag '%s' has synonyms and can't become a synonym itself", tag.Value),
+		}
+	}
+
+	return nil
+}
+
+func (service *TagsService) findRelatedTag(ctx context.Context, tagId string) (storage.Tag, error) {
+	parsedId, err := uuid.Parse(tagId)
+	if err != nil {
+		return storage.Tag{}, exception.InvalidArgument{Reason: "Invalid uuid of related tag"}
+	}
+
+	tag, err := service.tagsRepository.GetOne(ctx, parsedId.String())
+	if err != nil {
+		var notFound storage.NotFound
+		if errors.As(err, &notFound) {
+			return storage.Tag{}, exception.InvalidArgument{Reason: "Related tag " + tagId + " does not exist"}
+		}
+		return storage.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+// validateParent walks up the hierarchy of the parent to ensure the tag won't end up being its own ancestor
+func (service *TagsService) validateParent(ctx context.Context, tagId string, parent storage.Tag) error {
+	if parent.IsSynonym() {
+		return exception.InvalidArgument{
+			Reason: fmt.Sprintf("Tag '%s' is a synonym and can't be a parent", parent.Value),
+		}
+	}
+
+	ancestor := parent
+	for depth := 0; depth < tagHierarchyMaxDepth; depth++ {
+		if tagId != "" && ancestor.Id == tagId {
+			return exception.InvalidArgument{Reason: "Tag hierarchy can't contain cycles"}
+		}
+		if ancestor.ParentId == nil {
+			return nil
+		}
+
+		var err error
+		ancestor, err = service.tagsRepository.GetOne(ctx, *ancestor.ParentId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return exception.InvalidArgument{
+		Reason: fmt.Sprintf("Tag hierarchy can't be deeper than %d levels", tagHierarchyMaxDepth),
+	}
+}
@@ -0,0 +1,25 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"context"
+	"github.com/google/uuid"
+)
+
+// DeleteOne removes the tag from all the images, synonyms of the tag are removed as well while child tags
+// become root tags
+func (service *TagsService) DeleteOne(
+	ctx context.Context, authorization auth.AuthorizationDto, tagId string,
+) error {
+	parsedTagId, err := uuid.Parse(tagId)
+	if err != nil {
+		return exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	if _, err = service.authenticator.GetOrSyncUser(ctx, authorization); err != nil {
+		return err
+	}
+
+	return service.tagsRepository.DeleteOne(ctx, parsedTagId.String())
+}
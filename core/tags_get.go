@@ -0,0 +1,40 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/storage"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+func (service *TagsService) Get(ctx context.Context, limit, offset int) (storage.TagList, error) {
+	tags, err := service.tagsRepository.Get(ctx, limit, offset)
+	if err != nil {
+		return storage.TagList{}, fmt.Errorf("failed fetching tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (service *TagsService) GetOne(ctx context.Context, tagId string) (storage.Tag, error) {
+	parsedTagId, err := uuid.Parse(tagId)
+	if err != nil {
+		return storage.Tag{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	return service.tagsRepository.GetOne(ctx, parsedTagId.String())
+}
+
+// resolve returns the canonical tag for the value, so that synonyms like "aircraft" resolve to "plane"
+func (service *TagsService) resolve(ctx context.Context, value string) (storage.Tag, error) {
+	tag, err := service.tagsRepository.GetOneByValue(ctx, NormalizeTagValue(value))
+	if err != nil {
+		return storage.Tag{}, err
+	}
+	if !tag.IsSynonym() {
+		return tag, nil
+	}
+
+	return service.tagsRepository.GetOne(ctx, *tag.CanonicalId)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+// AttachToImage tags the image with the canonical tag of the value, tag is created if it doesn't exist
+func (service *TagsService) AttachToImage(
+	ctx context.Context, authorization auth.AuthorizationDto, imageId, value string,
+) (storage.Image, error) {
+	parsedImageId, err := uuid.Parse(imageId)
+	if err != nil {
+		return storage.Image{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+	normalized := NormalizeTagValue(value)
+	if !isValidTagValue(normalized) {
+		return storage.Image{}, exception.InvalidArgument{
+			Reason: fmt.Sprintf("Tag value should be between %d and %d characters", tagValueMinLength, tagValueMaxLength),
+		}
+	}
+
+	user, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return storage.Image{}, err
+	}
+
+	if _, err = service.imagesRepository.GetOne(ctx, parsedImageId.String()); err != nil {
+		return storage.Image{}, err
+	}
+
+	tag, err := service.resolve(ctx, normalized)
+	if err != nil {
+		var notFound storage.NotFound
+		if !errors.As(err, &notFound) {
+			return storage.Image{}, err
+		}
+		tag, err = service.tagsRepository.Create(ctx, storage.Tag{Value: normalized, AuthorId: &user.Id})
+		if err != nil {
+			return storage.Image{}, fmt.Errorf("failed creating tag %s: %w", normalized, err)
+		}
+	}
+
+	if err = service.tagsRepository.AttachToImage(ctx, parsedImageId.String(), tag.Id); err != nil {
+		return storage.Image{}, fmt.Errorf("failed attaching tag %s: %w", tag.Value, err)
+	}
+
+	return service.getImage(ctx, parsedImageId.String())
+}
+
+func (service *TagsService) DetachFromImage(
+	ctx context.Context, authorization auth.AuthorizationDto, imageId, tagId string,
+) (storage.Image, error) {
+	parsedImageId, err := uuid.Parse(imageId)
+	if err != nil {
+		return storage.Image{}, exception.InvalidArgument{Reason: "Invalid image uuid"}
+	}
+	parsedTagId, err := uuid.Parse(tagId)
+	if err != nil {
+		return storage.Image{}, exception.InvalidArgument{Reason: "Invalid tag uuid"}
+	}
+
+	if _, err = service.authenticator.GetOrSyncUser(ctx, authorization); err != nil {
+		return storage.Image{}, err
+	}
+
+	err = service.tagsRepository.DetachFromImage(ctx, parsedImageId.String(), parsedTagId.String())
+	if err != nil {
+		return storage.Image{}, err
+	}
+
+	return service.getImage(ctx, parsedImageId.String())
+}
+
+// getImage returns the image with its tags after they changed, along with its urls
+func (service *TagsService) getImage(ctx context.Context, imageId string) (storage.Image, error) {
+	img, err := service.imagesRepository.GetOne(ctx, imageId)
+	if err != nil {
+		return storage.Image{}, err
+	}
+
+	return service.urls.WithUrls(img), nil
+}
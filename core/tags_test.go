@@ -0,0 +1,61 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/storage"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"testing"
+)
+
+func TestNormalizeTagValue(t *testing.T) {
+	values := []struct {
+		Name     string
+		Value    string
+		Expected string
+	}{
+		{Name: "Already normalized", Value: "plane", Expected: "plane"},
+		{Name: "Upper case", Value: "Aircraft", Expected: "aircraft"},
+		{Name: "Extra whitespace", Value: "  World   War\t2 ", Expected: "world war 2"},
+		{Name: "Only whitespace", Value: "   ", Expected: ""},
+	}
+
+	for _, data := range values {
+		t.Run(data.Name, func(t *testing.T) {
+			result := NormalizeTagValue(data.Value)
+			if result != data.Expected {
+				t.Fatalf("Expected %s, got %s\n", data.Expected, result)
+			}
+		})
+	}
+}
+
+// synonymTagRepo returns tags that aren't synonyms and have synonyms of their own
+type synonymTagRepo struct {
+	storage.TagRepoMock
+}
+
+func (repo synonymTagRepo) GetOne(_ context.Context, tagId string) (storage.Tag, error) {
+	return storage.Tag{Id: tagId, Value: "aircraft"}, nil
+}
+
+func (repo synonymTagRepo) HasSynonyms(_ context.Context, _ string) (bool, error) {
+	return true, nil
+}
+
+func TestTagsService_Update_SynonymChain(t *testing.T) {
+	logger := zerolog.Nop()
+	service := NewTagsService(synonymTagRepo{}, storage.ImageRepoMock{}, &auth.Mock{}, NewUrlBuilder(Config{}), &logger)
+	canonicalId := "6f2b8a3c-5d1e-4f7a-9b0c-2d3e4f5a6b7c"
+
+	_, err := service.Update(
+		context.Background(), auth.AuthorizationDto{}, "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d",
+		TagDto{CanonicalId: &canonicalId},
+	)
+	var invalid exception.InvalidArgument
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected a tag with synonyms to be rejected as a synonym, got %v", err)
+	}
+}
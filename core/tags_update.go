@@ -0,0 +1,60 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+func (service *TagsService) Update(
+	ctx context.Context, authorization auth.AuthorizationDto, tagId string, dto TagDto,
+) (storage.Tag, error) {
+	parsedTagId, err := uuid.Parse(tagId)
+	if err != nil {
+		return storage.Tag{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+	if dto.Value == nil && dto.ParentId == nil && dto.CanonicalId == nil {
+		return storage.Tag{}, exception.InvalidArgument{
+			Reason: "Expected at least one of value, parentId or canonicalId, got all empty",
+		}
+	}
+
+	if _, err = service.authenticator.GetOrSyncUser(ctx, authorization); err != nil {
+		return storage.Tag{}, err
+	}
+
+	tag, err := service.tagsRepository.GetOne(ctx, parsedTagId.String())
+	if err != nil {
+		return storage.Tag{}, err
+	}
+
+	if dto.Value != nil {
+		value := NormalizeTagValue(*dto.Value)
+		if !isValidTagValue(value) {
+			return storage.Tag{}, exception.InvalidArgument{
+				Reason: fmt.Sprintf("Tag value should be between %d and %d characters", tagValueMinLength, tagValueMaxLength),
+			}
+		}
+		tag.Value = value
+	}
+
+	if err = service.applyRelations(ctx, &tag, dto); err != nil {
+		return storage.Tag{}, err
+	}
+
+	updated, err := service.tagsRepository.UpdateOne(ctx, tag)
+	if err != nil {
+		if errors.Is(err, storage.ErrDuplicate) {
+			return storage.Tag{}, exception.InvalidArgument{
+				Reason: fmt.Sprintf("Tag '%s' already exists", tag.Value),
+			}
+		}
+		return storage.Tag{}, err
+	}
+
+	return updated, nil
+}
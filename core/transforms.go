@@ -0,0 +1,250 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/image"
+	"api/objectstore"
+	"api/storage"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TransformsPath serves derivatives of originals in arbitrary sizes, like /img/{name}/300x200.jpg?fit=cover&q=80
+const TransformsPath = "/img"
+
+const transformsDirectory = "transforms"
+
+// Derivative is a rendered or cached transformation of an original, the caller closes the body
+type Derivative struct {
+	objectstore.Object
+	// ETag changes with the original, so revalidating clients notice updated images
+	ETag string
+}
+
+// TransformsService renders derivatives of originals in the allowed sizes and caches them in the object store.
+// Urls are signed so clients can't request arbitrary qualities or fits, and rendering is bounded by a number of
+// slots. Cached derivatives are kept by image id and update time, so updated originals are rendered again.
+type TransformsService struct {
+	config        Config
+	imagesService *ImagesService
+	store         objectstore.ObjectStore
+	transformer   image.Transformer
+	slots         chan struct{}
+	originals     originalReader
+	logger        *zerolog.Logger
+}
+
+func NewTransformsService(
+	config Config,
+	imagesService *ImagesService,
+	store objectstore.ObjectStore,
+	transformer image.Transformer,
+	logger *zerolog.Logger,
+) *TransformsService {
+	return &TransformsService{
+		config:        config,
+		imagesService: imagesService,
+		store:         store,
+		transformer:   transformer,
+		slots:         make(chan struct{}, config.ImagesTransformConcurrency),
+		originals:     newOriginalReader(config, store),
+		logger:        logger,
+	}
+}
+
+func (service *TransformsService) signature(name string, transformation image.Transformation) []byte {
+	mac := hmac.New(sha256.New, []byte(service.config.ImagesTransformSecret))
+	mac.Write([]byte(name + "/" + transformation.String()))
+	return mac.Sum(nil)
+}
+
+// SignPath returns the signed path of the derivative of the image with the name
+func (service *TransformsService) SignPath(name string, transformation image.Transformation) string {
+	query := url.Values{}
+	query.Set("fit", string(transformation.Fit))
+	query.Set("q", strconv.Itoa(transformation.Quality))
+	query.Set("s", hex.EncodeToString(service.signature(name, transformation)))
+
+	return fmt.Sprintf(
+		"%s/%s/%dx%d.%s?%s",
+		TransformsPath,
+		url.PathEscape(name),
+		transformation.Width,
+		transformation.Height,
+		transformation.Format,
+		query.Encode(),
+	)
+}
+
+// SignedTransformation is the signed url of a derivative, relative to this api
+type SignedTransformation struct {
+	Url string `json:"url"`
+}
+
+// Sign returns the url of the derivative of the image, so clients can request the derivatives admins picked
+func (service *TransformsService) Sign(
+	ctx context.Context, imageId string, transformation image.Transformation,
+) (SignedTransformation, error) {
+	if err := service.validate(transformation); err != nil {
+		return SignedTransformation{}, err
+	}
+
+	img, err := service.imagesService.GetOne(ctx, imageId)
+	if err != nil {
+		return SignedTransformation{}, err
+	}
+
+	return SignedTransformation{Url: service.SignPath(img.Name, transformation)}, nil
+}
+
+// MaxAge is how long derivatives can be cached
+func (service *TransformsService) MaxAge() time.Duration {
+	return time.Duration(service.config.ImagesTransformMaxAgeSec) * time.Second
+}
+
+func (service *TransformsService) validate(transformation image.Transformation) error {
+	if !transformation.Fit.IsSupported() {
+		return exception.InvalidArgument{Reason: "Invalid fit, expected contain, cover or fill"}
+	}
+	if transformation.Quality < 1 || transformation.Quality > 100 {
+		return exception.InvalidArgument{Reason: "Invalid quality, expected 1 to 100"}
+	}
+	if !transformation.Format.IsEncodable() {
+		return exception.InvalidArgument{Reason: "Unsupported format " + string(transformation.Format)}
+	}
+
+	size := image.Dimensions{Width: transformation.Width, Height: transformation.Height}
+	for _, allowed := range service.config.ImagesTransformSizes {
+		if allowed == size {
+			return nil
+		}
+	}
+
+	return exception.InvalidArgument{Reason: fmt.Sprintf("Size %dx%d is not allowed", size.Width, size.Height)}
+}
+
+// Transform returns the derivative of the image with the name once the signature of the request is verified.
+// Former names result in exception.Renamed like looking the image up by name does.
+func (service *TransformsService) Transform(
+	ctx context.Context, name string, transformation image.Transformation, signature string,
+) (Derivative, error) {
+	decodedSignature, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(decodedSignature, service.signature(name, transformation)) {
+		return Derivative{}, exception.Forbidden{Reason: "Invalid signature"}
+	}
+	if err = service.validate(transformation); err != nil {
+		return Derivative{}, err
+	}
+
+	img, err := service.imagesService.GetOneByName(ctx, name)
+	if err != nil {
+		return Derivative{}, err
+	}
+
+	key := transformKey(img, transformation)
+	derivative := Derivative{ETag: eTag(key)}
+	object, err := service.store.Get(ctx, key)
+	if err == nil {
+		derivative.Object = object
+		return derivative, nil
+	}
+	if !errors.Is(err, objectstore.ErrNotFound) {
+		return Derivative{}, fmt.Errorf("failed reading cached derivative: %w", err)
+	}
+
+	select {
+	case service.slots <- struct{}{}:
+		defer func() { <-service.slots }()
+	default:
+		return Derivative{}, exception.Unavailable{Reason: "Too many transformations, retry later"}
+	}
+
+	var buf bytes.Buffer
+	if err = service.render(ctx, img, transformation, &buf); err != nil {
+		return Derivative{}, err
+	}
+	rendered := buf.Bytes()
+	contentType := string(transformation.Format.ToContentType())
+
+	err = service.store.Put(ctx, key, bytes.NewReader(rendered), int64(len(rendered)), contentType)
+	if err != nil {
+		service.logger.Warn().Err(err).Str("key", key).Msg("failed caching derivative")
+	} else {
+		service.purgeOutdated(ctx, img, key)
+	}
+
+	derivative.Object = objectstore.Object{
+		Body:        io.NopCloser(bytes.NewReader(rendered)),
+		Size:        int64(len(rendered)),
+		ContentType: contentType,
+	}
+	return derivative, nil
+}
+
+func (service *TransformsService) render(
+	ctx context.Context, img storage.Image, transformation image.Transformation, w io.Writer,
+) error {
+	original, err := service.originals.open(ctx, img)
+	if err != nil {
+		return err
+	}
+	defer original.Close()
+
+	err = service.transformer.Transform(ctx, io.LimitReader(original, maxOriginalBytes), transformation, w)
+	if errors.Is(err, image.ErrTooManyPixels) {
+		return exception.InvalidArgument{Reason: "The original is too large to transform"}
+	}
+
+	return err
+}
+
+// purgeOutdated removes derivatives cached before the image was last updated
+func (service *TransformsService) purgeOutdated(ctx context.Context, img storage.Image, current string) {
+	prefix := fmt.Sprintf("%s/%s/", transformsDirectory, img.Id)
+	version := path.Dir(current) + "/"
+
+	objects, err := service.store.List(ctx, prefix)
+	if err != nil {
+		service.logger.Warn().Err(err).Str("image", img.Id).Msg("failed listing cached derivatives")
+		return
+	}
+	for _, object := range objects {
+		if strings.HasPrefix(object.Key, version) {
+			continue
+		}
+		if err = service.store.Delete(ctx, object.Key); err != nil {
+			service.logger.Warn().Err(err).Str("key", object.Key).Msg("failed removing outdated derivative")
+		}
+	}
+}
+
+// transformKey is where the derivative of the current version of the image is cached
+func transformKey(img storage.Image, transformation image.Transformation) string {
+	updatedAt := img.UpdatedAt
+	if updatedAt == nil {
+		updatedAt = img.CreatedAt
+	}
+	var version int64
+	if updatedAt != nil {
+		version = updatedAt.UnixNano()
+	}
+
+	return fmt.Sprintf("%s/%s/%d/%s", transformsDirectory, img.Id, version, transformation.String())
+}
+
+func eTag(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"api/core/exception"
+	"api/image"
+	"api/objectstore"
+	"api/objectstore/filesystem"
+	"api/storage"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type countingTransformer struct {
+	calls   int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (transformer *countingTransformer) Transform(
+	_ context.Context, original io.Reader, transformation image.Transformation, w io.Writer,
+) error {
+	transformer.calls++
+	if transformer.started != nil {
+		transformer.started <- struct{}{}
+		<-transformer.release
+	}
+	content, err := io.ReadAll(original)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(string(content) + " " + transformation.String()))
+	return err
+}
+
+func newTestTransformsService(
+	t *testing.T, img storage.Image, transformer image.Transformer,
+) (*TransformsService, objectstore.ObjectStore) {
+	logger := zerolog.Nop()
+	store, err := filesystem.NewStore(t.TempDir(), objectstore.NewSigner([]byte("secret"), "http://localhost:3000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Put(context.Background(), img.Original, strings.NewReader("original"), 8, string(image.PngType))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := Config{
+		ImagesResizer:              ResizerLocal,
+		ImagesTransformSecret:      "secret",
+		ImagesTransformSizes:       []image.Dimensions{{Width: 300, Height: 200}},
+		ImagesTransformConcurrency: 1,
+	}
+	imagesService := newTestImagesService(testImagesDeps{images: &memoryImageRepo{image: img}})
+
+	return NewTransformsService(config, imagesService, store, transformer, &logger), store
+}
+
+func signatureOf(t *testing.T, signedPath string) string {
+	parsed, err := url.Parse(signedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Query().Get("s")
+}
+
+func readDerivative(t *testing.T, derivative Derivative) string {
+	defer derivative.Body.Close()
+	content, err := io.ReadAll(derivative.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestTransformsService_Transform(t *testing.T) {
+	ctx := context.Background()
+	updatedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	img := storage.Image{
+		Id:        "0b3c7d5e-7f2a-4e1b-9c6d-8a2f4e6b1c3d",
+		Name:      "my-plane",
+		Original:  "images/my-plane.png",
+		UpdatedAt: &updatedAt,
+	}
+	transformer := &countingTransformer{}
+	service, store := newTestTransformsService(t, img, transformer)
+
+	transformation := image.Transformation{
+		Width: 300, Height: 200, Fit: image.FitCover, Quality: 80, Format: image.JpgFormat,
+	}
+	signature := signatureOf(t, service.SignPath(img.Name, transformation))
+
+	var forbidden exception.Forbidden
+	if _, err := service.Transform(ctx, img.Name, transformation, "00"+signature[2:]); !errors.As(err, &forbidden) {
+		t.Fatalf("Expected exception.Forbidden for an invalid signature, got %v", err)
+	}
+
+	tooLarge := transformation
+	tooLarge.Width = 3000
+	var invalidArgument exception.InvalidArgument
+	_, err := service.Transform(ctx, img.Name, tooLarge, signatureOf(t, service.SignPath(img.Name, tooLarge)))
+	if !errors.As(err, &invalidArgument) {
+		t.Fatalf("Expected exception.InvalidArgument for a size that isn't allowed, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		derivative, err := service.Transform(ctx, img.Name, transformation, signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content := readDerivative(t, derivative); content != "original "+transformation.String() {
+			t.Fatalf("Expected the derivative of the original, got %s", content)
+		}
+		if derivative.ContentType != string(image.JpegType) || derivative.ETag == "" {
+			t.Fatalf("Expected a jpeg with an etag, got %s %s", derivative.ContentType, derivative.ETag)
+		}
+	}
+	if transformer.calls != 1 {
+		t.Fatalf("Expected the cached derivative to be served, got %d transformations", transformer.calls)
+	}
+
+	updated := updatedAt.Add(time.Hour)
+	img.UpdatedAt = &updated
+	service.imagesService.imagesRepository = &memoryImageRepo{image: img}
+	if _, err = service.Transform(ctx, img.Name, transformation, signature); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := store.List(ctx, transformsDirectory+"/"+img.Id+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transformer.calls != 2 || len(cached) != 1 || cached[0].Key != transformKey(img, transformation) {
+		t.Fatalf("Expected only the derivative of the updated image to be cached, got %+v", cached)
+	}
+}
+
+func TestTransformsService_TransformBusy(t *testing.T) {
+	ctx := context.Background()
+	img := storage.Image{Id: "0b3c7d5e-7f2a-4e1b-9c6d-8a2f4e6b1c3d", Name: "my-plane", Original: "images/my-plane.png"}
+	transformer := &countingTransformer{started: make(chan struct{}), release: make(chan struct{})}
+	service, _ := newTestTransformsService(t, img, transformer)
+
+	transformation := image.Transformation{
+		Width: 300, Height: 200, Fit: image.FitContain, Quality: image.DefaultQuality, Format: image.PngFormat,
+	}
+	signature := signatureOf(t, service.SignPath(img.Name, transformation))
+
+	done := make(chan error)
+	go func() {
+		_, err := service.Transform(ctx, img.Name, transformation, signature)
+		done <- err
+	}()
+	<-transformer.started
+
+	var unavailable exception.Unavailable
+	if _, err := service.Transform(ctx, img.Name, transformation, signature); !errors.As(err, &unavailable) {
+		t.Fatalf("Expected exception.Unavailable while all slots are taken, got %v", err)
+	}
+
+	close(transformer.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransformsService_Sign(t *testing.T) {
+	ctx := context.Background()
+	img := storage.Image{Id: "0b3c7d5e-7f2a-4e1b-9c6d-8a2f4e6b1c3d", Name: "my-plane", Original: "images/my-plane.png"}
+	service, _ := newTestTransformsService(t, img, &countingTransformer{})
+	transformation := image.Transformation{
+		Width: 300, Height: 200, Fit: image.FitCover, Quality: 80, Format: image.JpgFormat,
+	}
+
+	signed, err := service.Sign(ctx, img.Id, transformation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	derivative, err := service.Transform(ctx, img.Name, transformation, signatureOf(t, signed.Url))
+	if err != nil {
+		t.Fatalf("Expected the signed url to be served, got %v", err)
+	}
+	_ = derivative.Body.Close()
+
+	tooLarge := transformation
+	tooLarge.Width = 3000
+	var invalidArgument exception.InvalidArgument
+	if _, err = service.Sign(ctx, img.Id, tooLarge); !errors.As(err, &invalidArgument) {
+		t.Fatalf("Expected a size that isn't allowed not to be signed, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"api/pkg/concurrency"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// TrashPurger periodically purges images whose retention in the trash has expired
+type TrashPurger struct {
+	config        Config
+	imagesService *ImagesService
+	closed        chan error
+	cancel        context.CancelFunc
+	logger        *zerolog.Logger
+}
+
+func NewTrashPurger(config Config, imagesService *ImagesService, logger *zerolog.Logger) *TrashPurger {
+	return &TrashPurger{
+		config:        config,
+		imagesService: imagesService,
+		closed:        make(chan error),
+		logger:        logger,
+	}
+}
+
+func (purger *TrashPurger) StartPurging(ctx context.Context) error {
+	retention := time.Duration(purger.config.TrashRetentionHours) * time.Hour
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			purged, err := purger.imagesService.PurgeTrash(ctx, purger.config.ImagesApiAuthorization, retention)
+			if err != nil {
+				purger.logger.Error().Msgf("error purging trash: %s", err.Error())
+			} else if purged > 0 {
+				purger.logger.Info().Msgf("purged %d images from the trash", purged)
+			}
+
+			err = concurrency.SleepSecondsWithContext(ctx, purger.config.TrashPurgeIntervalSec)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (purger *TrashPurger) StartPurgingAsync(ctx context.Context) {
+	purger.logger.Info().Msg("Started purging trash")
+
+	derivedCtx, cancel := context.WithCancel(ctx)
+	purger.cancel = cancel
+	go func() {
+		purger.closed <- purger.StartPurging(derivedCtx)
+		close(purger.closed)
+	}()
+}
+
+// Shutdown waits for the purge to stop, it does nothing when purging was never started
+func (purger *TrashPurger) Shutdown() error {
+	if purger.cancel == nil {
+		return nil
+	}
+	purger.logger.Info().Msg("Shutting down trash purger")
+	purger.cancel()
+	if err := <-purger.closed; err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/image"
+	"api/objectstore"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+// uploadIntentsPrefix is where the files uploaded to the signed urls of intents are kept in the object store
+const uploadIntentsPrefix = "intents/"
+
+// UploadIntent hands out signed urls to upload the original and the cropped file of an image directly to the object
+// store, the urls can't be used anymore once the intent expired
+type UploadIntent struct {
+	Id              string    `json:"id"`
+	OriginalFileUrl string    `json:"originalFileUrl"`
+	CroppedFileUrl  string    `json:"croppedFileUrl"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+func (service *UploadsService) intentExpiration() time.Duration {
+	return time.Duration(service.config.UploadIntentExpirationMin) * time.Minute
+}
+
+// CreateIntent signs urls for both files of a new image, the image is created once the intent is completed
+func (service *UploadsService) CreateIntent(
+	ctx context.Context, authorization auth.AuthorizationDto, imageName string, format image.Format,
+) (UploadIntent, error) {
+	if _, err := service.imagesService.newImageName(ctx, imageName); err != nil {
+		return UploadIntent{}, err
+	}
+
+	currentUser, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return UploadIntent{}, err
+	}
+
+	directory := uploadIntentsPrefix + uuid.NewString()
+	originalFile := fmt.Sprintf("%s/original.%s", directory, format)
+	croppedFile := fmt.Sprintf("%s/cropped.%s", directory, format)
+	originalUrl, err := service.objectStore.Presign(ctx, http.MethodPut, originalFile, service.intentExpiration())
+	if err != nil {
+		return UploadIntent{}, fmt.Errorf("failed signing original file url: %w", err)
+	}
+	croppedUrl, err := service.objectStore.Presign(ctx, http.MethodPut, croppedFile, service.intentExpiration())
+	if err != nil {
+		return UploadIntent{}, fmt.Errorf("failed signing cropped file url: %w", err)
+	}
+
+	// the intent expires once its urls did, so nothing is uploaded to an intent after it was purged
+	intent, err := service.intents.Create(ctx, storage.UploadIntent{
+		AuthorId:     currentUser.Id,
+		Name:         imageName,
+		Format:       string(format),
+		OriginalFile: originalFile,
+		CroppedFile:  croppedFile,
+		ExpiresAt:    time.Now().Add(service.intentExpiration()),
+	})
+	if err != nil {
+		return UploadIntent{}, err
+	}
+
+	return UploadIntent{
+		Id:              intent.Id,
+		OriginalFileUrl: originalUrl,
+		CroppedFileUrl:  croppedUrl,
+		ExpiresAt:       intent.ExpiresAt,
+	}, nil
+}
+
+// GetIntent hides intents of other users and expired intents that weren't purged yet
+func (service *UploadsService) GetIntent(
+	ctx context.Context, authorization auth.AuthorizationDto, intentId string,
+) (storage.UploadIntent, error) {
+	if _, err := uuid.Parse(intentId); err != nil {
+		return storage.UploadIntent{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	currentUser, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return storage.UploadIntent{}, err
+	}
+
+	intent, err := service.intents.GetOne(ctx, intentId)
+	if err != nil {
+		return storage.UploadIntent{}, err
+	}
+	if intent.AuthorId != currentUser.Id || intent.ExpiresAt.Before(time.Now()) {
+		return storage.UploadIntent{}, exception.NotFound{Msg: "Upload intent not found by id " + intentId}
+	}
+
+	return intent, nil
+}
+
+// OpenIntentFiles reads both files uploaded to the signed urls of the intent, files that weren't uploaded yet are
+// invalid fields
+func (service *UploadsService) OpenIntentFiles(
+	ctx context.Context, intent storage.UploadIntent,
+) (objectstore.Object, objectstore.Object, error) {
+	invalid := exception.InvalidFields{}
+	original, err := service.openIntentFile(ctx, intent.OriginalFile, "originalFile", &invalid)
+	if err != nil {
+		return objectstore.Object{}, objectstore.Object{}, err
+	}
+	cropped, err := service.openIntentFile(ctx, intent.CroppedFile, "croppedFile", &invalid)
+	if err == nil {
+		err = invalid.OrNil()
+	}
+	if err != nil {
+		for _, object := range []objectstore.Object{original, cropped} {
+			if object.Body != nil {
+				_ = object.Body.Close()
+			}
+		}
+		return objectstore.Object{}, objectstore.Object{}, err
+	}
+
+	return original, cropped, nil
+}
+
+func (service *UploadsService) openIntentFile(
+	ctx context.Context, fileName string, field string, invalid *exception.InvalidFields,
+) (objectstore.Object, error) {
+	object, err := service.objectStore.Get(ctx, fileName)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		invalid.Add(field, "Not uploaded")
+		return objectstore.Object{}, nil
+	}
+	if err != nil {
+		return objectstore.Object{}, fmt.Errorf("failed reading %s: %w", field, err)
+	}
+
+	return object, nil
+}
+
+// CompleteIntent uploads and resizes the files of the intent like a regular upload. An intent is only ever completed
+// once, its files are deleted whether the image could be created or not.
+func (service *UploadsService) CompleteIntent(
+	ctx context.Context,
+	authorization auth.AuthorizationDto,
+	intent storage.UploadIntent,
+	originalFile image.Upload,
+	croppedFile image.Upload,
+) (storage.Image, error) {
+	// the name may have been taken since the intent was created
+	if _, err := service.imagesService.newImageName(ctx, intent.Name); err != nil {
+		return storage.Image{}, err
+	}
+
+	// deleting the intent claims it, a concurrent request completing it fails here
+	err := service.intents.DeleteOne(ctx, intent.Id)
+	var notFound storage.NotFound
+	if errors.As(err, &notFound) {
+		return storage.Image{}, exception.Conflict{Reason: "The upload intent is already completed"}
+	}
+	if err != nil {
+		return storage.Image{}, err
+	}
+	// the intent is gone, so the files would not be purged with it
+	defer service.deleteIntentFiles(uncancelable{ctx}, intent)
+
+	return service.imagesService.UploadAndResize(
+		ctx, authorization, intent.Name, image.Format(intent.Format), originalFile, croppedFile,
+	)
+}
+
+// PurgeExpiredIntents deletes the intents that weren't completed in time along with any files uploaded to them
+func (service *UploadsService) PurgeExpiredIntents(ctx context.Context) (int, error) {
+	expired, err := service.intents.GetExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, intent := range expired {
+		service.deleteIntentFiles(ctx, intent)
+
+		err = service.intents.DeleteOne(ctx, intent.Id)
+		var notFound storage.NotFound
+		if err != nil && !errors.As(err, &notFound) {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// deleteIntentFiles removes whatever was uploaded to the signed urls of the intent
+func (service *UploadsService) deleteIntentFiles(ctx context.Context, intent storage.UploadIntent) {
+	for _, fileName := range []string{intent.OriginalFile, intent.CroppedFile} {
+		if err := service.objectStore.Delete(ctx, fileName); err != nil {
+			service.logger.Error().Msgf("failed deleting %s of upload intent %s: %s", fileName, intent.Id, err.Error())
+		}
+	}
+}
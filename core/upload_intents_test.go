@@ -0,0 +1,152 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/image"
+	"api/objectstore"
+	"api/objectstore/filesystem"
+	"api/storage"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testIntentId = "3c8a6e1f-2b4d-4f7a-9e0c-5d1b7a3f9e2c"
+
+type memoryUploadIntentRepo struct {
+	storage.UploadIntentRepoMock
+	intents map[string]storage.UploadIntent
+}
+
+func (repo *memoryUploadIntentRepo) GetOne(_ context.Context, intentId string) (storage.UploadIntent, error) {
+	intent, ok := repo.intents[intentId]
+	if !ok {
+		return storage.UploadIntent{}, storage.NotFound{Msg: "Upload intent not found by id " + intentId}
+	}
+	return intent, nil
+}
+
+func (repo *memoryUploadIntentRepo) DeleteOne(_ context.Context, intentId string) error {
+	if _, ok := repo.intents[intentId]; !ok {
+		return storage.NotFound{Msg: "Upload intent not found by id " + intentId}
+	}
+	delete(repo.intents, intentId)
+	return nil
+}
+
+func (repo *memoryUploadIntentRepo) GetExpired(_ context.Context, now time.Time) (storage.UploadIntentList, error) {
+	expired := storage.UploadIntentList{}
+	for _, intent := range repo.intents {
+		if intent.ExpiresAt.Before(now) {
+			expired = append(expired, intent)
+		}
+	}
+	return expired, nil
+}
+
+func newTestIntentsService(
+	t *testing.T, expiresAt time.Time,
+) (*UploadsService, *memoryUploadIntentRepo, objectstore.ObjectStore) {
+	logger := zerolog.Nop()
+	store, err := filesystem.NewStore(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imagesService := newTestImagesService(testImagesDeps{})
+	intents := &memoryUploadIntentRepo{intents: map[string]storage.UploadIntent{
+		testIntentId: {
+			Id:           testIntentId,
+			Name:         "my plane",
+			Format:       string(image.PngFormat),
+			OriginalFile: uploadIntentsPrefix + testIntentId + "/original.png",
+			CroppedFile:  uploadIntentsPrefix + testIntentId + "/cropped.png",
+			ExpiresAt:    expiresAt,
+		},
+	}}
+	service := NewUploadsService(
+		Config{UploadIntentExpirationMin: 60}, storage.UploadRepoMock{}, intents, store, imagesService, &auth.Mock{},
+		&logger,
+	)
+
+	return service, intents, store
+}
+
+func putIntentFile(t *testing.T, store objectstore.ObjectStore, key string) {
+	if err := store.Put(context.Background(), key, strings.NewReader("plane"), 5, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUploadsService_OpenIntentFiles(t *testing.T) {
+	ctx := context.Background()
+	service, intents, store := newTestIntentsService(t, time.Now().Add(time.Hour))
+	intent := intents.intents[testIntentId]
+	putIntentFile(t, store, intent.OriginalFile)
+
+	_, _, err := service.OpenIntentFiles(ctx, intent)
+	var invalid exception.InvalidFields
+	if !errors.As(err, &invalid) || len(invalid.Fields) != 1 || invalid.Fields[0].Field != "croppedFile" {
+		t.Fatalf("Expected the cropped file not to be uploaded, got %v", err)
+	}
+
+	putIntentFile(t, store, intent.CroppedFile)
+	original, cropped, err := service.OpenIntentFiles(ctx, intent)
+	if err != nil {
+		t.Fatalf("Expected both files to be opened, got %v", err)
+	}
+	_ = original.Body.Close()
+	_ = cropped.Body.Close()
+	if original.Size != 5 || cropped.Size != 5 {
+		t.Fatalf("Expected both files to have 5 bytes, got %d and %d", original.Size, cropped.Size)
+	}
+}
+
+func TestUploadsService_CompleteIntent(t *testing.T) {
+	ctx := context.Background()
+	service, intents, store := newTestIntentsService(t, time.Now().Add(time.Hour))
+	intent := intents.intents[testIntentId]
+	putIntentFile(t, store, intent.OriginalFile)
+	putIntentFile(t, store, intent.CroppedFile)
+	original := pngUpload("plane")
+	cropped := pngUpload("plane")
+
+	if _, err := service.CompleteIntent(ctx, auth.AuthorizationDto{}, intent, original, cropped); err != nil {
+		t.Fatalf("Expected the image to be created, got %v", err)
+	}
+	if _, ok := intents.intents[testIntentId]; ok {
+		t.Fatal("Expected the completed intent to be deleted")
+	}
+	if _, err := store.Get(ctx, intent.OriginalFile); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("Expected the files of the completed intent to be deleted, got %v", err)
+	}
+
+	var conflict exception.Conflict
+	_, err := service.CompleteIntent(ctx, auth.AuthorizationDto{}, intent, original, cropped)
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected a completed intent to conflict, got %v", err)
+	}
+}
+
+func TestUploadsService_PurgeExpiredIntents(t *testing.T) {
+	ctx := context.Background()
+	service, intents, store := newTestIntentsService(t, time.Now().Add(-time.Minute))
+	intent := intents.intents[testIntentId]
+	putIntentFile(t, store, intent.OriginalFile)
+
+	var notFound exception.NotFound
+	if _, err := service.GetIntent(ctx, auth.AuthorizationDto{}, testIntentId); !errors.As(err, &notFound) {
+		t.Fatalf("Expected an expired intent not to be found, got %v", err)
+	}
+
+	purged, err := service.PurgeExpiredIntents(ctx)
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected one intent to be purged, got %d and error %v", purged, err)
+	}
+	if _, err = store.Get(ctx, intent.OriginalFile); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("Expected the files of the expired intent to be deleted, got %v", err)
+	}
+}
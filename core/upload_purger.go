@@ -0,0 +1,79 @@
+package core
+
+import (
+	"api/pkg/concurrency"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+)
+
+// UploadPurger periodically purges resumable uploads and upload intents that expired before they were finished
+type UploadPurger struct {
+	config         Config
+	uploadsService *UploadsService
+	closed         chan error
+	cancel         context.CancelFunc
+	logger         *zerolog.Logger
+}
+
+func NewUploadPurger(config Config, uploadsService *UploadsService, logger *zerolog.Logger) *UploadPurger {
+	return &UploadPurger{
+		config:         config,
+		uploadsService: uploadsService,
+		closed:         make(chan error),
+		logger:         logger,
+	}
+}
+
+func (purger *UploadPurger) StartPurging(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			purged, err := purger.uploadsService.PurgeExpired(ctx)
+			if err != nil {
+				purger.logger.Error().Msgf("error purging expired uploads: %s", err.Error())
+			} else if purged > 0 {
+				purger.logger.Info().Msgf("purged %d expired uploads", purged)
+			}
+
+			purged, err = purger.uploadsService.PurgeExpiredIntents(ctx)
+			if err != nil {
+				purger.logger.Error().Msgf("error purging expired upload intents: %s", err.Error())
+			} else if purged > 0 {
+				purger.logger.Info().Msgf("purged %d expired upload intents", purged)
+			}
+
+			err = concurrency.SleepSecondsWithContext(ctx, purger.config.UploadPurgeIntervalSec)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (purger *UploadPurger) StartPurgingAsync(ctx context.Context) {
+	purger.logger.Info().Msg("Started purging expired uploads")
+
+	derivedCtx, cancel := context.WithCancel(ctx)
+	purger.cancel = cancel
+	go func() {
+		purger.closed <- purger.StartPurging(derivedCtx)
+		close(purger.closed)
+	}()
+}
+
+// Shutdown waits for the purge to stop, it does nothing when purging was never started
+func (purger *UploadPurger) Shutdown() error {
+	if purger.cancel == nil {
+		return nil
+	}
+	purger.logger.Info().Msg("Shutting down upload purger")
+	purger.cancel()
+	if err := <-purger.closed; err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return nil
+}
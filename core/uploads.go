@@ -0,0 +1,425 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/image"
+	"api/objectstore"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"io"
+	"time"
+)
+
+const (
+	// UploadMetadataName is the name of the image, set on the upload of the original file
+	UploadMetadataName = "name"
+	// UploadMetadataFormat is the format of both files, set on the upload of the original file
+	UploadMetadataFormat = "format"
+	// UploadMetadataOriginal is the id of the original upload, set on the upload of the cropped file
+	UploadMetadataOriginal = "original"
+)
+
+// uploadChunksPrefix is where the chunks of resumable uploads are kept in the object store
+const uploadChunksPrefix = "tus/"
+
+// UploadsService receives the files of an image in resumable chunks. Once both the original and the cropped
+// upload are complete, their chunks are streamed to the resize api as a regular image upload. Clients may also
+// upload both files directly to signed urls of an upload intent.
+type UploadsService struct {
+	config        Config
+	uploads       storage.UploadRepository
+	intents       storage.UploadIntentRepository
+	objectStore   objectstore.ObjectStore
+	imagesService *ImagesService
+	authenticator auth.Authenticator
+	logger        *zerolog.Logger
+}
+
+func NewUploadsService(
+	config Config,
+	uploads storage.UploadRepository,
+	intents storage.UploadIntentRepository,
+	objectStore objectstore.ObjectStore,
+	imagesService *ImagesService,
+	authenticator auth.Authenticator,
+	logger *zerolog.Logger,
+) *UploadsService {
+	return &UploadsService{
+		config:        config,
+		uploads:       uploads,
+		intents:       intents,
+		objectStore:   objectStore,
+		imagesService: imagesService,
+		authenticator: authenticator,
+		logger:        logger,
+	}
+}
+
+func (service *UploadsService) expiration() time.Duration {
+	return time.Duration(service.config.UploadExpirationHours) * time.Hour
+}
+
+// Create starts an upload of length bytes. An upload with UploadMetadataOriginal is the cropped file paired with
+// that upload, any other upload is of an original file and names the image.
+func (service *UploadsService) Create(
+	ctx context.Context, authorization auth.AuthorizationDto, length int64, metadata map[string]string,
+) (storage.Upload, error) {
+	currentUser, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return storage.Upload{}, err
+	}
+
+	upload := storage.Upload{
+		AuthorId:  currentUser.Id,
+		Length:    length,
+		Metadata:  metadata,
+		ExpiresAt: time.Now().Add(service.expiration()),
+	}
+	if originalId, isCropped := metadata[UploadMetadataOriginal]; isCropped {
+		original, err := service.getOwnUpload(ctx, currentUser, originalId)
+		if err != nil {
+			return storage.Upload{}, err
+		}
+		if original.OriginalId != nil {
+			return storage.Upload{}, exception.InvalidArgument{
+				Reason: fmt.Sprintf("Upload %s is not of an original file", originalId),
+			}
+		}
+		upload.OriginalId = &original.Id
+	} else if !image.Format(metadata[UploadMetadataFormat]).IsSupported() {
+		return storage.Upload{}, exception.InvalidArgument{
+			Reason: fmt.Sprintf("Unsupported format %s", metadata[UploadMetadataFormat]),
+		}
+	}
+
+	created, err := service.uploads.Create(ctx, upload)
+	if errors.Is(err, storage.ErrDuplicate) {
+		return storage.Upload{}, exception.InvalidArgument{
+			Reason: fmt.Sprintf("Upload %s already has a cropped file", metadata[UploadMetadataOriginal]),
+		}
+	}
+
+	return created, err
+}
+
+func (service *UploadsService) GetOne(
+	ctx context.Context, authorization auth.AuthorizationDto, uploadId string,
+) (storage.Upload, error) {
+	currentUser, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return storage.Upload{}, err
+	}
+
+	return service.getOwnUpload(ctx, currentUser, uploadId)
+}
+
+// getOwnUpload hides uploads of other users and expired uploads that weren't purged yet
+func (service *UploadsService) getOwnUpload(
+	ctx context.Context, currentUser storage.User, uploadId string,
+) (storage.Upload, error) {
+	if _, err := uuid.Parse(uploadId); err != nil {
+		return storage.Upload{}, exception.InvalidArgument{Reason: "Invalid uuid"}
+	}
+
+	upload, err := service.uploads.GetOne(ctx, uploadId)
+	if err != nil {
+		return storage.Upload{}, err
+	}
+	if upload.AuthorId != currentUser.Id || upload.ExpiresAt.Before(time.Now()) {
+		return storage.Upload{}, exception.NotFound{Msg: "Upload not found by id " + uploadId}
+	}
+
+	return upload, nil
+}
+
+// Append stores the body as the chunk at offset, which has to be the current offset of the upload. Whatever was
+// received before the body failed is kept, so the upload can be resumed from there.
+func (service *UploadsService) Append(
+	ctx context.Context, authorization auth.AuthorizationDto, uploadId string, offset int64, body io.Reader,
+) (storage.Upload, error) {
+	upload, err := service.GetOne(ctx, authorization, uploadId)
+	if err != nil {
+		return storage.Upload{}, err
+	}
+	if offset != upload.Offset {
+		return storage.Upload{}, exception.Conflict{
+			Reason: fmt.Sprintf("Offset %d doesn't match the upload offset %d", offset, upload.Offset),
+		}
+	}
+
+	// one byte past the remaining length tells an oversized body apart
+	received := &receivedReader{reader: io.LimitReader(body, upload.Length-upload.Offset+1)}
+	chunk := fmt.Sprintf("%s%s/%020d-%s", uploadChunksPrefix, upload.Id, offset, uuid.NewString())
+	// the client may be gone by now, the received bytes are stored regardless
+	storeCtx := uncancelable{ctx}
+	err = service.objectStore.Put(storeCtx, chunk, received, image.UnknownSize, "application/offset+octet-stream")
+	if err != nil {
+		return storage.Upload{}, fmt.Errorf("failed storing chunk: %w", err)
+	}
+	if received.size > upload.Length-upload.Offset {
+		service.deleteChunk(storeCtx, chunk)
+		return storage.Upload{}, exception.InvalidArgument{
+			Reason: fmt.Sprintf("Chunk exceeds the upload length of %d", upload.Length),
+		}
+	}
+	if received.size == 0 {
+		service.deleteChunk(storeCtx, chunk)
+		if received.err != nil {
+			return storage.Upload{}, fmt.Errorf("failed receiving chunk: %w", received.err)
+		}
+		return upload, nil
+	}
+
+	expiresAt := time.Now().Add(service.expiration())
+	isAdvanced, err := service.uploads.Advance(storeCtx, upload.Id, offset, chunk, received.size, expiresAt)
+	if err != nil || !isAdvanced {
+		service.deleteChunk(storeCtx, chunk)
+	}
+	if err != nil {
+		return storage.Upload{}, err
+	}
+	if !isAdvanced {
+		return storage.Upload{}, exception.Conflict{Reason: "The upload received another chunk at the same offset"}
+	}
+	if received.err != nil {
+		return storage.Upload{}, fmt.Errorf("failed receiving chunk: %w", received.err)
+	}
+
+	upload.Offset += received.size
+	upload.Chunks = append(upload.Chunks, chunk)
+	upload.ExpiresAt = expiresAt
+
+	return upload, nil
+}
+
+// GetCompletePair returns the original and the cropped upload paired with the upload, false is returned while
+// either of them hasn't been received whole
+func (service *UploadsService) GetCompletePair(
+	ctx context.Context, upload storage.Upload,
+) (storage.Upload, storage.Upload, bool, error) {
+	original, cropped := upload, upload
+	var err error
+	if upload.OriginalId != nil {
+		original, err = service.uploads.GetOne(ctx, *upload.OriginalId)
+	} else {
+		cropped, err = service.uploads.GetCropped(ctx, upload.Id)
+	}
+
+	var notFound storage.NotFound
+	if errors.As(err, &notFound) {
+		return storage.Upload{}, storage.Upload{}, false, nil
+	}
+	if err != nil {
+		return storage.Upload{}, storage.Upload{}, false, err
+	}
+
+	return original, cropped, original.IsComplete() && cropped.IsComplete(), nil
+}
+
+// Open reads the chunks of the upload in order
+func (service *UploadsService) Open(ctx context.Context, upload storage.Upload) io.ReadCloser {
+	return &chunksReader{ctx: ctx, store: service.objectStore, chunks: upload.Chunks}
+}
+
+// CreateImage uploads and resizes the files of the complete pair, a pair is only ever turned into one image
+func (service *UploadsService) CreateImage(
+	ctx context.Context,
+	authorization auth.AuthorizationDto,
+	original storage.Upload,
+	originalFile image.Upload,
+	croppedFile image.Upload,
+) (storage.Image, error) {
+	isClaimed, err := service.uploads.Claim(ctx, original.Id)
+	if err != nil {
+		return storage.Image{}, err
+	}
+	if !isClaimed {
+		return storage.Image{}, exception.Conflict{Reason: "The image of the upload is already being created"}
+	}
+
+	img, err := service.imagesService.UploadAndResize(
+		ctx,
+		authorization,
+		original.Metadata[UploadMetadataName],
+		image.Format(original.Metadata[UploadMetadataFormat]),
+		originalFile,
+		croppedFile,
+	)
+	if err != nil {
+		if releaseErr := service.uploads.Release(uncancelable{ctx}, original.Id); releaseErr != nil {
+			service.logger.Error().Msgf("failed releasing upload %s: %s", original.Id, releaseErr.Error())
+		}
+		return storage.Image{}, err
+	}
+
+	if err = service.uploads.SetImage(ctx, original.Id, img.Id); err != nil {
+		return storage.Image{}, err
+	}
+	service.deleteChunks(ctx, original.Id)
+	if cropped, err := service.uploads.GetCropped(ctx, original.Id); err == nil {
+		service.deleteChunks(ctx, cropped.Id)
+	}
+
+	return img, nil
+}
+
+// Terminate deletes the upload along with its received chunks
+func (service *UploadsService) Terminate(
+	ctx context.Context, authorization auth.AuthorizationDto, uploadId string,
+) error {
+	upload, err := service.GetOne(ctx, authorization, uploadId)
+	if err != nil {
+		return err
+	}
+
+	service.deleteChunks(ctx, upload.Id)
+
+	return service.uploads.DeleteOne(ctx, upload.Id)
+}
+
+// Reject deletes both uploads of the complete pair when its files are invalid, so the pair isn't left complete
+// without ever becoming an image. The client has to start both uploads again.
+func (service *UploadsService) Reject(ctx context.Context, original storage.Upload, cropped storage.Upload) error {
+	// the rejection is reported to the client, the uploads are deleted even if it is gone by now
+	storeCtx := uncancelable{ctx}
+	for _, upload := range []storage.Upload{cropped, original} {
+		service.deleteChunks(storeCtx, upload.Id)
+
+		err := service.uploads.DeleteOne(storeCtx, upload.Id)
+		var notFound storage.NotFound
+		if err != nil && !errors.As(err, &notFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes the uploads that haven't received a chunk within the expiration
+func (service *UploadsService) PurgeExpired(ctx context.Context) (int, error) {
+	expired, err := service.uploads.GetExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, upload := range expired {
+		service.deleteChunks(ctx, upload.Id)
+
+		err = service.uploads.DeleteOne(ctx, upload.Id)
+		var notFound storage.NotFound
+		if err != nil && !errors.As(err, &notFound) {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// deleteChunks removes every chunk stored for the upload, including chunks of requests that lost a race
+func (service *UploadsService) deleteChunks(ctx context.Context, uploadId string) {
+	chunks, err := service.objectStore.List(ctx, uploadChunksPrefix+uploadId+"/")
+	if err != nil {
+		service.logger.Error().Msgf("failed listing chunks of upload %s: %s", uploadId, err.Error())
+		return
+	}
+
+	for _, chunk := range chunks {
+		service.deleteChunk(ctx, chunk.Key)
+	}
+}
+
+func (service *UploadsService) deleteChunk(ctx context.Context, chunk string) {
+	if err := service.objectStore.Delete(ctx, chunk); err != nil {
+		service.logger.Error().Msgf("failed deleting chunk %s: %s", chunk, err.Error())
+	}
+}
+
+// receivedReader ends the body at its first error, so the bytes received before a dropped connection are stored
+type receivedReader struct {
+	reader io.Reader
+	size   int64
+	err    error
+}
+
+func (reader *receivedReader) Read(p []byte) (int, error) {
+	n, err := reader.reader.Read(p)
+	reader.size += int64(n)
+	if err != nil && err != io.EOF {
+		reader.err = err
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// chunksReader concatenates the chunks of an upload, each one is only fetched once the previous one was read
+type chunksReader struct {
+	ctx     context.Context
+	store   objectstore.ObjectStore
+	chunks  []string
+	current io.ReadCloser
+}
+
+func (reader *chunksReader) Read(p []byte) (int, error) {
+	for {
+		if reader.current == nil {
+			if len(reader.chunks) == 0 {
+				return 0, io.EOF
+			}
+
+			object, err := reader.store.Get(reader.ctx, reader.chunks[0])
+			if err != nil {
+				return 0, fmt.Errorf("failed reading chunk %s: %w", reader.chunks[0], err)
+			}
+			reader.current = object.Body
+			reader.chunks = reader.chunks[1:]
+		}
+
+		n, err := reader.current.Read(p)
+		if err == io.EOF {
+			err = reader.current.Close()
+			reader.current = nil
+			if n > 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+
+		return n, err
+	}
+}
+
+func (reader *chunksReader) Close() error {
+	if reader.current == nil {
+		return nil
+	}
+
+	err := reader.current.Close()
+	reader.current = nil
+
+	return err
+}
+
+// uncancelable keeps the values of its parent context but not its cancellation
+type uncancelable struct {
+	context.Context
+}
+
+func (uncancelable) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (uncancelable) Done() <-chan struct{} {
+	return nil
+}
+
+func (uncancelable) Err() error {
+	return nil
+}
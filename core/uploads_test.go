@@ -0,0 +1,176 @@
+package core
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/objectstore/filesystem"
+	"api/storage"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testUploadId = "5d7f1c2a-4b8e-4f3a-9c6d-2e1b0a9f8c7d"
+
+type memoryUploadRepo struct {
+	storage.UploadRepoMock
+	uploads map[string]storage.Upload
+}
+
+func (repo *memoryUploadRepo) GetOne(_ context.Context, uploadId string) (storage.Upload, error) {
+	upload, ok := repo.uploads[uploadId]
+	if !ok {
+		return storage.Upload{}, storage.NotFound{Msg: "Upload not found by id " + uploadId}
+	}
+	return upload, nil
+}
+
+func (repo *memoryUploadRepo) Advance(
+	_ context.Context, uploadId string, offset int64, chunk string, size int64, expiresAt time.Time,
+) (bool, error) {
+	upload := repo.uploads[uploadId]
+	if upload.Offset != offset {
+		return false, nil
+	}
+	upload.Offset += size
+	upload.Chunks = append(upload.Chunks, chunk)
+	upload.ExpiresAt = expiresAt
+	repo.uploads[uploadId] = upload
+	return true, nil
+}
+
+func (repo *memoryUploadRepo) GetExpired(_ context.Context, before time.Time) (storage.UploadList, error) {
+	expired := storage.UploadList{}
+	for _, upload := range repo.uploads {
+		if upload.ExpiresAt.Before(before) {
+			expired = append(expired, upload)
+		}
+	}
+	return expired, nil
+}
+
+func (repo *memoryUploadRepo) DeleteOne(_ context.Context, uploadId string) error {
+	delete(repo.uploads, uploadId)
+	return nil
+}
+
+type failingReader struct {
+	content string
+	isRead  bool
+}
+
+func (reader *failingReader) Read(p []byte) (int, error) {
+	if reader.isRead {
+		return 0, errors.New("connection reset")
+	}
+	reader.isRead = true
+	return copy(p, reader.content), nil
+}
+
+func newTestUploadsService(t *testing.T, length int64) (*UploadsService, *memoryUploadRepo) {
+	logger := zerolog.Nop()
+	store, err := filesystem.NewStore(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &memoryUploadRepo{uploads: map[string]storage.Upload{
+		testUploadId: {Id: testUploadId, Length: length, ExpiresAt: time.Now().Add(time.Hour)},
+	}}
+	service := NewUploadsService(
+		Config{UploadExpirationHours: 24}, repo, storage.UploadIntentRepoMock{}, store, nil, &auth.Mock{}, &logger,
+	)
+
+	return service, repo
+}
+
+func TestUploadsService_Append(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestUploadsService(t, 11)
+
+	upload, err := service.Append(ctx, auth.AuthorizationDto{}, testUploadId, 0, strings.NewReader("hello "))
+	if err != nil || upload.Offset != 6 {
+		t.Fatalf("Expected the offset to be 6, got %d and error %v", upload.Offset, err)
+	}
+
+	var conflict exception.Conflict
+	_, err = service.Append(ctx, auth.AuthorizationDto{}, testUploadId, 0, strings.NewReader("hello "))
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected an outdated offset to conflict, got %v", err)
+	}
+
+	var invalidArgument exception.InvalidArgument
+	_, err = service.Append(ctx, auth.AuthorizationDto{}, testUploadId, 6, strings.NewReader("world and more"))
+	if !errors.As(err, &invalidArgument) {
+		t.Fatalf("Expected a chunk past the length to be invalid, got %v", err)
+	}
+
+	_, err = service.Append(ctx, auth.AuthorizationDto{}, testUploadId, 6, &failingReader{content: "wo"})
+	if err == nil || repo.uploads[testUploadId].Offset != 8 {
+		t.Fatalf("Expected the bytes received before the failure to be kept, got error %v", err)
+	}
+
+	upload, err = service.Append(ctx, auth.AuthorizationDto{}, testUploadId, 8, strings.NewReader("rld"))
+	if err != nil || !upload.IsComplete() {
+		t.Fatalf("Expected the upload to be complete, got %+v and error %v", upload, err)
+	}
+
+	file := service.Open(ctx, upload)
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil || string(content) != "hello world" {
+		t.Fatalf("Expected the chunks to be read in order, got %q and error %v", content, err)
+	}
+}
+
+func TestUploadsService_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestUploadsService(t, 5)
+
+	if _, err := service.Append(ctx, auth.AuthorizationDto{}, testUploadId, 0, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	upload := repo.uploads[testUploadId]
+	upload.ExpiresAt = time.Now().Add(-time.Minute)
+	repo.uploads[testUploadId] = upload
+
+	if _, err := service.GetOne(ctx, auth.AuthorizationDto{}, testUploadId); err == nil {
+		t.Fatal("Expected an expired upload to be hidden")
+	}
+
+	purged, err := service.PurgeExpired(ctx)
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected one purged upload, got %d and error %v", purged, err)
+	}
+	chunks, err := service.objectStore.List(ctx, uploadChunksPrefix)
+	if err != nil || len(chunks) != 0 {
+		t.Fatalf("Expected the chunks to be deleted, got %v and error %v", chunks, err)
+	}
+}
+
+func TestUploadsService_Reject(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestUploadsService(t, 5)
+	croppedId := "8a2e6c4f-1d3b-4e5a-9f7c-0b6d2a8e4c1f"
+	repo.uploads[croppedId] = storage.Upload{Id: croppedId, Length: 5, ExpiresAt: time.Now().Add(time.Hour)}
+
+	for _, uploadId := range []string{testUploadId, croppedId} {
+		if _, err := service.Append(ctx, auth.AuthorizationDto{}, uploadId, 0, strings.NewReader("hello")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := service.Reject(ctx, repo.uploads[testUploadId], repo.uploads[croppedId]); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.uploads) != 0 {
+		t.Fatalf("Expected both uploads to be deleted, got %v", repo.uploads)
+	}
+	chunks, err := service.objectStore.List(ctx, uploadChunksPrefix)
+	if err != nil || len(chunks) != 0 {
+		t.Fatalf("Expected the chunks to be deleted, got %v and error %v", chunks, err)
+	}
+}
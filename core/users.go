@@ -0,0 +1,40 @@
+package core
+
+import (
+	"api/auth"
+	"api/storage"
+	"context"
+)
+
+type UsersService struct {
+	authenticator auth.Authenticator
+}
+
+func NewUsersService(authenticator auth.Authenticator) *UsersService {
+	return &UsersService{authenticator: authenticator}
+}
+
+// Profile is the stored user along with the role and groups granted by its token
+type Profile struct {
+	User   storage.User `json:"user"`
+	Role   auth.Role    `json:"role"`
+	Groups []string     `json:"groups"`
+}
+
+func (service *UsersService) GetProfile(ctx context.Context, authorization auth.AuthorizationDto) (Profile, error) {
+	user, err := service.authenticator.GetOrSyncUser(ctx, authorization)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	groups := authorization.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+
+	return Profile{
+		User:   user,
+		Role:   auth.RoleFromGroups(groups),
+		Groups: groups,
+	}, nil
+}
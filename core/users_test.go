@@ -0,0 +1,35 @@
+package core
+
+import (
+	"api/auth"
+	"context"
+	"testing"
+)
+
+func TestUsersService_GetProfile(t *testing.T) {
+	data := []struct {
+		testName     string
+		groups       []string
+		expectedRole auth.Role
+	}{
+		{testName: "Administrator", groups: []string{"Editors", "Administrators"}, expectedRole: auth.RoleAdmin},
+		{testName: "Other groups", groups: []string{"Editors"}, expectedRole: auth.RoleNone},
+		{testName: "No groups", groups: nil, expectedRole: auth.RoleNone},
+	}
+
+	service := NewUsersService(&auth.Mock{})
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			profile, err := service.GetProfile(context.Background(), auth.AuthorizationDto{Groups: d.groups})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile.Role != d.expectedRole {
+				t.Fatalf("Expected role %q, got %q", d.expectedRole, profile.Role)
+			}
+			if profile.Groups == nil || len(profile.Groups) != len(d.groups) {
+				t.Fatalf("Expected groups %v, got %v", d.groups, profile.Groups)
+			}
+		})
+	}
+}
@@ -8,5 +8,5 @@ import (
 type Authenticator interface {
 	IsTokenValid(
 		ctx context.Context, tokenString string, role auth.Role,
-	) (isValid bool, token string, err error)
+	) (isValid bool, claims auth.TokenClaims, err error)
 }
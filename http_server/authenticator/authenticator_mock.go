@@ -11,10 +11,10 @@ type Mock struct{}
 
 func (a Mock) IsTokenValid(
 	_ context.Context, tokenString string, _ auth.Role,
-) (isValid bool, token string, err error) {
+) (isValid bool, claims auth.TokenClaims, err error) {
 	if tokenString == bearerTokenMock {
-		return true, bearerTokenMock, nil
+		return true, auth.TokenClaims{Username: bearerTokenMock, Groups: []string{}}, nil
 	}
 
-	return false, "", nil
+	return false, auth.TokenClaims{}, nil
 }
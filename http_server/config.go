@@ -1,6 +1,7 @@
 package http_server
 
 import (
+	"api/image"
 	"errors"
 	"os"
 	"strconv"
@@ -24,20 +25,24 @@ type Config struct {
 	Domain                     string
 	OAuth2TokenUrl             string
 	OAuth2AuthorizationCodeUrl string
+	UploadMinResolution        image.Dimensions
+	UploadMaxResolution        image.Dimensions
 }
 
 func NewDefaultConfig() Config {
 	return Config{
-		Port:              3000,
-		Timeout:           30 * time.Second,
-		ReadTimeout:       30 * time.Second,
-		WriteTimeout:      30 * time.Second,
-		IdleTimeout:       30 * time.Second,
-		ReadHeaderTimeout: 30 * time.Second,
-		HeartbeatUrl:      "/",
-		BasicAuthUsername: "admin",
-		BasicAuthPassword: "1234",
-		BasicAuthRealm:    "simple_gopher",
+		Port:                3000,
+		Timeout:             30 * time.Second,
+		ReadTimeout:         30 * time.Second,
+		WriteTimeout:        30 * time.Second,
+		IdleTimeout:         30 * time.Second,
+		ReadHeaderTimeout:   30 * time.Second,
+		HeartbeatUrl:        "/",
+		BasicAuthUsername:   "admin",
+		BasicAuthPassword:   "1234",
+		BasicAuthRealm:      "simple_gopher",
+		UploadMinResolution: image.Dimensions{Width: 100, Height: 100},
+		UploadMaxResolution: image.Dimensions{Width: 10000, Height: 10000},
 	}
 }
 
@@ -103,6 +108,27 @@ func (c *Config) LoadFromEnv() error {
 		c.Timeout = parsed
 	}
 
+	if resolution := os.Getenv("UPLOAD_MIN_RESOLUTION"); resolution != "" {
+		parsed, err := parseResolution(resolution)
+		if err != nil {
+			return err
+		}
+		c.UploadMinResolution = parsed
+	}
+
+	if resolution := os.Getenv("UPLOAD_MAX_RESOLUTION"); resolution != "" {
+		parsed, err := parseResolution(resolution)
+		if err != nil {
+			return err
+		}
+		c.UploadMaxResolution = parsed
+	}
+
+	if c.UploadMinResolution.Width > c.UploadMaxResolution.Width ||
+		c.UploadMinResolution.Height > c.UploadMaxResolution.Height {
+		return errors.New("env UPLOAD_MIN_RESOLUTION must not exceed UPLOAD_MAX_RESOLUTION")
+	}
+
 	return nil
 }
 
@@ -1,5 +1,7 @@
 package http_util
 
+import "api/core/exception"
+
 type FailureResponse struct {
 	Err string `json:"error"`
 }
@@ -8,6 +10,12 @@ func (f FailureResponse) Error() string {
 	return f.Err
 }
 
+// FieldsFailureResponse lists the invalid fields of a request
+type FieldsFailureResponse struct {
+	Err    string                 `json:"error"`
+	Fields []exception.FieldError `json:"fields"`
+}
+
 func NewFailureResponse(msg string) FailureResponse {
 	return FailureResponse{Err: msg}
 }
@@ -15,3 +23,5 @@ func NewFailureResponse(msg string) FailureResponse {
 var serverErrorFailure = NewFailureResponse("Internal server error")
 var forbiddenFailure = NewFailureResponse("Forbidden")
 var notFoundFailure = NewFailureResponse("Not found")
+
+const invalidFieldsMessage = "Invalid fields"
@@ -2,6 +2,7 @@ package http_util
 
 import (
 	"api/core/exception"
+	"api/storage"
 	"errors"
 	"github.com/rs/zerolog"
 	"net/http"
@@ -21,6 +22,29 @@ func HandleError(logger *zerolog.Logger, w http.ResponseWriter, err error) {
 		return
 	}
 
+	var storageNotFoundFail storage.NotFound
+	if errors.As(err, &storageNotFoundFail) {
+		WriteJson(w, http.StatusNotFound, notFoundFailure)
+		return
+	}
+
+	var conflictFail exception.Conflict
+	if errors.As(err, &conflictFail) {
+		WriteJson(w, http.StatusConflict, &FailureResponse{
+			Err: conflictFail.Error(),
+		})
+		return
+	}
+
+	var invalidFieldsFail exception.InvalidFields
+	if errors.As(err, &invalidFieldsFail) {
+		WriteJson(w, http.StatusBadRequest, &FieldsFailureResponse{
+			Err:    invalidFieldsMessage,
+			Fields: invalidFieldsFail.Fields,
+		})
+		return
+	}
+
 	var invalidArgumentFail exception.InvalidArgument
 	if errors.As(err, &invalidArgumentFail) {
 		WriteJson(w, http.StatusBadRequest, &FailureResponse{
@@ -29,6 +53,36 @@ func HandleError(logger *zerolog.Logger, w http.ResponseWriter, err error) {
 		return
 	}
 
+	var unavailableFail exception.Unavailable
+	if errors.As(err, &unavailableFail) {
+		if unavailableFail.Err != nil {
+			logger.Err(err).Msg("Dependency unavailable")
+		}
+		w.Header().Set("Retry-After", "1")
+		WriteJson(w, http.StatusServiceUnavailable, &FailureResponse{
+			Err: unavailableFail.Error(),
+		})
+		return
+	}
+
+	var gatewayTimeoutFail exception.GatewayTimeout
+	if errors.As(err, &gatewayTimeoutFail) {
+		logger.Err(err).Msg("Dependency timed out")
+		WriteJson(w, http.StatusGatewayTimeout, &FailureResponse{
+			Err: gatewayTimeoutFail.Error(),
+		})
+		return
+	}
+
+	var badGatewayFail exception.BadGateway
+	if errors.As(err, &badGatewayFail) {
+		logger.Err(err).Msg("Dependency failed")
+		WriteJson(w, http.StatusBadGateway, &FailureResponse{
+			Err: badGatewayFail.Error(),
+		})
+		return
+	}
+
 	var failureResponse FailureResponse
 	if errors.As(err, &failureResponse) {
 		WriteJson(w, http.StatusBadRequest, failureResponse)
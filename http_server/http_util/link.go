@@ -0,0 +1,40 @@
+package http_util
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Link is a single RFC 8288 web link
+type Link struct {
+	Url string
+	Rel string
+}
+
+// FormatLinkHeader formats the links as a value of the Link header, for example:
+// </api/v1/images?cursor=abc>; rel="next", </api/v1/images>; rel="first"
+func FormatLinkHeader(links []Link) string {
+	values := make([]string, 0, len(links))
+	for _, link := range links {
+		values = append(values, fmt.Sprintf(`<%s>; rel="%s"`, link.Url, link.Rel))
+	}
+
+	return strings.Join(values, ", ")
+}
+
+// WithQuery returns a copy of the url with the query parameters set, empty values remove the parameter
+func WithQuery(requestUrl *url.URL, params map[string]string) string {
+	updated := *requestUrl
+	query := updated.Query()
+	for key, value := range params {
+		if value == "" {
+			query.Del(key)
+		} else {
+			query.Set(key, value)
+		}
+	}
+	updated.RawQuery = query.Encode()
+
+	return updated.RequestURI()
+}
@@ -0,0 +1,31 @@
+package http_util
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestFormatLinkHeader(t *testing.T) {
+	result := FormatLinkHeader([]Link{
+		{Url: "/api/v1/images?cursor=abc", Rel: "next"},
+		{Url: "/api/v1/images", Rel: "first"},
+	})
+
+	expected := `</api/v1/images?cursor=abc>; rel="next", </api/v1/images>; rel="first"`
+	if result != expected {
+		t.Fatalf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestWithQuery(t *testing.T) {
+	requestUrl, err := url.Parse("/api/v1/images?page=2&size=10&tag=plane")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := WithQuery(requestUrl, map[string]string{"page": "", "cursor": "abc"})
+	expected := "/api/v1/images?cursor=abc&size=10&tag=plane"
+	if result != expected {
+		t.Fatalf("Expected %s, got %s", expected, result)
+	}
+}
@@ -23,6 +23,11 @@ func (h RequestHandler) Handle(fn BasicRequestHandler) http.HandlerFunc {
 		if err != nil {
 			HandleError(h.logger, w, err)
 		} else {
+			for key, values := range response.Headers {
+				for _, value := range values {
+					w.Header().Add(key, value)
+				}
+			}
 			WriteJson(w, response.GetStatus(), response.Data)
 		}
 	}
@@ -1,10 +1,14 @@
 package http_util
 
 import (
+	"encoding/json"
+	"net/http"
 	"strconv"
 	"strings"
 )
 
+const maxJsonBodyBytes = 1024 * 1024 // 1MB
+
 func ToUint(value string) uint {
 	if value == "" {
 		return 0
@@ -24,3 +28,15 @@ func GetTokenFromHeader(authHeader string) string {
 	}
 	return parts[1]
 }
+
+// ReadJson decodes the json body of the request into the value, unknown fields are rejected
+func ReadJson(req *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, req.Body, maxJsonBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(value); err != nil {
+		return NewFailureResponse("failed parsing json body: " + err.Error())
+	}
+
+	return nil
+}
@@ -7,8 +7,18 @@ import (
 )
 
 type Response struct {
-	Status int
-	Data   interface{}
+	Status  int
+	Data    interface{}
+	Headers http.Header
+}
+
+// WithHeader adds the header value to the response, existing values of the same key are kept
+func (r *Response) WithHeader(key, value string) *Response {
+	if r.Headers == nil {
+		r.Headers = http.Header{}
+	}
+	r.Headers.Add(key, value)
+	return r
 }
 
 func (r *Response) WithStatus(status int) *Response {
@@ -11,33 +11,45 @@ import (
 	"api/image"
 	"api/storage"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
-const maxBodyLimitBytes = 30 * 1024 * 1024 // 20MB
+const maxBodyLimitBytes = 30 * 1024 * 1024 // 30MB
 
 type ImageHandler struct {
 	http_util.RequestHandler
 	imagesService *core.ImagesService
+	jobsService   *core.JobsService
 	logger        *zerolog.Logger
 	authenticator authenticator.Authenticator
+	uploadLimits  UploadLimits
 }
 
 func NewImageHandler(
 	logger *zerolog.Logger,
 	authenticator authenticator.Authenticator,
 	service *core.ImagesService,
+	jobsService *core.JobsService,
+	uploadLimits UploadLimits,
 ) *ImageHandler {
 	handler := http_util.NewRequestHandler(logger)
 
 	return &ImageHandler{
 		handler,
 		service,
+		jobsService,
 		logger,
 		authenticator,
+		uploadLimits,
 	}
 }
 
@@ -45,7 +57,13 @@ func (h ImageHandler) CreateRouter() func(router chi.Router) {
 	isAdmin := middleware.Authorize(h.logger, h.authenticator, auth.RoleAdmin)
 
 	return func(r chi.Router) {
+		r.Get("/search", h.Handle(h.searchImages))
+		r.Get("/search/suggestions", h.Handle(h.suggest))
+		r.Get("/by-name/{name}", h.Handle(h.fetchImageByName))
 		r.Get("/{imageId}", h.Handle(h.fetchImage))
+		r.Get("/{imageId}/best", h.Handle(h.fetchBest))
+		r.Get("/{imageId}/markup", h.Handle(h.fetchMarkup))
+		r.Get("/{imageId}/similar", h.Handle(h.fetchSimilar))
 		r.Get("/", h.Handle(h.fetchImages))
 		r.With(isAdmin).Post("/upload", h.Handle(h.addImage))
 		r.With(isAdmin).Patch("/{imageId}", h.Handle(h.updateImage))
@@ -63,62 +81,276 @@ func (h ImageHandler) fetchImage(ctx context.Context, req *http.Request) (*http_
 	return http_util.NewResponse(img), nil
 }
 
+// fetchBest responds with the file of the size in the format the Accept header prefers, with redirect=true it
+// redirects to the file so it can be the source of an image element
+func (h ImageHandler) fetchBest(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	imageId := chi.URLParam(req, "imageId")
+	best, err := h.imagesService.GetBest(ctx, imageId, req.URL.Query().Get("size"), req.Header.Get("Accept"))
+	if err != nil {
+		return nil, err
+	}
+
+	response := http_util.NewResponse(best).WithHeader("Vary", "Accept")
+	if req.URL.Query().Get("redirect") == "true" {
+		return response.WithStatus(http.StatusFound).WithHeader("Location", best.Url), nil
+	}
+
+	return response, nil
+}
+
+// fetchMarkup responds with the srcset and picture element of the image, sizes and alt fill in their attributes
+func (h ImageHandler) fetchMarkup(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	imageId := chi.URLParam(req, "imageId")
+	query := req.URL.Query()
+	markup, err := h.imagesService.GetMarkup(ctx, imageId, query.Get("sizes"), query.Get("alt"))
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(markup), nil
+}
+
+// fetchSimilar responds with the near duplicates of the image nearest first, at most limit of them
+func (h ImageHandler) fetchSimilar(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	imageId := chi.URLParam(req, "imageId")
+	limit := http_util.ToUint(req.URL.Query().Get("limit"))
+	similar, err := h.imagesService.GetSimilar(ctx, imageId, int(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(similar), nil
+}
+
+func (h ImageHandler) fetchImageByName(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	name := chi.URLParam(req, "name")
+	img, err := h.imagesService.GetOneByName(ctx, name)
+
+	var renamed exception.Renamed
+	if errors.As(err, &renamed) {
+		location := "/api/v1/images/by-name/" + url.PathEscape(renamed.Name)
+		return http_util.NewResponse(map[string]string{"name": renamed.Name}).
+			WithStatus(http.StatusMovedPermanently).
+			WithHeader("Location", location), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(img), nil
+}
+
 func (h ImageHandler) fetchImages(ctx context.Context, req *http.Request) (*http_util.Response, error) {
-	page := http_util.ToUint(req.URL.Query().Get("page"))
-	size := http_util.ToUint(req.URL.Query().Get("size"))
+	filter, err := imageFilterFromQuery(req.URL.Query())
+	if err != nil {
+		return nil, err
+	}
 
-	order := storage.ToOrderOr(req.URL.Query().Get("order"), storage.OrderDescending)
-	limit, offset := storage.PagingToLimitOffset(page, size)
+	pagination, err := paginationFromQuery(req.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	includeTotal := req.URL.Query().Get("includeTotal") == "true"
 
-	imageList, err := h.imagesService.Get(ctx, limit, offset, order)
+	imagePage, err := h.imagesService.Get(ctx, filter, pagination, includeTotal)
 	if err != nil {
 		return nil, err
 	}
 
-	return http_util.NewResponse(imageList), nil
+	return imagePageResponse(req.URL, imagePage), nil
 }
 
-type UploadImageDto struct {
-	Name   string
-	Format image.Format
+// paginationFromQuery reads the cursor pagination, falling back to page and size for existing clients
+func paginationFromQuery(query url.Values) (storage.Pagination, error) {
+	page := http_util.ToUint(query.Get("page"))
+	size := http_util.ToUint(query.Get("size"))
+	limit, offset := storage.PagingToLimitOffset(page, size)
+
+	order := storage.OrderDescending
+	if value := query.Get("order"); value != "" {
+		order = storage.Order(strings.ToUpper(value))
+		if !order.IsValid() {
+			return storage.Pagination{}, exception.InvalidArgument{Reason: "Invalid order, expected ASC or DESC"}
+		}
+	}
+	sort, err := storage.NewImageSort(query.Get("sort"))
+	if err != nil {
+		return storage.Pagination{}, exception.InvalidArgument{Reason: err.Error()}
+	}
+
+	pagination := storage.Pagination{
+		Limit:  limit,
+		Offset: offset,
+		Order:  order,
+		Sort:   sort,
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := storage.DecodeCursor(value)
+		if err != nil {
+			return storage.Pagination{}, exception.InvalidArgument{Reason: "Invalid cursor"}
+		}
+		if cursor.Sort != sort {
+			return storage.Pagination{}, exception.InvalidArgument{Reason: "Cursor doesn't match the sort"}
+		}
+		pagination.Cursor = &cursor
+		pagination.Offset = 0
+	}
+
+	return pagination, nil
 }
 
-func (dto UploadImageDto) validate() error {
-	if len(dto.Name) < 5 || len(dto.Name) > 200 {
-		return exception.InvalidArgument{
-			Reason: "Name should be between 5 and 250 characters",
+// imageFilterFromQuery reads the filter of the image list, values that can't be parsed are rejected
+func imageFilterFromQuery(query url.Values) (storage.ImageFilter, error) {
+	filter := storage.ImageFilter{
+		Tag:     query.Get("tag"),
+		Format:  storage.ImageFormat(query.Get("format")),
+		HasSize: query.Get("hasSize"),
+	}
+
+	if value := query.Get("authorId"); value != "" {
+		authorId, err := uuid.Parse(value)
+		if err != nil {
+			return storage.ImageFilter{}, exception.InvalidArgument{Reason: "Invalid authorId"}
 		}
+		filter.AuthorId = authorId.String()
 	}
 
-	if !dto.Format.IsSupported() {
-		return exception.InvalidArgument{
-			Reason: fmt.Sprintf("Unsupported format %s", dto.Format),
+	times := []struct {
+		key   string
+		field **time.Time
+	}{
+		{key: "createdAfter", field: &filter.CreatedAfter},
+		{key: "createdBefore", field: &filter.CreatedBefore},
+		{key: "updatedAfter", field: &filter.UpdatedAfter},
+		{key: "updatedBefore", field: &filter.UpdatedBefore},
+	}
+	for _, t := range times {
+		value := query.Get(t.key)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return storage.ImageFilter{}, exception.InvalidArgument{
+				Reason: fmt.Sprintf("Invalid %s, expected an RFC 3339 date time", t.key),
+			}
 		}
+		parsed = parsed.UTC()
+		*t.field = &parsed
 	}
 
-	return nil
+	dimensions := []struct {
+		key   string
+		field *int
+	}{
+		{key: "minWidth", field: &filter.MinWidth},
+		{key: "minHeight", field: &filter.MinHeight},
+	}
+	for _, d := range dimensions {
+		value := query.Get(d.key)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return storage.ImageFilter{}, exception.InvalidArgument{
+				Reason: fmt.Sprintf("Invalid %s, expected a positive number of pixels", d.key),
+			}
+		}
+		*d.field = int(parsed)
+	}
+
+	return filter, nil
 }
 
-func (h ImageHandler) addImage(ctx context.Context, req *http.Request) (*http_util.Response, error) {
-	err := req.ParseMultipartForm(maxBodyLimitBytes)
+// imagePageResponse responds with the page, links to the neighbouring pages are set in the Link header
+func imagePageResponse(requestUrl *url.URL, imagePage storage.ImagePage) *http_util.Response {
+	response := http_util.NewResponse(imagePage)
+	if links := pageLinks(requestUrl, imagePage); len(links) > 0 {
+		response.WithHeader("Link", http_util.FormatLinkHeader(links))
+	}
+
+	return response
+}
+
+func pageLinks(requestUrl *url.URL, imagePage storage.ImagePage) []http_util.Link {
+	var links []http_util.Link
+
+	if imagePage.NextCursor != nil {
+		links = append(links, http_util.Link{
+			Url: http_util.WithQuery(requestUrl, map[string]string{"cursor": *imagePage.NextCursor, "page": ""}),
+			Rel: "next",
+		})
+	}
+	if imagePage.PrevCursor != nil {
+		links = append(links, http_util.Link{
+			Url: http_util.WithQuery(requestUrl, map[string]string{"cursor": *imagePage.PrevCursor, "page": ""}),
+			Rel: "prev",
+		}, http_util.Link{
+			Url: http_util.WithQuery(requestUrl, map[string]string{"cursor": "", "page": ""}),
+			Rel: "first",
+		})
+	}
+
+	return links
+}
+
+func (h ImageHandler) searchImages(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	page := http_util.ToUint(req.URL.Query().Get("page"))
+	size := http_util.ToUint(req.URL.Query().Get("size"))
+	limit, offset := storage.PagingToLimitOffset(page, size)
+
+	results, err := h.imagesService.Search(ctx, req.URL.Query().Get("q"), limit, offset)
 	if err != nil {
-		return nil, http_util.NewFailureResponse("failed parsing multipart form data")
+		return nil, err
 	}
 
-	_, originalFileHeader, err := req.FormFile("originalFile")
+	return http_util.NewResponse(results), nil
+}
+
+func (h ImageHandler) suggest(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	suggestions, err := h.imagesService.Suggest(ctx, req.URL.Query().Get("q"))
 	if err != nil {
-		return nil, http_util.NewFailureResponse("missing originalFile")
+		return nil, err
+	}
+
+	return http_util.NewResponse(suggestions), nil
+}
+
+type UploadImageDto struct {
+	Name   string       `json:"name"`
+	Format image.Format `json:"format"`
+}
+
+// validate adds the invalid fields of the form, the files are validated as they are read
+func (dto UploadImageDto) validate(invalid *exception.InvalidFields) {
+	if len(dto.Name) < 5 || len(dto.Name) > 200 {
+		invalid.Add("name", "Name should be between 5 and 250 characters")
 	}
-	_, croppedFileHeader, err := req.FormFile("croppedFile")
+	if !dto.Format.IsSupported() {
+		invalid.Add("format", fmt.Sprintf("Unsupported format %s", dto.Format))
+	}
+}
+
+// addImage reads both files from the form while they are uploaded, see uploadForm for the order of the parts.
+// With the async query the image is resized by a job, which is returned with its status url.
+func (h ImageHandler) addImage(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	form, err := readUploadForm(req)
 	if err != nil {
-		return nil, http_util.NewFailureResponse("missing croppedFile")
+		return nil, err
 	}
 
-	data := &UploadImageDto{}
-	data.Name = req.PostFormValue("name")
-	data.Format = image.Format(req.PostFormValue("format"))
-	if err = data.validate(); err != nil {
-		return nil, http_util.NewFailureResponse(err.Error())
+	data := &UploadImageDto{
+		Name:   form.value("name"),
+		Format: image.Format(form.value("format")),
+	}
+	invalid := exception.InvalidFields{}
+	data.validate(&invalid)
+	originalFile := form.originalFile(h.uploadLimits, data.Format, &invalid)
+	croppedFile := form.croppedFile(h.uploadLimits, data.Format, &invalid)
+	if err = invalid.OrNil(); err != nil {
+		return nil, err
 	}
 
 	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
@@ -126,41 +358,72 @@ func (h ImageHandler) addImage(ctx context.Context, req *http.Request) (*http_ut
 		return nil, err
 	}
 
+	if req.URL.Query().Get("async") == "true" {
+		job, err := h.jobsService.UploadAndEnqueue(ctx, authorization, data.Name, data.Format, originalFile, croppedFile)
+		if err != nil {
+			return nil, form.uploadErr(err)
+		}
+
+		return http_util.NewResponse(job).
+			WithStatus(http.StatusAccepted).
+			WithHeader("Location", core.JobsPath+"/"+job.Id), nil
+	}
+
 	img, err := h.imagesService.UploadAndResize(
 		ctx,
 		authorization,
 		data.Name,
 		data.Format,
-		originalFileHeader,
-		croppedFileHeader,
+		originalFile,
+		croppedFile,
 	)
 	if err != nil {
-		return nil, err
+		return nil, form.uploadErr(err)
 	}
 
 	return http_util.NewResponse(img).WithStatus(http.StatusCreated), nil
 }
 
+type UpdateImageDto struct {
+	Name     string
+	Format   image.Format
+	HasFiles bool
+}
+
+// validate adds the invalid fields of the form, the format is only required when the files are replaced
+func (dto UpdateImageDto) validate(invalid *exception.InvalidFields) {
+	if dto.Name != "" && (len(dto.Name) < 5 || len(dto.Name) > 200) {
+		invalid.Add("name", "Name should be between 5 and 250 characters")
+	}
+	if dto.HasFiles && !dto.Format.IsSupported() {
+		invalid.Add("format", fmt.Sprintf("Unsupported format %s", dto.Format))
+	}
+}
+
+// updateImage accepts any part of the upload form, only the sent fields are changed
 func (h ImageHandler) updateImage(ctx context.Context, req *http.Request) (*http_util.Response, error) {
-	err := req.ParseMultipartForm(maxBodyLimitBytes)
+	imageId := chi.URLParam(req, "imageId")
+
+	form, err := readUploadForm(req)
 	if err != nil {
-		return nil, http_util.NewFailureResponse("failed parsing multipart form data")
+		return nil, err
 	}
 
-	_, originalFileHeader, err := req.FormFile("originalFile")
-	if err != nil {
-		return nil, http_util.NewFailureResponse("missing originalFile")
+	data := &UpdateImageDto{
+		Name:     form.value("name"),
+		Format:   image.Format(form.value("format")),
+		HasFiles: form.hasFiles(),
 	}
-	_, croppedFileHeader, err := req.FormFile("croppedFile")
-	if err != nil {
-		return nil, http_util.NewFailureResponse("missing croppedFile")
+	invalid := exception.InvalidFields{}
+	data.validate(&invalid)
+	var originalFile, croppedFile *image.Upload
+	if data.HasFiles {
+		original := form.originalFile(h.uploadLimits, data.Format, &invalid)
+		cropped := form.croppedFile(h.uploadLimits, data.Format, &invalid)
+		originalFile, croppedFile = &original, &cropped
 	}
-
-	data := &UploadImageDto{}
-	data.Name = req.PostFormValue("name")
-	data.Format = image.Format(req.PostFormValue("format"))
-	if err = data.validate(); err != nil {
-		return nil, http_util.NewFailureResponse(err.Error())
+	if err = invalid.OrNil(); err != nil {
+		return nil, err
 	}
 
 	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
@@ -168,19 +431,20 @@ func (h ImageHandler) updateImage(ctx context.Context, req *http.Request) (*http
 		return nil, err
 	}
 
-	img, err := h.imagesService.UploadAndResize(
+	img, err := h.imagesService.Update(
 		ctx,
+		imageId,
 		authorization,
 		data.Name,
 		data.Format,
-		originalFileHeader,
-		croppedFileHeader,
+		originalFile,
+		croppedFile,
 	)
 	if err != nil {
-		return nil, err
+		return nil, form.uploadErr(err)
 	}
 
-	return http_util.NewResponse(img).WithStatus(http.StatusCreated), nil
+	return http_util.NewResponse(img), nil
 }
 
 func (h ImageHandler) deleteOne(ctx context.Context, req *http.Request) (*http_util.Response, error) {
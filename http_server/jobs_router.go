@@ -0,0 +1,147 @@
+package http_server
+
+import (
+	"api/auth"
+	"api/core"
+	"api/http_server/authenticator"
+	"api/http_server/http_util"
+	"api/http_server/middleware"
+	"api/http_server/middleware/keys"
+	"api/image"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// signatureHeader is the hex encoded HMAC-SHA256 of the timestamp, the job id and the body of a callback, joined
+	// by dots. The job id keeps a signed callback from being posted to another job.
+	signatureHeader = "X-Signature"
+	// signatureTimestampHeader is when the callback was signed in unix seconds
+	signatureTimestampHeader = "X-Signature-Timestamp"
+	// maxSignatureAge rejects replayed callbacks
+	maxSignatureAge   = 5 * time.Minute
+	maxCallbackLength = 1024 * 1024
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+type JobHandler struct {
+	http_util.RequestHandler
+	jobsService    *core.JobsService
+	logger         *zerolog.Logger
+	authenticator  authenticator.Authenticator
+	callbackSecret string
+}
+
+func NewJobHandler(
+	logger *zerolog.Logger,
+	authenticator authenticator.Authenticator,
+	service *core.JobsService,
+	callbackSecret string,
+) *JobHandler {
+	handler := http_util.NewRequestHandler(logger)
+
+	return &JobHandler{
+		handler,
+		service,
+		logger,
+		authenticator,
+		callbackSecret,
+	}
+}
+
+// CreateRouter serves the status of jobs and, once a callback secret is configured, the callbacks of the resize api
+func (h JobHandler) CreateRouter() func(router chi.Router) {
+	isAdmin := middleware.Authorize(h.logger, h.authenticator, auth.RoleAdmin)
+
+	return func(r chi.Router) {
+		r.With(isAdmin).Get("/{jobId}", h.Handle(h.fetchJob))
+		if h.callbackSecret != "" {
+			r.Post("/{jobId}/callback", h.Handle(h.callback))
+		}
+	}
+}
+
+func (h JobHandler) fetchJob(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := h.jobsService.GetOne(ctx, authorization, chi.URLParam(req, "jobId"))
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(job).WithHeader("Cache-Control", "no-store"), nil
+}
+
+// callback receives the outcome of a resize the resize api was asked to report, it is authenticated by its
+// signature instead of a user
+func (h JobHandler) callback(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, req.Body, maxCallbackLength))
+	if err != nil {
+		return nil, http_util.NewFailureResponse("failed reading callback body")
+	}
+
+	jobId := chi.URLParam(req, "jobId")
+	err = verifyCallbackSignature(
+		h.callbackSecret,
+		req.Header.Get(signatureTimestampHeader),
+		req.Header.Get(signatureHeader),
+		jobId,
+		body,
+		time.Now(),
+	)
+	if err != nil {
+		return http_util.NewResponse(http_util.NewFailureResponse(err.Error())).
+			WithStatus(http.StatusUnauthorized), nil
+	}
+
+	var callback image.ResizeCallback
+	if err = json.Unmarshal(body, &callback); err != nil {
+		return nil, http_util.NewFailureResponse("failed parsing json body: " + err.Error())
+	}
+
+	if err = h.jobsService.Complete(ctx, jobId, callback); err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(nil).WithStatus(http.StatusNoContent), nil
+}
+
+func verifyCallbackSignature(secret, timestamp, signature, jobId string, body []byte, now time.Time) error {
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errInvalidSignature
+	}
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return errInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + jobId + "."))
+	mac.Write(body)
+	if !hmac.Equal(decoded, mac.Sum(nil)) {
+		return errInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(signedAt, 0))
+	if age > maxSignatureAge || age < -maxSignatureAge {
+		return fmt.Errorf("signature is older than %s", maxSignatureAge)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package http_server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	testJobId  = "2f6d8b1a-9c4e-4b7d-a3f0-8e5c1d7b9a4f"
+	otherJobId = "7c1e9a3b-5d2f-4a8e-b6c0-3f9d1e7a5b2c"
+)
+
+func sign(secret string, timestamp string, jobId string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + jobId + "." + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyCallbackSignature(t *testing.T) {
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	oldTimestamp := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+	body := `{"resized":{"name":"my-plane"}}`
+
+	data := []struct {
+		testName  string
+		timestamp string
+		signature string
+		body      string
+		isValid   bool
+	}{
+		{
+			testName:  "Valid",
+			timestamp: timestamp,
+			signature: sign("secret", timestamp, testJobId, body),
+			body:      body,
+			isValid:   true,
+		},
+		{testName: "Other secret", timestamp: timestamp, signature: sign("other", timestamp, testJobId, body), body: body},
+		{testName: "Changed body", timestamp: timestamp, signature: sign("secret", timestamp, testJobId, body), body: "{}"},
+		{testName: "Other job", timestamp: timestamp, signature: sign("secret", timestamp, otherJobId, body), body: body},
+		{
+			testName:  "Replayed",
+			timestamp: oldTimestamp,
+			signature: sign("secret", oldTimestamp, testJobId, body),
+			body:      body,
+		},
+		{testName: "Missing timestamp", signature: sign("secret", "", testJobId, body), body: body},
+		{testName: "Not hex", timestamp: timestamp, signature: "signature", body: body},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			err := verifyCallbackSignature("secret", d.timestamp, d.signature, testJobId, []byte(d.body), now)
+			if (err == nil) != d.isValid {
+				t.Fatalf("Expected valid to be %v, got %v", d.isValid, err)
+			}
+		})
+	}
+}
@@ -21,7 +21,7 @@ func Authorize(
 			token := http_util.GetTokenFromHeader(authHeader)
 			ctx := r.Context()
 
-			isValid, username, err := validator.IsTokenValid(ctx, token, group)
+			isValid, claims, err := validator.IsTokenValid(ctx, token, group)
 			if err != nil || !isValid {
 				if err != nil {
 					logger.Warn().Msgf("failed token validation: %s", err)
@@ -30,11 +30,17 @@ func Authorize(
 				return
 			}
 
+			// routes open to any token still carry the role granted by the groups of the token
+			role := group
+			if role == auth.RoleNone {
+				role = auth.RoleFromGroups(claims.Groups)
+			}
 			updatedReq := r.WithContext(
 				context.WithValue(ctx, keys.UserAuthDtoKey, auth.AuthorizationDto{
 					Header:   authHeader,
-					Username: username,
-					Role:     group,
+					Username: claims.Username,
+					Role:     role,
+					Groups:   claims.Groups,
 				}),
 			)
 			next.ServeHTTP(w, updatedReq)
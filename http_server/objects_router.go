@@ -0,0 +1,114 @@
+package http_server
+
+import (
+	"api/core/exception"
+	"api/http_server/http_util"
+	"api/objectstore"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ObjectHandler serves the signed urls of object stores that can't presign urls themselves, the signature in the
+// query authorizes the request instead of a token
+type ObjectHandler struct {
+	store  objectstore.ObjectStore
+	signer *objectstore.Signer
+	logger *zerolog.Logger
+}
+
+func NewObjectHandler(
+	logger *zerolog.Logger,
+	store objectstore.ObjectStore,
+	signer *objectstore.Signer,
+) *ObjectHandler {
+	return &ObjectHandler{
+		store:  store,
+		signer: signer,
+		logger: logger,
+	}
+}
+
+func (h ObjectHandler) CreateRouter() func(router chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/*", h.getObject)
+		r.Put("/*", h.putObject)
+	}
+}
+
+// authorize returns the key of the request once its signature is verified for the method
+func (h ObjectHandler) authorize(req *http.Request) (string, error) {
+	// chi routes the escaped path when it differs from the default encoding of the key
+	key := chi.URLParam(req, "*")
+	var err error
+	if req.URL.RawPath != "" {
+		key, err = url.PathUnescape(key)
+	}
+	if err != nil || objectstore.ValidateKey(key) != nil {
+		return "", exception.InvalidArgument{Reason: "Invalid object key"}
+	}
+
+	err = h.signer.Verify(req.Method, key, req.URL.Query(), time.Now())
+	if errors.Is(err, objectstore.ErrExpiredSignature) {
+		return "", exception.Forbidden{Reason: "Expired signature"}
+	}
+	if err != nil {
+		return "", exception.Forbidden{Reason: "Invalid signature"}
+	}
+
+	return key, nil
+}
+
+func (h ObjectHandler) getObject(w http.ResponseWriter, req *http.Request) {
+	key, err := h.authorize(req)
+	if err != nil {
+		http_util.HandleError(h.logger, w, err)
+		return
+	}
+
+	object, err := h.store.Get(req.Context(), key)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		err = exception.NotFound{Msg: "Object not found"}
+	}
+	if err != nil {
+		http_util.HandleError(h.logger, w, err)
+		return
+	}
+	defer object.Body.Close()
+
+	if object.ContentType != "" {
+		w.Header().Set("Content-Type", object.ContentType)
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(object.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err = io.Copy(w, object.Body); err != nil {
+		h.logger.Warn().Err(err).Str("key", key).Msg("failed writing object")
+	}
+}
+
+func (h ObjectHandler) putObject(w http.ResponseWriter, req *http.Request) {
+	key, err := h.authorize(req)
+	if err != nil {
+		http_util.HandleError(h.logger, w, err)
+		return
+	}
+
+	body := http.MaxBytesReader(w, req.Body, maxBodyLimitBytes)
+	err = h.store.Put(req.Context(), key, body, req.ContentLength, req.Header.Get("Content-Type"))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		err = http_util.NewFailureResponse(fmt.Sprintf("Object exceeds %d bytes", maxBytesErr.Limit))
+	}
+	if err != nil {
+		http_util.HandleError(h.logger, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -49,6 +49,10 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 	if err != nil {
 		return nil, err
 	}
+	fieldsErrResponseSchemaRef, _, err := openapi3gen.NewSchemaRefForValue(&http_util.FieldsFailureResponse{})
+	if err != nil {
+		return nil, err
+	}
 
 	swagger.Components.Schemas = openapi3.Schemas{
 		"Image": &openapi3.SchemaRef{
@@ -58,7 +62,7 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 						Value: &openapi3.Schema{Type: "string", Example: "my plane"},
 					},
 					"format": {
-						Value: &openapi3.Schema{Type: "string", Enum: []interface{}{"jpg", "png", "webp"}},
+						Value: &openapi3.Schema{Type: "string", Enum: []interface{}{"jpg", "png", "webp", "avif"}},
 					},
 					"originalFile": {
 						Value: &openapi3.Schema{Type: "string", Format: "binary"},
@@ -70,7 +74,207 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 				Required: []string{"name", "format", "originalFile", "croppedFile"},
 			},
 		},
-		"ErrResponse": errResponseSchemaRef,
+		"ImageRevision": &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type: "object",
+				Properties: map[string]*openapi3.SchemaRef{
+					"id":       {Value: &openapi3.Schema{Type: "string", Format: "uuid"}},
+					"imageId":  {Value: &openapi3.Schema{Type: "string", Format: "uuid"}},
+					"revision": {Value: &openapi3.Schema{Type: "integer", Example: 3}},
+					"name": {
+						Value: &openapi3.Schema{Type: "string", Description: "Name of the image at the time"},
+					},
+					"format":   {Value: &openapi3.Schema{Type: "string", Enum: []interface{}{"jpg", "png", "webp", "avif"}}},
+					"original": {Value: &openapi3.Schema{Type: "string"}},
+					"domain":   {Value: &openapi3.Schema{Type: "string"}},
+					"path":     {Value: &openapi3.Schema{Type: "string"}},
+					"sizes":    {Value: &openapi3.Schema{Type: "object"}},
+					"formats": {
+						Value: &openapi3.Schema{
+							Type:        "object",
+							Description: "Names of the sizes also available in each other format, like `{\"webp\":[\"s\"]}`",
+						},
+					},
+					"authorId": {Value: &openapi3.Schema{Type: "string", Format: "uuid", Nullable: true}},
+					"createdAt": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Format:      "date-time",
+							Description: "When the revision was replaced",
+						},
+					},
+				},
+			},
+		},
+		"Profile": &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type: "object",
+				Properties: map[string]*openapi3.SchemaRef{
+					"user": {
+						Value: &openapi3.Schema{
+							Type: "object",
+							Properties: map[string]*openapi3.SchemaRef{
+								"id":          {Value: &openapi3.Schema{Type: "string", Format: "uuid"}},
+								"email":       {Value: &openapi3.Schema{Type: "string"}},
+								"role":        {Value: &openapi3.Schema{Type: "string", Description: "Role stored with the user"}},
+								"cogUsername": {Value: &openapi3.Schema{Type: "string"}},
+								"cogSub":      {Value: &openapi3.Schema{Type: "string"}},
+								"cogName":     {Value: &openapi3.Schema{Type: "string"}},
+								"disabled":    {Value: &openapi3.Schema{Type: "boolean"}},
+								"createdAt":   {Value: &openapi3.Schema{Type: "string", Format: "date-time"}},
+								"updatedAt":   {Value: &openapi3.Schema{Type: "string", Format: "date-time", Nullable: true}},
+							},
+						},
+					},
+					"role": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Description: "Role granted by the groups of the token",
+							Example:     "Administrators",
+						},
+					},
+					"groups": {
+						Value: &openapi3.Schema{
+							Type:  "array",
+							Items: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
+						},
+					},
+				},
+			},
+		},
+		"ErrResponse":       errResponseSchemaRef,
+		"FieldsErrResponse": fieldsErrResponseSchemaRef,
+		"Tag": &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type: "object",
+				Properties: map[string]*openapi3.SchemaRef{
+					"id": {
+						Value: &openapi3.Schema{Type: "string", Format: "uuid"},
+					},
+					"value": {
+						Value: &openapi3.Schema{Type: "string", Example: "plane"},
+					},
+					"parentId": {
+						Value: &openapi3.Schema{Type: "string", Format: "uuid", Nullable: true},
+					},
+					"canonicalId": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Format:      "uuid",
+							Nullable:    true,
+							Description: "Set when the tag is a synonym of another tag",
+						},
+					},
+				},
+			},
+		},
+		"UploadIntentChanges": &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type:     "object",
+				Required: []string{"name", "format"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"name": {
+						Value: &openapi3.Schema{Type: "string", Example: "my plane"},
+					},
+					"format": {
+						Value: &openapi3.Schema{Type: "string", Example: "png"},
+					},
+				},
+			},
+		},
+		"UploadIntent": &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type: "object",
+				Properties: map[string]*openapi3.SchemaRef{
+					"id": {
+						Value: &openapi3.Schema{Type: "string", Format: "uuid"},
+					},
+					"originalFileUrl": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Description: "Signed url to PUT the original file to",
+						},
+					},
+					"croppedFileUrl": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Description: "Signed url to PUT the cropped file to",
+						},
+					},
+					"expiresAt": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Format:      "date-time",
+							Description: "The intent has to be completed before, it is purged with its files afterwards",
+						},
+					},
+				},
+			},
+		},
+		"Job": &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type: "object",
+				Properties: map[string]*openapi3.SchemaRef{
+					"id": {
+						Value: &openapi3.Schema{Type: "string", Format: "uuid"},
+					},
+					"status": {
+						Value: &openapi3.Schema{
+							Type: "string",
+							Enum: []interface{}{"queued", "running", "waiting", "succeeded", "failed"},
+						},
+					},
+					"imageId": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Format:      "uuid",
+							Nullable:    true,
+							Description: "Created image once the job succeeded",
+						},
+					},
+					"error": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Nullable:    true,
+							Description: "Why the job failed",
+						},
+					},
+					"attempts": {
+						Value: &openapi3.Schema{Type: "integer"},
+					},
+					"createdAt": {
+						Value: &openapi3.Schema{Type: "string", Format: "date-time"},
+					},
+					"updatedAt": {
+						Value: &openapi3.Schema{Type: "string", Format: "date-time", Nullable: true},
+					},
+				},
+			},
+		},
+		"TagChanges": &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type: "object",
+				Properties: map[string]*openapi3.SchemaRef{
+					"value": {
+						Value: &openapi3.Schema{Type: "string", Example: "aircraft"},
+					},
+					"parentId": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Format:      "uuid",
+							Description: "Parent tag, empty string removes the parent",
+						},
+					},
+					"canonicalId": {
+						Value: &openapi3.Schema{
+							Type:        "string",
+							Format:      "uuid",
+							Description: "Makes the tag a synonym of the canonical tag, empty string removes it",
+						},
+					},
+				},
+			},
+		},
 		"CreateImage": &openapi3.SchemaRef{
 			Value: &openapi3.Schema{
 				Type: "object",
@@ -79,7 +283,7 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 						Value: &openapi3.Schema{Type: "string", Example: "my plane"},
 					},
 					"format": {
-						Value: &openapi3.Schema{Type: "string", Enum: []interface{}{"jpg", "png", "webp"}},
+						Value: &openapi3.Schema{Type: "string", Enum: []interface{}{"jpg", "png", "webp", "avif"}},
 					},
 					"originalFile": {
 						Value: &openapi3.Schema{Type: "string", Format: "binary"},
@@ -96,7 +300,7 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 	swagger.Components.RequestBodies = openapi3.RequestBodies{
 		"UploadNewImage": &openapi3.RequestBodyRef{
 			Value: openapi3.NewRequestBody().
-				WithDescription("Create a new image. Ensure that the cropped image is in one of the allowed aspect ratios: `1:1` `3:2` `4:3` `5:8` `16:9`, otherwise it will fail.").
+				WithDescription("Create a new image. The content of both files has to match the format and be within the configured minimum and maximum resolution. Ensure that the cropped image is in one of the allowed aspect ratios: `1:1` `3:2` `4:3` `5:8` `16:9`, otherwise it will fail. The files are read while they are uploaded, so all fields have to precede the files, and originalFile should precede croppedFile.").
 				WithRequired(true).
 				WithContent(openapi3.NewContentWithFormDataSchemaRef(
 					&openapi3.SchemaRef{
@@ -107,7 +311,8 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 		"UpdateImage": &openapi3.RequestBodyRef{
 			Value: openapi3.NewRequestBody().
 				WithDescription(
-					"If you upload images, you will need to provide cropped, original and format. Name can be standalone",
+					"If you upload images, you will need to provide cropped, original and format. Name can be standalone. " +
+						"As for new images, the fields have to precede the files.",
 				).
 				WithRequired(true).
 				WithContent(openapi3.NewContentWithFormDataSchemaRef(&openapi3.SchemaRef{
@@ -118,7 +323,11 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 								Value: &openapi3.Schema{Type: "string", Example: "my plane"},
 							},
 							"format": {
-								Value: &openapi3.Schema{Type: "string", Enum: []interface{}{"jpg", "png", "webp"}},
+								Value: &openapi3.Schema{
+									Type:        "string",
+									Enum:        []interface{}{"jpg", "png", "webp", "avif"},
+									Description: "The local resizer rejects avif files",
+								},
 							},
 							"originalFile": {
 								Value: &openapi3.Schema{Type: "string", Format: "binary"},
@@ -133,6 +342,44 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 	}
 
 	swagger.Components.Responses = openapi3.Responses{
+		"JobResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Job, the Location header is its status url").
+				WithContent(
+					openapi3.NewContentWithJSONSchemaRef(
+						&openapi3.SchemaRef{
+							Ref: "#/components/schemas/Job",
+						},
+					),
+				),
+		},
+		"TagResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Tag").
+				WithContent(
+					openapi3.NewContentWithJSONSchemaRef(
+						&openapi3.SchemaRef{
+							Ref: "#/components/schemas/Tag",
+						},
+					),
+				),
+		},
+		"TagsResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Tags").
+				WithContent(
+					openapi3.NewContentWithJSONSchemaRef(
+						&openapi3.SchemaRef{
+							Value: &openapi3.Schema{
+								Type: "array",
+								Items: &openapi3.SchemaRef{
+									Ref: "#/components/schemas/Tag",
+								},
+							},
+						},
+					),
+				),
+		},
 		"ImageResponse": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
 				WithDescription("Created image where sizes in size map may be nullable").
@@ -146,14 +393,33 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 		},
 		"ImagesResponse": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
-				WithDescription("Images").
+				WithDescription("Page of images, cursors of neighbouring pages are also sent in the `Link` header").
 				WithContent(
 					openapi3.NewContentWithJSONSchemaRef(
 						&openapi3.SchemaRef{
 							Value: &openapi3.Schema{
-								Type: "array",
-								Items: &openapi3.SchemaRef{
-									Ref: "#/components/schemas/Image",
+								Type: "object",
+								Properties: map[string]*openapi3.SchemaRef{
+									"items": {
+										Value: &openapi3.Schema{
+											Type: "array",
+											Items: &openapi3.SchemaRef{
+												Ref: "#/components/schemas/Image",
+											},
+										},
+									},
+									"nextCursor": {
+										Value: &openapi3.Schema{Type: "string", Nullable: true},
+									},
+									"prevCursor": {
+										Value: &openapi3.Schema{Type: "string", Nullable: true},
+									},
+									"total": {
+										Value: &openapi3.Schema{
+											Type:        "integer",
+											Description: "Only present when includeTotal is set",
+										},
+									},
 								},
 							},
 						},
@@ -175,6 +441,17 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 					),
 				),
 		},
+		"InvalidFieldsResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Invalid fields, each with the reason it was rejected").
+				WithContent(
+					openapi3.NewContentWithJSONSchemaRef(
+						&openapi3.SchemaRef{
+							Ref: "#/components/schemas/FieldsErrResponse",
+						},
+					),
+				),
+		},
 		"ForbiddenResponse": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
 				WithDescription("Forbidden").
@@ -197,6 +474,17 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 					),
 				),
 		},
+		"ConflictResponse": &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription("Conflicts with the current state of the resource").
+				WithContent(
+					openapi3.NewContentWithJSONSchemaRef(
+						&openapi3.SchemaRef{
+							Ref: "#/components/schemas/ErrResponse",
+						},
+					),
+				),
+		},
 		"NotFoundResponse": &openapi3.ResponseRef{
 			Value: openapi3.NewResponse().
 				WithDescription("Resource not found").
@@ -248,11 +536,137 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 					},
 					{
 						Value: &openapi3.Parameter{
-							Name:        "order",
+							Name: "order",
+							In:   "query",
+							Description: "Specify descending or ascending order, default is descending. Unknown " +
+								"values are rejected with 400 instead of falling back to descending",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewSchema().WithEnum("DESC", "ASC"),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name: "sort",
+							In:   "query",
+							Description: "Field to sort by, default is createdAt. Never updated images are sorted by " +
+								"their creation for updatedAt, width is the width of the original",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewSchema().WithEnum("createdAt", "updatedAt", "name", "width"),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "tag",
+							In:          "query",
+							Description: "Only images with the tag, its synonyms or any of its child tags",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "format",
+							In:          "query",
+							Description: "Only images of the format",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewSchema().WithEnum("jpg", "png", "webp", "avif"),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "authorId",
+							In:          "query",
+							Description: "Only images uploaded by the user",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewUUIDSchema(),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "createdAfter",
+							In:          "query",
+							Description: "Only images created at or after the RFC 3339 date time",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewDateTimeSchema(),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "createdBefore",
+							In:          "query",
+							Description: "Only images created at or before the RFC 3339 date time",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewDateTimeSchema(),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "updatedAfter",
+							In:          "query",
+							Description: "Only images updated at or after the RFC 3339 date time",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewDateTimeSchema(),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "updatedBefore",
+							In:          "query",
+							Description: "Only images updated at or before the RFC 3339 date time",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewDateTimeSchema(),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "hasSize",
+							In:          "query",
+							Description: "Only images with a variant of the size profile, e.g. xs",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewStringSchema().WithPattern("^[a-z0-9_-]{1,32}$"),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "minWidth",
+							In:          "query",
+							Description: "Minimum width of the original in pixels",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewIntegerSchema().WithMin(0),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "minHeight",
+							In:          "query",
+							Description: "Minimum height of the original in pixels",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewIntegerSchema().WithMin(0),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "cursor",
+							In:          "query",
+							Description: "Opaque cursor from nextCursor or prevCursor, takes precedence over page",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "includeTotal",
 							In:          "query",
-							Description: "Specify descending or ascending order",
+							Description: "Set to true to include the total count of matched images",
 							Schema: &openapi3.SchemaRef{
-								Value: openapi3.NewSchema().WithEnum("First", "Second"),
+								Value: openapi3.NewBoolSchema(),
 							},
 						},
 					},
@@ -269,12 +683,26 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 			Post: &openapi3.Operation{
 				OperationID: "Upload and create an image",
 				Tags:        []string{"Images"},
-				Description: "Upload and save the image, requires admin authorization",
+				Description: "Upload and save the image, requires admin authorization. Near duplicates of existing " +
+					"images are listed in `duplicates`, or rejected when `IMAGES_DUPLICATES` is `reject`. Jobs " +
+					"of rejected uploads aren't queued, or fail when a near duplicate was created since.",
 				Security: &openapi3.SecurityRequirements{
 					openapi3.SecurityRequirement{
 						"oauth2": []string{},
 					},
 				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "async",
+							In:          "query",
+							Description: "Set to true to resize the uploaded files in a job instead of the request",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewBoolSchema(),
+							},
+						},
+					},
+				},
 				RequestBody: &openapi3.RequestBodyRef{
 					Ref: "#/components/requestBodies/UploadNewImage",
 				},
@@ -282,8 +710,11 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 					"201": &openapi3.ResponseRef{
 						Ref: "#/components/responses/ImageResponse",
 					},
+					"202": &openapi3.ResponseRef{
+						Ref: "#/components/responses/JobResponse",
+					},
 					"400": &openapi3.ResponseRef{
-						Ref: "#/components/responses/BadRequestResponse",
+						Ref: "#/components/responses/InvalidFieldsResponse",
 					},
 					"401": &openapi3.ResponseRef{
 						Ref: "#/components/responses/ForbiddenResponse",
@@ -291,6 +722,9 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 					"403": &openapi3.ResponseRef{
 						Ref: "#/components/responses/UnauthorizedResponse",
 					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ConflictResponse",
+					},
 					"500": &openapi3.ResponseRef{
 						Ref: "#/components/responses/ServerErrorResponse",
 					},
@@ -333,7 +767,7 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 			Delete: &openapi3.Operation{
 				OperationID: "DeleteImage",
 				Tags:        []string{"Images"},
-				Description: "Delete image and invalidate CDN images",
+				Description: "Move image to the trash, its files are deleted and invalidated once the retention expires",
 				Security: &openapi3.SecurityRequirements{
 					openapi3.SecurityRequirement{
 						"oauth2": []string{},
@@ -369,7 +803,8 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 			Patch: &openapi3.Operation{
 				OperationID: "Update image",
 				Tags:        []string{"Images"},
-				Description: "Update existing image or change the name. Note that this will invalidate the cashed image on edge locations.",
+				Description: "Update existing image or change the name. Note that this will invalidate the cashed " +
+					"image on edge locations. Replaced files are checked for near duplicates like uploads.",
 				Security: &openapi3.SecurityRequirements{
 					openapi3.SecurityRequirement{
 						"oauth2": []string{},
@@ -398,7 +833,7 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 						Ref: "#/components/responses/ImageResponse",
 					},
 					"400": &openapi3.ResponseRef{
-						Ref: "#/components/responses/BadRequestResponse",
+						Ref: "#/components/responses/InvalidFieldsResponse",
 					},
 					"401": &openapi3.ResponseRef{
 						Ref: "#/components/responses/ForbiddenResponse",
@@ -409,12 +844,1504 @@ func newOpenApi3(config OpenApi3Config) (*openapi3.T, error) {
 					"404": &openapi3.ResponseRef{
 						Ref: "#/components/responses/NotFoundResponse",
 					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ConflictResponse",
+					},
 					"500": &openapi3.ResponseRef{
 						Ref: "#/components/responses/ServerErrorResponse",
 					},
 				},
 			},
 		},
+		"/api/v1/images/search": &openapi3.PathItem{
+			Summary: "Search images",
+			Get: &openapi3.Operation{
+				OperationID: "SearchImages",
+				Tags:        []string{"Images"},
+				Description: "Full text and fuzzy search over image names and tags ordered by relevance. " +
+					"Matched words in the highlight are wrapped in `<mark></mark>`",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "q",
+							In:          "query",
+							Required:    true,
+							Description: "Search query, supports quoted phrases, `or` and `-` for exclusion",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "size",
+							In:          "query",
+							Description: "Number of results",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "page",
+							In:          "query",
+							Description: "Page number for pagination, minimum 1",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Search results").
+							WithJSONSchema(&openapi3.Schema{
+								Type: "array",
+								Items: &openapi3.SchemaRef{
+									Value: &openapi3.Schema{
+										Type: "object",
+										Properties: map[string]*openapi3.SchemaRef{
+											"image":       {Ref: "#/components/schemas/Image"},
+											"rank":        {Value: &openapi3.Schema{Type: "number"}},
+											"highlight":   {Value: &openapi3.Schema{Type: "string"}},
+											"matchedTags": {Value: openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema())},
+										},
+									},
+								},
+							}),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ServerErrorResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/search/suggestions": &openapi3.PathItem{
+			Summary: "Autocomplete",
+			Get: &openapi3.Operation{
+				OperationID: "SuggestImages",
+				Tags:        []string{"Images"},
+				Description: "Autocomplete suggestions of image names and tags",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "q",
+							In:          "query",
+							Required:    true,
+							Description: "Typed prefix",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Suggestions").
+							WithJSONSchema(&openapi3.Schema{
+								Type: "array",
+								Items: &openapi3.SchemaRef{
+									Value: &openapi3.Schema{
+										Type: "object",
+										Properties: map[string]*openapi3.SchemaRef{
+											"value": {Value: &openapi3.Schema{Type: "string"}},
+											"kind": {
+												Value: &openapi3.Schema{Type: "string", Enum: []interface{}{"image", "tag"}},
+											},
+										},
+									},
+								},
+							}),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/by-name/{name}": &openapi3.PathItem{
+			Summary: "Image by name",
+			Get: &openapi3.Operation{
+				OperationID: "GetImageByName",
+				Tags:        []string{"Images"},
+				Description: "Fetch image info by its SEO name, former names of renamed images are redirected",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "name",
+							In:          "path",
+							Required:    true,
+							Description: "SEO name of image",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ImageResponse",
+					},
+					"301": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Image was renamed, the `Location` header points to its current name").
+							WithJSONSchema(&openapi3.Schema{
+								Type: "object",
+								Properties: map[string]*openapi3.SchemaRef{
+									"name": {Value: &openapi3.Schema{Type: "string"}},
+								},
+							}),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/{imageId}/best": &openapi3.PathItem{
+			Summary: "Best file of a size",
+			Get: &openapi3.Operation{
+				OperationID: "GetBestImageFile",
+				Tags:        []string{"Images"},
+				Description: "Pick the url of a size in the format the `Accept` header prefers, among the format of the " +
+					"image and the formats listed for the size in `formats`. Formats named by the header win " +
+					"over wildcards, which only match the format of the image.",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "imageId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of image",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "size",
+							In:          "query",
+							Required:    true,
+							Description: "Name of a size profile or `original`",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "redirect",
+							In:          "query",
+							Description: "Redirect to the url with `true`, so it can be the source of an image element",
+							Schema:      &openapi3.SchemaRef{Value: openapi3.NewBoolSchema()},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Url of the size in the picked format").
+							WithJSONSchema(&openapi3.Schema{
+								Type: "object",
+								Properties: map[string]*openapi3.SchemaRef{
+									"url":    {Value: &openapi3.Schema{Type: "string"}},
+									"format": {Value: &openapi3.Schema{Type: "string"}},
+									"width":  {Value: &openapi3.Schema{Type: "integer"}},
+									"height": {Value: &openapi3.Schema{Type: "integer"}},
+								},
+							}),
+					},
+					"302": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Redirect to the url with `redirect=true`"),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/{imageId}/markup": &openapi3.PathItem{
+			Summary: "Responsive markup of an image",
+			Get: &openapi3.Operation{
+				OperationID: "GetImageMarkup",
+				Tags:        []string{"Images"},
+				Description: "Get the `srcset` of the original and the variants with width descriptors, and a " +
+					"`<picture>` element with a source for each other format in `formats`.",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "imageId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of image",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "sizes",
+							In:          "query",
+							Description: "The `sizes` attribute, like `(max-width: 600px) 100vw, 50vw`. Defaults to `100vw`",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "alt",
+							In:          "query",
+							Description: "The `alt` attribute of the img element",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Attributes of the img element and the picture element").
+							WithJSONSchema(&openapi3.Schema{
+								Type: "object",
+								Properties: map[string]*openapi3.SchemaRef{
+									"src":     {Value: &openapi3.Schema{Type: "string"}},
+									"srcset":  {Value: &openapi3.Schema{Type: "string"}},
+									"sizes":   {Value: &openapi3.Schema{Type: "string"}},
+									"width":   {Value: &openapi3.Schema{Type: "integer"}},
+									"height":  {Value: &openapi3.Schema{Type: "integer"}},
+									"picture": {Value: &openapi3.Schema{Type: "string"}},
+								},
+							}),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/{imageId}/similar": &openapi3.PathItem{
+			Summary: "Similar images",
+			Get: &openapi3.Operation{
+				OperationID: "GetSimilarImages",
+				Tags:        []string{"Images"},
+				Description: "List the near duplicates of an image nearest first, by the hamming distance of their " +
+					"perceptual hashes up to `IMAGES_DUPLICATE_DISTANCE`. Images without a perceptual hash have none.",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "imageId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of image",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "limit",
+							In:          "query",
+							Description: "Maximum number of similar images, defaults to 20 and at most 100",
+							Schema:      &openapi3.SchemaRef{Value: openapi3.NewIntegerSchema()},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Similar images with their distance").
+							WithJSONSchema(&openapi3.Schema{
+								Type: "array",
+								Items: &openapi3.SchemaRef{Value: &openapi3.Schema{
+									Type: "object",
+									Properties: map[string]*openapi3.SchemaRef{
+										"image":    {Ref: "#/components/schemas/Image"},
+										"distance": {Value: &openapi3.Schema{Type: "integer"}},
+									},
+								}},
+							}),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/tags": &openapi3.PathItem{
+			Summary: "Tags of images",
+			Get: &openapi3.Operation{
+				OperationID: "GetTags",
+				Tags:        []string{"Tags"},
+				Description: "Fetch list of tags ordered by value",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "size",
+							In:          "query",
+							Description: "Number of results",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "page",
+							In:          "query",
+							Description: "Page number for pagination, minimum 1",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/TagsResponse",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ServerErrorResponse",
+					},
+				},
+			},
+			Post: &openapi3.Operation{
+				OperationID: "CreateTag",
+				Tags:        []string{"Tags"},
+				Description: "Create a tag, requires admin authorization",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				RequestBody: &openapi3.RequestBodyRef{
+					Value: openapi3.NewRequestBody().
+						WithRequired(true).
+						WithJSONSchemaRef(&openapi3.SchemaRef{Ref: "#/components/schemas/TagChanges"}),
+				},
+				Responses: openapi3.Responses{
+					"201": &openapi3.ResponseRef{
+						Ref: "#/components/responses/TagResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"500": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ServerErrorResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/tags/{id}": &openapi3.PathItem{
+			Summary: "Tag",
+			Parameters: openapi3.Parameters{
+				{
+					Value: &openapi3.Parameter{
+						Name:        "id",
+						In:          "path",
+						Description: "Id of tag",
+						Schema: &openapi3.SchemaRef{
+							Value: &openapi3.Schema{
+								Type:   "string",
+								Format: "uuid",
+							},
+						},
+					},
+				},
+			},
+			Get: &openapi3.Operation{
+				OperationID: "GetTag",
+				Tags:        []string{"Tags"},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/TagResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+			Patch: &openapi3.Operation{
+				OperationID: "UpdateTag",
+				Tags:        []string{"Tags"},
+				Description: "Rename the tag or change its parent and synonym, requires admin authorization",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				RequestBody: &openapi3.RequestBodyRef{
+					Value: openapi3.NewRequestBody().
+						WithRequired(true).
+						WithJSONSchemaRef(&openapi3.SchemaRef{Ref: "#/components/schemas/TagChanges"}),
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/TagResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+			Delete: &openapi3.Operation{
+				OperationID: "DeleteTag",
+				Tags:        []string{"Tags"},
+				Description: "Delete the tag and its synonyms, requires admin authorization",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Responses: openapi3.Responses{
+					"204": &openapi3.ResponseRef{
+						Ref: "#/components/responses/EmptyResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/{id}/revisions": &openapi3.PathItem{
+			Summary: "Revisions of an image",
+			Get: &openapi3.Operation{
+				OperationID: "GetImageRevisions",
+				Tags:        []string{"Revisions"},
+				Description: "Fetch previous states of the image, latest first",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "id",
+							In:          "path",
+							Description: "Id of image",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{
+									Type:   "string",
+									Format: "uuid",
+								},
+							},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Revisions").
+							WithJSONSchema(&openapi3.Schema{
+								Type:  "array",
+								Items: &openapi3.SchemaRef{Ref: "#/components/schemas/ImageRevision"},
+							}),
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/{id}/revisions/{revision}": &openapi3.PathItem{
+			Summary: "Revision of an image",
+			Get: &openapi3.Operation{
+				OperationID: "GetImageRevision",
+				Tags:        []string{"Revisions"},
+				Description: "Fetch a previous state of the image",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "id",
+							In:          "path",
+							Description: "Id of image",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{
+									Type:   "string",
+									Format: "uuid",
+								},
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "revision",
+							In:          "path",
+							Description: "Number of the revision",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{Type: "integer"},
+							},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Revision").
+							WithJSONSchemaRef(&openapi3.SchemaRef{Ref: "#/components/schemas/ImageRevision"}),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/{id}/revisions/{revision}/rollback": &openapi3.PathItem{
+			Summary: "Roll back an image",
+			Post: &openapi3.Operation{
+				OperationID: "RollbackImage",
+				Tags:        []string{"Revisions"},
+				Description: "Restore the files of the revision, the current files are kept as a new revision. " +
+					"Requires admin authorization",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "id",
+							In:          "path",
+							Description: "Id of image",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{
+									Type:   "string",
+									Format: "uuid",
+								},
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "revision",
+							In:          "path",
+							Description: "Number of the revision",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{Type: "integer"},
+							},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ImageResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/trash": &openapi3.PathItem{
+			Summary: "Deleted images",
+			Get: &openapi3.Operation{
+				OperationID: "GetTrash",
+				Tags:        []string{"Trash"},
+				Description: "Fetch list of deleted images that weren't purged yet, requires admin authorization",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "size",
+							In:          "query",
+							Description: "Number of images",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "cursor",
+							In:          "query",
+							Description: "Opaque cursor from nextCursor or prevCursor",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "includeTotal",
+							In:          "query",
+							Description: "Set to true to include the total count of deleted images",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewBoolSchema(),
+							},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ImagesResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/trash/{id}/restore": &openapi3.PathItem{
+			Summary: "Restore deleted image",
+			Post: &openapi3.Operation{
+				OperationID: "RestoreImage",
+				Tags:        []string{"Trash"},
+				Description: "Move the image out of the trash, requires admin authorization",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "id",
+							In:          "path",
+							Description: "Id of image",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{
+									Type:   "string",
+									Format: "uuid",
+								},
+							},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ImageResponse",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/{id}/tags": &openapi3.PathItem{
+			Summary: "Tags of an image",
+			Post: &openapi3.Operation{
+				OperationID: "AttachTag",
+				Tags:        []string{"Tags"},
+				Description: "Attach a tag by value, synonyms resolve to their canonical tag and missing tags are created",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "id",
+							In:          "path",
+							Description: "Id of image",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{
+									Type:   "string",
+									Format: "uuid",
+								},
+							},
+						},
+					},
+				},
+				RequestBody: &openapi3.RequestBodyRef{
+					Value: openapi3.NewRequestBody().
+						WithRequired(true).
+						WithJSONSchema(&openapi3.Schema{
+							Type: "object",
+							Properties: map[string]*openapi3.SchemaRef{
+								"value": {
+									Value: &openapi3.Schema{Type: "string", Example: "aircraft"},
+								},
+							},
+							Required: []string{"value"},
+						}),
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ImageResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/{id}/tags/{tagId}": &openapi3.PathItem{
+			Summary: "Tag of an image",
+			Delete: &openapi3.Operation{
+				OperationID: "DetachTag",
+				Tags:        []string{"Tags"},
+				Description: "Detach the tag from the image",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "id",
+							In:          "path",
+							Description: "Id of image",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{
+									Type:   "string",
+									Format: "uuid",
+								},
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "tagId",
+							In:          "path",
+							Description: "Id of tag",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{
+									Type:   "string",
+									Format: "uuid",
+								},
+							},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ImageResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/users/{id}/images": &openapi3.PathItem{
+			Summary: "Images of a user",
+			Get: &openapi3.Operation{
+				OperationID: "GetUserImages",
+				Tags:        []string{"Users"},
+				Description: "Fetch list of images uploaded by the user",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "size",
+							In:          "query",
+							Description: "Number of images",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "cursor",
+							In:          "query",
+							Description: "Opaque cursor from nextCursor or prevCursor",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "includeTotal",
+							In:          "query",
+							Description: "Set to true to include the total count of images of the user",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewBoolSchema(),
+							},
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "id",
+							In:          "path",
+							Description: "Id of user",
+							Schema: &openapi3.SchemaRef{
+								Value: &openapi3.Schema{
+									Type:   "string",
+									Format: "uuid",
+								},
+							},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ImagesResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/me": &openapi3.PathItem{
+			Summary: "Authorized user",
+			Get: &openapi3.Operation{
+				OperationID: "GetMe",
+				Tags:        []string{"Users"},
+				Description: "Fetch the authorized user along with the role and groups of the token",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Profile").
+							WithContent(
+								openapi3.NewContentWithJSONSchemaRef(
+									&openapi3.SchemaRef{
+										Ref: "#/components/schemas/Profile",
+									},
+								),
+							),
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/UnauthorizedResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/me/images": &openapi3.PathItem{
+			Summary: "Images of the authorized user",
+			Get: &openapi3.Operation{
+				OperationID: "GetMyImages",
+				Tags:        []string{"Users"},
+				Description: "Fetch list of images uploaded by the authorized user",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "size",
+							In:          "query",
+							Description: "Number of images",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "cursor",
+							In:          "query",
+							Description: "Opaque cursor from nextCursor or prevCursor",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "includeTotal",
+							In:          "query",
+							Description: "Set to true to include the total count of your images",
+							Schema: &openapi3.SchemaRef{
+								Value: openapi3.NewBoolSchema(),
+							},
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ImagesResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/UnauthorizedResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/uploads": &openapi3.PathItem{
+			Summary: "Resumable uploads",
+			Options: &openapi3.Operation{
+				OperationID: "DiscoverUploads",
+				Tags:        []string{"Uploads"},
+				Description: "Tus 1.0 discovery of the supported version, extensions and maximum size of an upload",
+				Responses: openapi3.Responses{
+					"204": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Tus-Version, Tus-Extension and Tus-Max-Size headers"),
+					},
+				},
+			},
+			Post: &openapi3.Operation{
+				OperationID: "CreateUpload",
+				Tags:        []string{"Uploads"},
+				Description: "Start a tus 1.0 upload of one file of an image, requires admin authorization. The upload of the original file needs the name and format metadata, the upload of the cropped file the id of the original upload as the original metadata. Once both uploads are complete the image is created like by the upload of an image.",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "Tus-Resumable",
+							In:          "header",
+							Required:    true,
+							Description: "Version of the tus protocol, has to be 1.0.0",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "Upload-Length",
+							In:          "header",
+							Required:    true,
+							Description: "Length of the file in bytes",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "Upload-Metadata",
+							In:          "header",
+							Required:    false,
+							Description: "Comma separated keys with base64 encoded values: name and format, or original",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"201": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Upload created, its url is the Location header"),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"412": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Unsupported tus version"),
+					},
+					"413": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Upload-Length exceeds Tus-Max-Size"),
+					},
+				},
+			},
+		},
+		"/api/v1/uploads/{uploadId}": &openapi3.PathItem{
+			Summary: "Resumable upload",
+			Head: &openapi3.Operation{
+				OperationID: "GetUploadOffset",
+				Tags:        []string{"Uploads"},
+				Description: "Get the offset to resume the upload from, requires admin authorization",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "uploadId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of the upload",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "Tus-Resumable",
+							In:          "header",
+							Required:    true,
+							Description: "Version of the tus protocol, has to be 1.0.0",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Upload-Offset, Upload-Length, Upload-Expires and, once created, Image-Id headers"),
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+			Patch: &openapi3.Operation{
+				OperationID: "AppendUploadChunk",
+				Tags:        []string{"Uploads"},
+				Description: "Append the body of type application/offset+octet-stream at the offset of the upload, requires admin authorization. The request completing the second upload of an image creates the image and responds with its Image-Id header. When the files of the image are invalid both uploads are deleted and have to be started again.",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "uploadId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of the upload",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "Tus-Resumable",
+							In:          "header",
+							Required:    true,
+							Description: "Version of the tus protocol, has to be 1.0.0",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "Upload-Offset",
+							In:          "header",
+							Required:    true,
+							Description: "Current offset of the upload",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"204": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Upload-Offset and Upload-Expires headers"),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/InvalidFieldsResponse",
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ConflictResponse",
+					},
+					"415": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Unsupported content type"),
+					},
+				},
+			},
+			Delete: &openapi3.Operation{
+				OperationID: "TerminateUpload",
+				Tags:        []string{"Uploads"},
+				Description: "Delete the upload along with its received chunks, requires admin authorization",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "uploadId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of the upload",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "Tus-Resumable",
+							In:          "header",
+							Required:    true,
+							Description: "Version of the tus protocol, has to be 1.0.0",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"204": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Upload terminated"),
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/upload-intents": &openapi3.PathItem{
+			Summary: "Direct uploads",
+			Post: &openapi3.Operation{
+				OperationID: "CreateUploadIntent",
+				Tags:        []string{"Uploads"},
+				Description: "Get signed urls to upload the original and the cropped file of an image directly to " +
+					"storage, requires admin authorization. The image is created by completing the intent.",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				RequestBody: &openapi3.RequestBodyRef{
+					Value: openapi3.NewRequestBody().
+						WithRequired(true).
+						WithJSONSchemaRef(&openapi3.SchemaRef{Ref: "#/components/schemas/UploadIntentChanges"}),
+				},
+				Responses: openapi3.Responses{
+					"201": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Upload intent").
+							WithContent(openapi3.NewContentWithJSONSchemaRef(
+								&openapi3.SchemaRef{Ref: "#/components/schemas/UploadIntent"},
+							)),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/upload-intents/{intentId}/complete": &openapi3.PathItem{
+			Summary: "Complete a direct upload",
+			Post: &openapi3.Operation{
+				OperationID: "CompleteUploadIntent",
+				Tags:        []string{"Uploads"},
+				Description: "Create the image once both files were uploaded to the signed urls of the intent, " +
+					"requires admin authorization. The files are validated like regular uploads, the intent is kept " +
+					"when they are invalid so other files can be uploaded until it expires.",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "intentId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of the upload intent",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"201": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ImageResponse",
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ConflictResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/jobs/{jobId}": &openapi3.PathItem{
+			Summary: "Job",
+			Get: &openapi3.Operation{
+				OperationID: "GetJob",
+				Tags:        []string{"Jobs"},
+				Description: "Poll the status of a job, requires admin authorization",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "jobId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of the job",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Ref: "#/components/responses/JobResponse",
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/jobs/{jobId}/callback": &openapi3.PathItem{
+			Summary: "Resize callback",
+			Post: &openapi3.Operation{
+				OperationID: "CompleteJob",
+				Tags:        []string{"Jobs"},
+				Description: "Report the outcome of a requested resize, sent by the resize service. The X-Signature " +
+					"header is the hex encoded HMAC-SHA256 of the X-Signature-Timestamp header, the job id and the body, " +
+					"joined by dots. " +
+					"Only served when a callback secret is configured.",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "jobId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of the job",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "X-Signature",
+							In:          "header",
+							Required:    true,
+							Description: "Signature of the callback",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "X-Signature-Timestamp",
+							In:          "header",
+							Required:    true,
+							Description: "Unix seconds the callback was signed at, at most 5 minutes ago",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"204": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Callback received"),
+					},
+					"401": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Invalid signature"),
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+					"409": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ConflictResponse",
+					},
+				},
+			},
+		},
+		"/api/v1/images/{imageId}/transforms/{file}": &openapi3.PathItem{
+			Summary: "Signed image transformations",
+			Get: &openapi3.Operation{
+				OperationID: "SignDerivative",
+				Tags:        []string{"Images"},
+				Description: "Get the signed url of a derivative of the image, requires admin authorization. The url " +
+					"is relative to this api. Only served when a transform secret is configured.",
+				Security: &openapi3.SecurityRequirements{
+					openapi3.SecurityRequirement{
+						"oauth2": []string{},
+					},
+				},
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "imageId",
+							In:          "path",
+							Required:    true,
+							Description: "Id of the image",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "file",
+							In:          "path",
+							Required:    true,
+							Description: "Size and format like `300x200.jpg`, a side of 0 is unbounded",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "fit",
+							In:          "query",
+							Description: "Either `contain`, `cover` or `fill`, defaults to `contain`",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "q",
+							In:          "query",
+							Description: "Quality of 1 to 100, defaults to 85",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().
+							WithDescription("Signed url of the derivative").
+							WithJSONSchema(&openapi3.Schema{
+								Type: "object",
+								Properties: map[string]*openapi3.SchemaRef{
+									"url": {
+										Value: &openapi3.Schema{Type: "string"},
+									},
+								},
+							}),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"401": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+		},
+		"/img/{name}/{file}": &openapi3.PathItem{
+			Summary: "Image transformations",
+			Get: &openapi3.Operation{
+				OperationID: "GetDerivative",
+				Tags:        []string{"Images"},
+				Description: "Render a derivative of the original of an image in one of the allowed sizes, cached for " +
+					"following requests. The s parameter is the hex encoded HMAC-SHA256 of " +
+					"`{name}/{width}x{height}_{fit}_q{quality}.{format}` with the defaults applied. Former names " +
+					"redirect to the signed url of the current name. Only served when a transform secret is configured.",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "name",
+							In:          "path",
+							Required:    true,
+							Description: "Name of the image",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "file",
+							In:          "path",
+							Required:    true,
+							Description: "Size and format like `300x200.jpg`, a side of 0 is unbounded",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "fit",
+							In:          "query",
+							Description: "Either `contain`, `cover` or `fill`, defaults to `contain`",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "q",
+							In:          "query",
+							Description: "Quality of 1 to 100, defaults to 85",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "s",
+							In:          "query",
+							Required:    true,
+							Description: "Signature of the transformation",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Content of the derivative"),
+					},
+					"301": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("The image was renamed"),
+					},
+					"304": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("The derivative of the If-None-Match etag is current"),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+					"503": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Too many derivatives are rendered, retry later"),
+					},
+				},
+			},
+		},
+		"/api/v1/objects/{key}": &openapi3.PathItem{
+			Summary: "Signed objects",
+			Get: &openapi3.Operation{
+				OperationID: "GetObject",
+				Tags:        []string{"Objects"},
+				Description: "Download an object of the filesystem object store through a signed url",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "key",
+							In:          "path",
+							Required:    true,
+							Description: "Slash separated key of the object",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "expires",
+							In:          "query",
+							Required:    true,
+							Description: "Unix time the signed url expires at",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "signature",
+							In:          "query",
+							Required:    true,
+							Description: "Signature of the method, key and expiry",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Content of the object"),
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+					"404": &openapi3.ResponseRef{
+						Ref: "#/components/responses/NotFoundResponse",
+					},
+				},
+			},
+			Put: &openapi3.Operation{
+				OperationID: "PutObject",
+				Tags:        []string{"Objects"},
+				Description: "Upload an object to the filesystem object store through a signed url",
+				Parameters: openapi3.Parameters{
+					{
+						Value: &openapi3.Parameter{
+							Name:        "key",
+							In:          "path",
+							Required:    true,
+							Description: "Slash separated key of the object",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "expires",
+							In:          "query",
+							Required:    true,
+							Description: "Unix time the signed url expires at",
+						},
+					},
+					{
+						Value: &openapi3.Parameter{
+							Name:        "signature",
+							In:          "query",
+							Required:    true,
+							Description: "Signature of the method, key and expiry",
+						},
+					},
+				},
+				Responses: openapi3.Responses{
+					"200": &openapi3.ResponseRef{
+						Value: openapi3.NewResponse().WithDescription("Object stored"),
+					},
+					"400": &openapi3.ResponseRef{
+						Ref: "#/components/responses/BadRequestResponse",
+					},
+					"403": &openapi3.ResponseRef{
+						Ref: "#/components/responses/ForbiddenResponse",
+					},
+				},
+			},
+		},
 	}
 
 	swagger.Components.SecuritySchemes = openapi3.SecuritySchemes{
@@ -0,0 +1,73 @@
+package http_server
+
+import (
+	"api/auth"
+	"api/core/exception"
+	"api/http_server/http_util"
+	"api/http_server/middleware"
+	"api/http_server/middleware/keys"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+// CreateRevisionsRouter routes the revision history of an image, expects to be mounted under an {imageId}
+func (h ImageHandler) CreateRevisionsRouter() func(router chi.Router) {
+	isAdmin := middleware.Authorize(h.logger, h.authenticator, auth.RoleAdmin)
+
+	return func(r chi.Router) {
+		r.Get("/", h.Handle(h.fetchRevisions))
+		r.Get("/{revision}", h.Handle(h.fetchRevision))
+		r.With(isAdmin).Post("/{revision}/rollback", h.Handle(h.rollback))
+	}
+}
+
+func revisionFromPath(req *http.Request) (int, error) {
+	revision, err := strconv.Atoi(chi.URLParam(req, "revision"))
+	if err != nil || revision < 1 {
+		return 0, exception.InvalidArgument{Reason: "Revision should be a positive number"}
+	}
+	return revision, nil
+}
+
+func (h ImageHandler) fetchRevisions(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	revisions, err := h.imagesService.GetRevisions(ctx, chi.URLParam(req, "imageId"))
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(revisions), nil
+}
+
+func (h ImageHandler) fetchRevision(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	revision, err := revisionFromPath(req)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := h.imagesService.GetRevision(ctx, chi.URLParam(req, "imageId"), revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(found), nil
+}
+
+func (h ImageHandler) rollback(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	revision, err := revisionFromPath(req)
+	if err != nil {
+		return nil, err
+	}
+	authDto, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := h.imagesService.Rollback(ctx, authDto, chi.URLParam(req, "imageId"), revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(img), nil
+}
@@ -4,6 +4,7 @@ import (
 	"api/core"
 	coremiddleware "api/http_server/middleware"
 	"api/http_server/openapi"
+	"api/objectstore"
 	"context"
 	"fmt"
 	"github.com/go-chi/chi/v5"
@@ -33,16 +34,6 @@ func NewServer(logger *zerolog.Logger, config Config, app *core.App) (*Server, e
 	r.Use(middleware.Heartbeat(config.HeartbeatUrl))
 	r.Use(Cors(config.CorsAllowOrigins))
 
-	// Enable httprate request limiter of 100 requests per minute.
-	//
-	// In the code example below, rate-limiting is bound to the request IP address
-	// via the LimitByIP middleware handler.
-	//
-	// To have a single rate-limiter for all requests, use httprate.LimitAll(..).
-	//
-	// Please see _example/main.go for other more, or read the library code.
-	r.Use(httprate.LimitByIP(100, 1*time.Minute))
-
 	// Routing
 	swaggerRouter, err := openapi.NewOpenApi3Router(openapi.Config{
 		BasicAuthUsername:          config.BasicAuthUsername,
@@ -55,10 +46,57 @@ func NewServer(logger *zerolog.Logger, config Config, app *core.App) (*Server, e
 	if err != nil {
 		return nil, err
 	}
-	r.Route("/docs", swaggerRouter)
+	r.Group(func(r chi.Router) {
+		// Enable httprate request limiter of 100 requests per minute.
+		//
+		// In the code example below, rate-limiting is bound to the request IP address
+		// via the LimitByIP middleware handler.
+		//
+		// To have a single rate-limiter for all requests, use httprate.LimitAll(..).
+		//
+		// Please see _example/main.go for other more, or read the library code.
+		r.Use(httprate.LimitByIP(100, 1*time.Minute))
+
+		r.Route("/docs", swaggerRouter)
+
+		uploadLimits := UploadLimits{
+			Min:     config.UploadMinResolution,
+			Max:     config.UploadMaxResolution,
+			Formats: app.Config.UploadFormats(),
+		}
+		imagesHandler := NewImageHandler(logger, app.Auth, app.ImagesService, app.JobsService, uploadLimits)
+		uploadsHandler := NewUploadHandler(logger, app.Auth, app.UploadsService, uploadLimits)
+		jobsHandler := NewJobHandler(logger, app.Auth, app.JobsService, app.Config.JobsCallbackSecret)
+		tagsHandler := NewTagHandler(logger, app.Auth, app.TagsService)
+		usersHandler := NewUserHandler(logger, app.Auth, app.UsersService, app.ImagesService)
+		transformsHandler := NewTransformHandler(logger, app.Auth, app.Transforms)
+		r.Route("/api/v1/images", func(r chi.Router) {
+			imagesHandler.CreateRouter()(r)
+			r.Route("/upload-intents", uploadsHandler.CreateIntentsRouter())
+			r.Route("/{imageId}/tags", tagsHandler.CreateImageTagsRouter())
+			r.Route("/{imageId}/revisions", imagesHandler.CreateRevisionsRouter())
+			if app.Config.ImagesTransformSecret != "" {
+				r.Route("/{imageId}/transforms", transformsHandler.CreateSignRouter())
+			}
+		})
+		r.Route(UploadsPath, uploadsHandler.CreateRouter())
+		r.Route(core.JobsPath, jobsHandler.CreateRouter())
+		r.Route("/api/v1/tags", tagsHandler.CreateRouter())
+		r.Route("/api/v1/trash", imagesHandler.CreateTrashRouter())
+		r.Route("/api/v1/users", usersHandler.CreateRouter())
+		r.Route("/api/v1/me", usersHandler.CreateMeRouter())
+		if app.Config.ObjectStore == core.ObjectStoreFilesystem {
+			objectsHandler := NewObjectHandler(logger, app.ObjectStore, app.ObjectSigner)
+			r.Route(objectstore.Path, objectsHandler.CreateRouter())
+		}
+	})
 
-	imagesHandler := NewImageHandler(logger, app.Auth, app.ImagesService)
-	r.Route("/api/v1/images", imagesHandler.CreateRouter())
+	// Derivatives are requested by a CDN in front of this api rather than by clients, so they aren't rate limited by
+	// ip. Their signatures and the allowlist of sizes bound what can be requested.
+	if app.Config.ImagesTransformSecret != "" {
+		transformsHandler := NewTransformHandler(logger, app.Auth, app.Transforms)
+		r.Route(core.TransformsPath, transformsHandler.CreateRouter())
+	}
 
 	httpServer := &http.Server{
 		Addr:              port,
@@ -0,0 +1,171 @@
+package http_server
+
+import (
+	"api/auth"
+	"api/core"
+	"api/http_server/authenticator"
+	"api/http_server/http_util"
+	"api/http_server/middleware"
+	"api/http_server/middleware/keys"
+	"api/storage"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"net/http"
+)
+
+type TagHandler struct {
+	http_util.RequestHandler
+	tagsService   *core.TagsService
+	logger        *zerolog.Logger
+	authenticator authenticator.Authenticator
+}
+
+func NewTagHandler(
+	logger *zerolog.Logger,
+	authenticator authenticator.Authenticator,
+	service *core.TagsService,
+) *TagHandler {
+	handler := http_util.NewRequestHandler(logger)
+
+	return &TagHandler{
+		handler,
+		service,
+		logger,
+		authenticator,
+	}
+}
+
+func (h TagHandler) CreateRouter() func(router chi.Router) {
+	isAdmin := middleware.Authorize(h.logger, h.authenticator, auth.RoleAdmin)
+
+	return func(r chi.Router) {
+		r.Get("/", h.Handle(h.fetchTags))
+		r.Get("/{tagId}", h.Handle(h.fetchTag))
+		r.With(isAdmin).Post("/", h.Handle(h.createTag))
+		r.With(isAdmin).Patch("/{tagId}", h.Handle(h.updateTag))
+		r.With(isAdmin).Delete("/{tagId}", h.Handle(h.deleteTag))
+	}
+}
+
+// CreateImageTagsRouter routes attaching and detaching of tags, expects to be mounted under an {imageId}
+func (h TagHandler) CreateImageTagsRouter() func(router chi.Router) {
+	isAdmin := middleware.Authorize(h.logger, h.authenticator, auth.RoleAdmin)
+
+	return func(r chi.Router) {
+		r.With(isAdmin).Post("/", h.Handle(h.attachTag))
+		r.With(isAdmin).Delete("/{tagId}", h.Handle(h.detachTag))
+	}
+}
+
+func (h TagHandler) fetchTags(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	page := http_util.ToUint(req.URL.Query().Get("page"))
+	size := http_util.ToUint(req.URL.Query().Get("size"))
+	limit, offset := storage.PagingToLimitOffset(page, size)
+
+	tags, err := h.tagsService.Get(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(tags), nil
+}
+
+func (h TagHandler) fetchTag(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	tag, err := h.tagsService.GetOne(ctx, chi.URLParam(req, "tagId"))
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(tag), nil
+}
+
+func (h TagHandler) createTag(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	var dto core.TagDto
+	if err := http_util.ReadJson(req, &dto); err != nil {
+		return nil, err
+	}
+
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := h.tagsService.Create(ctx, authorization, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(tag).WithStatus(http.StatusCreated), nil
+}
+
+func (h TagHandler) updateTag(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	var dto core.TagDto
+	if err := http_util.ReadJson(req, &dto); err != nil {
+		return nil, err
+	}
+
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := h.tagsService.Update(ctx, authorization, chi.URLParam(req, "tagId"), dto)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(tag), nil
+}
+
+func (h TagHandler) deleteTag(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = h.tagsService.DeleteOne(ctx, authorization, chi.URLParam(req, "tagId")); err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(nil).WithStatus(http.StatusNoContent), nil
+}
+
+type AttachTagDto struct {
+	Value string `json:"value"`
+}
+
+func (h TagHandler) attachTag(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	var dto AttachTagDto
+	if err := http_util.ReadJson(req, &dto); err != nil {
+		return nil, err
+	}
+
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := h.tagsService.AttachToImage(ctx, authorization, chi.URLParam(req, "imageId"), dto.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(img), nil
+}
+
+func (h TagHandler) detachTag(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := h.tagsService.DetachFromImage(
+		ctx, authorization, chi.URLParam(req, "imageId"), chi.URLParam(req, "tagId"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(img), nil
+}
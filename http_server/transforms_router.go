@@ -0,0 +1,142 @@
+package http_server
+
+import (
+	"api/auth"
+	"api/core"
+	"api/core/exception"
+	"api/http_server/authenticator"
+	"api/http_server/http_util"
+	"api/http_server/middleware"
+	"api/image"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TransformHandler serves derivatives of originals in arbitrary sizes, the signature in the query authorizes the
+// request instead of a token so the responses can be cached by a CDN. Admins sign the urls of the derivatives.
+type TransformHandler struct {
+	http_util.RequestHandler
+	transforms    *core.TransformsService
+	authenticator authenticator.Authenticator
+	logger        *zerolog.Logger
+}
+
+func NewTransformHandler(
+	logger *zerolog.Logger, authenticator authenticator.Authenticator, transforms *core.TransformsService,
+) *TransformHandler {
+	return &TransformHandler{
+		RequestHandler: http_util.NewRequestHandler(logger),
+		transforms:     transforms,
+		authenticator:  authenticator,
+		logger:         logger,
+	}
+}
+
+func (h TransformHandler) CreateRouter() func(router chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/{name}/{file}", h.getDerivative)
+	}
+}
+
+// CreateSignRouter routes signing the urls of derivatives, expects to be mounted under an {imageId}
+func (h TransformHandler) CreateSignRouter() func(router chi.Router) {
+	isAdmin := middleware.Authorize(h.logger, h.authenticator, auth.RoleAdmin)
+
+	return func(r chi.Router) {
+		r.With(isAdmin).Get("/{file}", h.Handle(h.signDerivative))
+	}
+}
+
+// transformationFromRequest reads the transformation of a {width}x{height}.{format} file with fit and q in the query
+func transformationFromRequest(req *http.Request) (image.Transformation, error) {
+	size, format, found := strings.Cut(chi.URLParam(req, "file"), ".")
+	if !found {
+		return image.Transformation{}, exception.InvalidArgument{Reason: "Invalid file, expected WIDTHxHEIGHT.format"}
+	}
+	dimensions, err := image.ParseDimensions(size)
+	if err != nil {
+		return image.Transformation{}, exception.InvalidArgument{Reason: "Invalid size, expected WIDTHxHEIGHT"}
+	}
+
+	transformation := image.Transformation{
+		Width:   dimensions.Width,
+		Height:  dimensions.Height,
+		Fit:     image.FitContain,
+		Quality: image.DefaultQuality,
+		Format:  image.Format(format),
+	}
+	query := req.URL.Query()
+	if fit := query.Get("fit"); fit != "" {
+		transformation.Fit = image.Fit(fit)
+	}
+	if quality := query.Get("q"); quality != "" {
+		transformation.Quality, err = strconv.Atoi(quality)
+		if err != nil {
+			return image.Transformation{}, exception.InvalidArgument{Reason: "Invalid quality, expected 1 to 100"}
+		}
+	}
+
+	return transformation, nil
+}
+
+// signDerivative returns the url of the {width}x{height}.{format} file with fit and q in the query
+func (h TransformHandler) signDerivative(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	transformation, err := transformationFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := h.transforms.Sign(ctx, chi.URLParam(req, "imageId"), transformation)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(signed), nil
+}
+
+func (h TransformHandler) getDerivative(w http.ResponseWriter, req *http.Request) {
+	name := chi.URLParam(req, "name")
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(h.transforms.MaxAge().Seconds()))
+
+	transformation, err := transformationFromRequest(req)
+	if err != nil {
+		w.Header().Set("Cache-Control", "no-store")
+		http_util.HandleError(h.logger, w, err)
+		return
+	}
+
+	derivative, err := h.transforms.Transform(req.Context(), name, transformation, req.URL.Query().Get("s"))
+	var renamed exception.Renamed
+	if errors.As(err, &renamed) {
+		w.Header().Set("Cache-Control", cacheControl)
+		http.Redirect(w, req, h.transforms.SignPath(renamed.Name, transformation), http.StatusMovedPermanently)
+		return
+	}
+	if err != nil {
+		w.Header().Set("Cache-Control", "no-store")
+		http_util.HandleError(h.logger, w, err)
+		return
+	}
+	defer derivative.Body.Close()
+
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", derivative.ETag)
+	if req.Header.Get("If-None-Match") == derivative.ETag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", derivative.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(derivative.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err = io.Copy(w, derivative.Body); err != nil {
+		h.logger.Warn().Err(err).Str("name", name).Msg("failed writing derivative")
+	}
+}
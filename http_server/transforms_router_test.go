@@ -0,0 +1,55 @@
+package http_server
+
+import (
+	"api/image"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransformationFromRequest(t *testing.T) {
+	data := []struct {
+		testName string
+		file     string
+		query    string
+		expected image.Transformation
+		isValid  bool
+	}{
+		{
+			testName: "Defaults",
+			file:     "300x200.png",
+			expected: image.Transformation{
+				Width: 300, Height: 200, Fit: image.FitContain, Quality: image.DefaultQuality, Format: image.PngFormat,
+			},
+			isValid: true,
+		},
+		{
+			testName: "Fit and quality",
+			file:     "300x0.jpg",
+			query:    "?fit=cover&q=80",
+			expected: image.Transformation{Width: 300, Fit: image.FitCover, Quality: 80, Format: image.JpgFormat},
+			isValid:  true,
+		},
+		{testName: "Missing format", file: "300x200"},
+		{testName: "Invalid size", file: "300.jpg"},
+		{testName: "Invalid quality", file: "300x200.jpg", query: "?q=high"},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/img/my-plane/"+d.file+d.query, nil)
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("file", d.file)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+
+			transformation, err := transformationFromRequest(req)
+			if d.isValid != (err == nil) {
+				t.Fatalf("Expected valid to be %v, got %v", d.isValid, err)
+			}
+			if transformation != d.expected {
+				t.Fatalf("Expected %+v, got %+v", d.expected, transformation)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package http_server
+
+import (
+	"api/auth"
+	"api/http_server/http_util"
+	"api/http_server/middleware"
+	"api/http_server/middleware/keys"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+// CreateTrashRouter routes listing and restoring of deleted images, all routes require admin authorization
+func (h ImageHandler) CreateTrashRouter() func(router chi.Router) {
+	isAdmin := middleware.Authorize(h.logger, h.authenticator, auth.RoleAdmin)
+
+	return func(r chi.Router) {
+		r.Use(isAdmin)
+		r.Get("/", h.Handle(h.fetchTrash))
+		r.Post("/{imageId}/restore", h.Handle(h.restoreImage))
+	}
+}
+
+func (h ImageHandler) fetchTrash(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	pagination, err := paginationFromQuery(req.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	includeTotal := req.URL.Query().Get("includeTotal") == "true"
+
+	imagePage, err := h.imagesService.GetTrash(ctx, pagination, includeTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	return imagePageResponse(req.URL, imagePage), nil
+}
+
+func (h ImageHandler) restoreImage(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	imageId := chi.URLParam(req, "imageId")
+	authDto, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := h.imagesService.Restore(ctx, authDto, imageId)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(img), nil
+}
@@ -0,0 +1,220 @@
+package http_server
+
+import (
+	"api/core/exception"
+	"api/http_server/http_util"
+	"api/image"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// maxFieldLength bounds the values of the fields that precede the files
+const maxFieldLength = 1024
+
+// uploadForm reads a multipart upload form as a stream, nothing is spooled to disk. The fields have to precede the
+// files. Each file is validated from its inspected header and the rest of it is read by the upload, which processes
+// the metadata of both files before any of them is signed. The original file should precede the cropped file, a
+// cropped file sent first is held in memory until the original file was read.
+type uploadForm struct {
+	reader *multipart.Reader
+	fields map[string]string
+	// part is the first file of the form, nil when it has none
+	part *multipart.Part
+	// cropped is the cropped file when it preceded the original file
+	cropped []byte
+	// err is why a file failed once the upload read it
+	err error
+}
+
+// readUploadForm reads the fields of the form up to its first file
+func readUploadForm(req *http.Request) (*uploadForm, error) {
+	req.Body = http.MaxBytesReader(nil, req.Body, maxBodyLimitBytes)
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, http_util.NewFailureResponse("failed parsing multipart form data")
+	}
+
+	form := &uploadForm{reader: reader, fields: map[string]string{}}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			return nil, formErr(err)
+		}
+		if part.FileName() != "" {
+			form.part = part
+			return form, nil
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxFieldLength+1))
+		if err != nil {
+			return nil, formErr(err)
+		}
+		if len(value) > maxFieldLength {
+			return nil, http_util.NewFailureResponse(fmt.Sprintf("field %s is too long", part.FormName()))
+		}
+		form.fields[part.FormName()] = string(value)
+	}
+}
+
+// formErr reports why the form couldn't be read
+func formErr(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http_util.NewFailureResponse(fmt.Sprintf("form exceeds the limit of %d bytes", maxBytesErr.Limit))
+	}
+
+	return http_util.NewFailureResponse("failed parsing multipart form data")
+}
+
+func (form *uploadForm) value(name string) string {
+	return form.fields[name]
+}
+
+func (form *uploadForm) hasFiles() bool {
+	return form.part != nil
+}
+
+// nextFile moves to the next original or cropped file of the form, other parts are skipped. Nil is returned once
+// the form has no file left.
+func (form *uploadForm) nextFile() (*multipart.Part, error) {
+	for {
+		part, err := form.reader.NextPart()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if name := part.FormName(); name == originalFileField || name == croppedFileField {
+			return part, nil
+		}
+	}
+}
+
+// originalFile inspects the original file, a cropped file preceding it is read into memory first
+func (form *uploadForm) originalFile(
+	limits UploadLimits, format image.Format, invalid *exception.InvalidFields,
+) image.Upload {
+	part := form.part
+	if part != nil && part.FormName() == croppedFileField {
+		cropped, err := io.ReadAll(io.LimitReader(part, image.MaxProcessedFileLength+1))
+		if err == nil && len(cropped) > image.MaxProcessedFileLength {
+			err = fmt.Errorf("file exceeds the limit of %d bytes", image.MaxProcessedFileLength)
+		}
+		if err == nil {
+			form.cropped = cropped
+			part, err = form.nextFile()
+		}
+		if err != nil {
+			invalid.Add(originalFileField, "Failed reading file: "+err.Error())
+			return image.Upload{}
+		}
+	}
+	if part == nil || part.FormName() != originalFileField {
+		invalid.Add(originalFileField, "Missing file")
+		return image.Upload{}
+	}
+
+	_, body, _ := inspectUploadFile(limits, format, originalFileField, part, invalid)
+
+	return image.Upload{Body: &formFileReader{form: form, file: body}, Size: image.UnknownSize}
+}
+
+// croppedFile inspects the cropped file when it preceded the original file. Otherwise it is read from the part
+// following the original file, so it is only validated once the upload read the original file. A rejected file
+// fails the upload reading it, uploadErr then reports its invalid fields.
+func (form *uploadForm) croppedFile(
+	limits UploadLimits, format image.Format, invalid *exception.InvalidFields,
+) image.Upload {
+	if form.cropped != nil {
+		body := form.inspectCroppedFile(limits, format, bytes.NewReader(form.cropped), invalid)
+		return image.Upload{Body: body, Size: int64(len(form.cropped))}
+	}
+	if form.part == nil {
+		invalid.Add(croppedFileField, "Missing file")
+		return image.Upload{}
+	}
+
+	return image.Upload{
+		Body: &formFileReader{form: form, open: func() (io.Reader, error) {
+			return form.openCroppedFile(limits, format)
+		}},
+		Size: image.UnknownSize,
+	}
+}
+
+func (form *uploadForm) inspectCroppedFile(
+	limits UploadLimits, format image.Format, file io.Reader, invalid *exception.InvalidFields,
+) io.Reader {
+	info, body, isDecoded := inspectUploadFile(limits, format, croppedFileField, file, invalid)
+	if isDecoded {
+		validateCropAspectRatio(info, invalid)
+	}
+
+	return body
+}
+
+// openCroppedFile moves to the part following the original file and inspects it
+func (form *uploadForm) openCroppedFile(limits UploadLimits, format image.Format) (io.Reader, error) {
+	part, err := form.nextFile()
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := exception.InvalidFields{}
+	var file io.Reader
+	if part == nil || part.FormName() != croppedFileField {
+		invalid.Add(croppedFileField, "Missing file")
+	} else {
+		file = form.inspectCroppedFile(limits, format, part, &invalid)
+	}
+
+	return file, invalid.OrNil()
+}
+
+// uploadErr replaces the error of a failed upload with why the file it read failed
+func (form *uploadForm) uploadErr(err error) error {
+	if form.err == nil {
+		return err
+	}
+
+	var invalid exception.InvalidFields
+	if errors.As(form.err, &invalid) {
+		return form.err
+	}
+
+	return formErr(form.err)
+}
+
+// formFileReader reads a file of the form and keeps why it failed, as the upload only reports an invalid file.
+// With open, the file is opened on the first read.
+type formFileReader struct {
+	form *uploadForm
+	file io.Reader
+	open func() (io.Reader, error)
+}
+
+func (reader *formFileReader) Read(p []byte) (int, error) {
+	if reader.file == nil {
+		file, err := reader.open()
+		if err != nil {
+			reader.form.err = err
+			return 0, err
+		}
+		reader.file = file
+	}
+
+	n, err := reader.file.Read(p)
+	if err != nil && err != io.EOF {
+		reader.form.err = err
+	}
+
+	return n, err
+}
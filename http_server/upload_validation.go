@@ -0,0 +1,96 @@
+package http_server
+
+import (
+	"api/core/exception"
+	"api/image"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	originalFileField = "originalFile"
+	croppedFileField  = "croppedFile"
+)
+
+// UploadLimits bound the resolution of both uploaded files, Formats are the formats the resizer can resize
+type UploadLimits struct {
+	Min     image.Dimensions
+	Max     image.Dimensions
+	Formats []image.Format
+}
+
+func (limits UploadLimits) accepts(contentType image.ContentType) bool {
+	for _, format := range limits.Formats {
+		if format.ToContentType() == contentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inspectUploadFile adds the reasons the file is invalid, it has to be of the declared format and within the limits.
+// The returned reader replays the inspected header followed by the rest of the file, false is returned when the
+// file couldn't be decoded.
+func inspectUploadFile(
+	limits UploadLimits,
+	format image.Format,
+	field string,
+	file io.Reader,
+	invalid *exception.InvalidFields,
+) (image.FileInfo, io.Reader, bool) {
+	info, replay, err := image.Inspect(file)
+	if err != nil {
+		invalid.Add(field, "Not a supported image, expected one of jpg, png, webp or avif")
+		return image.FileInfo{}, replay, false
+	}
+
+	if format.IsSupported() && info.ContentType != format.ToContentType() {
+		invalid.Add(field, fmt.Sprintf("Content of type %s doesn't match the format %s", info.ContentType, format))
+	} else if !limits.accepts(info.ContentType) {
+		invalid.Add(field, fmt.Sprintf("Content of type %s can't be resized", info.ContentType))
+	}
+	if info.Width < limits.Min.Width || info.Height < limits.Min.Height {
+		invalid.Add(field, fmt.Sprintf(
+			"Resolution of %dx%d is below the minimum of %dx%d",
+			info.Width, info.Height, limits.Min.Width, limits.Min.Height,
+		))
+	}
+	if info.Width > limits.Max.Width || info.Height > limits.Max.Height {
+		invalid.Add(field, fmt.Sprintf(
+			"Resolution of %dx%d is above the maximum of %dx%d",
+			info.Width, info.Height, limits.Max.Width, limits.Max.Height,
+		))
+	}
+
+	return info, replay, true
+}
+
+// validateCropAspectRatio requires the cropped file to have one of the allowed aspect ratios
+func validateCropAspectRatio(info image.FileInfo, invalid *exception.InvalidFields) {
+	if image.HasAllowedAspectRatio(info.Width, info.Height) {
+		return
+	}
+
+	ratios := make([]string, 0, len(image.AllowedAspectRatios))
+	for _, ratio := range image.AllowedAspectRatios {
+		ratios = append(ratios, ratio.String())
+	}
+	invalid.Add(croppedFileField, fmt.Sprintf(
+		"Aspect ratio of %dx%d is not one of %s", info.Width, info.Height, strings.Join(ratios, ", "),
+	))
+}
+
+// parseResolution reads resolutions like 1920x1080
+func parseResolution(value string) (image.Dimensions, error) {
+	width, height, found := strings.Cut(value, "x")
+	parsedWidth, widthErr := strconv.ParseUint(width, 10, 31)
+	parsedHeight, heightErr := strconv.ParseUint(height, 10, 31)
+	if !found || widthErr != nil || heightErr != nil {
+		return image.Dimensions{}, fmt.Errorf("invalid resolution %s, expected WIDTHxHEIGHT", value)
+	}
+
+	return image.Dimensions{Width: int(parsedWidth), Height: int(parsedHeight)}, nil
+}
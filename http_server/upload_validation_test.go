@@ -0,0 +1,245 @@
+package http_server
+
+import (
+	"api/core/exception"
+	"api/image"
+	"bytes"
+	goimage "image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type formPart struct {
+	field string
+	value string
+	file  []byte
+}
+
+func newImageFile(t *testing.T, width, height int, encode func(*bytes.Buffer, goimage.Image) error) []byte {
+	var content bytes.Buffer
+	if err := encode(&content, goimage.NewRGBA(goimage.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+
+	return content.Bytes()
+}
+
+func newUploadRequest(t *testing.T, parts []formPart) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range parts {
+		if part.file == nil {
+			if err := writer.WriteField(part.field, part.value); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		fileWriter, err := writer.CreateFormFile(part.field, "upload")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = fileWriter.Write(part.file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/images", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req
+}
+
+func encodePng(buf *bytes.Buffer, img goimage.Image) error {
+	return png.Encode(buf, img)
+}
+
+func encodeJpeg(buf *bytes.Buffer, img goimage.Image) error {
+	return jpeg.Encode(buf, img, nil)
+}
+
+func assertInvalidFields(t *testing.T, err error, expected []string) {
+	t.Helper()
+	if len(expected) == 0 {
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return
+	}
+
+	invalid, ok := err.(exception.InvalidFields)
+	if !ok {
+		t.Fatalf("Expected invalid fields %v, got %v", expected, err)
+	}
+	if len(invalid.Fields) != len(expected) {
+		t.Fatalf("Expected fields %v, got %v", expected, invalid.Fields)
+	}
+	for i, field := range expected {
+		if invalid.Fields[i].Field != field {
+			t.Fatalf("Expected fields %v, got %v", expected, invalid.Fields)
+		}
+	}
+}
+
+func TestUploadForm_Validate(t *testing.T) {
+	limits := UploadLimits{
+		Min:     image.Dimensions{Width: 100, Height: 100},
+		Max:     image.Dimensions{Width: 2000, Height: 2000},
+		Formats: []image.Format{image.PngFormat},
+	}
+	original := newImageFile(t, 1600, 1000, encodePng)
+	cropped := newImageFile(t, 1200, 800, encodePng)
+
+	tests := []struct {
+		testName string
+		parts    []formPart
+		// expected are the fields rejected before anything is uploaded
+		expected []string
+		// expectedCropped are the fields rejected once the cropped file is read
+		expectedCropped []string
+	}{
+		{
+			testName: "Valid",
+			parts: []formPart{
+				{field: "name", value: "my plane"},
+				{field: "format", value: "png"},
+				{field: originalFileField, file: original},
+				{field: croppedFileField, file: cropped},
+			},
+		},
+		{
+			testName: "Missing files",
+			parts:    []formPart{{field: "name", value: "my"}, {field: "format", value: "gif"}},
+			expected: []string{"name", "format", originalFileField, croppedFileField},
+		},
+		{
+			testName: "Fields after the files",
+			parts: []formPart{
+				{field: originalFileField, file: original},
+				{field: croppedFileField, file: cropped},
+				{field: "name", value: "my plane"},
+				{field: "format", value: "png"},
+			},
+			expected: []string{"name", "format"},
+		},
+		{
+			testName: "Cropped file first",
+			parts: []formPart{
+				{field: "name", value: "my plane"},
+				{field: "format", value: "png"},
+				{field: croppedFileField, file: cropped},
+				{field: originalFileField, file: original},
+			},
+		},
+		{
+			testName: "Other files skipped",
+			parts: []formPart{
+				{field: "name", value: "my plane"},
+				{field: "format", value: "png"},
+				{field: originalFileField, file: original},
+				{field: "thumbnail", file: []byte("thumbnail")},
+				{field: croppedFileField, file: cropped},
+			},
+		},
+		{
+			testName: "Content mismatch",
+			parts: []formPart{
+				{field: "name", value: "my plane"},
+				{field: "format", value: "png"},
+				{field: originalFileField, file: newImageFile(t, 1600, 1000, encodeJpeg)},
+				{field: croppedFileField, file: cropped},
+			},
+			expected: []string{originalFileField},
+		},
+		{
+			testName: "Format the resizer can't resize",
+			parts: []formPart{
+				{field: "name", value: "my plane"},
+				{field: "format", value: "jpg"},
+				{field: originalFileField, file: newImageFile(t, 1600, 1000, encodeJpeg)},
+				{field: croppedFileField, file: newImageFile(t, 1200, 800, encodeJpeg)},
+			},
+			expected: []string{originalFileField},
+		},
+		{
+			testName: "Cropped ratio",
+			parts: []formPart{
+				{field: "name", value: "my plane"},
+				{field: "format", value: "png"},
+				{field: originalFileField, file: original},
+				{field: croppedFileField, file: newImageFile(t, 1000, 500, encodePng)},
+			},
+			expectedCropped: []string{croppedFileField},
+		},
+		{
+			testName: "Cropped ratio of a cropped file sent first",
+			parts: []formPart{
+				{field: "name", value: "my plane"},
+				{field: "format", value: "png"},
+				{field: croppedFileField, file: newImageFile(t, 1000, 500, encodePng)},
+				{field: originalFileField, file: original},
+			},
+			expected: []string{croppedFileField},
+		},
+		{
+			testName: "Resolution limits",
+			parts: []formPart{
+				{field: "name", value: "my plane"},
+				{field: "format", value: "png"},
+				{field: originalFileField, file: newImageFile(t, 2400, 1600, encodePng)},
+				{field: croppedFileField, file: newImageFile(t, 90, 90, encodePng)},
+			},
+			expected: []string{originalFileField},
+		},
+		{
+			testName: "Missing cropped file",
+			parts: []formPart{
+				{field: "name", value: "my plane"},
+				{field: "format", value: "png"},
+				{field: originalFileField, file: original},
+			},
+			expectedCropped: []string{croppedFileField},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			form, err := readUploadForm(newUploadRequest(t, test.parts))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := UploadImageDto{Name: form.value("name"), Format: image.Format(form.value("format"))}
+			invalid := exception.InvalidFields{}
+			data.validate(&invalid)
+			originalFile := form.originalFile(limits, data.Format, &invalid)
+			croppedFile := form.croppedFile(limits, data.Format, &invalid)
+			assertInvalidFields(t, invalid.OrNil(), test.expected)
+			if len(test.expected) != 0 {
+				return
+			}
+
+			uploadedOriginal, err := io.ReadAll(originalFile.Body)
+			if err != nil || !bytes.Equal(uploadedOriginal, original) {
+				t.Fatalf("Expected the whole original file, got %d bytes and error %v", len(uploadedOriginal), err)
+			}
+			uploadedCropped, err := io.ReadAll(croppedFile.Body)
+			if err != nil {
+				assertInvalidFields(t, form.uploadErr(err), test.expectedCropped)
+				return
+			}
+			assertInvalidFields(t, nil, test.expectedCropped)
+			if !bytes.Equal(uploadedCropped, cropped) {
+				t.Fatalf("Expected the whole cropped file, got %d bytes", len(uploadedCropped))
+			}
+		})
+	}
+}
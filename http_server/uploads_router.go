@@ -0,0 +1,379 @@
+package http_server
+
+import (
+	"api/auth"
+	"api/core"
+	"api/core/exception"
+	"api/http_server/authenticator"
+	"api/http_server/http_util"
+	"api/http_server/middleware"
+	"api/http_server/middleware/keys"
+	"api/image"
+	"api/storage"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// UploadsPath serves resumable uploads following the tus 1.0 protocol, see https://tus.io/protocols/resumable-upload
+	UploadsPath = "/api/v1/uploads"
+
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,expiration,termination"
+	tusContentType = "application/offset+octet-stream"
+	// imageIdHeader is set once both uploads of an image are complete and the image was created
+	imageIdHeader = "Image-Id"
+)
+
+// tusHeaders are read from or set on responses of resumable uploads, browsers have to be allowed to use them
+var tusHeaders = []string{
+	"Tus-Resumable", "Tus-Version", "Tus-Max-Size", "Tus-Extension",
+	"Upload-Length", "Upload-Metadata", "Upload-Offset", "Upload-Expires", imageIdHeader,
+}
+
+type UploadHandler struct {
+	http_util.RequestHandler
+	uploadsService *core.UploadsService
+	logger         *zerolog.Logger
+	authenticator  authenticator.Authenticator
+	uploadLimits   UploadLimits
+}
+
+func NewUploadHandler(
+	logger *zerolog.Logger,
+	authenticator authenticator.Authenticator,
+	service *core.UploadsService,
+	uploadLimits UploadLimits,
+) *UploadHandler {
+	handler := http_util.NewRequestHandler(logger)
+
+	return &UploadHandler{
+		handler,
+		service,
+		logger,
+		authenticator,
+		uploadLimits,
+	}
+}
+
+// CreateRouter serves the original and the cropped file of an image as two uploads. The upload of the original
+// file carries the name and format metadata, the upload of the cropped file its id as the original metadata.
+func (h UploadHandler) CreateRouter() func(router chi.Router) {
+	isAdmin := middleware.Authorize(h.logger, h.authenticator, auth.RoleAdmin)
+
+	return func(r chi.Router) {
+		r.Use(tusResumable)
+		r.Options("/", h.Handle(h.options))
+		r.With(isAdmin).Post("/", h.Handle(h.createUpload))
+		r.With(isAdmin).Head("/{uploadId}", h.Handle(h.getOffset))
+		r.With(isAdmin).Patch("/{uploadId}", h.Handle(h.appendChunk))
+		r.With(isAdmin).Delete("/{uploadId}", h.Handle(h.terminate))
+	}
+}
+
+// CreateIntentsRouter serves upload intents, which let clients upload both files of an image directly to storage
+func (h UploadHandler) CreateIntentsRouter() func(router chi.Router) {
+	isAdmin := middleware.Authorize(h.logger, h.authenticator, auth.RoleAdmin)
+
+	return func(r chi.Router) {
+		r.With(isAdmin).Post("/", h.Handle(h.createIntent))
+		r.With(isAdmin).Post("/{intentId}/complete", h.Handle(h.completeIntent))
+	}
+}
+
+// tusResumable rejects requests of other protocol versions, only the discovery of the versions is exempt
+func tusResumable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		if req.Method != http.MethodOptions && req.Header.Get("Tus-Resumable") != tusVersion {
+			w.Header().Set("Tus-Version", tusVersion)
+			http_util.WriteJson(w, http.StatusPreconditionFailed, http_util.NewFailureResponse(
+				fmt.Sprintf("Unsupported tus version, expected %s", tusVersion),
+			))
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (h UploadHandler) options(_ context.Context, _ *http.Request) (*http_util.Response, error) {
+	return http_util.NewResponse(nil).
+		WithStatus(http.StatusNoContent).
+		WithHeader("Tus-Version", tusVersion).
+		WithHeader("Tus-Extension", tusExtensions).
+		WithHeader("Tus-Max-Size", strconv.Itoa(maxBodyLimitBytes)), nil
+}
+
+func (h UploadHandler) createUpload(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	length, err := strconv.ParseInt(req.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		return nil, http_util.NewFailureResponse("Upload-Length has to be a positive number of bytes")
+	}
+	if length > maxBodyLimitBytes {
+		return http_util.NewResponse(http_util.NewFailureResponse(
+			fmt.Sprintf("Upload-Length exceeds the maximum of %d bytes", maxBodyLimitBytes),
+		)).WithStatus(http.StatusRequestEntityTooLarge), nil
+	}
+	metadata, err := parseUploadMetadata(req.Header.Get("Upload-Metadata"))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, isCropped := metadata[core.UploadMetadataOriginal]; !isCropped {
+		data := &UploadImageDto{
+			Name:   metadata[core.UploadMetadataName],
+			Format: image.Format(metadata[core.UploadMetadataFormat]),
+		}
+		invalid := exception.InvalidFields{}
+		data.validate(&invalid)
+		if err = invalid.OrNil(); err != nil {
+			return nil, err
+		}
+	}
+
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := h.uploadsService.Create(ctx, authorization, length, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(nil).
+		WithStatus(http.StatusCreated).
+		WithHeader("Location", UploadsPath+"/"+upload.Id).
+		WithHeader("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat)), nil
+}
+
+func (h UploadHandler) getOffset(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := h.uploadsService.GetOne(ctx, authorization, chi.URLParam(req, "uploadId"))
+	if err != nil {
+		return nil, err
+	}
+
+	return uploadResponse(upload, http.StatusOK).
+		WithHeader("Upload-Length", strconv.FormatInt(upload.Length, 10)).
+		WithHeader("Cache-Control", "no-store"), nil
+}
+
+// appendChunk receives the body at the offset of the upload, the image is created by the request completing the
+// second upload of the pair
+func (h UploadHandler) appendChunk(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	if req.Header.Get("Content-Type") != tusContentType {
+		return http_util.NewResponse(http_util.NewFailureResponse(
+			fmt.Sprintf("Content-Type has to be %s", tusContentType),
+		)).WithStatus(http.StatusUnsupportedMediaType), nil
+	}
+	offset, err := strconv.ParseInt(req.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return nil, http_util.NewFailureResponse("Upload-Offset has to be a number of bytes")
+	}
+
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := h.uploadsService.Append(ctx, authorization, chi.URLParam(req, "uploadId"), offset, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !upload.IsComplete() || upload.ImageId != nil {
+		return uploadResponse(upload, http.StatusNoContent), nil
+	}
+
+	img, err := h.createImage(ctx, authorization, upload)
+	if err != nil {
+		return nil, err
+	}
+	if img != nil {
+		upload.ImageId = &img.Id
+	}
+
+	return uploadResponse(upload, http.StatusNoContent), nil
+}
+
+// createImage validates both files of the complete pair like a regular upload before creating the image, nil is
+// returned while the other upload of the pair is incomplete. An invalid pair is deleted, as it can't be resumed.
+func (h UploadHandler) createImage(
+	ctx context.Context, authorization auth.AuthorizationDto, upload storage.Upload,
+) (*storage.Image, error) {
+	original, cropped, isComplete, err := h.uploadsService.GetCompletePair(ctx, upload)
+	if err != nil || !isComplete {
+		return nil, err
+	}
+
+	originalFile := h.uploadsService.Open(ctx, original)
+	defer originalFile.Close()
+	croppedFile := h.uploadsService.Open(ctx, cropped)
+	defer croppedFile.Close()
+
+	format := image.Format(original.Metadata[core.UploadMetadataFormat])
+	invalid := exception.InvalidFields{}
+	_, originalBody, _ := inspectUploadFile(h.uploadLimits, format, originalFileField, originalFile, &invalid)
+	info, croppedBody, isDecoded := inspectUploadFile(h.uploadLimits, format, croppedFileField, croppedFile, &invalid)
+	if isDecoded {
+		validateCropAspectRatio(info, &invalid)
+	}
+	if err = invalid.OrNil(); err != nil {
+		if rejectErr := h.uploadsService.Reject(ctx, original, cropped); rejectErr != nil {
+			return nil, rejectErr
+		}
+		return nil, err
+	}
+
+	img, err := h.uploadsService.CreateImage(
+		ctx,
+		authorization,
+		original,
+		image.Upload{Body: originalBody, Size: original.Length},
+		image.Upload{Body: croppedBody, Size: cropped.Length},
+	)
+	var conflict exception.Conflict
+	if errors.As(err, &conflict) {
+		// the request completing the other upload is creating the image
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &img, nil
+}
+
+func (h UploadHandler) terminate(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.uploadsService.Terminate(ctx, authorization, chi.URLParam(req, "uploadId"))
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(nil).WithStatus(http.StatusNoContent), nil
+}
+
+func (h UploadHandler) createIntent(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	var dto UploadImageDto
+	if err := http_util.ReadJson(req, &dto); err != nil {
+		return nil, err
+	}
+	invalid := exception.InvalidFields{}
+	dto.validate(&invalid)
+	if err := invalid.OrNil(); err != nil {
+		return nil, err
+	}
+
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	intent, err := h.uploadsService.CreateIntent(ctx, authorization, dto.Name, dto.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(intent).WithStatus(http.StatusCreated), nil
+}
+
+// completeIntent creates the image from the files the client uploaded to the signed urls of the intent
+func (h UploadHandler) completeIntent(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	authorization, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	intent, err := h.uploadsService.GetIntent(ctx, authorization, chi.URLParam(req, "intentId"))
+	if err != nil {
+		return nil, err
+	}
+	originalFile, croppedFile, err := h.uploadsService.OpenIntentFiles(ctx, intent)
+	if err != nil {
+		return nil, err
+	}
+	defer originalFile.Body.Close()
+	defer croppedFile.Body.Close()
+
+	// an invalid pair keeps the intent, so the client can upload other files until it expires
+	format := image.Format(intent.Format)
+	invalid := exception.InvalidFields{}
+	_, originalBody, _ := inspectUploadFile(h.uploadLimits, format, originalFileField, originalFile.Body, &invalid)
+	info, croppedBody, isDecoded := inspectUploadFile(
+		h.uploadLimits, format, croppedFileField, croppedFile.Body, &invalid,
+	)
+	if isDecoded {
+		validateCropAspectRatio(info, &invalid)
+	}
+	if err = invalid.OrNil(); err != nil {
+		return nil, err
+	}
+
+	img, err := h.uploadsService.CompleteIntent(
+		ctx,
+		authorization,
+		intent,
+		image.Upload{Body: originalBody, Size: originalFile.Size},
+		image.Upload{Body: croppedBody, Size: croppedFile.Size},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(img).WithStatus(http.StatusCreated), nil
+}
+
+func uploadResponse(upload storage.Upload, status int) *http_util.Response {
+	response := http_util.NewResponse(nil).
+		WithStatus(status).
+		WithHeader("Upload-Offset", strconv.FormatInt(upload.Offset, 10)).
+		WithHeader("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	if upload.ImageId != nil {
+		response.WithHeader(imageIdHeader, *upload.ImageId)
+	}
+
+	return response
+}
+
+// parseUploadMetadata reads comma separated pairs of a key and its base64 encoded value, the value may be omitted
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, http_util.NewFailureResponse("Upload-Metadata has an empty key")
+		}
+		if _, isDuplicate := metadata[key]; isDuplicate {
+			return nil, http_util.NewFailureResponse(fmt.Sprintf("Upload-Metadata has the key %s twice", key))
+		}
+
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, http_util.NewFailureResponse(fmt.Sprintf("Upload-Metadata value of %s is not base64", key))
+		}
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
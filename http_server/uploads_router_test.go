@@ -0,0 +1,57 @@
+package http_server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseUploadMetadata(t *testing.T) {
+	metadata, err := parseUploadMetadata("name bXkgcGxhbmU=, format cG5n,empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata["name"] != "my plane" || metadata["format"] != "png" || len(metadata) != 3 {
+		t.Fatalf("Unexpected metadata %v", metadata)
+	}
+	if value, ok := metadata["empty"]; !ok || value != "" {
+		t.Fatalf("Expected a key without a value, got %v", metadata)
+	}
+
+	invalid := []string{"name not-base64!", "name bXk=,name bXk=", " , format cG5n"}
+	for _, header := range invalid {
+		if _, err = parseUploadMetadata(header); err == nil {
+			t.Errorf("Expected %q to be invalid", header)
+		}
+	}
+}
+
+func TestTusResumable(t *testing.T) {
+	handler := tusResumable(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		method   string
+		version  string
+		expected int
+	}{
+		{method: http.MethodPost, version: tusVersion, expected: http.StatusNoContent},
+		{method: http.MethodPatch, version: "0.2.2", expected: http.StatusPreconditionFailed},
+		{method: http.MethodHead, expected: http.StatusPreconditionFailed},
+		{method: http.MethodOptions, expected: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, UploadsPath, nil)
+		if test.version != "" {
+			req.Header.Set("Tus-Resumable", test.version)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		if res.Code != test.expected || res.Header().Get("Tus-Resumable") != tusVersion {
+			t.Errorf("Expected %s with version %q to respond %d, got %d", test.method, test.version, test.expected, res.Code)
+		}
+	}
+}
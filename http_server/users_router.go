@@ -0,0 +1,105 @@
+package http_server
+
+import (
+	"api/auth"
+	"api/core"
+	"api/http_server/authenticator"
+	"api/http_server/http_util"
+	"api/http_server/middleware"
+	"api/http_server/middleware/keys"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"net/http"
+)
+
+type UserHandler struct {
+	http_util.RequestHandler
+	usersService  *core.UsersService
+	imagesService *core.ImagesService
+	logger        *zerolog.Logger
+	authenticator authenticator.Authenticator
+}
+
+func NewUserHandler(
+	logger *zerolog.Logger,
+	authenticator authenticator.Authenticator,
+	usersService *core.UsersService,
+	imagesService *core.ImagesService,
+) *UserHandler {
+	handler := http_util.NewRequestHandler(logger)
+
+	return &UserHandler{
+		handler,
+		usersService,
+		imagesService,
+		logger,
+		authenticator,
+	}
+}
+
+func (h UserHandler) CreateRouter() func(router chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/{userId}/images", h.Handle(h.fetchUserImages))
+	}
+}
+
+// CreateMeRouter routes the resources of the authorized user, any valid token is accepted
+func (h UserHandler) CreateMeRouter() func(router chi.Router) {
+	isAuthenticated := middleware.Authorize(h.logger, h.authenticator, auth.RoleNone)
+
+	return func(r chi.Router) {
+		r.Use(isAuthenticated)
+		r.Get("/", h.Handle(h.fetchMe))
+		r.Get("/images", h.Handle(h.fetchMyImages))
+	}
+}
+
+func (h UserHandler) fetchUserImages(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	userId := chi.URLParam(req, "userId")
+	pagination, err := paginationFromQuery(req.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	includeTotal := req.URL.Query().Get("includeTotal") == "true"
+
+	imagePage, err := h.imagesService.GetByAuthor(ctx, userId, pagination, includeTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	return imagePageResponse(req.URL, imagePage), nil
+}
+
+func (h UserHandler) fetchMe(ctx context.Context, _ *http.Request) (*http_util.Response, error) {
+	authDto, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := h.usersService.GetProfile(ctx, authDto)
+	if err != nil {
+		return nil, err
+	}
+
+	return http_util.NewResponse(profile), nil
+}
+
+func (h UserHandler) fetchMyImages(ctx context.Context, req *http.Request) (*http_util.Response, error) {
+	authDto, err := auth.ExtractAuthorizationDto(ctx, keys.UserAuthDtoKey)
+	if err != nil {
+		return nil, err
+	}
+	pagination, err := paginationFromQuery(req.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	includeTotal := req.URL.Query().Get("includeTotal") == "true"
+
+	imagePage, err := h.imagesService.GetOwn(ctx, authDto, pagination, includeTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	return imagePageResponse(req.URL, imagePage), nil
+}
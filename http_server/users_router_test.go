@@ -0,0 +1,53 @@
+package http_server
+
+import (
+	"api/auth"
+	"api/core"
+	"api/storage"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type adminTokenAuthenticator struct{}
+
+func (a adminTokenAuthenticator) IsTokenValid(
+	_ context.Context, _ string, _ auth.Role,
+) (isValid bool, claims auth.TokenClaims, err error) {
+	return true, auth.TokenClaims{Username: "admin", Groups: []string{string(auth.RoleAdmin)}}, nil
+}
+
+type syncRecordingAuthenticator struct {
+	auth.Mock
+	synced auth.AuthorizationDto
+}
+
+func (a *syncRecordingAuthenticator) GetOrSyncUser(
+	_ context.Context, authorization auth.AuthorizationDto,
+) (storage.User, error) {
+	a.synced = authorization
+	return storage.User{}, nil
+}
+
+func TestUserHandler_FetchMe_AdminRole(t *testing.T) {
+	logger := zerolog.Nop()
+	recorder := &syncRecordingAuthenticator{}
+	handler := NewUserHandler(&logger, adminTokenAuthenticator{}, core.NewUsersService(recorder), nil)
+	router := chi.NewRouter()
+	router.Route("/me", handler.CreateMeRouter())
+
+	req := httptest.NewRequest("GET", "/me/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, res.Code)
+	}
+	if recorder.synced.Role != auth.RoleAdmin {
+		t.Fatalf("Expected the user to be synced with role %q, got %q", auth.RoleAdmin, recorder.synced.Role)
+	}
+}
@@ -0,0 +1,115 @@
+package image
+
+import (
+	"encoding/binary"
+	"errors"
+	goimage "image"
+	"io"
+)
+
+// avifBrands are the major brands of the ftyp box of AVIF images and sequences
+var avifBrands = []string{"avif", "avis"}
+
+var ErrAvifDecoding = errors.New("avif images can only be inspected, decoding is not supported")
+
+// maxAvifBoxDepth bounds the nesting of boxes followed to the image spatial extents
+const maxAvifBoxDepth = 4
+
+func init() {
+	for _, brand := range avifBrands {
+		goimage.RegisterFormat("avif", "????ftyp"+brand, decodeAvif, decodeAvifConfig)
+	}
+}
+
+// isAvif tells if the head of a file is the ftyp box of an AVIF image
+func isAvif(head []byte) bool {
+	if len(head) < 12 || string(head[4:8]) != "ftyp" {
+		return false
+	}
+	for _, brand := range avifBrands {
+		if string(head[8:12]) == brand {
+			return true
+		}
+	}
+
+	return false
+}
+
+func decodeAvif(_ io.Reader) (goimage.Image, error) {
+	return nil, ErrAvifDecoding
+}
+
+// decodeAvifConfig reads the size of the image from the spatial extents property in meta/iprp/ipco/ispe. Images
+// with a grid or alpha plane have several, the largest one is the size of the image.
+func decodeAvifConfig(r io.Reader) (goimage.Config, error) {
+	config := goimage.Config{}
+	found, err := readAvifBoxes(r, 0, func(width, height int) {
+		if width*height > config.Width*config.Height {
+			config.Width, config.Height = width, height
+		}
+	})
+	if err != nil {
+		return goimage.Config{}, err
+	}
+	if !found {
+		return goimage.Config{}, errors.New("avif image has no spatial extents")
+	}
+
+	return config, nil
+}
+
+// readAvifBoxes walks the boxes of r down to the spatial extents, it returns once the meta box was read
+func readAvifBoxes(r io.Reader, depth int, extents func(width, height int)) (bool, error) {
+	found := false
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return found, nil
+			}
+			return found, err
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:])
+		length := size - 8
+		if size == 1 {
+			if _, err := io.ReadFull(r, header); err != nil {
+				return found, err
+			}
+			length = int64(binary.BigEndian.Uint64(header)) - 16
+		}
+		if size == 0 {
+			length = maxHeaderLength
+		}
+		if length < 0 {
+			return found, errors.New("invalid avif box size")
+		}
+		body := io.LimitReader(r, length)
+
+		switch {
+		case boxType == "ispe":
+			content := make([]byte, 12)
+			if _, err := io.ReadFull(body, content); err != nil {
+				return found, err
+			}
+			extents(int(binary.BigEndian.Uint32(content[4:8])), int(binary.BigEndian.Uint32(content[8:])))
+			found = true
+		case depth < maxAvifBoxDepth && (boxType == "meta" || boxType == "iprp" || boxType == "ipco"):
+			if boxType == "meta" {
+				// meta is a full box, its version and flags precede the children
+				if _, err := io.CopyN(io.Discard, body, 4); err != nil {
+					return found, err
+				}
+			}
+			childFound, err := readAvifBoxes(body, depth+1, extents)
+			found = found || childFound
+			if err != nil || boxType == "meta" {
+				return found, err
+			}
+		}
+
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return found, err
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// xmpContentType is the content type of the mime item holding the xmp of an avif
+const xmpContentType = "application/rdf+xml"
+
+// emptyXmp replaces the xmp of avif files when stripping, padded with spaces like xmp packets are
+var emptyXmp = []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"/>`)
+
+// isoBox is a box of an avif, content excludes the header and shares the memory of the file
+type isoBox struct {
+	kind    string
+	content []byte
+}
+
+// splitBoxes separates the boxes of the data, a box of size 0 runs until its end
+func splitBoxes(data []byte) ([]isoBox, error) {
+	var boxes []isoBox
+	pos, end := uint64(0), uint64(len(data))
+	for pos < end {
+		if pos+8 > end {
+			return nil, errors.New("truncated avif box")
+		}
+		size, header := uint64(binary.BigEndian.Uint32(data[pos:])), uint64(8)
+		switch size {
+		case 0:
+			size = end - pos
+		case 1:
+			if pos+16 > end {
+				return nil, errors.New("truncated avif box")
+			}
+			size, header = binary.BigEndian.Uint64(data[pos+8:]), 16
+		}
+		if size < header || size > end-pos {
+			return nil, fmt.Errorf("invalid size of avif box at %d", pos)
+		}
+		boxes = append(boxes, isoBox{kind: string(data[pos+4 : pos+8]), content: data[pos+header : pos+size]})
+		pos += size
+	}
+
+	return boxes, nil
+}
+
+func findBox(boxes []isoBox, kind string) (isoBox, bool) {
+	for _, box := range boxes {
+		if box.kind == kind {
+			return box, true
+		}
+	}
+
+	return isoBox{}, false
+}
+
+// boxReader reads the big endian fields of a box, the first read past its end sets err
+type boxReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *boxReader) uint(size int) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if size > 8 || r.pos+size > len(r.data) {
+		r.err = errors.New("truncated avif box")
+		return 0
+	}
+
+	var value uint64
+	for _, b := range r.data[r.pos : r.pos+size] {
+		value = value<<8 | uint64(b)
+	}
+	r.pos += size
+
+	return value
+}
+
+func (r *boxReader) string(size int) string {
+	if r.err != nil || r.pos+size > len(r.data) {
+		r.err = errors.New("truncated avif box")
+		return ""
+	}
+	value := string(r.data[r.pos : r.pos+size])
+	r.pos += size
+
+	return value
+}
+
+// terminatedString reads a null terminated string
+func (r *boxReader) terminatedString() string {
+	if r.err != nil {
+		return ""
+	}
+	length := bytes.IndexByte(r.data[r.pos:], 0)
+	if length < 0 {
+		r.err = errors.New("unterminated string in avif box")
+		return ""
+	}
+
+	return r.string(length + 1)[:length]
+}
+
+// avifItem is an item of the meta box, its payload is spread over extents of the file or of the idat box
+type avifItem struct {
+	id          uint64
+	kind        string
+	contentType string
+	extents     [][]byte
+}
+
+func (item avifItem) payload() []byte {
+	return bytes.Join(item.extents, nil)
+}
+
+// write replaces the payload in place with one of the same length
+func (item avifItem) write(payload []byte) {
+	for _, extent := range item.extents {
+		payload = payload[copy(extent, payload):]
+	}
+}
+
+// avifItems reads the items of the meta box that are stored in the file, their extents share its memory
+func avifItems(file []byte) ([]avifItem, error) {
+	boxes, err := splitBoxes(file)
+	if err != nil {
+		return nil, err
+	}
+	meta, ok := findBox(boxes, "meta")
+	if !ok {
+		return nil, nil
+	}
+	if len(meta.content) < 4 {
+		return nil, errors.New("truncated avif meta box")
+	}
+	// meta is a full box, its version and flags precede the children
+	children, err := splitBoxes(meta.content[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	iinf, ok := findBox(children, "iinf")
+	if !ok {
+		return nil, nil
+	}
+	items, err := readItemInfos(iinf.content)
+	if err != nil {
+		return nil, err
+	}
+	iloc, ok := findBox(children, "iloc")
+	if !ok {
+		return nil, errors.New("avif items have no locations")
+	}
+	idat, _ := findBox(children, "idat")
+
+	return items, readItemLocations(iloc.content, file, idat.content, items)
+}
+
+// readItemInfos reads the id and the type of the items, items of infe boxes before version 2 have no type and are
+// left out
+func readItemInfos(iinf []byte) ([]avifItem, error) {
+	r := &boxReader{data: iinf}
+	version := r.uint(1)
+	r.uint(3)
+	if version == 0 {
+		r.uint(2)
+	} else {
+		r.uint(4)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	boxes, err := splitBoxes(iinf[r.pos:])
+	if err != nil {
+		return nil, err
+	}
+
+	var items []avifItem
+	for _, box := range boxes {
+		if box.kind != "infe" {
+			continue
+		}
+		r = &boxReader{data: box.content}
+		version = r.uint(1)
+		r.uint(3)
+		if version < 2 {
+			continue
+		}
+
+		var item avifItem
+		if version == 2 {
+			item.id = r.uint(2)
+		} else {
+			item.id = r.uint(4)
+		}
+		r.uint(2)
+		item.kind = r.string(4)
+		r.terminatedString()
+		if item.kind == "mime" {
+			item.contentType = r.terminatedString()
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// readItemLocations sets the extents of the items stored in the file or in the idat box, items stored elsewhere
+// keep no extent
+func readItemLocations(iloc []byte, file []byte, idat []byte, items []avifItem) error {
+	r := &boxReader{data: iloc}
+	version := r.uint(1)
+	r.uint(3)
+	sizes := r.uint(1)
+	offsetSize, lengthSize := int(sizes>>4), int(sizes&0x0F)
+	sizes = r.uint(1)
+	baseOffsetSize, indexSize := int(sizes>>4), int(sizes&0x0F)
+	if version == 0 {
+		indexSize = 0
+	}
+	idSize := 2
+	if version == 2 {
+		idSize = 4
+	}
+	if indexSize+offsetSize+lengthSize == 0 {
+		return errors.New("avif item locations have no extents")
+	}
+
+	count := r.uint(idSize)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		id := r.uint(idSize)
+		method := uint64(0)
+		if version > 0 {
+			method = r.uint(2) & 0x0F
+		}
+		isStored := r.uint(2) == 0 && method <= 1
+		source := file
+		if method == 1 {
+			source = idat
+		}
+		baseOffset := r.uint(baseOffsetSize)
+
+		var extents [][]byte
+		extentCount := r.uint(2)
+		for j := uint64(0); j < extentCount && r.err == nil; j++ {
+			r.uint(indexSize)
+			offset := baseOffset + r.uint(offsetSize)
+			length := r.uint(lengthSize)
+			if length == 0 || offset < baseOffset || offset > uint64(len(source)) || length > uint64(len(source))-offset {
+				isStored = false
+				continue
+			}
+			extents = append(extents, source[offset:offset+length])
+		}
+
+		for k := range items {
+			if items[k].id == id && isStored {
+				items[k].extents = extents
+			}
+		}
+	}
+
+	return r.err
+}
+
+// processAvif strips the Exif item of the avif in place, as its items are located by their offset in the file. The
+// stripped exif is padded with zeros to the length of the item and the xmp item is blanked when stripping.
+func (processor *MetadataProcessor) processAvif(file []byte) ([]byte, Metadata, error) {
+	processed := append([]byte{}, file...)
+	items, err := avifItems(processed)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	var metadata Metadata
+	isChanged, isExifRead := false, false
+	for _, item := range items {
+		switch {
+		case item.kind == "Exif" && len(item.extents) > 0:
+			// the payload starts with the offset of the tiff header after it. Unreadable and repeated exif is
+			// blanked whole.
+			payload := item.payload()
+			var tiff []byte
+			start, isExifChanged := uint64(0), true
+			if !isExifRead && len(payload) >= 4 && 4+uint64(binary.BigEndian.Uint32(payload)) <= uint64(len(payload)) {
+				start = 4 + uint64(binary.BigEndian.Uint32(payload))
+				tiff, metadata, isExifChanged = processor.processTiff(payload[start:])
+			}
+			isExifRead = true
+			if !isExifChanged {
+				continue
+			}
+
+			region := payload[start:]
+			if len(tiff) > len(region) {
+				return nil, Metadata{}, errors.New("stripped exif no longer fits in its avif item")
+			}
+			copy(region, tiff)
+			for i := len(tiff); i < len(region); i++ {
+				region[i] = 0
+			}
+			item.write(payload)
+			isChanged = true
+		case item.kind == "mime" && item.contentType == xmpContentType && processor.isStripping:
+			blank := bytes.Repeat([]byte(" "), len(item.payload()))
+			if len(blank) >= len(emptyXmp) {
+				copy(blank, emptyXmp)
+			}
+			item.write(blank)
+			isChanged = true
+		}
+	}
+	if !isChanged {
+		return file, metadata, nil
+	}
+
+	return processed, metadata, nil
+}
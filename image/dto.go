@@ -13,6 +13,12 @@ type ResizeRequest struct {
 	Name             string `json:"name"`
 	FilePath         string `json:"filePath"`
 	OriginalFilePath string `json:"originalFilePath"`
+	// Profiles are the sizes to resize to, resizers without profile support use the v1 breakpoints
+	Profiles []Profile `json:"profiles,omitempty"`
+	// Formats are the other formats variants are also produced in, which the response lists in its Formats
+	Formats []Format `json:"formats,omitempty"`
+	// CallbackUrl receives the ResizeCallback of a resize that was requested with RequestResize
+	CallbackUrl string `json:"callbackUrl,omitempty"`
 }
 
 type ResizeResponse struct {
@@ -22,6 +28,14 @@ type ResizeResponse struct {
 	Domain   string `json:"domain"`
 	Path     string `json:"path"`
 	Sizes    Sizes  `json:"sizes"`
+	// Formats lists the variants also produced in the requested other formats
+	Formats VariantFormats `json:"formats,omitempty"`
+}
+
+// ResizeCallback reports the outcome of a requested resize, Error is set when it failed
+type ResizeCallback struct {
+	Resized *ResizeResponse `json:"resized,omitempty"`
+	Error   string          `json:"error,omitempty"`
 }
 
 type RenameRequest struct {
@@ -29,10 +43,18 @@ type RenameRequest struct {
 	NewName string `json:"newName"`
 	Format  Format `json:"format"`
 	SizeMap Sizes  `json:"sizeMap"`
+	// Formats lists the variants that are moved in other formats too
+	Formats VariantFormats `json:"formats,omitempty"`
 }
 
 type DeleteRequest struct {
 	Name       string       `json:"name"`
 	Format     Format       `json:"format"`
 	Dimensions []Dimensions `json:"dimensions"`
+	// Formats are the other formats the variants are also available in
+	Formats []Format `json:"formats,omitempty"`
+}
+
+type DeleteUploadsRequest struct {
+	FileNames []string `json:"fileNames"`
 }
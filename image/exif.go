@@ -0,0 +1,441 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tags of the main ifd of exif
+const (
+	tagImageDescription   = 0x010E
+	tagMake               = 0x010F
+	tagModel              = 0x0110
+	tagStripOffsets       = 0x0111
+	tagOrientation        = 0x0112
+	tagSoftware           = 0x0131
+	tagDateTime           = 0x0132
+	tagArtist             = 0x013B
+	tagTileOffsets        = 0x0144
+	tagSubIfds            = 0x014A
+	tagJpegInterchange    = 0x0201
+	tagCopyright          = 0x8298
+	tagExifIfd            = 0x8769
+	tagGpsIfd             = 0x8825
+	tagCameraSerialNumber = 0xC62F
+)
+
+// Tags of the exif ifd
+const (
+	tagDateTimeOriginal    = 0x9003
+	tagDateTimeDigitized   = 0x9004
+	tagOffsetTime          = 0x9010
+	tagOffsetTimeOriginal  = 0x9011
+	tagOffsetTimeDigitized = 0x9012
+	tagMakerNote           = 0x927C
+	tagSubSecTime          = 0x9290
+	tagSubSecTimeOriginal  = 0x9291
+	tagSubSecTimeDigitized = 0x9292
+	tagInteropIfd          = 0xA005
+	tagCameraOwnerName     = 0xA430
+	tagBodySerialNumber    = 0xA431
+	tagLensSpecification   = 0xA432
+	tagLensMake            = 0xA433
+	tagLensModel           = 0xA434
+	tagLensSerialNumber    = 0xA435
+)
+
+// Tags of the gps ifd
+const (
+	tagGpsLatitudeRef  = 0x0001
+	tagGpsLatitude     = 0x0002
+	tagGpsLongitudeRef = 0x0003
+	tagGpsLongitude    = 0x0004
+	tagGpsAltitudeRef  = 0x0005
+	tagGpsAltitude     = 0x0006
+)
+
+const (
+	tiffAscii    = 2
+	tiffShort    = 3
+	tiffLong     = 4
+	tiffRational = 5
+)
+
+// tiffTypeSizes are the byte sizes of the tiff types by id, entries of other types are dropped
+var tiffTypeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8, 13: 4}
+
+// relocatedTags are never kept when exif is written again. They hold offsets that would point elsewhere once
+// moved, maker notes included as vendors store offsets in them.
+var relocatedTags = []uint16{
+	tagStripOffsets, tagTileOffsets, tagSubIfds, tagJpegInterchange, tagExifIfd, tagGpsIfd, tagMakerNote, tagInteropIfd,
+}
+
+const exifDateLayout = "2006:01:02 15:04:05"
+
+type tiffEntry struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+type tiffIfd []tiffEntry
+
+func (ifd tiffIfd) find(tag uint16) (tiffEntry, bool) {
+	for _, entry := range ifd {
+		if entry.tag == tag {
+			return entry, true
+		}
+	}
+
+	return tiffEntry{}, false
+}
+
+// without returns a copy of the ifd without the tags
+func (ifd tiffIfd) without(tags []uint16) tiffIfd {
+	kept := make(tiffIfd, 0, len(ifd))
+	for _, entry := range ifd {
+		if !containsTag(tags, entry.tag) {
+			kept = append(kept, entry)
+		}
+	}
+
+	return kept
+}
+
+// length is the size of the ifd followed by the values that don't fit in its entries, padded to an even length
+func (ifd tiffIfd) length() uint32 {
+	if len(ifd) == 0 {
+		return 0
+	}
+
+	length := 2 + 12*uint32(len(ifd)) + 4
+	for _, entry := range ifd {
+		if len(entry.value) > 4 {
+			length += uint32(len(entry.value) + len(entry.value)%2)
+		}
+	}
+
+	return length
+}
+
+func containsTag(tags []uint16, tag uint16) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exifData is the content of an exif segment. The thumbnail ifd isn't kept.
+type exifData struct {
+	order binary.ByteOrder
+	main  tiffIfd
+	exif  tiffIfd
+	gps   tiffIfd
+}
+
+func parseExif(payload []byte) (exifData, error) {
+	tiff := payload[len(exifPrefix):]
+	if len(tiff) < 8 {
+		return exifData{}, errors.New("truncated exif header")
+	}
+
+	var data exifData
+	switch string(tiff[:2]) {
+	case "II":
+		data.order = binary.LittleEndian
+	case "MM":
+		data.order = binary.BigEndian
+	default:
+		return exifData{}, errors.New("invalid exif byte order")
+	}
+	if data.order.Uint16(tiff[2:]) != 42 {
+		return exifData{}, errors.New("invalid exif header")
+	}
+
+	var err error
+	data.main, err = readIfd(tiff, data.order, data.order.Uint32(tiff[4:]))
+	if err != nil {
+		return exifData{}, fmt.Errorf("failed reading main ifd: %w", err)
+	}
+	if entry, ok := data.main.find(tagExifIfd); ok {
+		data.exif, err = readIfd(tiff, data.order, entry.uint(data.order))
+		if err != nil {
+			return exifData{}, fmt.Errorf("failed reading exif ifd: %w", err)
+		}
+	}
+	if entry, ok := data.main.find(tagGpsIfd); ok {
+		data.gps, err = readIfd(tiff, data.order, entry.uint(data.order))
+		if err != nil {
+			return exifData{}, fmt.Errorf("failed reading gps ifd: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+func readIfd(tiff []byte, order binary.ByteOrder, offset uint32) (tiffIfd, error) {
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return nil, fmt.Errorf("ifd offset %d out of bounds", offset)
+	}
+	count := uint64(order.Uint16(tiff[offset:]))
+	start := uint64(offset) + 2
+	if start+12*count > uint64(len(tiff)) {
+		return nil, fmt.Errorf("ifd at %d out of bounds", offset)
+	}
+
+	ifd := make(tiffIfd, 0, count)
+	for i := uint64(0); i < count; i++ {
+		raw := tiff[start+12*i : start+12*i+12]
+		entry := tiffEntry{tag: order.Uint16(raw), kind: order.Uint16(raw[2:]), count: order.Uint32(raw[4:])}
+		size, ok := tiffTypeSizes[entry.kind]
+		if !ok {
+			continue
+		}
+
+		length := uint64(size) * uint64(entry.count)
+		if length <= 4 {
+			entry.value = raw[8 : 8+length]
+		} else {
+			valueOffset := uint64(order.Uint32(raw[8:]))
+			if valueOffset+length > uint64(len(tiff)) {
+				return nil, fmt.Errorf("value of tag %#04x out of bounds", entry.tag)
+			}
+			entry.value = tiff[valueOffset : valueOffset+length]
+		}
+		ifd = append(ifd, entry)
+	}
+
+	return ifd, nil
+}
+
+// strip removes the tags of the fields, true is returned when any was found
+func (data *exifData) strip(tags metadataTags) bool {
+	main := data.main.without(tags.main)
+	exif := data.exif.without(tags.exif)
+	stripped := len(main) != len(data.main) || len(exif) != len(data.exif)
+	data.main = main
+	data.exif = exif
+	if tags.gps && len(data.gps) > 0 {
+		data.gps = nil
+		stripped = true
+	}
+
+	return stripped
+}
+
+// setOrientation replaces the orientation, once the pixels were turned upright it is 1
+func (data *exifData) setOrientation(orientation uint16) {
+	value := make([]byte, 2)
+	data.order.PutUint16(value, orientation)
+	data.main = append(data.main.without([]uint16{tagOrientation}), tiffEntry{
+		tag: tagOrientation, kind: tiffShort, count: 1, value: value,
+	})
+}
+
+func (data exifData) isEmpty() bool {
+	return len(data.main.without(relocatedTags)) == 0 && len(data.exif.without(relocatedTags)) == 0 && len(data.gps) == 0
+}
+
+// encode writes the exif segment again, with the main ifd followed by the exif and the gps ifds
+func (data exifData) encode() []byte {
+	main := data.main.without(relocatedTags)
+	exif := data.exif.without(relocatedTags)
+	gps := data.gps
+	// the offsets of the pointers are set once the length of the main ifd is known
+	if len(exif) > 0 {
+		main = append(main, tiffEntry{tag: tagExifIfd, kind: tiffLong, count: 1, value: make([]byte, 4)})
+	}
+	if len(gps) > 0 {
+		main = append(main, tiffEntry{tag: tagGpsIfd, kind: tiffLong, count: 1, value: make([]byte, 4)})
+	}
+
+	mainOffset := uint32(8)
+	exifOffset := mainOffset + main.length()
+	gpsOffset := exifOffset + exif.length()
+	for _, entry := range main {
+		switch entry.tag {
+		case tagExifIfd:
+			data.order.PutUint32(entry.value, exifOffset)
+		case tagGpsIfd:
+			data.order.PutUint32(entry.value, gpsOffset)
+		}
+	}
+
+	tiff := make([]byte, gpsOffset+gps.length())
+	if data.order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	data.order.PutUint16(tiff[2:], 42)
+	data.order.PutUint32(tiff[4:], mainOffset)
+	writeIfd(tiff, data.order, main, mainOffset)
+	writeIfd(tiff, data.order, exif, exifOffset)
+	writeIfd(tiff, data.order, gps, gpsOffset)
+
+	return append(append([]byte{}, exifPrefix...), tiff...)
+}
+
+// writeIfd writes the entries sorted by tag followed by their values, without a next ifd
+func writeIfd(tiff []byte, order binary.ByteOrder, ifd tiffIfd, offset uint32) {
+	if len(ifd) == 0 {
+		return
+	}
+
+	sorted := append(tiffIfd{}, ifd...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].tag < sorted[j].tag
+	})
+
+	order.PutUint16(tiff[offset:], uint16(len(sorted)))
+	valueOffset := offset + 2 + 12*uint32(len(sorted)) + 4
+	for i, entry := range sorted {
+		raw := tiff[offset+2+12*uint32(i):]
+		order.PutUint16(raw, entry.tag)
+		order.PutUint16(raw[2:], entry.kind)
+		order.PutUint32(raw[4:], entry.count)
+		if len(entry.value) <= 4 {
+			copy(raw[8:12], entry.value)
+			continue
+		}
+
+		order.PutUint32(raw[8:], valueOffset)
+		copy(tiff[valueOffset:], entry.value)
+		valueOffset += uint32(len(entry.value) + len(entry.value)%2)
+	}
+}
+
+// metadata reads the fields of the exif
+func (data exifData) metadata() Metadata {
+	metadata := Metadata{
+		Camera:     joinMake(data.main.text(tagMake), data.main.text(tagModel)),
+		Lens:       joinMake(data.exif.text(tagLensMake), data.exif.text(tagLensModel)),
+		Copyright:  data.main.text(tagCopyright),
+		Creator:    data.main.text(tagArtist),
+		Caption:    data.main.text(tagImageDescription),
+		Gps:        data.coordinates(),
+		CapturedAt: parseExifDate(data.exif.text(tagDateTimeOriginal), data.exif.text(tagOffsetTimeOriginal)),
+	}
+	if entry, ok := data.main.find(tagOrientation); ok {
+		metadata.Orientation = int(entry.uint(data.order))
+	}
+	if metadata.CapturedAt == nil {
+		metadata.CapturedAt = parseExifDate(data.main.text(tagDateTime), data.exif.text(tagOffsetTime))
+	}
+
+	return metadata
+}
+
+func (data exifData) coordinates() *Coordinates {
+	latitude, isLatitude := data.gps.degrees(data.order, tagGpsLatitude, tagGpsLatitudeRef, "S")
+	longitude, isLongitude := data.gps.degrees(data.order, tagGpsLongitude, tagGpsLongitudeRef, "W")
+	if !isLatitude || !isLongitude {
+		return nil
+	}
+
+	coordinates := &Coordinates{Latitude: latitude, Longitude: longitude}
+	if entry, ok := data.gps.find(tagGpsAltitude); ok && entry.kind == tiffRational && entry.count > 0 {
+		altitude := entry.rationals(data.order)[0]
+		if ref, ok := data.gps.find(tagGpsAltitudeRef); ok && len(ref.value) > 0 && ref.value[0] == 1 {
+			altitude = -altitude
+		}
+		coordinates.Altitude = &altitude
+	}
+
+	return coordinates
+}
+
+// degrees reads degrees, minutes and seconds, negative towards the reference
+func (ifd tiffIfd) degrees(order binary.ByteOrder, tag uint16, refTag uint16, negativeRef string) (float64, bool) {
+	entry, ok := ifd.find(tag)
+	if !ok || entry.kind != tiffRational || entry.count != 3 {
+		return 0, false
+	}
+
+	parts := entry.rationals(order)
+	degrees := parts[0] + parts[1]/60 + parts[2]/3600
+	if strings.EqualFold(ifd.text(refTag), negativeRef) {
+		degrees = -degrees
+	}
+
+	return degrees, true
+}
+
+// text reads an ascii value up to its terminating null byte
+func (ifd tiffIfd) text(tag uint16) string {
+	entry, ok := ifd.find(tag)
+	if !ok || entry.kind != tiffAscii {
+		return ""
+	}
+
+	value := entry.value
+	if end := bytes.IndexByte(value, 0); end >= 0 {
+		value = value[:end]
+	}
+
+	return strings.TrimSpace(string(value))
+}
+
+func (entry tiffEntry) uint(order binary.ByteOrder) uint32 {
+	switch {
+	case entry.kind == tiffShort && len(entry.value) >= 2:
+		return uint32(order.Uint16(entry.value))
+	case entry.kind == tiffLong && len(entry.value) >= 4:
+		return order.Uint32(entry.value)
+	}
+
+	return 0
+}
+
+func (entry tiffEntry) rationals(order binary.ByteOrder) []float64 {
+	values := make([]float64, 0, entry.count)
+	for i := 0; i+8 <= len(entry.value); i += 8 {
+		numerator := order.Uint32(entry.value[i:])
+		denominator := order.Uint32(entry.value[i+4:])
+		if denominator == 0 {
+			values = append(values, 0)
+			continue
+		}
+		values = append(values, float64(numerator)/float64(denominator))
+	}
+
+	return values
+}
+
+// joinMake prefixes the model with the make, unless the model already names it like Canon EOS R5
+func joinMake(maker string, model string) string {
+	if model == "" {
+		return maker
+	}
+	if maker == "" || strings.HasPrefix(strings.ToLower(model), strings.ToLower(maker)) {
+		return model
+	}
+
+	return maker + " " + model
+}
+
+// parseExifDate reads a date with its utc offset, dates without one are taken as utc
+func parseExifDate(date string, offset string) *time.Time {
+	if date == "" {
+		return nil
+	}
+
+	parsed, err := time.Parse(exifDateLayout+"-07:00", date+offset)
+	if err != nil {
+		parsed, err = time.Parse(exifDateLayout, date)
+	}
+	if err != nil {
+		return nil
+	}
+
+	return &parsed
+}
@@ -6,9 +6,16 @@ const (
 	JpgFormat  Format = "jpg"
 	PngFormat  Format = "png"
 	WebpFormat Format = "webp"
+	AvifFormat Format = "avif"
 )
 
-var SupportedFormats = []Format{JpgFormat, PngFormat, WebpFormat}
+var SupportedFormats = []Format{JpgFormat, PngFormat, WebpFormat, AvifFormat}
+
+// EncodableFormats are the formats images can be encoded to in process, by the local resizer and transformations
+var EncodableFormats = []Format{JpgFormat, PngFormat}
+
+// DecodableFormats are the formats images can be decoded from in process, avif images can only be inspected
+var DecodableFormats = []Format{JpgFormat, PngFormat, WebpFormat}
 
 func (format Format) IsSupported() bool {
 	for _, f := range SupportedFormats {
@@ -20,6 +27,16 @@ func (format Format) IsSupported() bool {
 	return false
 }
 
+func (format Format) IsEncodable() bool {
+	for _, f := range EncodableFormats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (format Format) ToContentType() ContentType {
 	switch format {
 	case JpgFormat:
@@ -28,6 +45,8 @@ func (format Format) ToContentType() ContentType {
 		return PngType
 	case WebpFormat:
 		return WebpType
+	case AvifFormat:
+		return AvifType
 	}
 
 	return ""
@@ -39,4 +58,5 @@ const (
 	JpegType ContentType = "image/jpeg"
 	PngType  ContentType = "image/png"
 	WebpType ContentType = "image/webp"
+	AvifType ContentType = "image/avif"
 )
@@ -0,0 +1,127 @@
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	goimage "image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"net/http"
+
+	_ "golang.org/x/image/webp"
+)
+
+// sniffLength is the number of bytes http.DetectContentType considers
+const sniffLength = 512
+
+// maxHeaderLength bounds the bytes kept while decoding the header, jpg metadata may precede the dimensions
+const maxHeaderLength = 1 << 20
+
+// aspectRatioTolerance allows crops that are a pixel off of the ratio
+const aspectRatioTolerance = 0.01
+
+// MaxDecodedPixels bounds the images decoded in process, it's the default maximum resolution of uploads
+const MaxDecodedPixels = 10000 * 10000
+
+// ErrTooManyPixels is an image whose header claims more than MaxDecodedPixels
+var ErrTooManyPixels = errors.New("image has too many pixels to decode")
+
+// FileInfo is what the content of an uploaded file turned out to be
+type FileInfo struct {
+	ContentType ContentType
+	Width       int
+	Height      int
+}
+
+// Inspect sniffs the content type of the file and decodes only its header for the dimensions. The returned reader
+// replays the inspected bytes followed by the rest of the file, so a stream can still be forwarded whole.
+func Inspect(file io.Reader) (FileInfo, io.Reader, error) {
+	var head bytes.Buffer
+	reader := bufio.NewReaderSize(io.TeeReader(io.LimitReader(file, maxHeaderLength), &head), sniffLength)
+	replay := io.MultiReader(&head, file)
+
+	sniffed, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return FileInfo{}, replay, err
+	}
+
+	info := FileInfo{ContentType: ContentType(http.DetectContentType(sniffed))}
+	if isAvif(sniffed) {
+		info.ContentType = AvifType
+	}
+	if !info.ContentType.IsSupported() {
+		return info, replay, fmt.Errorf("unsupported content type %s", info.ContentType)
+	}
+
+	config, _, err := goimage.DecodeConfig(reader)
+	if err != nil {
+		return info, replay, fmt.Errorf("failed decoding image header: %w", err)
+	}
+	info.Width = config.Width
+	info.Height = config.Height
+
+	return info, replay, nil
+}
+
+// Decode decodes the image once its header shows it has at most MaxDecodedPixels, so a small file claiming huge
+// dimensions can't exhaust the memory
+func Decode(file io.Reader) (goimage.Image, string, error) {
+	var head bytes.Buffer
+	config, _, err := goimage.DecodeConfig(io.TeeReader(io.LimitReader(file, maxHeaderLength), &head))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(config.Width)*int64(config.Height) > MaxDecodedPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrTooManyPixels, config.Width, config.Height)
+	}
+
+	return goimage.Decode(io.MultiReader(&head, file))
+}
+
+func (contentType ContentType) IsSupported() bool {
+	for _, format := range SupportedFormats {
+		if format.ToContentType() == contentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+type AspectRatio struct {
+	Width  int
+	Height int
+}
+
+// AllowedAspectRatios are the ratios cropped images can have
+var AllowedAspectRatios = []AspectRatio{{1, 1}, {3, 2}, {4, 3}, {5, 8}, {16, 9}}
+
+func (ratio AspectRatio) String() string {
+	return fmt.Sprintf("%d:%d", ratio.Width, ratio.Height)
+}
+
+// Matches tells if the dimensions have the ratio, give or take the rounding of a crop
+func (ratio AspectRatio) Matches(width, height int) bool {
+	if width <= 0 || height <= 0 {
+		return false
+	}
+	expected := float64(ratio.Width) / float64(ratio.Height)
+	actual := float64(width) / float64(height)
+
+	return math.Abs(actual-expected)/expected <= aspectRatioTolerance
+}
+
+// HasAllowedAspectRatio tells if the dimensions match one of the AllowedAspectRatios
+func HasAllowedAspectRatio(width, height int) bool {
+	for _, ratio := range AllowedAspectRatios {
+		if ratio.Matches(width, height) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,129 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	goimage "image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	var pngFile bytes.Buffer
+	if err := png.Encode(&pngFile, goimage.NewRGBA(goimage.Rect(0, 0, 320, 180))); err != nil {
+		t.Fatal(err)
+	}
+	content := pngFile.Bytes()
+	info, replay, err := Inspect(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ContentType != PngType || info.Width != 320 || info.Height != 180 {
+		t.Fatalf("Unexpected info %+v", info)
+	}
+	replayed, err := io.ReadAll(replay)
+	if err != nil || !bytes.Equal(replayed, content) {
+		t.Fatalf("Expected the replay to return the whole file, got %d of %d bytes", len(replayed), len(content))
+	}
+
+	var jpegFile bytes.Buffer
+	if err = jpeg.Encode(&jpegFile, goimage.NewRGBA(goimage.Rect(0, 0, 30, 40)), nil); err != nil {
+		t.Fatal(err)
+	}
+	info, _, err = Inspect(&jpegFile)
+	if err != nil || info.ContentType != JpegType || info.Width != 30 || info.Height != 40 {
+		t.Fatalf("Unexpected info %+v and error %v", info, err)
+	}
+
+	info, _, err = Inspect(bytes.NewReader(avifFile(1920, 1080)))
+	if err != nil || info.ContentType != AvifType || info.Width != 1920 || info.Height != 1080 {
+		t.Fatalf("Unexpected info %+v and error %v", info, err)
+	}
+
+	if _, _, err = Inspect(strings.NewReader("GIF89a not really")); err == nil {
+		t.Fatal("Expected unsupported content to fail")
+	}
+	if _, _, err = Inspect(bytes.NewReader([]byte("\x89PNG\r\n\x1a\n"))); err == nil {
+		t.Fatal("Expected a truncated png to fail")
+	}
+}
+
+// box encodes an ISOBMFF box of the type around the content
+func box(boxType string, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(body)+8))
+	copy(header[4:], boxType)
+	return append(header, body...)
+}
+
+// avifFile is the header of an AVIF image with a thumbnail, like encoders write it
+func avifFile(width, height int) []byte {
+	extents := func(width, height int) []byte {
+		content := make([]byte, 12)
+		binary.BigEndian.PutUint32(content[4:], uint32(width))
+		binary.BigEndian.PutUint32(content[8:], uint32(height))
+		return box("ispe", content)
+	}
+
+	return bytes.Join([][]byte{
+		box("ftyp", []byte("avif\x00\x00\x00\x00avifmif1miaf")),
+		box("meta",
+			[]byte{0, 0, 0, 0},
+			box("hdlr", make([]byte, 24)),
+			box("iprp", box("ipco", extents(160, 90), box("pixi", make([]byte, 8)), extents(width, height))),
+		),
+		box("mdat", make([]byte, 64)),
+	}, nil)
+}
+
+// withPngDimensions rewrites the header of the png to claim other dimensions
+func withPngDimensions(t *testing.T, content []byte, width, height uint32) []byte {
+	// the IHDR chunk follows the signature, its data starts with the width and the height
+	if string(content[12:16]) != "IHDR" {
+		t.Fatal("Expected the png to start with IHDR")
+	}
+	claimed := append([]byte{}, content...)
+	binary.BigEndian.PutUint32(claimed[16:20], width)
+	binary.BigEndian.PutUint32(claimed[20:24], height)
+	binary.BigEndian.PutUint32(claimed[29:33], crc32.ChecksumIEEE(claimed[12:29]))
+	return claimed
+}
+
+func TestDecode(t *testing.T) {
+	var pngFile bytes.Buffer
+	if err := png.Encode(&pngFile, goimage.NewRGBA(goimage.Rect(0, 0, 32, 18))); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, format, err := Decode(bytes.NewReader(pngFile.Bytes()))
+	if err != nil || format != "png" || decoded.Bounds().Dx() != 32 {
+		t.Fatalf("Expected the png to be decoded, got %s and error %v", format, err)
+	}
+
+	_, _, err = Decode(bytes.NewReader(withPngDimensions(t, pngFile.Bytes(), 100000, 100000)))
+	if !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("Expected a png claiming 100000x100000 not to be decoded, got %v", err)
+	}
+}
+
+func TestHasAllowedAspectRatio(t *testing.T) {
+	allowed := [][2]int{{500, 500}, {1200, 800}, {1024, 768}, {500, 800}, {1920, 1080}, {1921, 1080}}
+	for _, dimensions := range allowed {
+		if !HasAllowedAspectRatio(dimensions[0], dimensions[1]) {
+			t.Errorf("Expected %dx%d to be allowed", dimensions[0], dimensions[1])
+		}
+	}
+
+	disallowed := [][2]int{{800, 500}, {1000, 500}, {0, 0}, {300, 1000}}
+	for _, dimensions := range disallowed {
+		if HasAllowedAspectRatio(dimensions[0], dimensions[1]) {
+			t.Errorf("Expected %dx%d to be disallowed", dimensions[0], dimensions[1])
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Photoshop resources of the APP13 segment
+const (
+	resourceIptc       = 0x0404
+	resourceIptcDigest = 0x0425
+)
+
+// Datasets of the application record of iptc
+const (
+	iptcRecordApplication = 2
+	iptcDateCreated       = 55
+	iptcTimeCreated       = 60
+	iptcDigitalDate       = 62
+	iptcDigitalTime       = 63
+	iptcByline            = 80
+	iptcCity              = 90
+	iptcSublocation       = 92
+	iptcProvince          = 95
+	iptcCountryCode       = 100
+	iptcCountry           = 101
+	iptcHeadline          = 105
+	iptcCopyright         = 116
+	iptcCaption           = 120
+)
+
+var photoshopSignature = []byte("8BIM")
+
+type photoshopResource struct {
+	id uint16
+	// name is the padded pascal string as it was read
+	name []byte
+	data []byte
+}
+
+type iptcDataset struct {
+	record  byte
+	dataset byte
+	value   []byte
+}
+
+// photoshopData is the content of an APP13 segment, with the datasets of its iptc resource
+type photoshopData struct {
+	resources []photoshopResource
+	iptc      []iptcDataset
+}
+
+func parsePhotoshop(payload []byte) (photoshopData, error) {
+	var data photoshopData
+	rest := payload[len(photoshopPrefix):]
+	for len(rest) > 0 {
+		if len(rest) < 7 || !bytes.Equal(rest[:4], photoshopSignature) {
+			return photoshopData{}, errors.New("invalid photoshop resource")
+		}
+		resource := photoshopResource{id: binary.BigEndian.Uint16(rest[4:])}
+		nameLength := 1 + int(rest[6])
+		nameLength += nameLength % 2
+		if len(rest) < 6+nameLength+4 {
+			return photoshopData{}, errors.New("truncated photoshop resource")
+		}
+		resource.name = rest[6 : 6+nameLength]
+		rest = rest[6+nameLength:]
+
+		size := int(binary.BigEndian.Uint32(rest))
+		if size < 0 || 4+size > len(rest) {
+			return photoshopData{}, fmt.Errorf("photoshop resource %#04x out of bounds", resource.id)
+		}
+		resource.data = rest[4 : 4+size]
+		rest = rest[4+size:]
+		if size%2 == 1 && len(rest) > 0 {
+			rest = rest[1:]
+		}
+
+		if resource.id == resourceIptc {
+			iptc, err := parseIptc(resource.data)
+			if err != nil {
+				return photoshopData{}, err
+			}
+			data.iptc = iptc
+		}
+		data.resources = append(data.resources, resource)
+	}
+
+	return data, nil
+}
+
+func parseIptc(data []byte) ([]iptcDataset, error) {
+	var datasets []iptcDataset
+	for len(data) > 0 {
+		if data[0] != 0x1C {
+			// trailing padding
+			break
+		}
+		if len(data) < 5 {
+			return nil, errors.New("truncated iptc dataset")
+		}
+		size := int(binary.BigEndian.Uint16(data[3:]))
+		if size&0x8000 != 0 {
+			return nil, errors.New("extended iptc datasets are not supported")
+		}
+		if 5+size > len(data) {
+			return nil, errors.New("iptc dataset out of bounds")
+		}
+		datasets = append(datasets, iptcDataset{record: data[1], dataset: data[2], value: data[5 : 5+size]})
+		data = data[5+size:]
+	}
+
+	return datasets, nil
+}
+
+// strip removes the application datasets, true is returned when any was found. The digest of the iptc would no
+// longer match, it is removed along.
+func (data *photoshopData) strip(datasets []byte) bool {
+	kept := make([]iptcDataset, 0, len(data.iptc))
+	for _, dataset := range data.iptc {
+		if dataset.record != iptcRecordApplication || bytes.IndexByte(datasets, dataset.dataset) < 0 {
+			kept = append(kept, dataset)
+		}
+	}
+	if len(kept) == len(data.iptc) {
+		return false
+	}
+
+	data.iptc = kept
+	resources := make([]photoshopResource, 0, len(data.resources))
+	for _, resource := range data.resources {
+		if resource.id != resourceIptcDigest {
+			resources = append(resources, resource)
+		}
+	}
+	data.resources = resources
+
+	return true
+}
+
+// encode writes the APP13 segment again with the current iptc datasets
+func (data photoshopData) encode() []byte {
+	var iptc []byte
+	for _, dataset := range data.iptc {
+		iptc = append(iptc, 0x1C, dataset.record, dataset.dataset)
+		iptc = binary.BigEndian.AppendUint16(iptc, uint16(len(dataset.value)))
+		iptc = append(iptc, dataset.value...)
+	}
+
+	payload := append([]byte{}, photoshopPrefix...)
+	for _, resource := range data.resources {
+		value := resource.data
+		if resource.id == resourceIptc {
+			value = iptc
+		}
+		payload = append(payload, photoshopSignature...)
+		payload = binary.BigEndian.AppendUint16(payload, resource.id)
+		payload = append(payload, resource.name...)
+		payload = binary.BigEndian.AppendUint32(payload, uint32(len(value)))
+		payload = append(payload, value...)
+		if len(value)%2 == 1 {
+			payload = append(payload, 0)
+		}
+	}
+
+	return payload
+}
+
+func (data photoshopData) text(dataset byte) string {
+	for _, d := range data.iptc {
+		if d.record == iptcRecordApplication && d.dataset == dataset {
+			return strings.TrimSpace(string(d.value))
+		}
+	}
+
+	return ""
+}
+
+// metadata reads the fields of the iptc, the capture date is in the CCYYMMDD format followed by a HHMMSS±HHMM time
+func (data photoshopData) metadata() Metadata {
+	metadata := Metadata{
+		Copyright: data.text(iptcCopyright),
+		Creator:   data.text(iptcByline),
+		Caption:   data.text(iptcCaption),
+	}
+	if date := data.text(iptcDateCreated); date != "" {
+		captured, err := time.Parse("20060102150405-0700", date+data.text(iptcTimeCreated))
+		if err != nil {
+			captured, err = time.Parse("20060102", date)
+		}
+		if err == nil {
+			metadata.CapturedAt = &captured
+		}
+	}
+
+	return metadata
+}
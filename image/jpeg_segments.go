@@ -0,0 +1,108 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	markerSoi   = 0xD8
+	markerEoi   = 0xD9
+	markerSos   = 0xDA
+	markerApp0  = 0xE0
+	markerApp1  = 0xE1
+	markerApp13 = 0xED
+	markerApp15 = 0xEF
+	markerCom   = 0xFE
+)
+
+// maxSegmentLength is the most data a segment holds, its length field counts itself
+const maxSegmentLength = 0xFFFF - 2
+
+var (
+	exifPrefix        = []byte("Exif\x00\x00")
+	xmpPrefix         = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	extendedXmpPrefix = []byte("http://ns.adobe.com/xmp/extension/\x00")
+	photoshopPrefix   = []byte("Photoshop 3.0\x00")
+)
+
+// jpegSegment is a marker segment of the header of a jpg, data excludes the marker and the length
+type jpegSegment struct {
+	marker byte
+	data   []byte
+}
+
+func (segment jpegSegment) isApp() bool {
+	return segment.marker >= markerApp0 && segment.marker <= markerApp15 || segment.marker == markerCom
+}
+
+func (segment jpegSegment) isExif() bool {
+	return segment.marker == markerApp1 && bytes.HasPrefix(segment.data, exifPrefix)
+}
+
+func (segment jpegSegment) isXmp() bool {
+	return segment.marker == markerApp1 &&
+		(bytes.HasPrefix(segment.data, xmpPrefix) || bytes.HasPrefix(segment.data, extendedXmpPrefix))
+}
+
+func (segment jpegSegment) isPhotoshop() bool {
+	return segment.marker == markerApp13 && bytes.HasPrefix(segment.data, photoshopPrefix)
+}
+
+// splitJpeg separates the segments of the header of the jpg from the scan, which starts at the first SOS marker
+// and runs until the end of the file
+func splitJpeg(file []byte) ([]jpegSegment, []byte, error) {
+	if len(file) < 4 || file[0] != 0xFF || file[1] != markerSoi {
+		return nil, nil, errors.New("not a jpg file")
+	}
+
+	var segments []jpegSegment
+	pos := 2
+	for {
+		if pos+2 > len(file) || file[pos] != 0xFF {
+			return nil, nil, fmt.Errorf("invalid jpg marker at %d", pos)
+		}
+		marker := file[pos+1]
+		if marker == 0xFF {
+			// fill byte preceding a marker
+			pos++
+			continue
+		}
+		if marker == markerSos || marker == markerEoi {
+			return segments, file[pos:], nil
+		}
+		if pos+4 > len(file) {
+			return nil, nil, errors.New("truncated jpg header")
+		}
+
+		length := int(binary.BigEndian.Uint16(file[pos+2:]))
+		if length < 2 || pos+2+length > len(file) {
+			return nil, nil, fmt.Errorf("invalid length of jpg segment at %d", pos)
+		}
+		segments = append(segments, jpegSegment{marker: marker, data: file[pos+4 : pos+2+length]})
+		pos += 2 + length
+	}
+}
+
+// joinJpeg writes the segments followed by the scan back into a jpg file
+func joinJpeg(segments []jpegSegment, scan []byte) ([]byte, error) {
+	size := 2 + len(scan)
+	for _, segment := range segments {
+		size += 4 + len(segment.data)
+	}
+
+	file := make([]byte, 0, size)
+	file = append(file, 0xFF, markerSoi)
+	for _, segment := range segments {
+		if len(segment.data) > maxSegmentLength {
+			return nil, fmt.Errorf("jpg segment of %d bytes is too long", len(segment.data))
+		}
+		file = append(file, 0xFF, segment.marker)
+		file = binary.BigEndian.AppendUint16(file, uint16(len(segment.data)+2))
+		file = append(file, segment.data...)
+	}
+
+	return append(file, scan...), nil
+}
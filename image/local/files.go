@@ -0,0 +1,97 @@
+package local
+
+import (
+	"api/image"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+)
+
+// fileNames lists the stored original followed by the variant of each of the dimensions, in the format of the image
+// and then in each of the other formats
+func fileNames(name string, format image.Format, dimensions []image.Dimensions, others []image.Format) []string {
+	names := []string{originalName(name, format)}
+	for _, d := range dimensions {
+		names = append(names, variantName(name, d, format))
+	}
+	for _, other := range others {
+		for _, d := range dimensions {
+			names = append(names, variantName(name, d, other))
+		}
+	}
+
+	return names
+}
+
+// Rename copies the original and every variant to the new name before the previous files are removed, copies are
+// removed again when a copy fails
+func (resizer *Resizer) Rename(
+	ctx context.Context,
+	_ string,
+	request image.RenameRequest,
+) (image.ResizeResponse, error) {
+	dimensions := request.SizeMap.GetAllDimensions()[1:]
+	from := fileNames(request.Name, request.Format, dimensions, nil)
+	to := fileNames(request.NewName, request.Format, dimensions, nil)
+	for _, format := range request.Formats.Others() {
+		for _, variant := range request.Formats[format] {
+			d := request.SizeMap.Variants[variant]
+			from = append(from, variantName(request.Name, d, format))
+			to = append(to, variantName(request.NewName, d, format))
+		}
+	}
+
+	for i := range from {
+		if err := resizer.copy(ctx, from[i], to[i]); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				if undoErr := resizer.store.Delete(ctx, to[j]); undoErr != nil {
+					resizer.logger.Error().Err(undoErr).Str("file", to[j]).Msg("failed removing copied file")
+				}
+			}
+			return image.ResizeResponse{}, err
+		}
+	}
+	for _, fileName := range from {
+		if err := resizer.store.Delete(ctx, fileName); err != nil {
+			resizer.logger.Warn().Err(err).Str("file", fileName).Msg("failed removing renamed file")
+		}
+	}
+
+	return image.ResizeResponse{
+		Format:   request.Format,
+		Original: to[0],
+		Name:     request.NewName,
+		Domain:   resizer.domain,
+		Path:     imagesDirectory,
+		Sizes:    request.SizeMap,
+		Formats:  request.Formats,
+	}, nil
+}
+
+func (resizer *Resizer) Delete(ctx context.Context, _ string, request image.DeleteRequest) error {
+	var errs []error
+	for _, fileName := range fileNames(request.Name, request.Format, request.Dimensions, request.Formats) {
+		if err := resizer.store.Delete(ctx, fileName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (resizer *Resizer) copy(ctx context.Context, from, to string) error {
+	object, err := resizer.store.Get(ctx, from)
+	if err != nil {
+		return fmt.Errorf("failed reading %s: %w", from, err)
+	}
+	defer object.Body.Close()
+
+	contentType := object.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(to))
+	}
+
+	return resizer.store.Put(ctx, to, object.Body, object.Size, contentType)
+}
@@ -0,0 +1,54 @@
+package local
+
+import (
+	"api/image"
+	"context"
+	"fmt"
+	goimage "image"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+// dHashWidth compares each of the 8 columns of a row with its right neighbour, 8 rows make the 64 bits
+const (
+	dHashWidth  = 9
+	dHashHeight = 8
+)
+
+// PerceptualHasher implements image.PerceptualHasher in process with a difference hash. Scaling the image down to
+// 9x8 gray pixels drops what compression, resizing and small edits change, so near duplicates share most bits.
+type PerceptualHasher struct{}
+
+func NewPerceptualHasher() *PerceptualHasher {
+	return &PerceptualHasher{}
+}
+
+// Hash sets a bit for every pixel that is brighter than its right neighbour, transparent areas are composited over
+// white
+func (hasher *PerceptualHasher) Hash(ctx context.Context, r io.Reader) (image.PerceptualHash, error) {
+	decoded, _, err := goimage.Decode(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed decoding image: %w", err)
+	}
+	if err = ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	thumbnail := scaleWithin(decoded, thumbnailSide)
+	gray := goimage.NewGray(goimage.Rect(0, 0, dHashWidth, dHashHeight))
+	draw.Draw(gray, gray.Bounds(), goimage.White, goimage.Point{}, draw.Src)
+	draw.ApproxBiLinear.Scale(gray, gray.Bounds(), thumbnail, thumbnail.Bounds(), draw.Over, nil)
+
+	var hash image.PerceptualHash
+	for y := 0; y < dHashHeight; y++ {
+		for x := 0; x < dHashWidth-1; x++ {
+			hash <<= 1
+			if gray.GrayAt(x, y).Y > gray.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash, nil
+}
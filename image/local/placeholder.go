@@ -0,0 +1,105 @@
+package local
+
+import (
+	"api/image"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	goimage "image"
+	"image/jpeg"
+	"io"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// thumbnailSide bounds the image the placeholder is computed from, a larger one wouldn't change the result
+	thumbnailSide = 32
+	// lqipSide bounds the embedded image, browsers scale it up blurred
+	lqipSide    = 16
+	lqipQuality = 40
+)
+
+// PlaceholderGenerator implements image.PlaceholderGenerator in process
+type PlaceholderGenerator struct{}
+
+func NewPlaceholderGenerator() *PlaceholderGenerator {
+	return &PlaceholderGenerator{}
+}
+
+// Generate computes the placeholder from a thumbnail of the image, transparent areas are white
+func (generator *PlaceholderGenerator) Generate(ctx context.Context, r io.Reader) (image.Placeholder, error) {
+	decoded, _, err := goimage.Decode(r)
+	if err != nil {
+		return image.Placeholder{}, fmt.Errorf("failed decoding image: %w", err)
+	}
+	if err = ctx.Err(); err != nil {
+		return image.Placeholder{}, err
+	}
+
+	thumbnail := scaleWithin(decoded, thumbnailSide)
+	xComponents, yComponents := 4, 3
+	if thumbnail.Bounds().Dy() > thumbnail.Bounds().Dx() {
+		xComponents, yComponents = 3, 4
+	}
+
+	var lqip bytes.Buffer
+	err = jpeg.Encode(&lqip, scaleWithin(thumbnail, lqipSide), &jpeg.Options{Quality: lqipQuality})
+	if err != nil {
+		return image.Placeholder{}, fmt.Errorf("failed encoding lqip: %w", err)
+	}
+
+	return image.Placeholder{
+		BlurHash:      encodeBlurHash(thumbnail, xComponents, yComponents),
+		Lqip:          "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(lqip.Bytes()),
+		DominantColor: dominantColor(thumbnail),
+	}, nil
+}
+
+// scaleWithin draws the image on white, scaled down to fit a square of the side
+func scaleWithin(img goimage.Image, side int) *goimage.RGBA {
+	bounds := img.Bounds()
+	scale := math.Min(1, math.Min(float64(side)/float64(bounds.Dx()), float64(side)/float64(bounds.Dy())))
+	width := int(math.Max(1, math.Round(float64(bounds.Dx())*scale)))
+	height := int(math.Max(1, math.Round(float64(bounds.Dy())*scale)))
+
+	scaled := goimage.NewRGBA(goimage.Rect(0, 0, width, height))
+	draw.Draw(scaled, scaled.Bounds(), goimage.White, goimage.Point{}, draw.Src)
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Over, nil)
+
+	return scaled
+}
+
+// dominantColor averages the pixels of the most common color, colors are grouped by their 4 high bits
+func dominantColor(img *goimage.RGBA) string {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[int]*bucket)
+	var dominant *bucket
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel := img.RGBAAt(x, y)
+			key := int(pixel.R>>4)<<8 | int(pixel.G>>4)<<4 | int(pixel.B>>4)
+			b, ok := buckets[key]
+			if !ok {
+				b = &bucket{}
+				buckets[key] = b
+			}
+			b.count++
+			b.r += int(pixel.R)
+			b.g += int(pixel.G)
+			b.b += int(pixel.B)
+			if dominant == nil || b.count > dominant.count {
+				dominant = b
+			}
+		}
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", dominant.r/dominant.count, dominant.g/dominant.count, dominant.b/dominant.count)
+}
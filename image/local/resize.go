@@ -0,0 +1,182 @@
+package local
+
+import (
+	"api/image"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	goimage "image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// originalName is the name of the stored original, variants are stored next to it
+func originalName(name string, format image.Format) string {
+	return fmt.Sprintf("%s/%s.%s", imagesDirectory, name, format)
+}
+
+func variantName(name string, dimensions image.Dimensions, format image.Format) string {
+	return fmt.Sprintf("%s/%s_%dx%d.%s", imagesDirectory, name, dimensions.Width, dimensions.Height, format)
+}
+
+// RequestResize is not supported, images are resized in process without a callback
+func (resizer *Resizer) RequestResize(_ context.Context, _ string, _ image.ResizeRequest) error {
+	return image.ErrCallbackUnsupported
+}
+
+// Resize stores the original and resizes the cropped file to every size profile. WebP can only be decoded, so WebP
+// uploads are stored as png, which is reflected in the format of the response.
+func (resizer *Resizer) Resize(
+	ctx context.Context,
+	_ string,
+	request image.ResizeRequest,
+) (image.ResizeResponse, error) {
+	original, decodedFormat, err := resizer.decode(ctx, request.OriginalFilePath)
+	if err != nil {
+		return image.ResizeResponse{}, err
+	}
+	cropped, _, err := resizer.decode(ctx, request.FilePath)
+	if err != nil {
+		return image.ResizeResponse{}, err
+	}
+
+	format := image.PngFormat
+	if decodedFormat == "jpeg" {
+		format = image.JpgFormat
+	}
+
+	stored := originalName(request.Name, format)
+	if err = resizer.encode(ctx, stored, original, format, image.DefaultQuality); err != nil {
+		return image.ResizeResponse{}, fmt.Errorf("failed storing original: %w", err)
+	}
+
+	bounds := original.Bounds()
+	sizes := image.Sizes{
+		Original: image.Dimensions{Width: bounds.Dx(), Height: bounds.Dy()},
+		Variants: make(map[string]image.Dimensions),
+	}
+	var formats image.VariantFormats
+
+	croppedBounds := cropped.Bounds()
+	for _, profile := range resizer.profiles {
+		dimensions, ok := profile.Dimensions(croppedBounds.Dx(), croppedBounds.Dy())
+		if !ok {
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			return image.ResizeResponse{}, err
+		}
+
+		source := croppedBounds
+		if profile.Fit == image.FitCover {
+			source = coverSource(croppedBounds, dimensions)
+		}
+		variant := goimage.NewRGBA(goimage.Rect(0, 0, dimensions.Width, dimensions.Height))
+		draw.CatmullRom.Scale(variant, variant.Bounds(), cropped, source, draw.Src, nil)
+
+		name := variantName(request.Name, dimensions, format)
+		if err = resizer.encode(ctx, name, variant, format, profile.Quality); err != nil {
+			return image.ResizeResponse{}, fmt.Errorf("failed storing variant %s: %w", profile.Name, err)
+		}
+		sizes.Variants[profile.Name] = dimensions
+
+		for _, other := range resizer.otherFormats(format) {
+			name = variantName(request.Name, dimensions, other)
+			if err = resizer.encode(ctx, name, variant, other, profile.Quality); err != nil {
+				return image.ResizeResponse{}, fmt.Errorf("failed storing %s variant %s: %w", other, profile.Name, err)
+			}
+			if formats == nil {
+				formats = make(image.VariantFormats)
+			}
+			formats[other] = append(formats[other], profile.Name)
+		}
+	}
+
+	if err = resizer.store.Delete(ctx, request.FilePath); err != nil {
+		resizer.logger.Warn().Err(err).Msg("failed removing resized upload")
+	}
+	if err = resizer.store.Delete(ctx, request.OriginalFilePath); err != nil {
+		resizer.logger.Warn().Err(err).Msg("failed removing original upload")
+	}
+
+	return image.ResizeResponse{
+		Format:   format,
+		Original: stored,
+		Name:     request.Name,
+		Domain:   resizer.domain,
+		Path:     imagesDirectory,
+		Sizes:    sizes,
+		Formats:  formats,
+	}, nil
+}
+
+// otherFormats are the configured formats variants are produced in besides the format
+func (resizer *Resizer) otherFormats(format image.Format) []image.Format {
+	formats := make([]image.Format, 0, len(resizer.formats))
+	for _, other := range resizer.formats {
+		if other != format {
+			formats = append(formats, other)
+		}
+	}
+
+	return formats
+}
+
+func (resizer *Resizer) decode(ctx context.Context, name string) (goimage.Image, string, error) {
+	object, err := resizer.store.Get(ctx, name)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed reading upload: %w", err)
+	}
+	defer object.Body.Close()
+
+	decoded, format, err := image.Decode(object.Body)
+	if err != nil {
+		return nil, "", &image.BadRequest{
+			RequestError: image.RequestError{Url: name, Message: "failed decoding upload", Err: err},
+		}
+	}
+
+	return decoded, format, nil
+}
+
+// coverSource is the centered part of the bounds with the aspect ratio of the dimensions
+func coverSource(bounds goimage.Rectangle, dimensions image.Dimensions) goimage.Rectangle {
+	width, height := bounds.Dx(), bounds.Dy()
+	if width*dimensions.Height > height*dimensions.Width {
+		width = int(math.Round(float64(height) * float64(dimensions.Width) / float64(dimensions.Height)))
+	} else {
+		height = int(math.Round(float64(width) * float64(dimensions.Height) / float64(dimensions.Width)))
+	}
+	minX := bounds.Min.X + (bounds.Dx()-width)/2
+	minY := bounds.Min.Y + (bounds.Dy()-height)/2
+
+	return goimage.Rect(minX, minY, minX+width, minY+height)
+}
+
+func (resizer *Resizer) encode(
+	ctx context.Context, name string, img goimage.Image, format image.Format, quality int,
+) error {
+	var buf bytes.Buffer
+	if err := encodeTo(&buf, img, format, quality); err != nil {
+		return err
+	}
+
+	return resizer.store.Put(ctx, name, &buf, int64(buf.Len()), string(format.ToContentType()))
+}
+
+func encodeTo(w io.Writer, img goimage.Image, format image.Format, quality int) error {
+	switch format {
+	case image.JpgFormat:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case image.PngFormat:
+		return png.Encode(w, img)
+	}
+
+	return errors.New("unsupported output format " + string(format))
+}
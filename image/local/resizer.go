@@ -0,0 +1,87 @@
+package local
+
+import (
+	"api/core"
+	"api/image"
+	"api/objectstore"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	uploadsDirectory = "uploads"
+	imagesDirectory  = "images"
+	// uploadUrlExpiry is how long signed upload urls are valid for
+	uploadUrlExpiry = 15 * time.Minute
+)
+
+// Resizer implements image.Resizer in process, files are kept in the object store instead of the resize api.
+// Signed urls are presigned upload urls of the store, file names are the keys they upload to.
+type Resizer struct {
+	store    objectstore.ObjectStore
+	domain   string
+	profiles []image.Profile
+	formats  []image.Format
+	logger   *zerolog.Logger
+}
+
+func NewResizer(config core.Config, store objectstore.ObjectStore, logger *zerolog.Logger) *Resizer {
+	return &Resizer{
+		store:    store,
+		domain:   config.ImagesLocalDomain,
+		profiles: config.SizeProfiles,
+		formats:  config.VariantFormats,
+		logger:   logger,
+	}
+}
+
+func (resizer *Resizer) FetchSignedUrl(
+	ctx context.Context, _ string, format image.Format,
+) (image.SignedResponse, error) {
+	fileName := fmt.Sprintf("%s/%s.%s", uploadsDirectory, uuid.NewString(), format)
+
+	signedUrl, err := resizer.store.Presign(ctx, http.MethodPut, fileName, uploadUrlExpiry)
+	if err != nil {
+		return image.SignedResponse{}, fmt.Errorf("failed presigning upload: %w", err)
+	}
+
+	return image.SignedResponse{SignedUrl: signedUrl, FileName: fileName}, nil
+}
+
+// UploadFile puts the file to the store under the file name the url was signed for
+func (resizer *Resizer) UploadFile(
+	ctx context.Context,
+	signed image.SignedResponse,
+	format image.Format,
+	body io.Reader,
+	size int64,
+) error {
+	if err := resizer.store.Put(ctx, signed.FileName, body, size, string(format.ToContentType())); err != nil {
+		return fmt.Errorf("failed uploading %s: %w", signed.FileName, err)
+	}
+
+	return nil
+}
+
+func (resizer *Resizer) DeleteUploads(ctx context.Context, _ string, request image.DeleteUploadsRequest) error {
+	var errs []error
+	for _, fileName := range request.FileNames {
+		if err := resizer.store.Delete(ctx, fileName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Invalidate does nothing as local files are not cached by a CDN
+func (resizer *Resizer) Invalidate(_ context.Context, _ string, request image.DeleteRequest) error {
+	resizer.logger.Debug().Str("name", request.Name).Msg("skipping invalidation of local files")
+	return nil
+}
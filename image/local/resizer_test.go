@@ -0,0 +1,400 @@
+package local
+
+import (
+	"api/core"
+	"api/image"
+	"api/objectstore"
+	"api/objectstore/filesystem"
+	"bytes"
+	"context"
+	goimage "image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func newTestResizer(t *testing.T) *Resizer {
+	logger := zerolog.Nop()
+	signer := objectstore.NewSigner([]byte("secret"), "http://localhost:3000")
+	store, err := filesystem.NewStore(t.TempDir(), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewResizer(core.Config{SizeProfiles: image.DefaultProfiles}, store, &logger)
+}
+
+func writeUpload(t *testing.T, resizer *Resizer, width, height int) string {
+	signed, err := resizer.FetchSignedUrl(context.Background(), "", image.PngFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := goimage.NewRGBA(goimage.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{R: 200, A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	err = resizer.store.Put(context.Background(), signed.FileName, &buf, int64(buf.Len()), string(image.PngType))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed.FileName
+}
+
+func assertExists(t *testing.T, resizer *Resizer, name string, expected bool) {
+	object, err := resizer.store.Get(context.Background(), name)
+	if err == nil {
+		_ = object.Body.Close()
+	}
+	if exists := err == nil; exists != expected {
+		t.Fatalf("Expected %s to exist: %v, got %v", name, expected, err)
+	}
+}
+
+func TestResizer_ResizeRenameDelete(t *testing.T) {
+	ctx := context.Background()
+	resizer := newTestResizer(t)
+	original := writeUpload(t, resizer, 1600, 1200)
+	cropped := writeUpload(t, resizer, 1200, 800)
+
+	res, err := resizer.Resize(ctx, "", image.ResizeRequest{
+		Name:             "my-plane",
+		FilePath:         cropped,
+		OriginalFilePath: original,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Format != image.PngFormat || res.Original != "images/my-plane.png" {
+		t.Fatalf("Unexpected original %s of format %s", res.Original, res.Format)
+	}
+	expected := image.Sizes{
+		Original: image.Dimensions{Width: 1600, Height: 1200},
+		Variants: map[string]image.Dimensions{
+			"xs": {Width: 100, Height: 67},
+			"s":  {Width: 300, Height: 200},
+			"m":  {Width: 500, Height: 333},
+			"l":  {Width: 750, Height: 500},
+			"xl": {Width: 1000, Height: 667},
+		},
+	}
+	if !res.Sizes.IsEqualTo(expected) {
+		t.Fatalf("Expected sizes %s, got %s", expected.ToString(), res.Sizes.ToString())
+	}
+	assertExists(t, resizer, "images/my-plane_500x333.png", true)
+	assertExists(t, resizer, cropped, false)
+
+	renamed, err := resizer.Rename(ctx, "", image.RenameRequest{
+		Name:    "my-plane",
+		NewName: "revisions/3c47d736-6c4e-4a1c-a04b-3744cc30b263/1",
+		Format:  res.Format,
+		SizeMap: res.Sizes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExists(t, resizer, "images/my-plane.png", false)
+	assertExists(t, resizer, renamed.Original, true)
+
+	err = resizer.Delete(ctx, "", image.DeleteRequest{
+		Name:       renamed.Name,
+		Format:     renamed.Format,
+		Dimensions: renamed.Sizes.GetAllDimensions(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExists(t, resizer, renamed.Original, false)
+	assertExists(t, resizer, "images/revisions/3c47d736-6c4e-4a1c-a04b-3744cc30b263/1_100x67.png", false)
+}
+
+func TestResizer_Rename_MovesBackOnFailure(t *testing.T) {
+	ctx := context.Background()
+	resizer := newTestResizer(t)
+	res, err := resizer.Resize(ctx, "", image.ResizeRequest{
+		Name:             "my-plane",
+		FilePath:         writeUpload(t, resizer, 320, 240),
+		OriginalFilePath: writeUpload(t, resizer, 320, 240),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := res.Sizes
+	sizes.Variants["m"] = image.Dimensions{Width: 500, Height: 375}
+	_, err = resizer.Rename(ctx, "", image.RenameRequest{
+		Name: "my-plane", NewName: "my-jet", Format: res.Format, SizeMap: sizes,
+	})
+	if err == nil {
+		t.Fatal("Expected renaming a missing variant to fail")
+	}
+	assertExists(t, resizer, "images/my-plane.png", true)
+	assertExists(t, resizer, "images/my-plane_300x225.png", true)
+	assertExists(t, resizer, "images/my-jet.png", false)
+}
+
+func TestResizer_Resize_Profiles(t *testing.T) {
+	ctx := context.Background()
+	resizer := newTestResizer(t)
+	resizer.profiles = []image.Profile{
+		{Name: "thumbnail", Width: 150, Height: 150, Fit: image.FitCover, Quality: 80},
+		{Name: "hero", Width: 1920, Height: 1080, Fit: image.FitCover, Quality: 90},
+	}
+
+	res, err := resizer.Resize(ctx, "", image.ResizeRequest{
+		Name:             "my-plane",
+		FilePath:         writeUpload(t, resizer, 640, 480),
+		OriginalFilePath: writeUpload(t, resizer, 640, 480),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thumbnail, ok := res.Sizes.Variants["thumbnail"]
+	if !ok || thumbnail.Width != 150 || thumbnail.Height != 150 || len(res.Sizes.Variants) != 1 {
+		t.Fatalf("Expected only the thumbnail variant, got %s", res.Sizes.ToString())
+	}
+	assertExists(t, resizer, "images/my-plane_150x150.png", true)
+}
+
+func TestResizer_Resize_Formats(t *testing.T) {
+	ctx := context.Background()
+	resizer := newTestResizer(t)
+	resizer.profiles = []image.Profile{{Name: "s", Width: 300, Fit: image.FitContain, Quality: 80}}
+	resizer.formats = []image.Format{image.PngFormat, image.JpgFormat}
+
+	res, err := resizer.Resize(ctx, "", image.ResizeRequest{
+		Name:             "my-plane",
+		FilePath:         writeUpload(t, resizer, 640, 480),
+		OriginalFilePath: writeUpload(t, resizer, 640, 480),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Formats) != 1 || len(res.Formats[image.JpgFormat]) != 1 {
+		t.Fatalf("Expected the variant to be listed as jpg, got %v", res.Formats)
+	}
+	assertExists(t, resizer, "images/my-plane_300x225.png", true)
+	assertExists(t, resizer, "images/my-plane_300x225.jpg", true)
+
+	renamed, err := resizer.Rename(ctx, "", image.RenameRequest{
+		Name: "my-plane", NewName: "my-jet", Format: res.Format, SizeMap: res.Sizes, Formats: res.Formats,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExists(t, resizer, "images/my-plane_300x225.jpg", false)
+	assertExists(t, resizer, "images/my-jet_300x225.jpg", true)
+
+	err = resizer.Delete(ctx, "", image.DeleteRequest{
+		Name:       renamed.Name,
+		Format:     renamed.Format,
+		Dimensions: renamed.Sizes.GetAllDimensions(),
+		Formats:    []image.Format{image.JpgFormat},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExists(t, resizer, "images/my-jet_300x225.jpg", false)
+}
+
+func TestResizer_UploadFile(t *testing.T) {
+	resizer := newTestResizer(t)
+	ctx := context.Background()
+	signed, err := resizer.FetchSignedUrl(ctx, "", image.PngFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = resizer.UploadFile(ctx, signed, image.PngFormat, strings.NewReader("png bytes"), 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := resizer.store.Get(ctx, signed.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer object.Body.Close()
+	if received, _ := io.ReadAll(object.Body); string(received) != "png bytes" {
+		t.Fatalf("Expected the file to be stored under its name, got %q", received)
+	}
+
+	invalid := image.SignedResponse{SignedUrl: signed.SignedUrl, FileName: "../escape.png"}
+	if err = resizer.UploadFile(ctx, invalid, image.PngFormat, strings.NewReader("png bytes"), 9); err == nil {
+		t.Fatal("Expected an upload to an invalid file name to fail")
+	}
+}
+
+func TestTransformer_Transform(t *testing.T) {
+	img := goimage.NewRGBA(goimage.Rect(0, 0, 1600, 1200))
+	var original bytes.Buffer
+	if err := png.Encode(&original, img); err != nil {
+		t.Fatal(err)
+	}
+
+	var derivative bytes.Buffer
+	transformation := image.Transformation{
+		Width: 300, Height: 300, Fit: image.FitCover, Quality: 80, Format: image.JpgFormat,
+	}
+	err := NewTransformer().Transform(context.Background(), &original, transformation, &derivative)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, format, err := goimage.Decode(&derivative)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" || decoded.Bounds().Dx() != 300 || decoded.Bounds().Dy() != 300 {
+		t.Fatalf("Expected a 300x300 jpeg, got a %dx%d %s", decoded.Bounds().Dx(), decoded.Bounds().Dy(), format)
+	}
+}
+
+func TestPlaceholderGenerator_Generate(t *testing.T) {
+	img := goimage.NewRGBA(goimage.Rect(0, 0, 400, 300))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 300; y++ {
+			img.Set(x, y, color.RGBA{A: 255})
+			if x < 100 {
+				img.Set(x, y, color.RGBA{R: 200, A: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	placeholder, err := NewPlaceholderGenerator().Generate(context.Background(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placeholder.BlurHash) != 28 || !strings.HasPrefix(placeholder.BlurHash, "L") {
+		t.Fatalf("Expected a blurhash of 4x3 components, got %s", placeholder.BlurHash)
+	}
+	if placeholder.DominantColor != "#000000" {
+		t.Fatalf("Expected black to be dominant, got %s", placeholder.DominantColor)
+	}
+	if !strings.HasPrefix(placeholder.Lqip, "data:image/jpeg;base64,") {
+		t.Fatalf("Expected a jpeg data url, got %s", placeholder.Lqip)
+	}
+
+	if _, err = NewPlaceholderGenerator().Generate(context.Background(), strings.NewReader("text")); err == nil {
+		t.Fatal("Expected an error for a file that isn't an image")
+	}
+}
+
+func TestEncodeBlurHash(t *testing.T) {
+	img := goimage.NewRGBA(goimage.Rect(0, 0, 32, 24))
+	for x := 0; x < 32; x++ {
+		for y := 0; y < 24; y++ {
+			img.Set(x, y, color.RGBA{A: 255})
+		}
+	}
+
+	// A plain color has no AC components, which are all encoded as fQ
+	expected := "L00000" + strings.Repeat("fQ", 11)
+	if hash := encodeBlurHash(img, 4, 3); hash != expected {
+		t.Fatalf("Expected blurhash %s, got %s", expected, hash)
+	}
+}
+
+func TestPerceptualHasher_Hash(t *testing.T) {
+	gradient := func(width, height int, shade uint8) []byte {
+		img := goimage.NewRGBA(goimage.Rect(0, 0, width, height))
+		for x := 0; x < width; x++ {
+			for y := 0; y < height; y++ {
+				img.Set(x, y, color.RGBA{R: uint8(255 * x / width), G: shade, B: uint8(255 * y / height), A: 255})
+			}
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	hasher := NewPerceptualHasher()
+
+	hash, err := hasher.Hash(context.Background(), bytes.NewReader(gradient(400, 300, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a left to right gradient only gets darker towards the left
+	if hash != 0 {
+		t.Fatalf("Expected no pixel to be brighter than its right neighbour, got %s", hash)
+	}
+	resized, err := hasher.Hash(context.Background(), bytes.NewReader(gradient(200, 150, 10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if distance := hash.Distance(resized); distance > 2 {
+		t.Fatalf("Expected a resized copy to be a near duplicate, got a distance of %d", distance)
+	}
+
+	var mirrored bytes.Buffer
+	img := goimage.NewRGBA(goimage.Rect(0, 0, 400, 300))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 300; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(255 - 255*x/400), A: 255})
+		}
+	}
+	if err = png.Encode(&mirrored, img); err != nil {
+		t.Fatal(err)
+	}
+	other, err := hasher.Hash(context.Background(), &mirrored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if distance := hash.Distance(other); distance < 32 {
+		t.Fatalf("Expected a mirrored image to differ, got a distance of %d", distance)
+	}
+
+	if _, err = hasher.Hash(context.Background(), strings.NewReader("text")); err == nil {
+		t.Fatal("Expected an error for a file that isn't an image")
+	}
+}
+
+func TestPerceptualHasher_Hash_Transparent(t *testing.T) {
+	// halves encodes an image whose left half has the left color and whose right half is black
+	halves := func(left color.Color) []byte {
+		img := goimage.NewNRGBA(goimage.Rect(0, 0, 400, 300))
+		for x := 0; x < 400; x++ {
+			for y := 0; y < 300; y++ {
+				img.Set(x, y, color.Black)
+				if x < 200 {
+					img.Set(x, y, left)
+				}
+			}
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	hasher := NewPerceptualHasher()
+
+	transparent, err := hasher.Hash(context.Background(), bytes.NewReader(halves(color.Transparent)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	white, err := hasher.Hash(context.Background(), bytes.NewReader(halves(color.White)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transparent == 0 || transparent != white {
+		t.Fatalf("Expected the transparent half to be hashed as white %s, got %s", white, transparent)
+	}
+}
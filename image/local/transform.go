@@ -0,0 +1,41 @@
+package local
+
+import (
+	"api/image"
+	"context"
+	"fmt"
+	goimage "image"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+// Transformer implements image.Transformer in process
+type Transformer struct{}
+
+func NewTransformer() *Transformer {
+	return &Transformer{}
+}
+
+func (transformer *Transformer) Transform(
+	ctx context.Context, original io.Reader, transformation image.Transformation, w io.Writer,
+) error {
+	decoded, _, err := image.Decode(original)
+	if err != nil {
+		return fmt.Errorf("failed decoding original: %w", err)
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	bounds := decoded.Bounds()
+	dimensions := transformation.Dimensions(bounds.Dx(), bounds.Dy())
+	source := bounds
+	if transformation.Fit == image.FitCover {
+		source = coverSource(bounds, dimensions)
+	}
+	derivative := goimage.NewRGBA(goimage.Rect(0, 0, dimensions.Width, dimensions.Height))
+	draw.CatmullRom.Scale(derivative, derivative.Bounds(), decoded, source, draw.Src, nil)
+
+	return encodeTo(w, derivative, transformation.Format, transformation.Quality)
+}
@@ -0,0 +1,390 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	goimage "image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"strings"
+	"time"
+)
+
+// orientedQuality is the quality jpg files are encoded with again once their pixels were turned upright
+const orientedQuality = 92
+
+// MaxProcessedFileLength bounds the uploaded files read whole to process their metadata
+const MaxProcessedFileLength = 64 * 1024 * 1024
+
+// Metadata is what the camera and the editing software recorded in the exif and iptc of a file
+type Metadata struct {
+	Camera     string
+	Lens       string
+	CapturedAt *time.Time
+	// Orientation is the exif orientation the camera recorded, files are stored turned upright
+	Orientation int
+	Copyright   string
+	Creator     string
+	Caption     string
+	Gps         *Coordinates
+}
+
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+	// Altitude in meters, negative below sea level
+	Altitude *float64
+}
+
+// merge fills the empty fields with the ones of the other metadata
+func (metadata Metadata) merge(other Metadata) Metadata {
+	if metadata.Camera == "" {
+		metadata.Camera = other.Camera
+	}
+	if metadata.Lens == "" {
+		metadata.Lens = other.Lens
+	}
+	if metadata.CapturedAt == nil {
+		metadata.CapturedAt = other.CapturedAt
+	}
+	if metadata.Orientation == 0 {
+		metadata.Orientation = other.Orientation
+	}
+	if metadata.Copyright == "" {
+		metadata.Copyright = other.Copyright
+	}
+	if metadata.Creator == "" {
+		metadata.Creator = other.Creator
+	}
+	if metadata.Caption == "" {
+		metadata.Caption = other.Caption
+	}
+	if metadata.Gps == nil {
+		metadata.Gps = other.Gps
+	}
+
+	return metadata
+}
+
+// MetadataField is a group of metadata that can be stripped from uploaded files
+type MetadataField string
+
+const (
+	// GpsField is the location where the photo was taken, including the city and country of iptc
+	GpsField MetadataField = "gps"
+	// SerialField are the serial numbers of the camera and the lens, the name of the owner and the maker notes
+	SerialField MetadataField = "serial"
+	// CameraField are the make and model of the camera and the lens, and the software
+	CameraField    MetadataField = "camera"
+	CaptureField   MetadataField = "capture"
+	CopyrightField MetadataField = "copyright"
+	CaptionField   MetadataField = "caption"
+)
+
+var MetadataFields = []MetadataField{GpsField, SerialField, CameraField, CaptureField, CopyrightField, CaptionField}
+
+// metadataTags are the exif tags and the iptc datasets of a field
+type metadataTags struct {
+	main []uint16
+	exif []uint16
+	// gps drops the whole gps ifd
+	gps  bool
+	iptc []byte
+}
+
+var metadataFieldTags = map[MetadataField]metadataTags{
+	GpsField: {
+		gps:  true,
+		iptc: []byte{iptcCity, iptcSublocation, iptcProvince, iptcCountryCode, iptcCountry},
+	},
+	SerialField: {
+		main: []uint16{tagCameraSerialNumber},
+		exif: []uint16{tagCameraOwnerName, tagBodySerialNumber, tagLensSerialNumber, tagMakerNote},
+	},
+	CameraField: {
+		main: []uint16{tagMake, tagModel, tagSoftware},
+		exif: []uint16{tagLensSpecification, tagLensMake, tagLensModel},
+	},
+	CaptureField: {
+		main: []uint16{tagDateTime},
+		exif: []uint16{
+			tagDateTimeOriginal, tagDateTimeDigitized, tagOffsetTime, tagOffsetTimeOriginal, tagOffsetTimeDigitized,
+			tagSubSecTime, tagSubSecTimeOriginal, tagSubSecTimeDigitized,
+		},
+		iptc: []byte{iptcDateCreated, iptcTimeCreated, iptcDigitalDate, iptcDigitalTime},
+	},
+	CopyrightField: {
+		main: []uint16{tagCopyright, tagArtist},
+		iptc: []byte{iptcByline, iptcCopyright},
+	},
+	CaptionField: {
+		main: []uint16{tagImageDescription},
+		iptc: []byte{iptcHeadline, iptcCaption},
+	},
+}
+
+// ParseMetadataFields reads a comma separated list of fields, none strips nothing
+func ParseMetadataFields(value string) ([]MetadataField, error) {
+	if strings.TrimSpace(value) == "none" {
+		return nil, nil
+	}
+
+	var fields []MetadataField
+	for _, name := range strings.Split(value, ",") {
+		field := MetadataField(strings.TrimSpace(name))
+		if _, ok := metadataFieldTags[field]; !ok {
+			return nil, fmt.Errorf("unknown metadata field %s", name)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// MetadataProcessor reads the metadata of uploaded files, turns their pixels upright according to their
+// orientation and strips the configured fields from them before they are uploaded
+type MetadataProcessor struct {
+	tags metadataTags
+	// isStripping drops xmp and unreadable iptc, as they may repeat any stripped field
+	isStripping bool
+}
+
+func NewMetadataProcessor(strip []MetadataField) *MetadataProcessor {
+	processor := &MetadataProcessor{isStripping: len(strip) > 0}
+	for _, field := range strip {
+		tags := metadataFieldTags[field]
+		processor.tags.main = append(processor.tags.main, tags.main...)
+		processor.tags.exif = append(processor.tags.exif, tags.exif...)
+		processor.tags.gps = processor.tags.gps || tags.gps
+		processor.tags.iptc = append(processor.tags.iptc, tags.iptc...)
+	}
+
+	return processor
+}
+
+// Process returns the upload as it's stored and the metadata it kept. The exif of every format is read and stripped,
+// along with the iptc of jpg files. Only jpg files are turned upright, the other formats keep their orientation tag.
+func (processor *MetadataProcessor) Process(format Format, upload Upload) (Upload, Metadata, error) {
+	file, err := io.ReadAll(io.LimitReader(upload.Body, MaxProcessedFileLength+1))
+	if err != nil {
+		return Upload{}, Metadata{}, err
+	}
+	if len(file) > MaxProcessedFileLength {
+		return Upload{}, Metadata{}, fmt.Errorf("file exceeds the limit of %d bytes", MaxProcessedFileLength)
+	}
+
+	var processed []byte
+	var metadata Metadata
+	switch format {
+	case JpgFormat:
+		processed, metadata, err = processor.processJpeg(file)
+	case PngFormat:
+		processed, metadata, err = processor.processPng(file)
+	case WebpFormat:
+		processed, metadata, err = processor.processWebp(file)
+	case AvifFormat:
+		processed, metadata, err = processor.processAvif(file)
+	default:
+		processed = file
+	}
+	if err != nil {
+		return Upload{}, Metadata{}, fmt.Errorf("failed processing %s metadata: %w", format, err)
+	}
+
+	return Upload{Body: bytes.NewReader(processed), Size: int64(len(processed))}, metadata, nil
+}
+
+func (processor *MetadataProcessor) processJpeg(file []byte) ([]byte, Metadata, error) {
+	segments, scan, err := splitJpeg(file)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	var exifMetadata, iptcMetadata Metadata
+	var exif *exifData
+	isChanged, isExifChanged := false, false
+	kept := make([]jpegSegment, 0, len(segments))
+	for _, segment := range segments {
+		switch {
+		case segment.isExif():
+			data, err := parseExif(segment.data)
+			if err != nil || exif != nil {
+				// unreadable exif can't be stripped selectively, it is dropped along with repeated segments
+				isChanged = true
+				continue
+			}
+			exif = &data
+			isExifChanged = exif.strip(processor.tags)
+			exifMetadata = exif.metadata()
+			kept = append(kept, segment)
+		case segment.isXmp() && processor.isStripping:
+			isChanged = true
+		case segment.isPhotoshop():
+			data, err := parsePhotoshop(segment.data)
+			if err != nil {
+				if processor.isStripping {
+					isChanged = true
+					continue
+				}
+				kept = append(kept, segment)
+				continue
+			}
+			if data.strip(processor.tags.iptc) {
+				segment.data = data.encode()
+				isChanged = true
+			}
+			iptcMetadata = data.metadata()
+			kept = append(kept, segment)
+		default:
+			kept = append(kept, segment)
+		}
+	}
+	metadata := exifMetadata.merge(iptcMetadata)
+
+	if metadata.Orientation > 1 && metadata.Orientation <= 8 {
+		kept, scan, err = orientJpeg(file, kept, metadata.Orientation)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		exif.setOrientation(1)
+		isExifChanged = true
+	}
+	if !isChanged && !isExifChanged {
+		return file, metadata, nil
+	}
+
+	if isExifChanged {
+		kept = withExif(kept, exif)
+	}
+	processed, err := joinJpeg(kept, scan)
+
+	return processed, metadata, err
+}
+
+// processTiff strips the tiff of the exif of a format other than jpg and reads the metadata it kept. Unreadable exif
+// can't be stripped selectively, nil is returned when the exif is dropped.
+func (processor *MetadataProcessor) processTiff(tiff []byte) ([]byte, Metadata, bool) {
+	exif, err := parseExif(append(append([]byte{}, exifPrefix...), tiff...))
+	if err != nil {
+		return nil, Metadata{}, true
+	}
+	if !exif.strip(processor.tags) {
+		return tiff, exif.metadata(), false
+	}
+	if exif.isEmpty() {
+		return nil, exif.metadata(), true
+	}
+
+	return exif.encode()[len(exifPrefix):], exif.metadata(), true
+}
+
+// withExif writes the kept exif again in place of its segment, it is removed when no tag was kept
+func withExif(segments []jpegSegment, exif *exifData) []jpegSegment {
+	written := make([]jpegSegment, 0, len(segments))
+	for _, segment := range segments {
+		if !segment.isExif() {
+			written = append(written, segment)
+			continue
+		}
+		if !exif.isEmpty() {
+			written = append(written, jpegSegment{marker: segment.marker, data: exif.encode()})
+		}
+	}
+
+	return written
+}
+
+// orientJpeg decodes the pixels of the file and encodes them turned upright. Only the application segments of the
+// file are kept, the encoder writes the tables matching its scan.
+func orientJpeg(file []byte, segments []jpegSegment, orientation int) ([]jpegSegment, []byte, error) {
+	decoded, _, err := Decode(bytes.NewReader(file))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed decoding jpg: %w", err)
+	}
+
+	var encoded bytes.Buffer
+	err = jpeg.Encode(&encoded, orient(decoded, orientation), &jpeg.Options{Quality: orientedQuality})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed encoding jpg: %w", err)
+	}
+	tables, scan, err := splitJpeg(encoded.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	oriented := make([]jpegSegment, 0, len(segments)+len(tables))
+	for _, segment := range segments {
+		if segment.isApp() {
+			oriented = append(oriented, segment)
+		}
+	}
+
+	return append(oriented, tables...), scan, nil
+}
+
+// orient turns the pixels upright according to the exif orientation, mirroring them for orientations 2, 4, 5 and 7.
+// The pixels of decoded jpg files are read in place, other images are converted first.
+func orient(img goimage.Image, orientation int) goimage.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	pixel := rgbaPixels(img)
+
+	dst := goimage.NewRGBA(goimage.Rect(0, 0, width, height))
+	if orientation >= 5 {
+		dst = goimage.NewRGBA(goimage.Rect(0, 0, height, width))
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = width-1-x, y
+			case 3:
+				dx, dy = width-1-x, height-1-y
+			case 4:
+				dx, dy = x, height-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = height-1-y, x
+			case 7:
+				dx, dy = height-1-y, width-1-x
+			case 8:
+				dx, dy = y, width-1-x
+			default:
+				dx, dy = x, y
+			}
+			offset := dst.PixOffset(dx, dy)
+			dst.Pix[offset], dst.Pix[offset+1], dst.Pix[offset+2], dst.Pix[offset+3] = pixel(bounds.Min.X+x, bounds.Min.Y+y)
+		}
+	}
+
+	return dst
+}
+
+// rgbaPixels reads the pixels of the image as rgba
+func rgbaPixels(img goimage.Image) func(x, y int) (uint8, uint8, uint8, uint8) {
+	switch typed := img.(type) {
+	case *goimage.YCbCr:
+		return func(x, y int) (uint8, uint8, uint8, uint8) {
+			c := typed.YCbCrAt(x, y)
+			r, g, b := color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+			return r, g, b, 0xFF
+		}
+	case *goimage.Gray:
+		return func(x, y int) (uint8, uint8, uint8, uint8) {
+			c := typed.GrayAt(x, y)
+			return c.Y, c.Y, c.Y, 0xFF
+		}
+	}
+
+	bounds := img.Bounds()
+	converted := goimage.NewRGBA(bounds)
+	draw.Draw(converted, bounds, img, bounds.Min, draw.Src)
+	return func(x, y int) (uint8, uint8, uint8, uint8) {
+		offset := converted.PixOffset(x, y)
+		return converted.Pix[offset], converted.Pix[offset+1], converted.Pix[offset+2], converted.Pix[offset+3]
+	}
+}
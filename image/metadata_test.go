@@ -0,0 +1,418 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	goimage "image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func asciiEntry(tag uint16, value string) tiffEntry {
+	return tiffEntry{tag: tag, kind: tiffAscii, count: uint32(len(value) + 1), value: append([]byte(value), 0)}
+}
+
+func shortEntry(order binary.ByteOrder, tag uint16, value uint16) tiffEntry {
+	raw := make([]byte, 2)
+	order.PutUint16(raw, value)
+	return tiffEntry{tag: tag, kind: tiffShort, count: 1, value: raw}
+}
+
+func rationalEntry(order binary.ByteOrder, tag uint16, values ...uint32) tiffEntry {
+	raw := make([]byte, 4*len(values))
+	for i, value := range values {
+		order.PutUint32(raw[4*i:], value)
+	}
+	return tiffEntry{tag: tag, kind: tiffRational, count: uint32(len(values) / 2), value: raw}
+}
+
+// photoJpeg encodes a jpg of the size with the exif and an iptc segment after the JFIF one
+func photoJpeg(t *testing.T, width int, height int, exif exifData) []byte {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, goimage.NewRGBA(goimage.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	tables, scan, err := splitJpeg(encoded.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iptc := photoshopData{
+		resources: []photoshopResource{{id: resourceIptc, name: []byte{0, 0}}},
+		iptc: []iptcDataset{
+			{record: iptcRecordApplication, dataset: iptcByline, value: []byte("Jane Doe")},
+			{record: iptcRecordApplication, dataset: iptcCity, value: []byte("Lyon")},
+		},
+	}
+	segments := []jpegSegment{
+		{marker: markerApp0, data: []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")},
+		{marker: markerApp1, data: exif.encode()},
+		{marker: markerApp1, data: append(append([]byte{}, xmpPrefix...), "<x:xmpmeta/>"...)},
+		{marker: markerApp13, data: iptc.encode()},
+	}
+	file, err := joinJpeg(append(segments, tables...), scan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func readExif(t *testing.T, file []byte) (*exifData, []jpegSegment) {
+	segments, _, err := splitJpeg(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, segment := range segments {
+		if segment.isExif() {
+			exif, err := parseExif(segment.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return &exif, segments
+		}
+	}
+
+	return nil, segments
+}
+
+func TestMetadataProcessor_Process(t *testing.T) {
+	order := binary.BigEndian
+	exif := exifData{
+		order: order,
+		main: tiffIfd{
+			asciiEntry(tagMake, "Canon"),
+			asciiEntry(tagModel, "Canon EOS R5"),
+			asciiEntry(tagCopyright, "(c) Jane Doe"),
+			shortEntry(order, tagOrientation, 1),
+		},
+		exif: tiffIfd{
+			asciiEntry(tagDateTimeOriginal, "2021:06:21 18:30:00"),
+			asciiEntry(tagOffsetTimeOriginal, "+02:00"),
+			asciiEntry(tagLensModel, "RF24-105mm F4 L IS USM"),
+			asciiEntry(tagBodySerialNumber, "012345678"),
+		},
+		gps: tiffIfd{
+			asciiEntry(tagGpsLatitudeRef, "N"),
+			rationalEntry(order, tagGpsLatitude, 45, 1, 45, 1, 0, 1),
+			asciiEntry(tagGpsLongitudeRef, "W"),
+			rationalEntry(order, tagGpsLongitude, 4, 1, 30, 1, 0, 1),
+		},
+	}
+	file := photoJpeg(t, 30, 40, exif)
+
+	_, metadata, err := NewMetadataProcessor(nil).Process(JpgFormat, Upload{Body: bytes.NewReader(file)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	capturedAt := time.Date(2021, 6, 21, 16, 30, 0, 0, time.UTC)
+	if metadata.Camera != "Canon EOS R5" || metadata.Lens != "RF24-105mm F4 L IS USM" || metadata.Orientation != 1 ||
+		metadata.Copyright != "(c) Jane Doe" || metadata.Creator != "Jane Doe" ||
+		metadata.CapturedAt == nil || !metadata.CapturedAt.Equal(capturedAt) {
+		t.Fatalf("Unexpected metadata %+v", metadata)
+	}
+	if metadata.Gps == nil || metadata.Gps.Latitude != 45.75 || metadata.Gps.Longitude != -4.5 {
+		t.Fatalf("Unexpected coordinates %+v", metadata.Gps)
+	}
+
+	processor := NewMetadataProcessor([]MetadataField{GpsField, SerialField})
+	upload, metadata, err := processor.Process(JpgFormat, Upload{Body: bytes.NewReader(file), Size: int64(len(file))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Gps != nil || metadata.Camera != "Canon EOS R5" {
+		t.Fatalf("Expected the coordinates to be stripped from the metadata, got %+v", metadata)
+	}
+	processed, err := io.ReadAll(upload.Body)
+	if err != nil || int64(len(processed)) != upload.Size {
+		t.Fatalf("Expected the size %d to be the one of the processed file, got %d bytes", upload.Size, len(processed))
+	}
+	stripped, segments := readExif(t, processed)
+	if stripped == nil || len(stripped.gps) > 0 {
+		t.Fatalf("Expected the gps ifd to be stripped, got %+v", stripped)
+	}
+	if _, ok := stripped.exif.find(tagBodySerialNumber); ok {
+		t.Fatal("Expected the serial number to be stripped")
+	}
+	if stripped.exif.text(tagLensModel) != "RF24-105mm F4 L IS USM" || stripped.main.text(tagModel) != "Canon EOS R5" {
+		t.Fatalf("Expected the other tags to be kept, got %+v", stripped.metadata())
+	}
+	for _, segment := range segments {
+		if segment.isXmp() {
+			t.Fatal("Expected the xmp to be dropped")
+		}
+		if segment.isPhotoshop() {
+			iptc, err := parsePhotoshop(segment.data)
+			if err != nil || iptc.text(iptcCity) != "" || iptc.text(iptcByline) != "Jane Doe" {
+				t.Fatalf("Expected only the city to be stripped from the iptc, got %+v and error %v", iptc, err)
+			}
+		}
+	}
+	if bytes.Contains(processed, []byte("012345678")) || bytes.Contains(processed, []byte("Lyon")) {
+		t.Fatal("Expected the stripped values to be gone from the file")
+	}
+	if _, err = jpeg.Decode(bytes.NewReader(processed)); err != nil {
+		t.Fatalf("Expected the processed file to decode, got %v", err)
+	}
+}
+
+func TestMetadataProcessor_Process_Orientation(t *testing.T) {
+	order := binary.LittleEndian
+	file := photoJpeg(t, 30, 40, exifData{
+		order: order,
+		main:  tiffIfd{asciiEntry(tagMake, "Canon"), shortEntry(order, tagOrientation, 6)},
+	})
+
+	upload, metadata, err := NewMetadataProcessor(nil).Process(JpgFormat, Upload{Body: bytes.NewReader(file)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Orientation != 6 {
+		t.Fatalf("Expected the recorded orientation, got %d", metadata.Orientation)
+	}
+	processed, _ := io.ReadAll(upload.Body)
+	config, err := jpeg.DecodeConfig(bytes.NewReader(processed))
+	if err != nil || config.Width != 40 || config.Height != 30 {
+		t.Fatalf("Expected the pixels to be turned upright, got %+v and error %v", config, err)
+	}
+	exif, segments := readExif(t, processed)
+	if exif == nil || exif.main.text(tagMake) != "Canon" {
+		t.Fatal("Expected the exif to be kept")
+	}
+	if orientation, _ := exif.main.find(tagOrientation); orientation.uint(order) != 1 {
+		t.Fatalf("Expected the orientation to be reset, got %d", orientation.uint(order))
+	}
+	if !segments[0].isApp() || segments[0].marker != markerApp0 {
+		t.Fatal("Expected the application segments to precede the tables")
+	}
+}
+
+func TestMetadataProcessor_Process_Passthrough(t *testing.T) {
+	processor := NewMetadataProcessor([]MetadataField{GpsField})
+	upload, metadata, err := processor.Process(PngFormat, Upload{Body: strings.NewReader("\x89PNG\r\n\x1a\n"), Size: 8})
+	if err != nil || upload.Size != 8 || metadata.Camera != "" {
+		t.Fatalf("Expected files without metadata to be kept, got %+v and error %v", upload, err)
+	}
+
+	if _, _, err = processor.Process(JpgFormat, Upload{Body: strings.NewReader("not a jpg")}); err == nil {
+		t.Fatal("Expected an invalid jpg to fail")
+	}
+	if _, _, err = processor.Process(WebpFormat, Upload{Body: strings.NewReader("RIFF\xff\xff\xff\xffWEBP")}); err == nil {
+		t.Fatal("Expected a truncated webp to fail")
+	}
+}
+
+func TestMetadataProcessor_Process_TooManyPixels(t *testing.T) {
+	order := binary.LittleEndian
+	file := photoJpeg(t, 30, 40, exifData{order: order, main: tiffIfd{shortEntry(order, tagOrientation, 6)}})
+	// the frame header follows its marker, length and precision
+	frame := bytes.Index(file, []byte{0xFF, 0xC0}) + 5
+	binary.BigEndian.PutUint16(file[frame:], 20000)
+	binary.BigEndian.PutUint16(file[frame+2:], 20000)
+
+	_, _, err := NewMetadataProcessor(nil).Process(JpgFormat, Upload{Body: bytes.NewReader(file)})
+	if !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("Expected a jpg claiming too many pixels not to be turned upright, got %v", err)
+	}
+}
+
+// gpsTiff is the tiff of an exif naming the camera and the coordinates 45°N 4°W
+func gpsTiff() []byte {
+	order := binary.BigEndian
+	exif := exifData{
+		order: order,
+		main:  tiffIfd{asciiEntry(tagMake, "Canon")},
+		gps: tiffIfd{
+			asciiEntry(tagGpsLatitudeRef, "N"),
+			rationalEntry(order, tagGpsLatitude, 45, 1, 0, 1, 0, 1),
+			asciiEntry(tagGpsLongitudeRef, "W"),
+			rationalEntry(order, tagGpsLongitude, 4, 1, 0, 1, 0, 1),
+		},
+	}
+
+	return exif.encode()[len(exifPrefix):]
+}
+
+const testXmp = "<x:xmpmeta><exif:GPSLatitude>45,0N</exif:GPSLatitude></x:xmpmeta>"
+
+// gpsPng encodes a png with an eXIf chunk and an xmp text chunk after its header
+func gpsPng(t *testing.T) []byte {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, goimage.NewRGBA(goimage.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := splitPng(encoded.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := []pngChunk{
+		{kind: "eXIf", data: gpsTiff()},
+		{kind: "iTXt", data: []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00" + testXmp)},
+	}
+	return joinPng(append(chunks[:1], append(metadata, chunks[1:]...)...))
+}
+
+// gpsWebp writes a webp with EXIF and XMP chunks, its bitstream isn't decodable
+func gpsWebp() []byte {
+	header := []byte{webpExifFlag | webpXmpFlag, 0, 0, 0, 7, 0, 0, 7, 0, 0}
+	return joinWebp([]riffChunk{
+		{fourcc: "VP8X", data: header},
+		{fourcc: "VP8L", data: []byte{0x2F, 0x07, 0xC0, 0x01, 0x00}},
+		{fourcc: "EXIF", data: append(append([]byte{}, exifPrefix...), gpsTiff()...)},
+		{fourcc: "XMP ", data: []byte(testXmp)},
+	})
+}
+
+func isoBoxOf(kind string, contents ...[]byte) []byte {
+	content := bytes.Join(contents, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(content)))
+	return append(append(box, kind...), content...)
+}
+
+// gpsAvif writes an avif whose Exif and xmp items are stored in its mdat, it has no image item
+func gpsAvif() []byte {
+	exif := append(binary.BigEndian.AppendUint32(nil, uint32(len(exifPrefix))), exifPrefix...)
+	exif = append(exif, gpsTiff()...)
+	ftyp := isoBoxOf("ftyp", []byte("avif\x00\x00\x00\x00avifmif1"))
+
+	meta := func(exifOffset uint32, xmpOffset uint32) []byte {
+		iinf := isoBoxOf("iinf", []byte{0, 0, 0, 0, 0, 2},
+			isoBoxOf("infe", []byte{2, 0, 0, 0, 0, 1, 0, 0}, []byte("Exif\x00")),
+			isoBoxOf("infe", []byte{2, 0, 0, 0, 0, 2, 0, 0}, []byte("mime\x00"+xmpContentType+"\x00")),
+		)
+		iloc := []byte{0, 0, 0, 0, 0x44, 0, 0, 2}
+		for i, extent := range [][2]uint32{{exifOffset, uint32(len(exif))}, {xmpOffset, uint32(len(testXmp))}} {
+			iloc = append(iloc, 0, byte(i+1), 0, 0, 0, 1)
+			iloc = binary.BigEndian.AppendUint32(iloc, extent[0])
+			iloc = binary.BigEndian.AppendUint32(iloc, extent[1])
+		}
+		return isoBoxOf("meta", []byte{0, 0, 0, 0}, isoBoxOf("hdlr", make([]byte, 25)), iinf, isoBoxOf("iloc", iloc))
+	}
+	start := uint32(len(ftyp)+len(meta(0, 0))) + 8
+	mdat := isoBoxOf("mdat", exif, []byte(testXmp))
+
+	return bytes.Join([][]byte{ftyp, meta(start, start+uint32(len(exif))), mdat}, nil)
+}
+
+// containerTiff reads the tiff of the exif and the xmp left in the file
+func containerTiff(t *testing.T, format Format, file []byte) ([]byte, []byte) {
+	var tiff, xmp []byte
+	switch format {
+	case PngFormat:
+		chunks, err := splitPng(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, chunk := range chunks {
+			if chunk.isExif() {
+				tiff = chunk.data
+			}
+			if chunk.isText() {
+				xmp = chunk.data
+			}
+		}
+	case WebpFormat:
+		chunks, err := splitWebp(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, chunk := range chunks {
+			switch chunk.fourcc {
+			case "EXIF":
+				tiff = bytes.TrimPrefix(chunk.data, exifPrefix)
+			case "XMP ":
+				xmp = chunk.data
+			case "VP8X":
+				if chunk.data[0]&webpXmpFlag != 0 {
+					t.Fatal("Expected the xmp flag to be cleared")
+				}
+			}
+		}
+	case AvifFormat:
+		items, err := avifItems(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items {
+			if item.kind == "Exif" {
+				payload := item.payload()
+				tiff = payload[4+binary.BigEndian.Uint32(payload):]
+			}
+			if item.kind == "mime" && !bytes.HasPrefix(item.payload(), emptyXmp) {
+				xmp = item.payload()
+			}
+		}
+	}
+
+	return tiff, xmp
+}
+
+func TestMetadataProcessor_Process_Containers(t *testing.T) {
+	data := []struct {
+		format Format
+		file   []byte
+	}{
+		{format: PngFormat, file: gpsPng(t)},
+		{format: WebpFormat, file: gpsWebp()},
+		{format: AvifFormat, file: gpsAvif()},
+	}
+
+	for _, d := range data {
+		t.Run(string(d.format), func(t *testing.T) {
+			upload, metadata, err := NewMetadataProcessor(nil).Process(d.format, Upload{Body: bytes.NewReader(d.file)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			kept, _ := io.ReadAll(upload.Body)
+			if !bytes.Equal(kept, d.file) || metadata.Camera != "Canon" || metadata.Gps == nil ||
+				metadata.Gps.Latitude != 45 || metadata.Gps.Longitude != -4 {
+				t.Fatalf("Expected the file to be kept with its metadata read, got %+v", metadata)
+			}
+
+			processor := NewMetadataProcessor([]MetadataField{GpsField})
+			upload, metadata, err = processor.Process(d.format, Upload{Body: bytes.NewReader(d.file)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if metadata.Camera != "Canon" || metadata.Gps != nil {
+				t.Fatalf("Expected the coordinates to be stripped from the metadata, got %+v", metadata)
+			}
+			processed, _ := io.ReadAll(upload.Body)
+			if int64(len(processed)) != upload.Size {
+				t.Fatalf("Expected the size %d to be the one of the processed file, got %d", upload.Size, len(processed))
+			}
+			tiff, xmp := containerTiff(t, d.format, processed)
+			exif, err := parseExif(append(append([]byte{}, exifPrefix...), tiff...))
+			if err != nil || len(exif.gps) > 0 || exif.main.text(tagMake) != "Canon" {
+				t.Fatalf("Expected only the gps ifd to be stripped, got %+v and error %v", exif, err)
+			}
+			if xmp != nil || bytes.Contains(processed, []byte("GPSLatitude")) {
+				t.Fatal("Expected the xmp to be dropped")
+			}
+			if d.format == PngFormat {
+				if _, err = png.Decode(bytes.NewReader(processed)); err != nil {
+					t.Fatalf("Expected the processed png to decode, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMetadataFields(t *testing.T) {
+	fields, err := ParseMetadataFields("gps, serial")
+	if err != nil || len(fields) != 2 || fields[0] != GpsField || fields[1] != SerialField {
+		t.Fatalf("Unexpected fields %v and error %v", fields, err)
+	}
+	if fields, err = ParseMetadataFields("none"); err != nil || len(fields) != 0 {
+		t.Fatalf("Expected none to strip nothing, got %v and error %v", fields, err)
+	}
+	if _, err = ParseMetadataFields("gps,location"); err == nil {
+		t.Fatal("Expected an unknown field to fail")
+	}
+}
@@ -0,0 +1,102 @@
+package image
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatPreference orders formats by how well they compress, it breaks ties between explicitly accepted formats
+var formatPreference = []Format{AvifFormat, WebpFormat, JpgFormat, PngFormat}
+
+type acceptance struct {
+	quality  float64
+	explicit bool
+}
+
+// parseAccept reads the qualities of the media types of an Accept header
+func parseAccept(accept string) map[string]float64 {
+	qualities := make(map[string]float64)
+	for _, item := range strings.Split(accept, ",") {
+		parts := strings.Split(item, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(parts[0]))
+		if mediaType == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range parts[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.ToLower(key) != "q" {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = parsed
+			}
+		}
+		qualities[mediaType] = quality
+	}
+
+	return qualities
+}
+
+func accepts(qualities map[string]float64, format Format) acceptance {
+	if quality, ok := qualities[string(format.ToContentType())]; ok {
+		return acceptance{quality: quality, explicit: true}
+	}
+	if quality, ok := qualities["image/*"]; ok {
+		return acceptance{quality: quality}
+	}
+	if quality, ok := qualities["*/*"]; ok {
+		return acceptance{quality: quality}
+	}
+
+	return acceptance{}
+}
+
+func preference(format Format) int {
+	for i, f := range formatPreference {
+		if f == format {
+			return i
+		}
+	}
+
+	return len(formatPreference)
+}
+
+// SortByPreference orders the formats from the best compressing one, like the sources of a picture element
+func SortByPreference(formats []Format) {
+	sort.SliceStable(formats, func(i, j int) bool {
+		return preference(formats[i]) < preference(formats[j])
+	})
+}
+
+// NegotiateFormat picks the available format the Accept header prefers. Formats named by the header win over
+// wildcard matches of the same quality and the best compressing of them is picked. Wildcards only match the
+// fallback, the format of the image, since clients list the modern formats they support, like browsers do for
+// images. The fallback is also picked when nothing is acceptable or there is no header.
+func NegotiateFormat(accept string, available []Format, fallback Format) Format {
+	if strings.TrimSpace(accept) == "" {
+		return fallback
+	}
+	qualities := parseAccept(accept)
+
+	best, bestAcceptance := fallback, acceptance{}
+	for _, format := range available {
+		current := accepts(qualities, format)
+		if !current.explicit && format != fallback {
+			continue
+		}
+		if current.quality <= 0 {
+			continue
+		}
+		switch {
+		case current.quality > bestAcceptance.quality,
+			current.quality == bestAcceptance.quality && current.explicit && !bestAcceptance.explicit,
+			current.quality == bestAcceptance.quality && current.explicit == bestAcceptance.explicit &&
+				preference(format) < preference(best):
+			best, bestAcceptance = format, current
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,30 @@
+package image
+
+import (
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	available := []Format{JpgFormat, WebpFormat, AvifFormat}
+	data := []struct {
+		testName string
+		accept   string
+		expected Format
+	}{
+		{testName: "Browser", accept: "image/avif,image/webp,image/apng,image/*,*/*;q=0.8", expected: AvifFormat},
+		{testName: "Without avif", accept: "image/webp,*/*", expected: WebpFormat},
+		{testName: "Lower quality", accept: "image/avif;q=0.5,image/webp", expected: WebpFormat},
+		{testName: "Rejected", accept: "image/avif;q=0,image/webp;q=0,image/*", expected: JpgFormat},
+		{testName: "Wildcard", accept: "*/*", expected: JpgFormat},
+		{testName: "Nothing acceptable", accept: "application/json", expected: JpgFormat},
+		{testName: "Missing", expected: JpgFormat},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			if format := NegotiateFormat(d.accept, available, JpgFormat); format != d.expected {
+				t.Fatalf("Expected %s, got %s", d.expected, format)
+			}
+		})
+	}
+}
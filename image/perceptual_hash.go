@@ -0,0 +1,45 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+)
+
+// PerceptualHash is a 64 bit fingerprint of what an image looks like, near duplicates differ in few bits
+type PerceptualHash uint64
+
+// ParsePerceptualHash reads the hash from its 16 hex digits
+func ParsePerceptualHash(value string) (PerceptualHash, error) {
+	hash, err := strconv.ParseUint(value, 16, 64)
+	if err != nil || len(value) != 16 {
+		return 0, fmt.Errorf("invalid perceptual hash %s", value)
+	}
+
+	return PerceptualHash(hash), nil
+}
+
+func (hash PerceptualHash) String() string {
+	return fmt.Sprintf("%016x", uint64(hash))
+}
+
+// Distance is the hamming distance between the hashes, the number of bits they differ in
+func (hash PerceptualHash) Distance(other PerceptualHash) int {
+	return bits.OnesCount64(uint64(hash ^ other))
+}
+
+// PerceptualHasher computes perceptual hashes of images
+type PerceptualHasher interface {
+	// Hash decodes the image and computes its perceptual hash
+	Hash(ctx context.Context, r io.Reader) (PerceptualHash, error)
+}
+
+// PerceptualHasherMock hashes every image to 0
+type PerceptualHasherMock struct {
+}
+
+func (hasher PerceptualHasherMock) Hash(_ context.Context, _ io.Reader) (PerceptualHash, error) {
+	return 0, nil
+}
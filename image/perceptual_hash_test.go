@@ -0,0 +1,19 @@
+package image
+
+import "testing"
+
+func TestPerceptualHash(t *testing.T) {
+	hash, err := ParsePerceptualHash("f0f0000000000001")
+	if err != nil || hash.String() != "f0f0000000000001" {
+		t.Fatalf("Unexpected hash %s and error %v", hash, err)
+	}
+	if distance := hash.Distance(0); distance != 9 {
+		t.Fatalf("Expected a distance of 9, got %d", distance)
+	}
+
+	for _, value := range []string{"", "f0f0", "f0f000000000000g", "f0f00000000000001"} {
+		if _, err = ParsePerceptualHash(value); err == nil {
+			t.Fatalf("Expected %q to be invalid", value)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package image
+
+import (
+	"context"
+	"io"
+)
+
+// Placeholder is shown by clients while the image loads
+type Placeholder struct {
+	BlurHash string
+	// Lqip is a tiny blurred version of the image as a base64 data url
+	Lqip string
+	// DominantColor is the most common color as #rrggbb
+	DominantColor string
+}
+
+// PlaceholderGenerator computes placeholders of images
+type PlaceholderGenerator interface {
+	// Generate decodes the image and computes its placeholder
+	Generate(ctx context.Context, r io.Reader) (Placeholder, error)
+}
+
+// PlaceholderMock generates empty placeholders
+type PlaceholderMock struct {
+}
+
+func (generator PlaceholderMock) Generate(_ context.Context, _ io.Reader) (Placeholder, error) {
+	return Placeholder{}, nil
+}
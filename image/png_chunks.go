@@ -0,0 +1,114 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// maxPngChunkLength is the longest chunk the png specification allows
+const maxPngChunkLength = 1<<31 - 1
+
+// pngChunk is a chunk of a png, data excludes the length, the type and the crc
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+func (chunk pngChunk) isExif() bool {
+	return chunk.kind == "eXIf"
+}
+
+// isText tells if the chunk is a keyword and its text, which may hold xmp, raw exif and iptc profiles, or the author
+// and the creation time of the file
+func (chunk pngChunk) isText() bool {
+	return chunk.kind == "tEXt" || chunk.kind == "zTXt" || chunk.kind == "iTXt"
+}
+
+// splitPng separates the chunks of the png up to the IEND chunk, what follows it is dropped
+func splitPng(file []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(file, pngSignature) {
+		return nil, errors.New("not a png file")
+	}
+
+	var chunks []pngChunk
+	pos := len(pngSignature)
+	for pos < len(file) {
+		if pos+8 > len(file) {
+			return nil, errors.New("truncated png chunk")
+		}
+		length := uint64(binary.BigEndian.Uint32(file[pos:]))
+		if length > maxPngChunkLength || uint64(pos)+12+length > uint64(len(file)) {
+			return nil, fmt.Errorf("invalid length of png chunk at %d", pos)
+		}
+		chunk := pngChunk{kind: string(file[pos+4 : pos+8]), data: file[pos+8 : pos+8+int(length)]}
+		chunks = append(chunks, chunk)
+		pos += 12 + int(length)
+		if chunk.kind == "IEND" {
+			break
+		}
+	}
+
+	return chunks, nil
+}
+
+// joinPng writes the chunks back into a png file with their crc
+func joinPng(chunks []pngChunk) []byte {
+	size := len(pngSignature)
+	for _, chunk := range chunks {
+		size += 12 + len(chunk.data)
+	}
+
+	file := make([]byte, 0, size)
+	file = append(file, pngSignature...)
+	for _, chunk := range chunks {
+		file = binary.BigEndian.AppendUint32(file, uint32(len(chunk.data)))
+		start := len(file)
+		file = append(file, chunk.kind...)
+		file = append(file, chunk.data...)
+		file = binary.BigEndian.AppendUint32(file, crc32.ChecksumIEEE(file[start:]))
+	}
+
+	return file
+}
+
+// processPng strips the eXIf chunk of the png, its text chunks are dropped when stripping as they may repeat any
+// stripped field
+func (processor *MetadataProcessor) processPng(file []byte) ([]byte, Metadata, error) {
+	chunks, err := splitPng(file)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	var metadata Metadata
+	isChanged, isExifRead := false, false
+	kept := make([]pngChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		switch {
+		case chunk.isExif() && !isExifRead:
+			isExifRead = true
+			tiff, exifMetadata, isExifChanged := processor.processTiff(chunk.data)
+			metadata = exifMetadata
+			isChanged = isChanged || isExifChanged
+			if tiff != nil {
+				kept = append(kept, pngChunk{kind: chunk.kind, data: tiff})
+			}
+		case chunk.isExif():
+			// repeated exif is dropped like in jpg files
+			isChanged = true
+		case chunk.isText() && processor.isStripping:
+			isChanged = true
+		default:
+			kept = append(kept, chunk)
+		}
+	}
+	if !isChanged {
+		return file, metadata, nil
+	}
+
+	return joinPng(kept), metadata, nil
+}
@@ -0,0 +1,144 @@
+package image
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OriginalSize is the key of the original dimensions in sizes, no profile can be named like it
+const OriginalSize = "original"
+
+const DefaultQuality = 85
+
+// Fit is how a profile scales images into its width and height
+type Fit string
+
+const (
+	// FitContain scales within the width and height keeping the aspect ratio, a side of 0 is unbounded
+	FitContain Fit = "contain"
+	// FitCover scales and center crops to exactly the width and height
+	FitCover Fit = "cover"
+	// FitFill stretches to exactly the width and height
+	FitFill Fit = "fill"
+)
+
+func (fit Fit) IsSupported() bool {
+	return fit == FitContain || fit == FitCover || fit == FitFill
+}
+
+// Profile is a named size every uploaded image is resized to
+type Profile struct {
+	Name    string `json:"name"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Fit     Fit    `json:"fit"`
+	Quality int    `json:"quality"`
+}
+
+// DefaultProfiles are the breakpoints of the v1 api, the width is kept and the height follows the aspect ratio
+var DefaultProfiles = []Profile{
+	{Name: "xs", Width: 100, Fit: FitContain, Quality: DefaultQuality},
+	{Name: "s", Width: 300, Fit: FitContain, Quality: DefaultQuality},
+	{Name: "m", Width: 500, Fit: FitContain, Quality: DefaultQuality},
+	{Name: "l", Width: 750, Fit: FitContain, Quality: DefaultQuality},
+	{Name: "xl", Width: 1000, Fit: FitContain, Quality: DefaultQuality},
+	{Name: "xxl", Width: 1500, Fit: FitContain, Quality: DefaultQuality},
+	{Name: "xxxl", Width: 2000, Fit: FitContain, Quality: DefaultQuality},
+}
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+func IsValidProfileName(name string) bool {
+	return name != OriginalSize && profileNamePattern.MatchString(name)
+}
+
+// Dimensions returns the size of the variant resized from an image of the width and height, images are never
+// upscaled so false is returned when the image is smaller than the profile
+func (profile Profile) Dimensions(width, height int) (Dimensions, bool) {
+	if width <= 0 || height <= 0 {
+		return Dimensions{}, false
+	}
+
+	switch profile.Fit {
+	case FitCover, FitFill:
+		if profile.Width > width || profile.Height > height {
+			return Dimensions{}, false
+		}
+		return Dimensions{Width: profile.Width, Height: profile.Height}, true
+	}
+
+	scale := math.Inf(1)
+	if profile.Width > 0 {
+		scale = float64(profile.Width) / float64(width)
+	}
+	if profile.Height > 0 {
+		scale = math.Min(scale, float64(profile.Height)/float64(height))
+	}
+	if scale > 1 {
+		return Dimensions{}, false
+	}
+
+	return Dimensions{
+		Width:  int(math.Max(1, math.Round(float64(width)*scale))),
+		Height: int(math.Max(1, math.Round(float64(height)*scale))),
+	}, true
+}
+
+// ParseProfiles reads comma separated profiles of name:WIDTHxHEIGHT[:fit[:quality]], e.g. "card:600x400:cover:80".
+// The fit defaults to contain and the quality to DefaultQuality.
+func ParseProfiles(value string) ([]Profile, error) {
+	profiles := make([]Profile, 0)
+	names := make(map[string]bool)
+
+	for _, definition := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(definition), ":")
+		if len(parts) < 2 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid size profile %q, expected name:WIDTHxHEIGHT[:fit[:quality]]", definition)
+		}
+
+		profile := Profile{Name: parts[0], Fit: FitContain, Quality: DefaultQuality}
+		if !IsValidProfileName(profile.Name) {
+			return nil, fmt.Errorf("invalid size profile name %q", profile.Name)
+		}
+		if names[profile.Name] {
+			return nil, fmt.Errorf("duplicate size profile %s", profile.Name)
+		}
+		names[profile.Name] = true
+
+		width, height, found := strings.Cut(parts[1], "x")
+		var widthErr, heightErr error
+		profile.Width, widthErr = strconv.Atoi(width)
+		profile.Height, heightErr = strconv.Atoi(height)
+		if !found || widthErr != nil || heightErr != nil || profile.Width < 0 || profile.Height < 0 {
+			return nil, fmt.Errorf("invalid dimensions %q of size profile %s", parts[1], profile.Name)
+		}
+
+		if len(parts) > 2 {
+			profile.Fit = Fit(parts[2])
+			if !profile.Fit.IsSupported() {
+				return nil, fmt.Errorf("unsupported fit %s of size profile %s", parts[2], profile.Name)
+			}
+		}
+		if len(parts) > 3 {
+			quality, err := strconv.Atoi(parts[3])
+			if err != nil || quality < 1 || quality > 100 {
+				return nil, fmt.Errorf("invalid quality %q of size profile %s", parts[3], profile.Name)
+			}
+			profile.Quality = quality
+		}
+
+		if profile.Width == 0 && profile.Height == 0 {
+			return nil, fmt.Errorf("size profile %s needs a width or a height", profile.Name)
+		}
+		if profile.Fit != FitContain && (profile.Width == 0 || profile.Height == 0) {
+			return nil, fmt.Errorf("size profile %s needs both a width and a height to %s", profile.Name, profile.Fit)
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
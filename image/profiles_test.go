@@ -0,0 +1,64 @@
+package image
+
+import (
+	"testing"
+)
+
+func TestParseProfiles(t *testing.T) {
+	profiles, err := ParseProfiles("thumbnail:150x150:cover:80, card:600x0,hero:1920x1080:contain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Profile{
+		{Name: "thumbnail", Width: 150, Height: 150, Fit: FitCover, Quality: 80},
+		{Name: "card", Width: 600, Height: 0, Fit: FitContain, Quality: DefaultQuality},
+		{Name: "hero", Width: 1920, Height: 1080, Fit: FitContain, Quality: DefaultQuality},
+	}
+	if len(profiles) != len(expected) {
+		t.Fatalf("Expected %d profiles, got %d", len(expected), len(profiles))
+	}
+	for i := range expected {
+		if profiles[i] != expected[i] {
+			t.Fatalf("Expected profile %+v, got %+v", expected[i], profiles[i])
+		}
+	}
+
+	invalid := []string{
+		"", "card", "card:600", "original:100x100", "card:100x100,card:200x200", "card:0x0",
+		"card:100x0:cover", "card:100x100:stretch", "card:100x100:fill:101", "Card:100x100",
+	}
+	for _, value := range invalid {
+		if _, err = ParseProfiles(value); err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestProfile_Dimensions(t *testing.T) {
+	tests := []struct {
+		profile  Profile
+		width    int
+		height   int
+		expected Dimensions
+		ok       bool
+	}{
+		{Profile{Width: 300, Fit: FitContain}, 1200, 800, Dimensions{Width: 300, Height: 200}, true},
+		{Profile{Width: 300, Fit: FitContain}, 200, 800, Dimensions{}, false},
+		{Profile{Width: 400, Height: 400, Fit: FitContain}, 1200, 800, Dimensions{Width: 400, Height: 267}, true},
+		{Profile{Height: 100, Fit: FitContain}, 1200, 800, Dimensions{Width: 150, Height: 100}, true},
+		{Profile{Width: 150, Height: 150, Fit: FitCover}, 1200, 800, Dimensions{Width: 150, Height: 150}, true},
+		{Profile{Width: 150, Height: 900, Fit: FitCover}, 1200, 800, Dimensions{}, false},
+		{Profile{Width: 100, Height: 300, Fit: FitFill}, 1200, 800, Dimensions{Width: 100, Height: 300}, true},
+	}
+
+	for _, test := range tests {
+		dimensions, ok := test.profile.Dimensions(test.width, test.height)
+		if ok != test.ok || dimensions != test.expected {
+			t.Errorf(
+				"Expected %+v of %dx%d to be %+v %v, got %+v %v",
+				test.profile, test.width, test.height, test.expected, test.ok, dimensions, ok,
+			)
+		}
+	}
+}
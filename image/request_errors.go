@@ -0,0 +1,48 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// NewStatusError classifies a response of a failed request by its status code
+func NewStatusError(requestError RequestError, body string) error {
+	switch {
+	case requestError.StatusCode == http.StatusForbidden:
+		return &Forbidden{RequestError: requestError, Body: body}
+	case requestError.StatusCode == http.StatusServiceUnavailable:
+		return &Unavailable{RequestError: requestError, Body: body}
+	case requestError.StatusCode == http.StatusGatewayTimeout:
+		return &Timeout{RequestError: requestError, Body: body}
+	case requestError.StatusCode >= 500:
+		return &ServerError{RequestError: requestError, Body: body}
+	default:
+		return &BadRequest{RequestError: requestError, Body: body}
+	}
+}
+
+// NewTransportError classifies a request that failed without a response, requestError.Err is the failure
+func NewTransportError(requestError RequestError, body string) error {
+	var netErr net.Error
+	if errors.Is(requestError.Err, context.DeadlineExceeded) ||
+		errors.As(requestError.Err, &netErr) && netErr.Timeout() {
+		return &Timeout{RequestError: requestError, Body: body}
+	}
+
+	return &Unavailable{RequestError: requestError, Body: body}
+}
+
+// IsRetryable tells whether a request may succeed when it is sent again, requests that were rejected won't
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var serverError *ServerError
+	var unavailable *Unavailable
+	var timeout *Timeout
+
+	return errors.As(err, &serverError) || errors.As(err, &unavailable) || errors.As(err, &timeout)
+}
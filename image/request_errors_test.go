@@ -0,0 +1,56 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+)
+
+func TestNewStatusError(t *testing.T) {
+	data := []struct {
+		statusCode  int
+		expected    string
+		isRetryable bool
+	}{
+		{statusCode: http.StatusBadRequest, expected: "*image.BadRequest"},
+		{statusCode: http.StatusForbidden, expected: "*image.Forbidden"},
+		{statusCode: http.StatusInternalServerError, expected: "*image.ServerError", isRetryable: true},
+		{statusCode: http.StatusBadGateway, expected: "*image.ServerError", isRetryable: true},
+		{statusCode: http.StatusServiceUnavailable, expected: "*image.Unavailable", isRetryable: true},
+		{statusCode: http.StatusGatewayTimeout, expected: "*image.Timeout", isRetryable: true},
+	}
+
+	for _, d := range data {
+		t.Run(http.StatusText(d.statusCode), func(t *testing.T) {
+			err := NewStatusError(RequestError{StatusCode: d.statusCode}, "")
+			if kind := fmt.Sprintf("%T", err); kind != d.expected {
+				t.Fatalf("Expected %s, got %s", d.expected, kind)
+			}
+			if IsRetryable(err) != d.isRetryable {
+				t.Fatalf("Expected retryable to be %v", d.isRetryable)
+			}
+		})
+	}
+}
+
+func TestNewTransportError(t *testing.T) {
+	var timeout *Timeout
+	err := NewTransportError(RequestError{Err: fmt.Errorf("post: %w", context.DeadlineExceeded)}, "")
+	if !errors.As(err, &timeout) || !IsRetryable(err) {
+		t.Fatalf("Expected an exceeded deadline to time out, got %v", err)
+	}
+
+	var unavailable *Unavailable
+	err = NewTransportError(RequestError{Err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED)}, "")
+	if !errors.As(err, &unavailable) || !IsRetryable(err) {
+		t.Fatalf("Expected a refused connection to be unavailable, got %v", err)
+	}
+
+	err = NewTransportError(RequestError{Err: fmt.Errorf("post: %w", context.Canceled)}, "")
+	if IsRetryable(err) {
+		t.Fatal("Expected a canceled request not to be retried")
+	}
+}
@@ -2,15 +2,47 @@ package resize
 
 import (
 	"api/core"
+	"api/image"
+	"api/pkg/concurrency"
+	"bytes"
+	"context"
 	"fmt"
 	"github.com/rs/zerolog"
+	"io"
 	"net/http"
 	"time"
 )
 
+// Endpoints of the resize api, timeouts are configured by these names
+const (
+	signedEndpoint        = "signed"
+	uploadEndpoint        = "upload"
+	resizeEndpoint        = "resize"
+	renameEndpoint        = "rename"
+	deleteEndpoint        = "delete"
+	invalidateEndpoint    = "invalidate"
+	deleteUploadsEndpoint = "delete-uploads"
+)
+
+var endpoints = []string{
+	signedEndpoint,
+	uploadEndpoint,
+	resizeEndpoint,
+	renameEndpoint,
+	deleteEndpoint,
+	invalidateEndpoint,
+	deleteUploadsEndpoint,
+}
+
 type Client struct {
-	domain string
-	client *http.Client
+	domain   string
+	profiles []image.Profile
+	formats  []image.Format
+	client   *http.Client
+	timeout  time.Duration
+	timeouts map[string]time.Duration
+	// retry resends idempotent requests that failed for reasons other than being rejected
+	retry  *concurrency.Retry
 	logger *zerolog.Logger
 }
 
@@ -18,19 +50,127 @@ func NewClient(
 	config core.Config,
 	logger *zerolog.Logger,
 ) *Client {
+	for endpoint := range config.ImagesApiTimeouts {
+		if !isEndpoint(endpoint) {
+			logger.Warn().Msgf("ignoring timeout of unknown images api endpoint %s", endpoint)
+		}
+	}
+
 	return &Client{
-		domain: config.ImagesApiDomain,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		domain:   config.ImagesApiDomain,
+		profiles: config.SizeProfiles,
+		formats:  config.VariantFormats,
+		client:   &http.Client{},
+		timeout:  config.ImagesApiTimeout,
+		timeouts: config.ImagesApiTimeouts,
+		retry: concurrency.NewRetry(config.ImagesApiRetries, 0).
+			WithBackoff(100*time.Millisecond, 2*time.Second),
 		logger: logger,
 	}
 }
 
+func isEndpoint(name string) bool {
+	for _, endpoint := range endpoints {
+		if endpoint == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (client *Client) url(relativePath string) string {
 	return fmt.Sprintf("%s%s", client.domain, relativePath)
 }
 
+// withTimeout bounds a request to the endpoint, each retry gets a timeout of its own
+func (client *Client) withTimeout(ctx context.Context, endpoint string) (context.Context, context.CancelFunc) {
+	timeout, ok := client.timeouts[endpoint]
+	if !ok {
+		timeout = client.timeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// postJson posts the json to the endpoint and reads the whole response body, failures are classified by
+// image.NewTransportError and image.NewStatusError
+func (client *Client) postJson(
+	ctx context.Context,
+	endpoint string,
+	authorizationHeader string,
+	jsonData []byte,
+	message string,
+) ([]byte, error) {
+	ctx, cancel := client.withTimeout(ctx, endpoint)
+	defer cancel()
+
+	reqUrl := client.url("/" + endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqUrl, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", authorizationHeader)
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := client.client.Do(req)
+	if err != nil {
+		return nil, image.NewTransportError(
+			image.RequestError{Url: reqUrl, Message: message, Err: err},
+			string(jsonData),
+		)
+	}
+	defer func() {
+		if closeErr := res.Body.Close(); closeErr != nil {
+			client.logger.Warn().Msgf("failed closing body: %s", closeErr.Error())
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, image.NewTransportError(
+			image.RequestError{Url: reqUrl, StatusCode: res.StatusCode, Message: message, Err: err},
+			string(jsonData),
+		)
+	}
+	if !isResponseOk(res.StatusCode) {
+		return nil, image.NewStatusError(
+			image.RequestError{Url: reqUrl, StatusCode: res.StatusCode, Message: message},
+			string(body),
+		)
+	}
+
+	return body, nil
+}
+
+// retryJson posts like postJson, requests that may succeed when sent again are retried
+func (client *Client) retryJson(
+	ctx context.Context,
+	endpoint string,
+	authorizationHeader string,
+	jsonData []byte,
+	message string,
+) ([]byte, error) {
+	var body []byte
+	err := client.retry.Execute(ctx, func(ctx context.Context, retryCount uint) error {
+		if retryCount > 0 {
+			client.logger.Warn().Msgf("retrying %s request, attempt %d", endpoint, retryCount+1)
+		}
+
+		var err error
+		body, err = client.postJson(ctx, endpoint, authorizationHeader, jsonData, message)
+		if err != nil && !image.IsRetryable(err) {
+			return concurrency.Stop(err)
+		}
+		return err
+	})
+
+	return body, err
+}
+
 func isResponseOk(statusCode int) bool {
 	return statusCode >= 200 && statusCode < 300
 }
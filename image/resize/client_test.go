@@ -0,0 +1,115 @@
+package resize
+
+import (
+	"api/core"
+	"api/image"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClient(domain string, retries uint) *Client {
+	logger := zerolog.Nop()
+	client := NewClient(core.Config{
+		ImagesApiDomain:   domain,
+		ImagesApiTimeout:  time.Second,
+		ImagesApiTimeouts: map[string]time.Duration{invalidateEndpoint: 10 * time.Millisecond},
+		ImagesApiRetries:  retries,
+	}, &logger)
+	client.retry.WithBackoff(time.Millisecond, time.Millisecond)
+
+	return client
+}
+
+func TestClient_Delete_Retries(t *testing.T) {
+	var statuses []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status := statuses[0]
+		statuses = statuses[1:]
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	client := newTestClient(server.URL, 2)
+
+	statuses = []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK}
+	if err := client.Delete(context.Background(), "", image.DeleteRequest{}); err != nil {
+		t.Fatalf("Expected the delete to succeed once retried, got %v", err)
+	}
+
+	statuses = []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}
+	var serverError *image.ServerError
+	if err := client.Delete(context.Background(), "", image.DeleteRequest{}); !errors.As(err, &serverError) {
+		t.Fatalf("Expected a server error once the retries ran out, got %v", err)
+	}
+
+	statuses = []int{http.StatusBadRequest, http.StatusOK}
+	var badRequest *image.BadRequest
+	if err := client.Delete(context.Background(), "", image.DeleteRequest{}); !errors.As(err, &badRequest) {
+		t.Fatalf("Expected a rejected delete not to be retried, got %v", err)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("Expected a single request, %d responses are left", len(statuses))
+	}
+}
+
+func TestClient_Invalidate_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+	client := newTestClient(server.URL, 0)
+
+	var timeout *image.Timeout
+	if err := client.Invalidate(context.Background(), "", image.DeleteRequest{}); !errors.As(err, &timeout) {
+		t.Fatalf("Expected the invalidation to time out, got %v", err)
+	}
+}
+
+func TestClient_Resize_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	domain := server.URL
+	server.Close()
+	client := newTestClient(domain, 0)
+
+	var unavailable *image.Unavailable
+	if _, err := client.Resize(context.Background(), "", image.ResizeRequest{}); !errors.As(err, &unavailable) {
+		t.Fatalf("Expected a closed server to be unavailable, got %v", err)
+	}
+}
+
+func TestClient_UploadFile_ContentLength(t *testing.T) {
+	var contentLength int64
+	var transferEncoding []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		contentLength, transferEncoding = req.ContentLength, req.TransferEncoding
+	}))
+	defer server.Close()
+	client := newTestClient(server.URL, 0)
+	signed := image.SignedResponse{SignedUrl: server.URL + "/upload", FileName: "upload.png"}
+
+	err := client.UploadFile(context.Background(), signed, image.PngFormat, strings.NewReader("png bytes"), 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentLength != 9 || len(transferEncoding) != 0 {
+		t.Fatalf("Expected the file to be put with its length, got %d sent %v", contentLength, transferEncoding)
+	}
+
+	err = client.UploadFile(
+		context.Background(), signed, image.PngFormat, strings.NewReader("png bytes"), image.UnknownSize,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentLength != -1 || len(transferEncoding) != 1 || transferEncoding[0] != "chunked" {
+		t.Fatalf("Expected a file of unknown size to be sent chunked, got %d sent %v", contentLength, transferEncoding)
+	}
+}
@@ -0,0 +1,22 @@
+package resize
+
+import (
+	"api/image"
+	"context"
+	"encoding/json"
+)
+
+func (client *Client) DeleteUploads(
+	ctx context.Context,
+	authorizationHeader string,
+	request image.DeleteUploadsRequest,
+) error {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.postJson(ctx, deleteUploadsEndpoint, authorizationHeader, jsonData, "failed deleting uploads")
+
+	return err
+}
@@ -2,11 +2,8 @@ package resize
 
 import (
 	"api/image"
-	"bytes"
 	"context"
 	"encoding/json"
-	"io"
-	"net/http"
 )
 
 func (client *Client) FetchSignedUrl(
@@ -19,59 +16,10 @@ func (client *Client) FetchSignedUrl(
 		return image.SignedResponse{}, err
 	}
 
-	reqUrl := client.url("/signed")
-
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		reqUrl,
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return image.SignedResponse{}, err
-	}
-
-	req.Header.Add("Authorization", authorization)
-	req.Header.Add("Content-Type", "application/json")
-
-	res, err := client.client.Do(req)
-	if err != nil {
-		var status int
-		if res != nil {
-			status = res.StatusCode
-		}
-		return image.SignedResponse{}, &image.BadRequest{
-			RequestError: image.RequestError{
-				Url:        reqUrl,
-				StatusCode: status,
-				Message:    "failed making signed url request",
-				Err:        err,
-			},
-			Body: string(jsonData),
-		}
-	}
-
-	defer func() {
-		if closeErr := res.Body.Close(); closeErr != nil {
-			client.logger.Warn().Msgf("failed closing body: %s", closeErr.Error())
-		}
-	}()
-
-	body, err := io.ReadAll(res.Body)
+	body, err := client.retryJson(ctx, signedEndpoint, authorization, jsonData, "failed making signed url request")
 	if err != nil {
 		return image.SignedResponse{}, err
 	}
-	if !isResponseOk(res.StatusCode) {
-		return image.SignedResponse{}, &image.BadRequest{
-			RequestError: image.RequestError{
-				Url:        reqUrl,
-				StatusCode: res.StatusCode,
-				Message:    "failed reading response",
-				Err:        err,
-			},
-			Body: string(body),
-		}
-	}
 
 	var signedResponse image.SignedResponse
 
@@ -79,10 +27,9 @@ func (client *Client) FetchSignedUrl(
 	if err != nil {
 		return image.SignedResponse{}, &image.BadRequest{
 			RequestError: image.RequestError{
-				Url:        reqUrl,
-				StatusCode: res.StatusCode,
-				Message:    "failed unmarshalling response",
-				Err:        err,
+				Url:     client.url("/" + signedEndpoint),
+				Message: "failed unmarshalling response",
+				Err:     err,
 			},
 			Body: string(body),
 		}
@@ -2,10 +2,8 @@ package resize
 
 import (
 	"api/image"
-	"bytes"
 	"context"
 	"encoding/json"
-	"net/http"
 )
 
 func (client *Client) Invalidate(
@@ -17,40 +15,8 @@ func (client *Client) Invalidate(
 	if err != nil {
 		return err
 	}
-	reqUrl := client.url("/invalidate")
 
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		reqUrl,
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return err
-	}
-
-	req.Header.Add("Authorization", authorizationHeader)
-	req.Header.Add("Content-Type", "application/json")
-
-	res, err := client.client.Do(req)
-	if err != nil {
-		var status int
-		if res != nil {
-			status = res.StatusCode
-		}
-		return &image.BadRequest{
-			RequestError: image.RequestError{
-				Url:        reqUrl,
-				StatusCode: status,
-				Message:    "failed invalidating",
-				Err:        err,
-			},
-			Body: string(jsonData),
-		}
-	}
-	if err = res.Body.Close(); err != nil {
-		client.logger.Warn().Msgf("failed closing body: %s", err.Error())
-	}
+	_, err = client.retryJson(ctx, invalidateEndpoint, authorizationHeader, jsonData, "failed invalidating")
 
-	return nil
+	return err
 }
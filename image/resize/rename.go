@@ -3,12 +3,33 @@ package resize
 import (
 	"api/image"
 	"context"
+	"encoding/json"
 )
 
+// Rename moves every size variant of the image to the new name, the response describes the renamed variants
 func (client *Client) Rename(
 	ctx context.Context,
 	authorizationHeader string,
 	request image.RenameRequest,
 ) (image.ResizeResponse, error) {
-	return image.ResizeResponse{}, nil
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return image.ResizeResponse{}, err
+	}
+
+	client.logger.Info().Msg("issuing rename request")
+
+	body, err := client.postJson(ctx, renameEndpoint, authorizationHeader, jsonData, "Failed rename request")
+	if err != nil {
+		return image.ResizeResponse{}, err
+	}
+
+	var response image.ResizeResponse
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return image.ResizeResponse{}, err
+	}
+
+	return response, nil
 }
@@ -4,50 +4,46 @@ import (
 	"api/image"
 	"context"
 	"fmt"
-	"io/ioutil"
-	"mime/multipart"
+	"io"
 	"net/http"
 )
 
+// UploadFile puts the file to the signed url, it is not retried as the body can only be read once
 func (client *Client) UploadFile(
 	ctx context.Context,
-	signedUrl string,
+	signed image.SignedResponse,
 	format image.Format,
-	fileHeader *multipart.FileHeader,
+	body io.Reader,
+	size int64,
 ) error {
-	file, err := fileHeader.Open()
-	if err != nil {
-		return fmt.Errorf("failed opening file header: %w", err)
-	}
 	contentType := string(format.ToContentType())
 
+	ctx, cancel := client.withTimeout(ctx, uploadEndpoint)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodPut,
-		signedUrl,
-		file,
+		signed.SignedUrl,
+		body,
 	)
 	if err != nil {
 		return err
 	}
 
-	req.ContentLength = fileHeader.Size
+	// a body of UnknownSize is sent chunked, which signed urls of s3 reject, processed files are put with their size
+	req.ContentLength = size
 
 	res, err := client.client.Do(req)
 	if err != nil {
-		var status int
-		if res != nil {
-			status = res.StatusCode
-		}
-		return &image.BadRequest{
-			RequestError: image.RequestError{
-				Url:        signedUrl,
-				StatusCode: status,
-				Message:    fmt.Sprintf("failed upload of file type %s", contentType),
-				Err:        err,
+		return image.NewTransportError(
+			image.RequestError{
+				Url:     signed.SignedUrl,
+				Message: fmt.Sprintf("failed upload of file type %s", contentType),
+				Err:     err,
 			},
-			Body: "",
-		}
+			"",
+		)
 	}
 	defer func() {
 		if closeErr := res.Body.Close(); closeErr != nil {
@@ -56,32 +52,20 @@ func (client *Client) UploadFile(
 	}()
 
 	if !isResponseOk(res.StatusCode) {
-		body, e := ioutil.ReadAll(res.Body)
+		body, e := io.ReadAll(res.Body)
 		if e != nil {
 			return e
 		}
-		bodyMsg := fmt.Sprintf("Body: %s", body)
-		fileTypeErr := fmt.Errorf("failed uploading file of type %s", contentType)
-
-		if res.StatusCode == 403 {
-			return &image.Forbidden{
-				RequestError: image.RequestError{
-					Url:        signedUrl,
-					StatusCode: res.StatusCode,
-					Message:    bodyMsg,
-					Err:        fileTypeErr,
-				},
-			}
-		}
 
-		return &image.BadRequest{
-			RequestError: image.RequestError{
-				Url:        signedUrl,
+		return image.NewStatusError(
+			image.RequestError{
+				Url:        signed.SignedUrl,
 				StatusCode: res.StatusCode,
-				Message:    bodyMsg,
-				Err:        fileTypeErr,
+				Message:    fmt.Sprintf("Body: %s", body),
+				Err:        fmt.Errorf("failed uploading file of type %s", contentType),
 			},
-		}
+			"",
+		)
 	}
 
 	return nil
@@ -2,26 +2,48 @@ package image
 
 import (
 	"context"
-	"mime/multipart"
+	"errors"
+	"io"
 )
 
+// ErrCallbackUnsupported is returned by resizers that can't report a requested resize through a callback
+var ErrCallbackUnsupported = errors.New("resize callbacks are not supported")
+
+// UnknownSize is the size of a body whose length is only known once it was read, it is sent chunked
+const UnknownSize int64 = -1
+
+// Upload is the content of a file that is streamed to a signed url while it is read
+type Upload struct {
+	Body io.Reader
+	// Size is the length of Body or UnknownSize, signed urls of s3 reject bodies sent without it
+	Size int64
+}
+
 type Resizer interface {
 	FetchSignedUrl(
 		ctx context.Context,
 		authorization string,
 		format Format,
 	) (SignedResponse, error)
+	// UploadFile streams the body to the signed url of the response, with a size of UnknownSize it is sent chunked
 	UploadFile(
 		ctx context.Context,
-		signedUrl string,
+		signed SignedResponse,
 		format Format,
-		fileHeader *multipart.FileHeader,
+		body io.Reader,
+		size int64,
 	) error
 	Resize(
 		ctx context.Context,
 		authorizationHeader string,
 		imageResizeRequest ResizeRequest,
 	) (ResizeResponse, error)
+	// RequestResize starts resizing in the background, its ResizeCallback is posted to the CallbackUrl of the request
+	RequestResize(
+		ctx context.Context,
+		authorizationHeader string,
+		imageResizeRequest ResizeRequest,
+	) error
 	Rename(
 		ctx context.Context,
 		authorizationHeader string,
@@ -37,4 +59,10 @@ type Resizer interface {
 		authorizationHeader string,
 		request DeleteRequest,
 	) error
+	// DeleteUploads removes files uploaded through signed urls that were not resized
+	DeleteUploads(
+		ctx context.Context,
+		authorizationHeader string,
+		request DeleteUploadsRequest,
+	) error
 }
@@ -2,7 +2,7 @@ package image
 
 import (
 	"context"
-	"mime/multipart"
+	"io"
 )
 
 type Mock struct {
@@ -18,9 +18,10 @@ func (resize Mock) FetchSignedUrl(
 
 func (resize Mock) UploadFile(
 	ctx context.Context,
-	signedUrl string,
+	signed SignedResponse,
 	format Format,
-	fileHeader *multipart.FileHeader,
+	body io.Reader,
+	size int64,
 ) error {
 	return nil
 }
@@ -31,6 +32,12 @@ func (resize Mock) Resize(
 	return ResizeResponse{}, nil
 }
 
+func (resize Mock) RequestResize(
+	ctx context.Context, authorizationHeader string, imageResizeRequest ResizeRequest,
+) error {
+	return nil
+}
+
 func (resize Mock) Invalidate(
 	ctx context.Context,
 	authorizationHeader string,
@@ -53,3 +60,11 @@ func (resize Mock) Delete(
 ) error {
 	return nil
 }
+
+func (resize Mock) DeleteUploads(
+	ctx context.Context,
+	authorizationHeader string,
+	request DeleteUploadsRequest,
+) error {
+	return nil
+}
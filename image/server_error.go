@@ -0,0 +1,7 @@
+package image
+
+// ServerError is a 5xx response of the images api, other than an unavailable api or a timeout
+type ServerError struct {
+	RequestError
+	Body string
+}
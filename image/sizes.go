@@ -1,6 +1,12 @@
 package image
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 type Dimensions struct {
 	Width  int `json:"width,omitempty"`
@@ -36,86 +42,159 @@ func AreEqual(dimensions *Dimensions, d *Dimensions) bool {
 	return false
 }
 
+// Sizes are the dimensions of the original and of the variant of each size profile. In json the variants are keyed
+// by profile name next to the original, which is the shape of the v1 api and the resize api.
 type Sizes struct {
-	Original Dimensions  `json:"original"`
-	Xs       *Dimensions `json:"xs,omitempty"`
-	S        *Dimensions `json:"s,omitempty"`
-	M        *Dimensions `json:"m,omitempty"`
-	L        *Dimensions `json:"l,omitempty"`
-	XL       *Dimensions `json:"xl,omitempty"`
-	XXL      *Dimensions `json:"xxl,omitempty"`
-	XXXL     *Dimensions `json:"xxxl,omitempty"`
+	Original Dimensions
+	Variants map[string]Dimensions
 }
 
-func (sizes Sizes) ToString() string {
-	return fmt.Sprintf(
-		"Sizes{original: %s, xs: %s, s: %s, m: %s, l: %s, xl: %s, xxl: %s, xxxl: %s}",
-		sizes.Original.ToString(),
-		sizes.Xs.ToString(),
-		sizes.S.ToString(),
-		sizes.M.ToString(),
-		sizes.L.ToString(),
-		sizes.XL.ToString(),
-		sizes.XXL.ToString(),
-		sizes.XXXL.ToString(),
-	)
-}
+// VariantFormats lists by format the variants that are also available in other formats than the one of the image.
+// It is kept next to the sizes, which only name profiles.
+type VariantFormats map[Format][]string
 
-func (sizes Sizes) IsEqualTo(compareSizes Sizes) bool {
-	if !AreEqual(&sizes.Original, &compareSizes.Original) {
-		return false
-	}
-	if !AreEqual(sizes.Xs, compareSizes.Xs) {
-		return false
-	}
-	if !AreEqual(sizes.S, compareSizes.S) {
-		return false
+// Others lists the formats any variant is available in, in the order of SupportedFormats
+func (formats VariantFormats) Others() []Format {
+	others := make([]Format, 0, len(formats))
+	for _, format := range SupportedFormats {
+		if len(formats[format]) > 0 {
+			others = append(others, format)
+		}
 	}
-	if !AreEqual(sizes.M, compareSizes.M) {
-		return false
+
+	return others
+}
+
+// Of lists the format of the image followed by the other formats the variant of the size is available in
+func (formats VariantFormats) Of(format Format, name string) []Format {
+	available := []Format{format}
+	for _, other := range SupportedFormats {
+		for _, variant := range formats[other] {
+			if variant == name && other != format {
+				available = append(available, other)
+				break
+			}
+		}
 	}
-	if !AreEqual(sizes.L, compareSizes.L) {
+
+	return available
+}
+
+func (formats VariantFormats) IsEqualTo(compareFormats VariantFormats) bool {
+	if len(formats) != len(compareFormats) {
 		return false
 	}
-	if !AreEqual(sizes.XL, compareSizes.XL) {
-		return false
+	for format, names := range formats {
+		if strings.Join(names, ",") != strings.Join(compareFormats[format], ",") {
+			return false
+		}
 	}
-	if !AreEqual(sizes.XXL, compareSizes.XXL) {
-		return false
+
+	return true
+}
+
+// VariantNames are the profile names of the variants ordered by width, then by name
+func (sizes Sizes) VariantNames() []string {
+	names := make([]string, 0, len(sizes.Variants))
+	for name := range sizes.Variants {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		left, right := sizes.Variants[names[i]], sizes.Variants[names[j]]
+		if left.Width != right.Width {
+			return left.Width < right.Width
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+func (sizes Sizes) ToString() string {
+	values := []string{"original: " + sizes.Original.ToString()}
+	for _, name := range sizes.VariantNames() {
+		variant := sizes.Variants[name]
+		values = append(values, name+": "+variant.ToString())
 	}
-	if !AreEqual(sizes.XXL, compareSizes.XXL) {
+
+	return fmt.Sprintf("Sizes{%s}", strings.Join(values, ", "))
+}
+
+func (sizes Sizes) IsEqualTo(compareSizes Sizes) bool {
+	if !AreEqual(&sizes.Original, &compareSizes.Original) || len(sizes.Variants) != len(compareSizes.Variants) {
 		return false
 	}
-	if !AreEqual(sizes.XXXL, compareSizes.XXXL) {
-		return false
+	for name, variant := range sizes.Variants {
+		compareVariant, ok := compareSizes.Variants[name]
+		if !ok || !AreEqual(&variant, &compareVariant) {
+			return false
+		}
 	}
+
 	return true
 }
 
+// GetAllDimensions lists the original followed by the variants in the order of VariantNames
 func (sizes Sizes) GetAllDimensions() []Dimensions {
 	dimensions := []Dimensions{sizes.Original}
-
-	if sizes.Xs != nil {
-		dimensions = append(dimensions, *sizes.Xs)
-	}
-	if sizes.S != nil {
-		dimensions = append(dimensions, *sizes.S)
+	for _, name := range sizes.VariantNames() {
+		dimensions = append(dimensions, sizes.Variants[name])
 	}
-	if sizes.M != nil {
-		dimensions = append(dimensions, *sizes.M)
-	}
-	if sizes.L != nil {
-		dimensions = append(dimensions, *sizes.L)
+
+	return dimensions
+}
+
+func (sizes Sizes) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString(`{"original":`)
+	if err := writeJsonValue(buf, sizes.Original); err != nil {
+		return nil, err
+	}
+	for _, name := range sizes.VariantNames() {
+		buf.WriteByte(',')
+		if err := writeJsonValue(buf, name); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := writeJsonValue(buf, sizes.Variants[name]); err != nil {
+			return nil, err
+		}
 	}
-	if sizes.XL != nil {
-		dimensions = append(dimensions, *sizes.XL)
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func (sizes *Sizes) UnmarshalJSON(data []byte) error {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
 	}
-	if sizes.XXL != nil {
-		dimensions = append(dimensions, *sizes.XXL)
+
+	*sizes = Sizes{Variants: make(map[string]Dimensions, len(values))}
+	for name, value := range values {
+		var err error
+		switch name {
+		case OriginalSize:
+			err = json.Unmarshal(value, &sizes.Original)
+		default:
+			var dimensions Dimensions
+			err = json.Unmarshal(value, &dimensions)
+			sizes.Variants[name] = dimensions
+		}
+		if err != nil {
+			return err
+		}
 	}
-	if sizes.XXXL != nil {
-		dimensions = append(dimensions, *sizes.XXXL)
+
+	return nil
+}
+
+func writeJsonValue(buf *bytes.Buffer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
 	}
+	buf.Write(data)
 
-	return dimensions
+	return nil
 }
@@ -0,0 +1,7 @@
+package image
+
+// Timeout is an images api that didn't respond in time or responded with 504
+type Timeout struct {
+	RequestError
+	Body string
+}
@@ -0,0 +1,91 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Transformation is a derivative of an original in an arbitrary size, it scales like a profile of the same size
+type Transformation struct {
+	Width   int
+	Height  int
+	Fit     Fit
+	Quality int
+	Format  Format
+}
+
+// Transformer renders derivatives of originals
+type Transformer interface {
+	// Transform decodes the original and writes the encoded derivative to w
+	Transform(ctx context.Context, original io.Reader, transformation Transformation, w io.Writer) error
+}
+
+// ParseDimensions reads WIDTHxHEIGHT, a side of 0 is unbounded like in size profiles
+func ParseDimensions(value string) (Dimensions, error) {
+	width, height, found := strings.Cut(value, "x")
+	parsedWidth, widthErr := strconv.Atoi(width)
+	parsedHeight, heightErr := strconv.Atoi(height)
+	if !found || widthErr != nil || heightErr != nil || parsedWidth < 0 || parsedHeight < 0 {
+		return Dimensions{}, fmt.Errorf("invalid dimensions %q, expected WIDTHxHEIGHT", value)
+	}
+	if parsedWidth == 0 && parsedHeight == 0 {
+		return Dimensions{}, fmt.Errorf("invalid dimensions %q, at least one side has to be set", value)
+	}
+
+	return Dimensions{Width: parsedWidth, Height: parsedHeight}, nil
+}
+
+// ParseDimensionsList reads comma separated dimensions, like 300x200,600x0
+func ParseDimensionsList(value string) ([]Dimensions, error) {
+	list := make([]Dimensions, 0)
+	for _, item := range strings.Split(value, ",") {
+		dimensions, err := ParseDimensions(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, dimensions)
+	}
+
+	return list, nil
+}
+
+func (transformation Transformation) Profile() Profile {
+	return Profile{
+		Width:   transformation.Width,
+		Height:  transformation.Height,
+		Fit:     transformation.Fit,
+		Quality: transformation.Quality,
+	}
+}
+
+// Dimensions returns the size of the derivative of an image of the width and height. Images are never upscaled,
+// the derivative of a smaller image has the largest size within it that keeps the requested aspect ratio.
+func (transformation Transformation) Dimensions(width, height int) Dimensions {
+	if dimensions, ok := transformation.Profile().Dimensions(width, height); ok {
+		return dimensions
+	}
+	if transformation.Fit == FitContain || transformation.Width == 0 || transformation.Height == 0 {
+		return Dimensions{Width: width, Height: height}
+	}
+
+	scale := math.Min(
+		float64(width)/float64(transformation.Width),
+		float64(height)/float64(transformation.Height),
+	)
+	return Dimensions{
+		Width:  int(math.Max(1, math.Round(float64(transformation.Width)*scale))),
+		Height: int(math.Max(1, math.Round(float64(transformation.Height)*scale))),
+	}
+}
+
+// String is the canonical form of the transformation, which is signed and identifies cached derivatives
+func (transformation Transformation) String() string {
+	return fmt.Sprintf(
+		"%dx%d_%s_q%d.%s",
+		transformation.Width, transformation.Height, transformation.Fit, transformation.Quality, transformation.Format,
+	)
+}
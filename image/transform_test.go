@@ -0,0 +1,53 @@
+package image
+
+import (
+	"testing"
+)
+
+func TestParseDimensionsList(t *testing.T) {
+	list, err := ParseDimensionsList("300x200, 600x0,0x100")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Dimensions{{Width: 300, Height: 200}, {Width: 600}, {Height: 100}}
+	if len(list) != len(expected) {
+		t.Fatalf("Expected %d dimensions, got %d", len(expected), len(list))
+	}
+	for i := range expected {
+		if list[i] != expected[i] {
+			t.Fatalf("Expected dimensions %+v, got %+v", expected[i], list[i])
+		}
+	}
+
+	for _, value := range []string{"", "300", "0x0", "-1x100", "300x200,", "axb"} {
+		if _, err = ParseDimensionsList(value); err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestTransformation_Dimensions(t *testing.T) {
+	tests := []struct {
+		transformation Transformation
+		width          int
+		height         int
+		expected       Dimensions
+	}{
+		{Transformation{Width: 300, Fit: FitContain}, 1200, 800, Dimensions{Width: 300, Height: 200}},
+		{Transformation{Width: 300, Fit: FitContain}, 200, 100, Dimensions{Width: 200, Height: 100}},
+		{Transformation{Width: 300, Height: 300, Fit: FitCover}, 1200, 800, Dimensions{Width: 300, Height: 300}},
+		{Transformation{Width: 1600, Height: 900, Fit: FitCover}, 1200, 800, Dimensions{Width: 1200, Height: 675}},
+		{Transformation{Width: 400, Height: 1000, Fit: FitFill}, 1200, 800, Dimensions{Width: 320, Height: 800}},
+	}
+
+	for _, test := range tests {
+		dimensions := test.transformation.Dimensions(test.width, test.height)
+		if dimensions != test.expected {
+			t.Errorf(
+				"Expected %+v of %dx%d to be %+v, got %+v",
+				test.transformation, test.width, test.height, test.expected, dimensions,
+			)
+		}
+	}
+}
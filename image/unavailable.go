@@ -0,0 +1,7 @@
+package image
+
+// Unavailable is an images api that can't be reached or responded with 503
+type Unavailable struct {
+	RequestError
+	Body string
+}
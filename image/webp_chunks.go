@@ -0,0 +1,129 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Flags of the VP8X chunk telling which metadata chunks follow
+const (
+	webpXmpFlag  = 0x04
+	webpExifFlag = 0x08
+)
+
+// riffChunk is a chunk of a webp, data excludes the fourcc, the length and the padding
+type riffChunk struct {
+	fourcc string
+	data   []byte
+}
+
+// splitWebp separates the chunks of the webp, the RIFF header is written again when joined
+func splitWebp(file []byte) ([]riffChunk, error) {
+	if len(file) < 12 || string(file[:4]) != "RIFF" || string(file[8:12]) != "WEBP" {
+		return nil, errors.New("not a webp file")
+	}
+	end := 8 + uint64(binary.LittleEndian.Uint32(file[4:]))
+	if end > uint64(len(file)) {
+		return nil, errors.New("truncated webp file")
+	}
+
+	var chunks []riffChunk
+	pos := uint64(12)
+	for pos < end {
+		if pos+8 > end {
+			return nil, errors.New("truncated webp chunk")
+		}
+		length := uint64(binary.LittleEndian.Uint32(file[pos+4:]))
+		if pos+8+length > end {
+			return nil, fmt.Errorf("invalid length of webp chunk at %d", pos)
+		}
+		chunks = append(chunks, riffChunk{fourcc: string(file[pos : pos+4]), data: file[pos+8 : pos+8+length]})
+		pos += 8 + length + length%2
+	}
+
+	return chunks, nil
+}
+
+// joinWebp writes the chunks back into a webp file, padding the odd ones
+func joinWebp(chunks []riffChunk) []byte {
+	size := 4
+	for _, chunk := range chunks {
+		size += 8 + len(chunk.data) + len(chunk.data)%2
+	}
+
+	file := make([]byte, 0, 8+size)
+	file = append(file, "RIFF"...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(size))
+	file = append(file, "WEBP"...)
+	for _, chunk := range chunks {
+		file = append(file, chunk.fourcc...)
+		file = binary.LittleEndian.AppendUint32(file, uint32(len(chunk.data)))
+		file = append(file, chunk.data...)
+		if len(chunk.data)%2 == 1 {
+			file = append(file, 0)
+		}
+	}
+
+	return file
+}
+
+// processWebp strips the EXIF chunk of the webp, its XMP chunk is dropped when stripping. The flags of the VP8X
+// chunk follow the metadata chunks that are left.
+func (processor *MetadataProcessor) processWebp(file []byte) ([]byte, Metadata, error) {
+	chunks, err := splitWebp(file)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	var metadata Metadata
+	isChanged, isExifRead, isExifKept, isXmpKept := false, false, false, false
+	kept := make([]riffChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		switch {
+		case chunk.fourcc == "EXIF" && !isExifRead:
+			isExifRead = true
+			// some encoders write the exif with the prefix of its jpg segment
+			prefix := []byte{}
+			if bytes.HasPrefix(chunk.data, exifPrefix) {
+				prefix = exifPrefix
+			}
+			tiff, exifMetadata, isExifChanged := processor.processTiff(chunk.data[len(prefix):])
+			metadata = exifMetadata
+			isChanged = isChanged || isExifChanged
+			if tiff != nil {
+				isExifKept = true
+				kept = append(kept, riffChunk{fourcc: chunk.fourcc, data: append(append([]byte{}, prefix...), tiff...)})
+			}
+		case chunk.fourcc == "EXIF":
+			// repeated exif is dropped like in jpg files
+			isChanged = true
+		case chunk.fourcc == "XMP " && processor.isStripping:
+			isChanged = true
+		default:
+			isXmpKept = isXmpKept || chunk.fourcc == "XMP "
+			kept = append(kept, chunk)
+		}
+	}
+	if !isChanged {
+		return file, metadata, nil
+	}
+
+	for i, chunk := range kept {
+		if chunk.fourcc != "VP8X" || len(chunk.data) == 0 {
+			continue
+		}
+		header := append([]byte{}, chunk.data...)
+		header[0] &^= webpExifFlag | webpXmpFlag
+		if isExifKept {
+			header[0] |= webpExifFlag
+		}
+		if isXmpKept {
+			header[0] |= webpXmpFlag
+		}
+		kept[i].data = header
+	}
+
+	return joinWebp(kept), metadata, nil
+}
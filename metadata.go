@@ -0,0 +1,11 @@
+package api
+
+import (
+	"api/core"
+	"api/image"
+)
+
+// NewMetadataProcessor strips the metadata fields of the IMAGES_STRIP_METADATA configuration from uploads
+func NewMetadataProcessor(config core.Config) *image.MetadataProcessor {
+	return image.NewMetadataProcessor(config.ImagesStripMetadata)
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"api/core"
+	"api/objectstore"
+	"api/objectstore/filesystem"
+	"api/objectstore/s3"
+	"crypto/rand"
+	"fmt"
+	"github.com/rs/zerolog"
+)
+
+// NewObjectSigner signs with the OBJECT_STORE_SIGNING_KEY, without one a random key is generated and signed urls
+// stop working on restart
+func NewObjectSigner(config core.Config, logger *zerolog.Logger) (*objectstore.Signer, error) {
+	key := []byte(config.ObjectStoreSigningKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed generating signing key: %w", err)
+		}
+		if config.ObjectStore == core.ObjectStoreFilesystem {
+			logger.Warn().Msg("missing env OBJECT_STORE_SIGNING_KEY, signed urls expire on restart")
+		}
+	}
+
+	return objectstore.NewSigner(key, config.ObjectStorePublicUrl), nil
+}
+
+// NewObjectStore returns the objectstore.ObjectStore selected by the OBJECT_STORE configuration
+func NewObjectStore(config core.Config, signer *objectstore.Signer) (objectstore.ObjectStore, error) {
+	if config.ObjectStore == core.ObjectStoreS3 {
+		return s3.NewStore(config)
+	}
+
+	return filesystem.NewStore(config.ImagesLocalDirectory, signer)
+}
@@ -0,0 +1,164 @@
+package filesystem
+
+import (
+	"api/objectstore"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const tmpPrefix = ".tmp-"
+
+// Store keeps objects as files of a local directory, presigned urls point to the objects endpoint of the api. The
+// content type of an object is derived from the extension of its key.
+type Store struct {
+	directory string
+	signer    *objectstore.Signer
+}
+
+func NewStore(directory string, signer *objectstore.Signer) (*Store, error) {
+	absolute, err := filepath.Abs(directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving objects directory: %w", err)
+	}
+	if err = os.MkdirAll(absolute, 0o755); err != nil {
+		return nil, fmt.Errorf("failed creating objects directory: %w", err)
+	}
+
+	return &Store{directory: absolute, signer: signer}, nil
+}
+
+func (store *Store) path(key string) (string, error) {
+	if err := objectstore.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(store.directory, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file that is moved to the key once complete, so readers never see partial objects
+func (store *Store) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	filePath, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), tmpPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (store *Store) Get(_ context.Context, key string) (objectstore.Object, error) {
+	filePath, err := store.path(key)
+	if err != nil {
+		return objectstore.Object{}, err
+	}
+
+	file, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return objectstore.Object{}, objectstore.ErrNotFound
+	}
+	if err != nil {
+		return objectstore.Object{}, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return objectstore.Object{}, err
+	}
+	if info.IsDir() {
+		_ = file.Close()
+		return objectstore.Object{}, objectstore.ErrNotFound
+	}
+
+	return objectstore.Object{
+		Body:        file,
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+	}, nil
+}
+
+func (store *Store) Delete(_ context.Context, key string) error {
+	filePath, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// List walks the directory of the prefix, temporary files of unfinished puts are skipped
+func (store *Store) List(_ context.Context, prefix string) ([]objectstore.ObjectInfo, error) {
+	objects := make([]objectstore.ObjectInfo, 0)
+	root := store.directory
+	if dir := path.Dir(prefix); prefix != "" && dir != "." {
+		root = filepath.Join(store.directory, filepath.FromSlash(path.Clean("/"+dir)))
+	}
+
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tmpPrefix) {
+			return nil
+		}
+
+		relative, err := filepath.Rel(store.directory, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relative)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, objectstore.ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+
+		return nil
+	})
+
+	return objects, err
+}
+
+func (store *Store) Presign(_ context.Context, method string, key string, expiry time.Duration) (string, error) {
+	if err := objectstore.ValidateKey(key); err != nil {
+		return "", err
+	}
+	if !objectstore.IsPresignableMethod(method) {
+		return "", fmt.Errorf("can't presign method %s", method)
+	}
+
+	return store.signer.SignUrl(method, key, time.Now().Add(expiry)), nil
+}
@@ -0,0 +1,111 @@
+package filesystem
+
+import (
+	"api/objectstore"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *Store {
+	store, err := NewStore(t.TempDir(), objectstore.NewSigner([]byte("secret"), "http://localhost:3000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func TestStore_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	if err := store.Put(ctx, "images/a.png", strings.NewReader("png"), -1, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	object, err := store.Get(ctx, "images/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(object.Body)
+	_ = object.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "png" || object.Size != 3 || object.ContentType != "image/png" {
+		t.Fatalf("Unexpected object %q of size %d and type %s", content, object.Size, object.ContentType)
+	}
+
+	if err = store.Delete(ctx, "images/a.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Delete(ctx, "images/a.png"); err != nil {
+		t.Fatalf("Expected deleting a missing object to succeed, got %v", err)
+	}
+	if _, err = store.Get(ctx, "images/a.png"); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("Expected deleted object to be not found, got %v", err)
+	}
+	if _, err = store.Get(ctx, "images"); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("Expected directories to be not found, got %v", err)
+	}
+	if err = store.Put(ctx, "../a.png", strings.NewReader(""), 0, ""); !errors.Is(err, objectstore.ErrInvalidKey) {
+		t.Fatalf("Expected keys outside the directory to be rejected, got %v", err)
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	for _, key := range []string{"images/a.png", "images/a_100x100.png", "images/b.png", "uploads/a.png"} {
+		if err := store.Put(ctx, key, strings.NewReader(key), -1, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	objects, err := store.List(ctx, "images/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0)
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "images/a.png,images/a_100x100.png" {
+		t.Fatalf("Unexpected keys %v", keys)
+	}
+
+	objects, err = store.List(ctx, "missing/")
+	if err != nil || len(objects) != 0 {
+		t.Fatalf("Expected no objects of a missing prefix, got %v and %v", objects, err)
+	}
+}
+
+func TestStore_Presign(t *testing.T) {
+	store := newTestStore(t)
+
+	signed, err := store.Presign(context.Background(), http.MethodGet, "images/a.png", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Path != "/api/v1/objects/images/a.png" {
+		t.Fatalf("Unexpected path %s", parsed.Path)
+	}
+	if err = store.signer.Verify(http.MethodGet, "images/a.png", parsed.Query(), time.Now()); err != nil {
+		t.Fatalf("Expected presigned url to verify, got %v", err)
+	}
+
+	if _, err = store.Presign(context.Background(), http.MethodDelete, "images/a.png", time.Minute); err == nil {
+		t.Fatal("Expected presigning deletes to fail")
+	}
+}
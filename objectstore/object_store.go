@@ -0,0 +1,62 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Path is where the api serves the objects endpoint of signed urls
+const Path = "/api/v1/objects"
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Object is the content of a stored object, the caller closes the body
+type Object struct {
+	Body        io.ReadCloser
+	Size        int64
+	ContentType string
+}
+
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ObjectStore keeps objects under slash separated keys
+type ObjectStore interface {
+	// Put stores the body under the key, size is -1 when it's unknown
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get returns ErrNotFound when there is no object with the key
+	Get(ctx context.Context, key string) (Object, error)
+	// Delete succeeds for keys that don't exist
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Presign returns a url that allows the method, http.MethodGet or http.MethodPut, on the key until it expires
+	Presign(ctx context.Context, method string, key string, expiry time.Duration) (string, error)
+}
+
+// ValidateKey rejects keys that are empty, absolute or could escape their prefix
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsAny(key, "\\\x00") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
+
+func IsPresignableMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodPut
+}
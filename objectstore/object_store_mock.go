@@ -0,0 +1,30 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type Mock struct {
+}
+
+func (store Mock) Put(_ context.Context, _ string, _ io.Reader, _ int64, _ string) error {
+	return nil
+}
+
+func (store Mock) Get(_ context.Context, _ string) (Object, error) {
+	return Object{}, ErrNotFound
+}
+
+func (store Mock) Delete(_ context.Context, _ string) error {
+	return nil
+}
+
+func (store Mock) List(_ context.Context, _ string) ([]ObjectInfo, error) {
+	return []ObjectInfo{}, nil
+}
+
+func (store Mock) Presign(_ context.Context, _ string, _ string, _ time.Duration) (string, error) {
+	return "", nil
+}
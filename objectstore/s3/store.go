@@ -0,0 +1,172 @@
+package s3
+
+import (
+	"api/core"
+	"api/objectstore"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Store keeps objects in a bucket of S3 or of an S3 compatible server like MinIO, which is set as the endpoint
+type Store struct {
+	bucket   string
+	client   *awss3.S3
+	uploader *s3manager.Uploader
+}
+
+func NewStore(config core.Config) (*Store, error) {
+	if config.S3Bucket == "" {
+		return nil, errors.New("missing s3 bucket")
+	}
+
+	awsConfig := aws.NewConfig().
+		WithRegion(config.AwsRegion).
+		WithS3ForcePathStyle(config.S3ForcePathStyle)
+	if config.S3Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(config.S3Endpoint)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed creating s3 session: %w", err)
+	}
+	client := awss3.New(sess)
+
+	return &Store{
+		bucket:   config.S3Bucket,
+		client:   client,
+		uploader: s3manager.NewUploaderWithClient(client),
+	}, nil
+}
+
+// Put streams the body, bodies larger than a part are uploaded in parts so the size doesn't need to be known
+func (store *Store) Put(ctx context.Context, key string, body io.Reader, _ int64, contentType string) error {
+	if err := objectstore.ValidateKey(key); err != nil {
+		return err
+	}
+
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if _, err := store.uploader.UploadWithContext(ctx, input); err != nil {
+		return fmt.Errorf("failed putting object %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (store *Store) Get(ctx context.Context, key string) (objectstore.Object, error) {
+	if err := objectstore.ValidateKey(key); err != nil {
+		return objectstore.Object{}, err
+	}
+
+	out, err := store.client.GetObjectWithContext(ctx, &awss3.GetObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return objectstore.Object{}, objectstore.ErrNotFound
+		}
+		return objectstore.Object{}, fmt.Errorf("failed getting object %s: %w", key, err)
+	}
+
+	return objectstore.Object{
+		Body:        out.Body,
+		Size:        aws.Int64Value(out.ContentLength),
+		ContentType: aws.StringValue(out.ContentType),
+	}, nil
+}
+
+// Delete succeeds for missing keys as S3 doesn't report them
+func (store *Store) Delete(ctx context.Context, key string) error {
+	if err := objectstore.ValidateKey(key); err != nil {
+		return err
+	}
+
+	_, err := store.client.DeleteObjectWithContext(ctx, &awss3.DeleteObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed deleting object %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (store *Store) List(ctx context.Context, prefix string) ([]objectstore.ObjectInfo, error) {
+	objects := make([]objectstore.ObjectInfo, 0)
+	input := &awss3.ListObjectsV2Input{
+		Bucket: aws.String(store.bucket),
+		Prefix: aws.String(prefix),
+	}
+
+	err := store.client.ListObjectsV2PagesWithContext(ctx, input, func(page *awss3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			objects = append(objects, objectstore.ObjectInfo{
+				Key:          aws.StringValue(object.Key),
+				Size:         aws.Int64Value(object.Size),
+				LastModified: aws.TimeValue(object.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed listing objects of %s: %w", prefix, err)
+	}
+
+	return objects, nil
+}
+
+func (store *Store) Presign(_ context.Context, method string, key string, expiry time.Duration) (string, error) {
+	if err := objectstore.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	var req *request.Request
+	switch method {
+	case http.MethodGet:
+		req, _ = store.client.GetObjectRequest(&awss3.GetObjectInput{
+			Bucket: aws.String(store.bucket),
+			Key:    aws.String(key),
+		})
+	case http.MethodPut:
+		req, _ = store.client.PutObjectRequest(&awss3.PutObjectInput{
+			Bucket: aws.String(store.bucket),
+			Key:    aws.String(key),
+		})
+	default:
+		return "", fmt.Errorf("can't presign method %s", method)
+	}
+
+	return req.Presign(expiry)
+}
+
+func isNotFound(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+
+	return awsErr.Code() == awss3.ErrCodeNoSuchKey || awsErr.Code() == "NotFound"
+}
@@ -0,0 +1,79 @@
+package objectstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	expiresParam   = "expires"
+	signatureParam = "signature"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpiredSignature = errors.New("expired signature")
+)
+
+// Signer signs urls of the objects endpoint, which serves stores that can't presign urls themselves
+type Signer struct {
+	key     []byte
+	baseUrl string
+}
+
+// NewSigner signs urls of the objects endpoint served under the public url
+func NewSigner(key []byte, publicUrl string) *Signer {
+	return &Signer{
+		key:     key,
+		baseUrl: strings.TrimSuffix(publicUrl, "/") + Path,
+	}
+}
+
+func (signer *Signer) signature(method, key string, expires int64) string {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(method + "\n" + key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignUrl returns the url of the objects endpoint allowing the method on the key until expiry
+func (signer *Signer) SignUrl(method, key string, expiry time.Time) string {
+	expires := expiry.Unix()
+	query := url.Values{}
+	query.Set(expiresParam, strconv.FormatInt(expires, 10))
+	query.Set(signatureParam, signer.signature(method, key, expires))
+
+	escaped := make([]string, 0)
+	for _, segment := range strings.Split(key, "/") {
+		escaped = append(escaped, url.PathEscape(segment))
+	}
+
+	return signer.baseUrl + "/" + strings.Join(escaped, "/") + "?" + query.Encode()
+}
+
+// Verify checks the signature in the query of a signed url
+func (signer *Signer) Verify(method, key string, query url.Values, now time.Time) error {
+	expires, err := strconv.ParseInt(query.Get(expiresParam), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	signature, err := hex.DecodeString(query.Get(signatureParam))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	expected, _ := hex.DecodeString(signer.signature(method, key, expires))
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidSignature
+	}
+	if now.Unix() > expires {
+		return ErrExpiredSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package objectstore
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner_SignUrlAndVerify(t *testing.T) {
+	signer := NewSigner([]byte("secret"), "http://localhost:3000/")
+	now := time.Unix(1700000000, 0)
+
+	signed := signer.SignUrl(http.MethodPut, "uploads/a b.png", now.Add(time.Minute))
+	if !strings.HasPrefix(signed, "http://localhost:3000/api/v1/objects/uploads/a%20b.png?") {
+		t.Fatalf("Unexpected signed url %s", signed)
+	}
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+
+	if err = signer.Verify(http.MethodPut, "uploads/a b.png", query, now); err != nil {
+		t.Fatalf("Expected signature to be valid, got %v", err)
+	}
+	if err = signer.Verify(http.MethodGet, "uploads/a b.png", query, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected signature of another method to be invalid, got %v", err)
+	}
+	if err = signer.Verify(http.MethodPut, "uploads/other.png", query, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected signature of another key to be invalid, got %v", err)
+	}
+	err = signer.Verify(http.MethodPut, "uploads/a b.png", query, now.Add(2*time.Minute))
+	if !errors.Is(err, ErrExpiredSignature) {
+		t.Fatalf("Expected signature to be expired, got %v", err)
+	}
+
+	query.Set(expiresParam, "1800000000")
+	if err = signer.Verify(http.MethodPut, "uploads/a b.png", query, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected an extended expiry to invalidate the signature, got %v", err)
+	}
+	other := NewSigner([]byte("other"), "http://localhost:3000")
+	if err = other.Verify(http.MethodPut, "uploads/a b.png", parsed.Query(), now); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected signature of another key to be invalid, got %v", err)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	valid := []string{"uploads/a.png", "images/revisions/1/2.jpg", "a"}
+	for _, key := range valid {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", key, err)
+		}
+	}
+
+	invalid := []string{"", "/etc/passwd", "../a.png", "images/../../a.png", "images//a.png", "images/", "a\\b"}
+	for _, key := range invalid {
+		if err := ValidateKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected %q to be invalid, got %v", key, err)
+		}
+	}
+}
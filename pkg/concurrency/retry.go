@@ -2,6 +2,7 @@ package concurrency
 
 import (
 	"context"
+	"errors"
 	"math/rand"
 	"time"
 )
@@ -24,6 +25,30 @@ func NewRetry(retries uint, delay time.Duration) *Retry {
 	}
 }
 
+// WithBackoff sets the backoff doubled on every retry, starting at base and capped at maximum
+func (r *Retry) WithBackoff(base time.Duration, maximum time.Duration) *Retry {
+	r.baseBackoff = base
+	r.maximumBackoff = maximum
+	return r
+}
+
+// stopError ends the retries of Execute
+type stopError struct {
+	err error
+}
+
+func (stop stopError) Error() string {
+	return stop.err.Error()
+}
+
+// Stop wraps an error that is not worth retrying, Execute returns the wrapped error right away
+func Stop(err error) error {
+	if err == nil {
+		return nil
+	}
+	return stopError{err: err}
+}
+
 // Execute will retry failed request with a jitter backoff algorithm. Please
 // execute a rand.Seed(time.Now().UTC().UnixNano()) at the start of the main
 // function to have different numbers generated
@@ -31,7 +56,7 @@ func (r *Retry) Execute(ctx context.Context, effector RetryEffector) error {
 	err := effector(ctx, 0)
 
 	var retryCount uint
-	for backoff := r.baseBackoff; err != nil && retryCount < r.retries; backoff <<= 1 {
+	for backoff := r.baseBackoff; err != nil && !isStopped(err) && retryCount < r.retries; backoff <<= 1 {
 		retryCount++
 
 		if backoff > r.maximumBackoff {
@@ -40,9 +65,23 @@ func (r *Retry) Execute(ctx context.Context, effector RetryEffector) error {
 
 		jitter := rand.Int63n(int64(backoff * 3))
 		sleep := r.baseBackoff + time.Duration(jitter)
-		time.Sleep(sleep)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(sleep):
+		}
 		err = effector(ctx, retryCount)
 	}
 
+	var stop stopError
+	if errors.As(err, &stop) {
+		return stop.err
+	}
+
 	return err
 }
+
+func isStopped(err error) bool {
+	var stop stopError
+	return errors.As(err, &stop)
+}
@@ -103,3 +103,35 @@ func TestRetry_Execute_Err(t *testing.T) {
 		t.Fatal("didn't receive expected execution counts")
 	}
 }
+
+func TestRetry_Execute_Stop(t *testing.T) {
+	newRetry := NewRetry(3, 0).WithBackoff(time.Microsecond, 10*time.Microsecond)
+
+	counter := 0
+	errPermanent := errors.New("permanent error")
+	err := newRetry.Execute(context.Background(), func(_ context.Context, retryCount uint) error {
+		counter++
+		return Stop(errPermanent)
+	})
+	if err != errPermanent {
+		t.Fatalf("Expected the stopped error, got %v", err)
+	}
+	if counter != 1 {
+		t.Fatalf("Expected a stopped execution not to be retried, got %d executions", counter)
+	}
+}
+
+func TestRetry_Execute_Canceled(t *testing.T) {
+	newRetry := NewRetry(3, 0).WithBackoff(time.Hour, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	counter := 0
+	err := newRetry.Execute(ctx, func(_ context.Context, retryCount uint) error {
+		counter++
+		return errors.New("an error")
+	})
+	if err == nil || counter != 1 {
+		t.Fatalf("Expected a canceled context to end the retries, got %d executions and error %v", counter, err)
+	}
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"api/core"
+	"api/image"
+	"api/image/local"
+	"api/image/resize"
+	"api/objectstore"
+	"github.com/rs/zerolog"
+)
+
+// NewResizer returns the image.Resizer selected by the IMAGES_RESIZER configuration
+func NewResizer(config core.Config, store objectstore.ObjectStore, logger *zerolog.Logger) image.Resizer {
+	if config.ImagesResizer == core.ResizerLocal {
+		return local.NewResizer(config, store, logger)
+	}
+
+	return resize.NewClient(config, logger)
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points to a position in a list ordered by (created_at, id) or by (Key, id) for other sorts, Backward
+// cursors fetch the items before the position instead of after it
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	Id        string    `json:"id"`
+	Backward  bool      `json:"b,omitempty"`
+	Sort      ImageSort `json:"s,omitempty"`
+	Key       string    `json:"k,omitempty"`
+}
+
+// Encode returns an opaque url safe representation of the cursor
+func (cursor Cursor) Encode() string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if cursor.Id == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	// Cursors issued before sorting was added are ordered by creation
+	if cursor.Sort == "" {
+		cursor.Sort = ImageSortCreatedAt
+	}
+	if cursor.Sort == ImageSortCreatedAt {
+		if cursor.CreatedAt.IsZero() {
+			return Cursor{}, ErrInvalidCursor
+		}
+	} else if _, err = cursor.Sort.ParseKey(cursor.Key); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// Pagination is either offset based with Limit and Offset or keyset based when Cursor is set
+type Pagination struct {
+	Limit  int
+	Offset int
+	Order  Order
+	// Sort defaults to ImageSortCreatedAt, it has to match the sort of the cursor
+	Sort   ImageSort
+	Cursor *Cursor
+}
+
+type ImagePage struct {
+	Items      ImageList `json:"items"`
+	NextCursor *string   `json:"nextCursor"`
+	PrevCursor *string   `json:"prevCursor"`
+	Total      *int64    `json:"total,omitempty"`
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursor_Encode(t *testing.T) {
+	cursor := Cursor{
+		CreatedAt: time.Date(2021, 8, 20, 10, 30, 15, 123456000, time.UTC),
+		Id:        "3c47d736-6c4e-4a1c-a04b-3744cc30b263",
+		Backward:  true,
+	}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) {
+		t.Fatalf("Expected CreatedAt %s, got %s", cursor.CreatedAt, decoded.CreatedAt)
+	}
+	if decoded.Id != cursor.Id {
+		t.Fatalf("Expected Id %s, got %s", cursor.Id, decoded.Id)
+	}
+	if !decoded.Backward {
+		t.Fatal("Expected cursor to be backward")
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	data := []struct {
+		testName string
+		value    string
+	}{
+		{testName: "Empty", value: ""},
+		{testName: "Not base64", value: "!!!"},
+		{testName: "Not json", value: "bm90LWpzb24"},
+		{testName: "Missing id", value: Cursor{CreatedAt: time.Now()}.Encode()},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			_, err := DecodeCursor(d.value)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("Expected invalid cursor error, got %v", err)
+			}
+		})
+	}
+}
@@ -15,7 +15,23 @@ type Image struct {
 	Sizes     ImageSizes  `json:"sizes"`
 	CreatedAt *time.Time  `json:"createdAt"`
 	UpdatedAt *time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time  `json:"deletedAt,omitempty"`
 	AuthorId  string      `json:"authorId"`
+	Tags      TagList     `json:"tags"`
+	// Formats lists the variants also available in other formats, it is kept out of Sizes which only name profiles
+	Formats ImageVariantFormats `json:"formats,omitempty"`
+	// BlurHash, Lqip and DominantColor are shown by clients while the image loads, they are empty until computed
+	BlurHash      string `json:"blurHash,omitempty"`
+	Lqip          string `json:"lqip,omitempty"`
+	DominantColor string `json:"dominantColor,omitempty"`
+	// Metadata is nil for files without exif or iptc
+	Metadata *ImageMetadata `json:"metadata,omitempty"`
+	// PerceptualHash is the hex difference hash of the cropped file, empty until computed
+	PerceptualHash string `json:"perceptualHash,omitempty"`
+	// Duplicates are the ids of the near duplicates found when the image was uploaded, only set in that response
+	Duplicates []string `json:"duplicates,omitempty"`
+	// Urls are the absolute urls of the original and the variants by size, resolved when the image is returned
+	Urls map[string]string `json:"urls,omitempty"`
 }
 
 func (image Image) IsEqualTo(img Image) bool {
@@ -35,7 +51,13 @@ func (image Image) IsEqualTo(img Image) bool {
 		return false
 	}
 
-	if !image.Sizes.IsEqualTo(img.Sizes) {
+	if !image.Sizes.IsEqualTo(img.Sizes) || !image.Formats.IsEqualTo(img.Formats) {
+		return false
+	}
+	if image.BlurHash != img.BlurHash || image.Lqip != img.Lqip || image.DominantColor != img.DominantColor {
+		return false
+	}
+	if !image.Metadata.IsEqualTo(img.Metadata) || image.PerceptualHash != img.PerceptualHash {
 		return false
 	}
 
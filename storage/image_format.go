@@ -6,9 +6,10 @@ const (
 	JpgFormat  ImageFormat = "jpg"
 	PngFormat  ImageFormat = "png"
 	WebpFormat ImageFormat = "webp"
+	AvifFormat ImageFormat = "avif"
 )
 
-var SupportedImageFormats = []ImageFormat{JpgFormat, PngFormat, WebpFormat}
+var SupportedImageFormats = []ImageFormat{JpgFormat, PngFormat, WebpFormat, AvifFormat}
 
 func (format ImageFormat) IsSupported() bool {
 	for _, f := range SupportedImageFormats {
@@ -28,6 +29,8 @@ func (format ImageFormat) ToImageContentType() ImageContentType {
 		return PngType
 	case WebpFormat:
 		return WebpType
+	case AvifFormat:
+		return AvifType
 	}
 
 	return ""
@@ -39,4 +42,5 @@ const (
 	JpegType ImageContentType = "image/jpeg"
 	PngType  ImageContentType = "image/png"
 	WebpType ImageContentType = "image/webp"
+	AvifType ImageContentType = "image/avif"
 )
@@ -0,0 +1,56 @@
+package storage
+
+import "time"
+
+// ImageMetadata is what was recorded in the exif and iptc of the uploaded file, after the configured fields were
+// stripped from it
+type ImageMetadata struct {
+	Camera     string     `json:"camera,omitempty"`
+	Lens       string     `json:"lens,omitempty"`
+	CapturedAt *time.Time `json:"capturedAt,omitempty"`
+	// Orientation is the exif orientation the camera recorded, files are stored turned upright
+	Orientation int          `json:"orientation,omitempty"`
+	Copyright   string       `json:"copyright,omitempty"`
+	Creator     string       `json:"creator,omitempty"`
+	Caption     string       `json:"caption,omitempty"`
+	Gps         *Coordinates `json:"gps,omitempty"`
+}
+
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Altitude in meters, negative below sea level
+	Altitude *float64 `json:"altitude,omitempty"`
+}
+
+func (metadata *ImageMetadata) IsEqualTo(other *ImageMetadata) bool {
+	if metadata == nil || other == nil {
+		return metadata == other
+	}
+	if metadata.Camera != other.Camera || metadata.Lens != other.Lens || metadata.Orientation != other.Orientation {
+		return false
+	}
+	if metadata.Copyright != other.Copyright || metadata.Creator != other.Creator || metadata.Caption != other.Caption {
+		return false
+	}
+	if (metadata.CapturedAt == nil) != (other.CapturedAt == nil) ||
+		metadata.CapturedAt != nil && !metadata.CapturedAt.Equal(*other.CapturedAt) {
+		return false
+	}
+
+	return metadata.Gps.isEqualTo(other.Gps)
+}
+
+func (coordinates *Coordinates) isEqualTo(other *Coordinates) bool {
+	if coordinates == nil || other == nil {
+		return coordinates == other
+	}
+	if coordinates.Latitude != other.Latitude || coordinates.Longitude != other.Longitude {
+		return false
+	}
+	if coordinates.Altitude == nil || other.Altitude == nil {
+		return coordinates.Altitude == other.Altitude
+	}
+
+	return *coordinates.Altitude == *other.Altitude
+}
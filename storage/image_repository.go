@@ -2,15 +2,58 @@ package storage
 
 import (
 	"context"
+	"time"
 )
 
+// ImageFilter narrows down the images returned by ImagesRepository.Get, empty fields are ignored
+type ImageFilter struct {
+	// Tag value, synonyms resolve to their canonical tag and child tags are matched as well
+	Tag string
+	// Trashed lists images in the trash instead of the active ones
+	Trashed bool
+	// AuthorId lists only the images uploaded by the user
+	AuthorId string
+	Format   ImageFormat
+	// CreatedAfter and the other time bounds are inclusive
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// UpdatedAfter and UpdatedBefore never match images that weren't updated
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// HasSize is the name of a size profile the images must have a variant of
+	HasSize string
+	// MinWidth and MinHeight bound the dimensions of the original
+	MinWidth  int
+	MinHeight int
+}
+
 type ImagesRepository interface {
-	Get(ctx context.Context, limit, offset int, order Order) (ImageList, error)
+	Get(ctx context.Context, filter ImageFilter, pagination Pagination) (ImageList, error)
+	Count(ctx context.Context, filter ImageFilter) (int64, error)
 	GetOne(ctx context.Context, imageId string) (Image, error)
+	Search(ctx context.Context, query string, limit, offset int) (ImageSearchResultList, error)
+	Suggest(ctx context.Context, prefix string, limit int) (SuggestionList, error)
 	GetOneByName(ctx context.Context, name string) (Image, error)
+	// GetCurrentName resolves a former name of a renamed image, returns NotFound when no image had the name
+	GetCurrentName(ctx context.Context, formerName string) (string, error)
 	DoesImageExist(ctx context.Context, name string) (bool, error)
 	Create(ctx context.Context, image Image) (Image, error)
 	SetNameById(ctx context.Context, imageId, newName string) (Image, error)
 	UpdateOne(ctx context.Context, updates Image) error
+	// Trash soft deletes the image, it's hidden from every read except the trash listing
+	Trash(ctx context.Context, imageId string) error
+	Restore(ctx context.Context, imageId string) (Image, error)
+	// GetTrashedBefore returns the images trashed before the time, oldest first
+	GetTrashedBefore(ctx context.Context, before time.Time, limit int) (ImageList, error)
+	// DeleteOne removes the image permanently
 	DeleteOne(ctx context.Context, imageId string) error
+	// GetWithoutPlaceholder returns the images without placeholders ordered by id, after the id unless it's empty
+	GetWithoutPlaceholder(ctx context.Context, afterId string, limit int) (ImageList, error)
+	SetPlaceholder(ctx context.Context, img Image) error
+	// GetWithoutHash returns the images without perceptual hash ordered by id, after the id unless it's empty
+	GetWithoutHash(ctx context.Context, afterId string, limit int) (ImageList, error)
+	SetPerceptualHash(ctx context.Context, img Image) error
+	// GetSimilar returns the images whose perceptual hash is within the hamming distance of the hash, nearest first.
+	// The image of the excluded id isn't returned.
+	GetSimilar(ctx context.Context, hash string, excludedId string, maxDistance int, limit int) (SimilarImageList, error)
 }
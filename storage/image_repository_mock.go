@@ -2,13 +2,14 @@ package storage
 
 import (
 	"context"
+	"time"
 )
 
 type ImageRepoMock struct {
 }
 
 func (repo ImageRepoMock) Get(
-	_ context.Context, _, _ int, _ Order,
+	_ context.Context, _ ImageFilter, _ Pagination,
 ) (ImageList, error) {
 	images := ImageList{
 		{
@@ -23,17 +24,10 @@ func (repo ImageRepoMock) Get(
 					Width:  688,
 					Height: 516,
 				},
-				Xs: &Dimensions{
-					Width:  100,
-					Height: 75,
-				},
-				S: &Dimensions{
-					Width:  300,
-					Height: 225,
-				},
-				M: &Dimensions{
-					Width:  500,
-					Height: 375,
+				Variants: map[string]Dimensions{
+					"xs": {Width: 100, Height: 75},
+					"s":  {Width: 300, Height: 225},
+					"m":  {Width: 500, Height: 375},
 				},
 			},
 			CreatedAt: nil,
@@ -44,14 +38,30 @@ func (repo ImageRepoMock) Get(
 	return images, nil
 }
 
+func (repo ImageRepoMock) Count(_ context.Context, _ ImageFilter) (int64, error) {
+	return 1, nil
+}
+
 func (repo ImageRepoMock) GetOne(_ context.Context, _ string) (Image, error) {
 	return Image{}, nil
 }
 
+func (repo ImageRepoMock) Search(_ context.Context, _ string, _, _ int) (ImageSearchResultList, error) {
+	return ImageSearchResultList{}, nil
+}
+
+func (repo ImageRepoMock) Suggest(_ context.Context, _ string, _ int) (SuggestionList, error) {
+	return SuggestionList{}, nil
+}
+
 func (repo ImageRepoMock) GetOneByName(_ context.Context, _ string) (Image, error) {
 	return Image{}, nil
 }
 
+func (repo ImageRepoMock) GetCurrentName(_ context.Context, _ string) (string, error) {
+	return "", NotFound{}
+}
+
 func (repo ImageRepoMock) DoesImageExist(_ context.Context, _ string) (bool, error) {
 	return false, nil
 }
@@ -68,6 +78,38 @@ func (repo ImageRepoMock) UpdateOne(_ context.Context, _ Image) error {
 	return nil
 }
 
+func (repo ImageRepoMock) Trash(_ context.Context, _ string) error {
+	return nil
+}
+
+func (repo ImageRepoMock) Restore(_ context.Context, _ string) (Image, error) {
+	return Image{}, nil
+}
+
+func (repo ImageRepoMock) GetTrashedBefore(_ context.Context, _ time.Time, _ int) (ImageList, error) {
+	return ImageList{}, nil
+}
+
 func (repo ImageRepoMock) DeleteOne(_ context.Context, _ string) error {
 	return nil
 }
+
+func (repo ImageRepoMock) GetWithoutPlaceholder(_ context.Context, _ string, _ int) (ImageList, error) {
+	return ImageList{}, nil
+}
+
+func (repo ImageRepoMock) SetPlaceholder(_ context.Context, _ Image) error {
+	return nil
+}
+
+func (repo ImageRepoMock) GetWithoutHash(_ context.Context, _ string, _ int) (ImageList, error) {
+	return ImageList{}, nil
+}
+
+func (repo ImageRepoMock) SetPerceptualHash(_ context.Context, _ Image) error {
+	return nil
+}
+
+func (repo ImageRepoMock) GetSimilar(_ context.Context, _ string, _ string, _ int, _ int) (SimilarImageList, error) {
+	return SimilarImageList{}, nil
+}
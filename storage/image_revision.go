@@ -0,0 +1,28 @@
+package storage
+
+import "time"
+
+// ImageRevision is a previous state of an image. Name is the name the image had, while the files are stored under
+// the revision specific name.
+type ImageRevision struct {
+	Id        string      `json:"id"`
+	ImageId   string      `json:"imageId"`
+	Revision  int         `json:"revision"`
+	Name      string      `json:"name"`
+	Format    ImageFormat `json:"format"`
+	Original  string      `json:"original"`
+	Domain    string      `json:"domain"`
+	Path      string      `json:"path"`
+	Sizes     ImageSizes  `json:"sizes"`
+	AuthorId  *string     `json:"authorId"`
+	CreatedAt *time.Time  `json:"createdAt"`
+	// Formats lists the variants of the revision also available in other formats
+	Formats ImageVariantFormats `json:"formats,omitempty"`
+	// BlurHash, Lqip and DominantColor are the placeholder of the files, revisions archived before they were kept
+	// have none
+	BlurHash      string `json:"blurHash,omitempty"`
+	Lqip          string `json:"lqip,omitempty"`
+	DominantColor string `json:"dominantColor,omitempty"`
+}
+
+type ImageRevisionList []ImageRevision
@@ -0,0 +1,13 @@
+package storage
+
+import "context"
+
+type ImageRevisionRepository interface {
+	// Get returns the revisions of the image, latest first
+	Get(ctx context.Context, imageId string) (ImageRevisionList, error)
+	GetOne(ctx context.Context, imageId string, revision int) (ImageRevision, error)
+	// NextRevision returns the number the following revision of the image should use
+	NextRevision(ctx context.Context, imageId string) (int, error)
+	Create(ctx context.Context, revision ImageRevision) (ImageRevision, error)
+	DeleteOne(ctx context.Context, imageId string, revision int) error
+}
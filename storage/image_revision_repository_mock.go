@@ -0,0 +1,26 @@
+package storage
+
+import "context"
+
+type ImageRevisionRepoMock struct {
+}
+
+func (repo ImageRevisionRepoMock) Get(_ context.Context, _ string) (ImageRevisionList, error) {
+	return ImageRevisionList{}, nil
+}
+
+func (repo ImageRevisionRepoMock) GetOne(_ context.Context, _ string, _ int) (ImageRevision, error) {
+	return ImageRevision{}, NotFound{}
+}
+
+func (repo ImageRevisionRepoMock) NextRevision(_ context.Context, _ string) (int, error) {
+	return 1, nil
+}
+
+func (repo ImageRevisionRepoMock) Create(_ context.Context, revision ImageRevision) (ImageRevision, error) {
+	return revision, nil
+}
+
+func (repo ImageRevisionRepoMock) DeleteOne(_ context.Context, _ string, _ int) error {
+	return nil
+}
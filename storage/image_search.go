@@ -0,0 +1,26 @@
+package storage
+
+type ImageSearchResult struct {
+	Image Image   `json:"image"`
+	Rank  float64 `json:"rank"`
+	// Highlight is the image name with matched words wrapped in <mark></mark>
+	Highlight string `json:"highlight"`
+	// MatchedTags are the tags of the image that matched the search query
+	MatchedTags []string `json:"matchedTags"`
+}
+
+type ImageSearchResultList []ImageSearchResult
+
+type SuggestionKind string
+
+const (
+	SuggestionKindImage SuggestionKind = "image"
+	SuggestionKindTag   SuggestionKind = "tag"
+)
+
+type Suggestion struct {
+	Value string         `json:"value"`
+	Kind  SuggestionKind `json:"kind"`
+}
+
+type SuggestionList []Suggestion
@@ -0,0 +1,9 @@
+package storage
+
+type SimilarImage struct {
+	Image Image `json:"image"`
+	// Distance is the number of bits the perceptual hashes differ in, 0 for identical looking images
+	Distance int `json:"distance"`
+}
+
+type SimilarImageList []SimilarImage
@@ -1,59 +1,113 @@
 package storage
 
-import "fmt"
+import (
+	"api/image"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
+// OriginalSize is the key of the original dimensions in the json of sizes
+const OriginalSize = image.OriginalSize
+
+// ImageSizes are the dimensions of the original and of the variant of each size profile. Ordering and json are those
+// of image.Sizes, which the sizes are converted to, so the v1 api keeps the shape of the resize api.
 type ImageSizes struct {
-	Original Dimensions  `json:"original"`
-	Xs       *Dimensions `json:"xs,omitempty"`
-	S        *Dimensions `json:"s,omitempty"`
-	M        *Dimensions `json:"m,omitempty"`
-	L        *Dimensions `json:"l,omitempty"`
-	XL       *Dimensions `json:"xl,omitempty"`
-	XXL      *Dimensions `json:"xxl,omitempty"`
-	XXXL     *Dimensions `json:"xxxl,omitempty"`
+	Original Dimensions
+	Variants map[string]Dimensions
 }
 
-func (imageSizes ImageSizes) ToString() string {
-	return fmt.Sprintf(
-		"ImageSizes{original: %s, xs: %s, s: %s, m: %s, l: %s, xl: %s, xxl: %s, xxxl: %s}",
-		imageSizes.Original.ToString(),
-		imageSizes.Xs.ToString(),
-		imageSizes.S.ToString(),
-		imageSizes.M.ToString(),
-		imageSizes.L.ToString(),
-		imageSizes.XL.ToString(),
-		imageSizes.XXL.ToString(),
-		imageSizes.XXXL.ToString(),
-	)
-}
+// ImageVariantFormats lists by format the variants that are also available in other formats than the one of the
+// image. Images expose it next to their sizes, so the sizes keep the shape of the v1 api.
+type ImageVariantFormats map[ImageFormat][]string
 
-func (imageSizes ImageSizes) IsEqualTo(sizes ImageSizes) bool {
-	if !AreEqual(&imageSizes.Original, &sizes.Original) {
-		return false
+// NewImageVariantFormats converts the formats returned by a resizer, nil when the variants have no other format
+func NewImageVariantFormats(formats image.VariantFormats) ImageVariantFormats {
+	if len(formats) == 0 {
+		return nil
 	}
-	if !AreEqual(imageSizes.Xs, sizes.Xs) {
-		return false
+
+	converted := make(ImageVariantFormats, len(formats))
+	for format, names := range formats {
+		converted[ImageFormat(format)] = names
 	}
-	if !AreEqual(imageSizes.S, sizes.S) {
-		return false
+
+	return converted
+}
+
+func (formats ImageVariantFormats) IsEqualTo(compareFormats ImageVariantFormats) bool {
+	return formats.ToVariantFormats().IsEqualTo(compareFormats.ToVariantFormats())
+}
+
+// ToVariantFormats converts the formats to the ones resizers work with
+func (formats ImageVariantFormats) ToVariantFormats() image.VariantFormats {
+	if len(formats) == 0 {
+		return nil
 	}
-	if !AreEqual(imageSizes.M, sizes.M) {
-		return false
+
+	converted := make(image.VariantFormats, len(formats))
+	for format, names := range formats {
+		converted[image.Format(format)] = names
 	}
-	if !AreEqual(imageSizes.L, sizes.L) {
-		return false
+
+	return converted
+}
+
+// NewImageSizes converts the sizes returned by a resizer
+func NewImageSizes(sizes image.Sizes) ImageSizes {
+	variants := make(map[string]Dimensions, len(sizes.Variants))
+	for name, dimensions := range sizes.Variants {
+		variants[name] = Dimensions{Width: dimensions.Width, Height: dimensions.Height}
 	}
-	if !AreEqual(imageSizes.XL, sizes.XL) {
-		return false
+
+	return ImageSizes{
+		Original: Dimensions{Width: sizes.Original.Width, Height: sizes.Original.Height},
+		Variants: variants,
 	}
-	if !AreEqual(imageSizes.XXL, sizes.XXL) {
-		return false
+}
+
+// ToSizes converts the sizes to the ones resizers work with
+func (imageSizes ImageSizes) ToSizes() image.Sizes {
+	variants := make(map[string]image.Dimensions, len(imageSizes.Variants))
+	for name, dimensions := range imageSizes.Variants {
+		variants[name] = image.Dimensions{Width: dimensions.Width, Height: dimensions.Height}
 	}
-	if !AreEqual(imageSizes.XXL, sizes.XXL) {
-		return false
+
+	return image.Sizes{
+		Original: image.Dimensions{Width: imageSizes.Original.Width, Height: imageSizes.Original.Height},
+		Variants: variants,
+	}
+}
+
+// VariantNames are the profile names of the variants ordered by width, then by name
+func (imageSizes ImageSizes) VariantNames() []string {
+	return imageSizes.ToSizes().VariantNames()
+}
+
+func (imageSizes ImageSizes) ToString() string {
+	values := []string{"original: " + imageSizes.Original.ToString()}
+	for _, name := range imageSizes.VariantNames() {
+		variant := imageSizes.Variants[name]
+		values = append(values, name+": "+variant.ToString())
 	}
-	if !AreEqual(imageSizes.XXXL, sizes.XXXL) {
-		return false
+
+	return fmt.Sprintf("ImageSizes{%s}", strings.Join(values, ", "))
+}
+
+func (imageSizes ImageSizes) IsEqualTo(sizes ImageSizes) bool {
+	return imageSizes.ToSizes().IsEqualTo(sizes.ToSizes())
+}
+
+func (imageSizes ImageSizes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(imageSizes.ToSizes())
+}
+
+func (imageSizes *ImageSizes) UnmarshalJSON(data []byte) error {
+	var sizes image.Sizes
+	if err := json.Unmarshal(data, &sizes); err != nil {
+		return err
 	}
-	return true
+	*imageSizes = NewImageSizes(sizes)
+
+	return nil
 }
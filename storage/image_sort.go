@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ImageSort is the field images are ordered by, ties are broken by the image id
+type ImageSort string
+
+const (
+	ImageSortCreatedAt ImageSort = "createdAt"
+	// ImageSortUpdatedAt orders never updated images by their creation
+	ImageSortUpdatedAt ImageSort = "updatedAt"
+	ImageSortName      ImageSort = "name"
+	// ImageSortWidth orders by the width of the original
+	ImageSortWidth ImageSort = "width"
+)
+
+var ImageSorts = []ImageSort{ImageSortCreatedAt, ImageSortUpdatedAt, ImageSortName, ImageSortWidth}
+
+// NewImageSort converts the value to the sort, empty value is the default sort by creation
+func NewImageSort(value string) (ImageSort, error) {
+	if value == "" {
+		return ImageSortCreatedAt, nil
+	}
+	for _, sort := range ImageSorts {
+		if string(sort) == value {
+			return sort, nil
+		}
+	}
+
+	return "", errors.New("Invalid image sort of " + value)
+}
+
+// Key returns the value of the sorted field of the image, cursors of ImageSortCreatedAt don't need a key
+func (sort ImageSort) Key(img Image) string {
+	switch sort {
+	case ImageSortUpdatedAt:
+		if img.UpdatedAt != nil {
+			return img.UpdatedAt.Format(time.RFC3339Nano)
+		}
+		if img.CreatedAt != nil {
+			return img.CreatedAt.Format(time.RFC3339Nano)
+		}
+	case ImageSortName:
+		return img.Name
+	case ImageSortWidth:
+		return strconv.Itoa(img.Sizes.Original.Width)
+	}
+
+	return ""
+}
+
+// ParseKey converts the cursor key back to the type of the sorted field
+func (sort ImageSort) ParseKey(key string) (interface{}, error) {
+	switch sort {
+	case ImageSortUpdatedAt:
+		return time.Parse(time.RFC3339Nano, key)
+	case ImageSortName:
+		if key == "" {
+			return nil, ErrInvalidCursor
+		}
+		return key, nil
+	case ImageSortWidth:
+		return strconv.Atoi(key)
+	}
+
+	return nil, ErrInvalidCursor
+}
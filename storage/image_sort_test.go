@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestImageSort_Key(t *testing.T) {
+	createdAt := time.Date(2021, 8, 20, 10, 30, 15, 123456000, time.UTC)
+	img := Image{
+		Id:        "3c47d736-6c4e-4a1c-a04b-3744cc30b263",
+		Name:      "my-plane",
+		CreatedAt: &createdAt,
+		Sizes:     ImageSizes{Original: Dimensions{Width: 1920, Height: 1080}},
+	}
+
+	data := []struct {
+		sort     ImageSort
+		expected interface{}
+	}{
+		{sort: ImageSortUpdatedAt, expected: createdAt},
+		{sort: ImageSortName, expected: "my-plane"},
+		{sort: ImageSortWidth, expected: 1920},
+	}
+
+	for _, d := range data {
+		t.Run(string(d.sort), func(t *testing.T) {
+			cursor := Cursor{Id: img.Id, Sort: d.sort, Key: d.sort.Key(img)}
+			decoded, err := DecodeCursor(cursor.Encode())
+			if err != nil {
+				t.Fatalf("expected error to be nil, got %v", err)
+			}
+
+			key, err := decoded.Sort.ParseKey(decoded.Key)
+			if err != nil {
+				t.Fatalf("expected error to be nil, got %v", err)
+			}
+			if parsedTime, ok := key.(time.Time); ok {
+				key = parsedTime.UTC()
+			}
+			if key != d.expected {
+				t.Fatalf("Expected key %v, got %v", d.expected, key)
+			}
+		})
+	}
+}
+
+func TestNewImageSort(t *testing.T) {
+	sort, err := NewImageSort("")
+	if err != nil || sort != ImageSortCreatedAt {
+		t.Fatalf("Expected default sort %s, got %s and %v", ImageSortCreatedAt, sort, err)
+	}
+
+	if _, err = NewImageSort("name; DROP TABLE images"); err == nil {
+		t.Fatal("Expected unknown sort to be rejected")
+	}
+}
+
+func TestDecodeCursor_InvalidKey(t *testing.T) {
+	data := []struct {
+		testName string
+		cursor   Cursor
+	}{
+		{testName: "Unknown sort", cursor: Cursor{Id: "x", Sort: "size", Key: "1"}},
+		{testName: "Missing key", cursor: Cursor{Id: "x", Sort: ImageSortName}},
+		{testName: "Invalid width", cursor: Cursor{Id: "x", Sort: ImageSortWidth, Key: "wide"}},
+		{testName: "Invalid time", cursor: Cursor{Id: "x", Sort: ImageSortUpdatedAt, Key: "yesterday"}},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			_, err := DecodeCursor(d.cursor.Encode())
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("Expected invalid cursor error, got %v", err)
+			}
+		})
+	}
+}
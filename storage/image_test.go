@@ -8,13 +8,10 @@ import (
 func TestImageSizes_ToJson(t *testing.T) {
 	imageSizes := ImageSizes{
 		Original: Dimensions{Width: 500, Height: 300},
-		Xs:       &Dimensions{Width: 300, Height: 100},
-		S:        nil,
-		M:        nil,
-		L:        nil,
-		XL:       nil,
-		XXL:      nil,
-		XXXL:     nil,
+		Variants: map[string]Dimensions{
+			"xs":   {Width: 300, Height: 100},
+			"card": {Width: 200, Height: 200},
+		},
 	}
 
 	data, err := json.Marshal(imageSizes)
@@ -22,9 +19,50 @@ func TestImageSizes_ToJson(t *testing.T) {
 		t.Fatal("error converting to String", err)
 	}
 
-	expectedJson := "{\"original\":{\"width\":500,\"height\":300},\"xs\":{\"width\":300,\"height\":100}}"
+	expectedJson := "{\"original\":{\"width\":500,\"height\":300},\"card\":{\"width\":200,\"height\":200}," +
+		"\"xs\":{\"width\":300,\"height\":100}}"
 
 	if string(data) != expectedJson {
 		t.Fatalf("Expected: %s\nGot: %s", expectedJson, string(data))
 	}
+
+	var decoded ImageSizes
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal("error converting from json", err)
+	}
+	if !decoded.IsEqualTo(imageSizes) {
+		t.Fatalf("Expected: %s\nGot: %s", imageSizes.ToString(), decoded.ToString())
+	}
+}
+
+func TestImage_FormatsToJson(t *testing.T) {
+	img := Image{
+		Sizes: ImageSizes{
+			Original: Dimensions{Width: 500, Height: 300},
+			Variants: map[string]Dimensions{"formats": {Width: 300, Height: 100}},
+		},
+		Formats: ImageVariantFormats{WebpFormat: {"formats"}},
+	}
+
+	data, err := json.Marshal(img)
+	if err != nil {
+		t.Fatal("error converting to String", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		t.Fatal("error converting from json", err)
+	}
+	expectedSizes := "{\"original\":{\"width\":500,\"height\":300},\"formats\":{\"width\":300,\"height\":100}}"
+	if string(fields["sizes"]) != expectedSizes || string(fields["formats"]) != "{\"webp\":[\"formats\"]}" {
+		t.Fatalf("Expected the formats next to the sizes, got %s", string(data))
+	}
+
+	var decoded Image
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal("error converting from json", err)
+	}
+	if !decoded.IsEqualTo(img) || len(decoded.Sizes.Variants) != 1 {
+		t.Fatalf("Expected: %s\nGot: %s", img.toString(), decoded.toString())
+	}
 }
@@ -0,0 +1,56 @@
+package storage
+
+import "time"
+
+type JobStatus string
+
+const (
+	// JobQueued waits for a worker to claim it
+	JobQueued JobStatus = "queued"
+	// JobRunning is processed by a worker until its lease ends
+	JobRunning JobStatus = "running"
+	// JobWaiting waits for the resize api to call back until its lease ends
+	JobWaiting JobStatus = "waiting"
+	// JobSucceeded created its image
+	JobSucceeded JobStatus = "succeeded"
+	// JobFailed won't be processed again, Error tells why
+	JobFailed JobStatus = "failed"
+)
+
+// Job creates an image in the background from files that were already uploaded
+type Job struct {
+	Id       string    `json:"id"`
+	AuthorId string    `json:"authorId"`
+	Status   JobStatus `json:"status"`
+	// Payload holds the paths of the uploaded files, which aren't returned to clients
+	Payload JobPayload `json:"-"`
+	// ImageId is the created image once the job succeeded
+	ImageId *string `json:"imageId"`
+	// Error is why the job failed
+	Error *string `json:"error"`
+	// Attempts counts the claims of the job, including the current one
+	Attempts    int        `json:"attempts"`
+	LockedUntil *time.Time `json:"-"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
+
+type JobPayload struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	// OriginalFile and CroppedFile are the remote paths of the uploaded files
+	OriginalFile string `json:"originalFile"`
+	CroppedFile  string `json:"croppedFile"`
+	// Metadata was read from the original when it was uploaded
+	Metadata *ImageMetadata `json:"metadata,omitempty"`
+	// BlurHash, Lqip, DominantColor and PerceptualHash were computed from the cropped file when it was uploaded
+	BlurHash       string `json:"blurHash,omitempty"`
+	Lqip           string `json:"lqip,omitempty"`
+	DominantColor  string `json:"dominantColor,omitempty"`
+	PerceptualHash string `json:"perceptualHash,omitempty"`
+}
+
+// IsFinished tells whether the job won't change anymore
+func (job Job) IsFinished() bool {
+	return job.Status == JobSucceeded || job.Status == JobFailed
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type JobRepository interface {
+	Create(ctx context.Context, job Job) (Job, error)
+	GetOne(ctx context.Context, jobId string) (Job, error)
+	// ClaimNext runs the oldest queued job, or a running or waiting job whose lease expired, until lockedUntil.
+	// Concurrent workers never claim the same job, false is returned when there is none.
+	ClaimNext(ctx context.Context, lockedUntil time.Time) (Job, bool, error)
+	// Wait sets a running job waiting for a callback until lockedUntil
+	Wait(ctx context.Context, jobId string, lockedUntil time.Time) error
+	// Resume runs a waiting job again until lockedUntil, false is returned when the job isn't waiting
+	Resume(ctx context.Context, jobId string, lockedUntil time.Time) (bool, error)
+	Succeed(ctx context.Context, jobId string, imageId string) error
+	Fail(ctx context.Context, jobId string, reason string) error
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type JobRepoMock struct {
+}
+
+func (repo JobRepoMock) Create(_ context.Context, job Job) (Job, error) {
+	job.Id = "7e4b2d9a-1c3f-4a8e-b6d0-9f2a5c7e1b3d"
+	job.Status = JobQueued
+	return job, nil
+}
+
+func (repo JobRepoMock) GetOne(_ context.Context, jobId string) (Job, error) {
+	return Job{}, NotFound{Msg: "Job not found by id " + jobId}
+}
+
+func (repo JobRepoMock) ClaimNext(_ context.Context, _ time.Time) (Job, bool, error) {
+	return Job{}, false, nil
+}
+
+func (repo JobRepoMock) Wait(_ context.Context, _ string, _ time.Time) error {
+	return nil
+}
+
+func (repo JobRepoMock) Resume(_ context.Context, _ string, _ time.Time) (bool, error) {
+	return true, nil
+}
+
+func (repo JobRepoMock) Succeed(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+func (repo JobRepoMock) Fail(_ context.Context, _ string, _ string) error {
+	return nil
+}
@@ -0,0 +1,7 @@
+UPDATE images
+SET sizes = (sizes->'variants') || jsonb_build_object('original', sizes->'original')
+WHERE sizes ? 'variants';
+
+UPDATE image_revisions
+SET sizes = (sizes->'variants') || jsonb_build_object('original', sizes->'original')
+WHERE sizes ? 'variants';
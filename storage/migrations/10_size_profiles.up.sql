@@ -0,0 +1,8 @@
+-- Variants move from next to the original into a map keyed by size profile name
+UPDATE images
+SET sizes = jsonb_build_object('original', sizes->'original', 'variants', sizes - 'original')
+WHERE NOT sizes ? 'variants';
+
+UPDATE image_revisions
+SET sizes = jsonb_build_object('original', sizes->'original', 'variants', sizes - 'original')
+WHERE NOT sizes ? 'variants';
@@ -0,0 +1,25 @@
+-- Resumable uploads of image files, their content is kept in the object store as chunks until both files are complete
+CREATE TABLE IF NOT EXISTS uploads
+(
+    id            UUID PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    author_id     UUID             NOT NULL,
+    original_id   UUID UNIQUE,
+    length        BIGINT           NOT NULL,
+    upload_offset BIGINT           NOT NULL DEFAULT 0,
+    metadata      jsonb            NOT NULL,
+    chunks        TEXT[]           NOT NULL DEFAULT '{}',
+    finishing     BOOLEAN          NOT NULL DEFAULT false,
+    image_id      UUID,
+    expires_at    timestamp        NOT NULL,
+    created_at    timestamp        NOT NULL DEFAULT now(),
+    updated_at    timestamp,
+
+    CONSTRAINT author_fk
+        FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT original_fk
+        FOREIGN KEY (original_id) REFERENCES uploads (id) ON DELETE SET NULL,
+    CONSTRAINT image_fk
+        FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_expiresAt ON uploads (expires_at);
@@ -0,0 +1,17 @@
+-- Signed urls handed out for direct uploads, left over rows past their expiry are purged along with their files
+CREATE TABLE IF NOT EXISTS upload_intents
+(
+    id            UUID PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    author_id     UUID             NOT NULL,
+    name          VARCHAR(255)     NOT NULL,
+    format        VARCHAR(30)      NOT NULL,
+    original_file VARCHAR(255)     NOT NULL,
+    cropped_file  VARCHAR(255)     NOT NULL,
+    expires_at    timestamp        NOT NULL,
+    created_at    timestamp        NOT NULL DEFAULT now(),
+
+    CONSTRAINT author_fk
+        FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_intents_expiresAt ON upload_intents (expires_at);
@@ -0,0 +1,22 @@
+-- Images created in the background, queued jobs are claimed by the workers of any api instance
+CREATE TABLE IF NOT EXISTS jobs
+(
+    id           UUID PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    author_id    UUID             NOT NULL,
+    status       VARCHAR(20)      NOT NULL DEFAULT 'queued',
+    payload      jsonb            NOT NULL,
+    image_id     UUID             NULL,
+    error        TEXT             NULL,
+    attempts     INT              NOT NULL DEFAULT 0,
+    -- a running or waiting job whose lease expired is claimed again
+    locked_until timestamp        NULL,
+    created_at   timestamp        NOT NULL DEFAULT now(),
+    updated_at   timestamp        NULL,
+
+    CONSTRAINT author_fk
+        FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT image_fk
+        FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status_createdAt ON jobs (status, created_at);
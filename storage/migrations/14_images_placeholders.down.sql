@@ -0,0 +1,4 @@
+ALTER TABLE images
+    DROP COLUMN IF EXISTS dominant_color,
+    DROP COLUMN IF EXISTS lqip,
+    DROP COLUMN IF EXISTS blur_hash;
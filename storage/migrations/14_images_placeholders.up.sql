@@ -0,0 +1,5 @@
+-- Placeholders shown while images load, images created before are backfilled from their originals
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS blur_hash      VARCHAR(100) NULL,
+    ADD COLUMN IF NOT EXISTS lqip           TEXT         NULL,
+    ADD COLUMN IF NOT EXISTS dominant_color VARCHAR(7)   NULL;
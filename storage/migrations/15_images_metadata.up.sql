@@ -0,0 +1,3 @@
+-- Exif and iptc of the uploaded files, null for images created before or from formats without metadata
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS metadata JSONB NULL;
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_images_perceptualHashBand3;
+DROP INDEX IF EXISTS idx_images_perceptualHashBand2;
+DROP INDEX IF EXISTS idx_images_perceptualHashBand1;
+DROP INDEX IF EXISTS idx_images_perceptualHashBand0;
+
+ALTER TABLE images
+    DROP COLUMN IF EXISTS perceptual_hash_band3,
+    DROP COLUMN IF EXISTS perceptual_hash_band2,
+    DROP COLUMN IF EXISTS perceptual_hash_band1,
+    DROP COLUMN IF EXISTS perceptual_hash_band0,
+    DROP COLUMN IF EXISTS perceptual_hash;
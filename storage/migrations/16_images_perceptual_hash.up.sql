@@ -0,0 +1,21 @@
+-- Difference hash of the cropped file, near duplicates are within a small hamming distance. Images created before have
+-- no hash.
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS perceptual_hash BIGINT NULL;
+
+-- The bands are the four 16 bit quarters of the perceptual hash. Hashes within a hamming distance of d have a band
+-- within d/4 bits of each other, so near duplicates are looked up in the band indexes instead of in every hash.
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS perceptual_hash_band0 INTEGER
+        GENERATED ALWAYS AS (((perceptual_hash >> 48) & 65535)::integer) STORED,
+    ADD COLUMN IF NOT EXISTS perceptual_hash_band1 INTEGER
+        GENERATED ALWAYS AS (((perceptual_hash >> 32) & 65535)::integer) STORED,
+    ADD COLUMN IF NOT EXISTS perceptual_hash_band2 INTEGER
+        GENERATED ALWAYS AS (((perceptual_hash >> 16) & 65535)::integer) STORED,
+    ADD COLUMN IF NOT EXISTS perceptual_hash_band3 INTEGER
+        GENERATED ALWAYS AS ((perceptual_hash & 65535)::integer) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_images_perceptualHashBand0 ON images (perceptual_hash_band0) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_images_perceptualHashBand1 ON images (perceptual_hash_band1) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_images_perceptualHashBand2 ON images (perceptual_hash_band2) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_images_perceptualHashBand3 ON images (perceptual_hash_band3) WHERE deleted_at IS NULL;
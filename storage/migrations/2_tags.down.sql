@@ -0,0 +1,22 @@
+DROP INDEX IF EXISTS idx_images_tags_unique;
+
+ALTER TABLE images_tags
+    DROP CONSTRAINT IF EXISTS tag_fk,
+    DROP CONSTRAINT IF EXISTS image_fk;
+
+ALTER TABLE images_tags
+    ADD CONSTRAINT tag_fk
+        FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE SET NULL,
+    ADD CONSTRAINT image_fk
+        FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE SET NULL;
+
+DROP INDEX IF EXISTS idx_tags_canonicalId;
+DROP INDEX IF EXISTS idx_tags_parentId;
+
+ALTER TABLE tags
+    DROP CONSTRAINT IF EXISTS canonical_fk,
+    DROP CONSTRAINT IF EXISTS parent_fk;
+
+ALTER TABLE tags
+    DROP COLUMN IF EXISTS canonical_id,
+    DROP COLUMN IF EXISTS parent_id;
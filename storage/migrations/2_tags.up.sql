@@ -0,0 +1,26 @@
+-- TAGS hierarchy and synonyms
+ALTER TABLE tags
+    ADD COLUMN IF NOT EXISTS parent_id    UUID,
+    ADD COLUMN IF NOT EXISTS canonical_id UUID;
+
+ALTER TABLE tags
+    ADD CONSTRAINT parent_fk
+        FOREIGN KEY (parent_id) REFERENCES tags (id) ON DELETE SET NULL,
+    ADD CONSTRAINT canonical_fk
+        FOREIGN KEY (canonical_id) REFERENCES tags (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_tags_parentId ON tags (parent_id);
+CREATE INDEX IF NOT EXISTS idx_tags_canonicalId ON tags (canonical_id);
+
+-- IMAGES_TAGS, columns are not nullable so rows have to be removed with the image or tag
+ALTER TABLE images_tags
+    DROP CONSTRAINT IF EXISTS tag_fk,
+    DROP CONSTRAINT IF EXISTS image_fk;
+
+ALTER TABLE images_tags
+    ADD CONSTRAINT tag_fk
+        FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE,
+    ADD CONSTRAINT image_fk
+        FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_images_tags_unique ON images_tags (image_id, tag_id);
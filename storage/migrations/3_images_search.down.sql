@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tags_value_trgm;
+DROP INDEX IF EXISTS idx_images_name_trgm;
+DROP INDEX IF EXISTS idx_images_searchVector;
+
+ALTER TABLE images
+    DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,10 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Image names are SEO formatted, dashes are replaced so words are parsed separately
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+        GENERATED ALWAYS AS (to_tsvector('english'::regconfig, replace(name, '-', ' '))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_images_searchVector ON images USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_images_name_trgm ON images USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_tags_value_trgm ON tags USING GIN (value gin_trgm_ops);
@@ -0,0 +1,2 @@
+-- Keyset pagination orders by (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_images_createdAt_id ON images (created_at, id);
@@ -0,0 +1,13 @@
+-- Former names of images, so published links keep working after a rename
+CREATE TABLE IF NOT EXISTS slug_history
+(
+    id         UUID PRIMARY KEY    NOT NULL DEFAULT uuid_generate_v4(),
+    image_id   UUID                NOT NULL,
+    name       VARCHAR(255) UNIQUE NOT NULL,
+    created_at timestamp           NOT NULL DEFAULT now(),
+
+    CONSTRAINT image_fk
+        FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_slug_history_imageId ON slug_history (image_id);
@@ -0,0 +1,15 @@
+-- Multi step image operations in progress, left over rows are finished or rolled back at startup
+CREATE TABLE IF NOT EXISTS pending_operations
+(
+    id         UUID PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    kind       VARCHAR(30)      NOT NULL,
+    image_id   UUID,
+    payload    jsonb            NOT NULL,
+    created_at timestamp        NOT NULL DEFAULT now(),
+    updated_at timestamp,
+
+    CONSTRAINT image_fk
+        FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_operations_createdAt ON pending_operations (created_at);
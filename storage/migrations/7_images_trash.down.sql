@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_images_deletedAt;
+
+ALTER TABLE images
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,5 @@
+-- Soft deleted images stay in the trash until they are purged
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+
+CREATE INDEX IF NOT EXISTS idx_images_deletedAt ON images (deleted_at) WHERE deleted_at IS NOT NULL;
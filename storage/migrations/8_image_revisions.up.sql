@@ -0,0 +1,26 @@
+-- Previous states of images, their files are kept under revision specific names. Revisions keep the placeholders of
+-- their files, so restoring one doesn't wait for the backfill.
+CREATE TABLE IF NOT EXISTS image_revisions
+(
+    id             UUID PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    image_id       UUID             NOT NULL,
+    revision       INTEGER          NOT NULL,
+    name           VARCHAR(255)     NOT NULL,
+    format         VARCHAR(30)      NOT NULL,
+    original       VARCHAR(255)     NOT NULL,
+    domain         VARCHAR(255)     NOT NULL,
+    path           VARCHAR(255)     NOT NULL,
+    sizes          jsonb            NOT NULL,
+    author_id      UUID,
+    created_at     timestamp        NOT NULL DEFAULT now(),
+    blur_hash      VARCHAR(100)     NULL,
+    lqip           TEXT             NULL,
+    dominant_color VARCHAR(7)       NULL,
+
+    CONSTRAINT image_fk
+        FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE,
+    CONSTRAINT author_fk
+        FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_image_revisions_imageId_revision ON image_revisions (image_id, revision);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_images_format;
+DROP INDEX IF EXISTS idx_images_width_id;
+DROP INDEX IF EXISTS idx_images_updatedAt_id;
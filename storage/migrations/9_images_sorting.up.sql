@@ -0,0 +1,4 @@
+-- Keyset pagination of the other sorts orders by (expression, id)
+CREATE INDEX IF NOT EXISTS idx_images_updatedAt_id ON images ((COALESCE(updated_at, created_at)), id);
+CREATE INDEX IF NOT EXISTS idx_images_width_id ON images ((COALESCE((sizes->'original'->>'width')::int, 0)), id);
+CREATE INDEX IF NOT EXISTS idx_images_format ON images (format);
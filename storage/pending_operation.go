@@ -0,0 +1,44 @@
+package storage
+
+import "time"
+
+type PendingOperationKind string
+
+const (
+	// PendingOperationUpload creates a new image
+	PendingOperationUpload PendingOperationKind = "upload"
+	// PendingOperationReplace replaces files of an existing image
+	PendingOperationReplace PendingOperationKind = "replace"
+	// PendingOperationRename renames the files of an existing image
+	PendingOperationRename PendingOperationKind = "rename"
+	// PendingOperationRollback restores the files of an image revision
+	PendingOperationRollback PendingOperationKind = "rollback"
+)
+
+// PendingOperation is a multi step image operation that hasn't completed yet. The payload holds everything needed
+// to finish or roll it back after a crash.
+type PendingOperation struct {
+	Id        string                  `json:"id"`
+	Kind      PendingOperationKind    `json:"kind"`
+	ImageId   *string                 `json:"imageId"`
+	Payload   PendingOperationPayload `json:"payload"`
+	CreatedAt *time.Time              `json:"createdAt"`
+	UpdatedAt *time.Time              `json:"updatedAt"`
+}
+
+type PendingOperationPayload struct {
+	// Image is the image to be saved, its files and sizes are set once resizing succeeds
+	Image Image `json:"image"`
+	// UploadedFiles are remote paths of the uploaded original and cropped files
+	UploadedFiles []string `json:"uploadedFiles"`
+	// Resized is set once the resize api created or renamed the variants of Image
+	Resized bool `json:"resized"`
+	// Previous is the replaced image
+	Previous *Image `json:"previous,omitempty"`
+	// Archive is the revision the files of Previous were moved to
+	Archive *ImageRevision `json:"archive,omitempty"`
+	// RestoredRevision is the revision whose files are moved back to the image by a rollback
+	RestoredRevision int `json:"restoredRevision,omitempty"`
+}
+
+type PendingOperationList []PendingOperation
@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type PendingOperationRepository interface {
+	// GetStartedBefore returns the operations started before the time, newer ones could still be in progress
+	GetStartedBefore(ctx context.Context, before time.Time) (PendingOperationList, error)
+	Create(ctx context.Context, operation PendingOperation) (PendingOperation, error)
+	UpdatePayload(ctx context.Context, operationId string, payload PendingOperationPayload) error
+	DeleteOne(ctx context.Context, operationId string) error
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type PendingOperationRepoMock struct {
+}
+
+func (repo PendingOperationRepoMock) GetStartedBefore(_ context.Context, _ time.Time) (PendingOperationList, error) {
+	return PendingOperationList{}, nil
+}
+
+func (repo PendingOperationRepoMock) Create(
+	_ context.Context, operation PendingOperation,
+) (PendingOperation, error) {
+	operation.Id = "0b3b6f8e-7a4a-4bb5-9d6e-3f0f5c1f2a10"
+	return operation, nil
+}
+
+func (repo PendingOperationRepoMock) UpdatePayload(_ context.Context, _ string, _ PendingOperationPayload) error {
+	return nil
+}
+
+func (repo PendingOperationRepoMock) DeleteOne(_ context.Context, _ string) error {
+	return nil
+}
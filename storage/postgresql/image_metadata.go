@@ -0,0 +1,21 @@
+package postgresql
+
+import (
+	"api/storage"
+	"encoding/json"
+)
+
+// marshalMetadata encodes the metadata jsonb, images without metadata keep it null
+func marshalMetadata(metadata *storage.ImageMetadata) (*string, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	value := string(data)
+
+	return &value, nil
+}
@@ -3,10 +3,10 @@ package postgresql
 import (
 	"api/storage"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v4"
+	"strings"
 	"time"
 )
 
@@ -18,15 +18,162 @@ func NewImageRepository(db *Database) *ImageRepo {
 	return &ImageRepo{database: db}
 }
 
-func (repo ImageRepo) Get(ctx context.Context, limit, offset int, order storage.Order) (storage.ImageList, error) {
-	query := `SELECT
- id, name, format, original, domain, path, sizes, created_at, updated_at, author_id
+// imageTagsColumn selects tags of an image as a json array, so they can be scanned directly into storage.TagList
+const imageTagsColumn = `COALESCE((
+  SELECT json_agg(json_build_object('id', t.id, 'value', t.value, 'parentId', t.parent_id) ORDER BY t.value)
+  FROM images_tags it
+  JOIN tags t ON t.id = it.tag_id
+  WHERE it.image_id = images.id
+ ), '[]')`
+
+// imagesByTagCondition matches images tagged with the canonical tag of the value or any of its descendants. Images
+// tagged with their synonyms match too, as they may have been tagged before the tag became a synonym.
+const imagesByTagCondition = `images.id IN (
+  SELECT it.image_id FROM images_tags it WHERE it.tag_id IN (
+   WITH RECURSIVE matched AS (
+    SELECT COALESCE(t.canonical_id, t.id) AS id FROM tags t WHERE t.value = $%d
+    UNION
+    SELECT child.id FROM tags child JOIN matched m ON child.parent_id = m.id
+   )
+   SELECT id FROM matched
+   UNION
+   SELECT synonym.id FROM tags synonym JOIN matched m ON synonym.canonical_id = m.id
+  )
+ )`
+
+// originalWidthExpression and originalHeightExpression read the dimensions of the original from the sizes
+const (
+	originalWidthExpression  = `COALESCE((images.sizes->'original'->>'width')::int, 0)`
+	originalHeightExpression = `COALESCE((images.sizes->'original'->>'height')::int, 0)`
+)
+
+// imageSortExpressions are the sql expressions of the sortable fields, they are the only sql that is derived from
+// the sort requested by users
+var imageSortExpressions = map[storage.ImageSort]string{
+	storage.ImageSortCreatedAt: "images.created_at",
+	storage.ImageSortUpdatedAt: "COALESCE(images.updated_at, images.created_at)",
+	storage.ImageSortName:      "images.name",
+	storage.ImageSortWidth:     originalWidthExpression,
+}
+
+// imageFilterConditions converts the filter to sql conditions, values are appended to the args as parameters
+func imageFilterConditions(filter storage.ImageFilter, args []interface{}) ([]string, []interface{}) {
+	conditions := []string{"images.deleted_at IS NULL"}
+	if filter.Trashed {
+		conditions[0] = "images.deleted_at IS NOT NULL"
+	}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Tag != "" {
+		addCondition(imagesByTagCondition, filter.Tag)
+	}
+	if filter.AuthorId != "" {
+		addCondition("images.author_id = $%d", filter.AuthorId)
+	}
+	if filter.Format != "" {
+		addCondition("images.format = $%d", string(filter.Format))
+	}
+	if filter.CreatedAfter != nil {
+		addCondition("images.created_at >= $%d", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		addCondition("images.created_at <= $%d", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		addCondition("images.updated_at >= $%d", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		addCondition("images.updated_at <= $%d", *filter.UpdatedBefore)
+	}
+	if filter.HasSize != "" {
+		addCondition("images.sizes->'variants' ? $%d", filter.HasSize)
+	}
+	if filter.MinWidth > 0 {
+		addCondition(originalWidthExpression+" >= $%d", filter.MinWidth)
+	}
+	if filter.MinHeight > 0 {
+		addCondition(originalHeightExpression+" >= $%d", filter.MinHeight)
+	}
+
+	return conditions, args
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// keysetOrder returns the comparison operator and the sql order for reading pages starting at the cursor
+func keysetOrder(order storage.Order, cursor *storage.Cursor) (comparison string, sqlOrder storage.Order) {
+	backward := cursor != nil && cursor.Backward
+	if order == storage.OrderAscending {
+		if backward {
+			return "<", storage.OrderDescending
+		}
+		return ">", storage.OrderAscending
+	}
+
+	if backward {
+		return ">", storage.OrderAscending
+	}
+	return "<", storage.OrderDescending
+}
+
+// orderSql never passes the order value itself to the query
+func orderSql(order storage.Order) string {
+	if order == storage.OrderAscending {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+// Get reads the images by offset or after the cursor when it's set. Results are always returned in the requested
+// order, even for backward cursors.
+func (repo ImageRepo) Get(
+	ctx context.Context, filter storage.ImageFilter, pagination storage.Pagination,
+) (storage.ImageList, error) {
+	args := []interface{}{pagination.Limit}
+	conditions, args := imageFilterConditions(filter, args)
+
+	sort := pagination.Sort
+	if sort == "" {
+		sort = storage.ImageSortCreatedAt
+	}
+	sortExpression, ok := imageSortExpressions[sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image sort %s", sort)
+	}
+
+	comparison, sqlOrder := keysetOrder(pagination.Order, pagination.Cursor)
+	offset := ""
+	if pagination.Cursor != nil {
+		key, err := cursorKey(sort, *pagination.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, key, pagination.Cursor.Id)
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s, images.id) %s ($%d, $%d::uuid)", sortExpression, comparison, len(args)-1, len(args),
+		))
+	} else {
+		args = append(args, pagination.Offset)
+		offset = fmt.Sprintf("OFFSET $%d", len(args))
+	}
+
+	query := `SELECT ` + imageColumns + `
  FROM images
- ORDER BY created_at ` + string(order) + `
+ ` + whereClause(conditions) + `
+ ORDER BY ` + sortExpression + ` ` + orderSql(sqlOrder) + `, images.id ` + orderSql(sqlOrder) + `
  LIMIT $1
- OFFSET $2
+ ` + offset + `
 `
-	rows, err := repo.database.dbPool.Query(ctx, query, limit, offset)
+	rows, err := repo.database.dbPool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed querying images: %w", err)
 	}
@@ -35,50 +182,80 @@ func (repo ImageRepo) Get(ctx context.Context, limit, offset int, order storage.
 	var imageList []storage.Image
 
 	for rows.Next() {
-		var id, name, format, original, domain, path, authorId string
-		var sizes storage.ImageSizes
-		var createdAt, updatedAt *time.Time
-
-		err = rows.Scan(
-			&id, &name, &format, &original, &domain, &path, &sizes, &createdAt, &updatedAt, &authorId,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed scaning images: %w", err)
-		}
-		imageList = append(imageList, storage.Image{
-			Id:        id,
-			Name:      name,
-			Format:    storage.ImageFormat(format),
-			Original:  original,
-			Domain:    domain,
-			Path:      path,
-			Sizes:     sizes,
-			CreatedAt: createdAt,
-			UpdatedAt: updatedAt,
-			AuthorId:  authorId,
-		})
+		image, scanErr := scanImage(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("failed scaning images: %w", scanErr)
+		}
+		imageList = append(imageList, image)
 	}
 
 	if imageList == nil {
 		return storage.ImageList{}, nil
 	}
 
+	if sqlOrder != pagination.Order {
+		for i, j := 0, len(imageList)-1; i < j; i, j = i+1, j-1 {
+			imageList[i], imageList[j] = imageList[j], imageList[i]
+		}
+	}
+
 	return imageList, nil
 }
 
-func (repo *ImageRepo) GetOne(ctx context.Context, imageId string) (storage.Image, error) {
-	query := `SELECT
-id, name, format, original, domain, path, sizes, created_at, updated_at, author_id
-FROM images
-WHERE id = $1
-LIMIT 1
-`
+// cursorKey returns the value of the sorted field at the cursor position
+func cursorKey(sort storage.ImageSort, cursor storage.Cursor) (interface{}, error) {
+	cursorSort := cursor.Sort
+	if cursorSort == "" {
+		cursorSort = storage.ImageSortCreatedAt
+	}
+	if cursorSort != sort {
+		return nil, storage.ErrInvalidCursor
+	}
+	if sort == storage.ImageSortCreatedAt {
+		return cursor.CreatedAt, nil
+	}
+
+	return sort.ParseKey(cursor.Key)
+}
+
+func (repo *ImageRepo) Count(ctx context.Context, filter storage.ImageFilter) (int64, error) {
+	conditions, args := imageFilterConditions(filter, nil)
+	query := "SELECT count(*) FROM images " + whereClause(conditions)
+
+	var count int64
+	if err := repo.database.dbPool.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed counting images: %w", err)
+	}
+
+	return count, nil
+}
+
+// placeholderColumns are empty until the placeholders are computed
+const placeholderColumns = `COALESCE(blur_hash, ''), COALESCE(lqip, ''), COALESCE(dominant_color, '')`
+
+// imageColumns are the columns read by scanImage
+const imageColumns = `id, name, format, original, domain, path, sizes, created_at, updated_at, deleted_at,
+ author_id, ` + imageTagsColumn + `, ` + placeholderColumns + `, metadata, perceptual_hash`
+
+// scanImage scans the imageColumns followed by the extra columns of the query
+func scanImage(row pgx.Row, extra ...interface{}) (storage.Image, error) {
 	var image storage.Image
 
-	err := repo.database.dbPool.QueryRow(ctx, query, imageId).Scan(
+	columns := []interface{}{
 		&image.Id, &image.Name, &image.Format, &image.Original, &image.Domain, &image.Path,
-		&image.Sizes, &image.CreatedAt, &image.UpdatedAt, &image.AuthorId,
-	)
+		sizesColumn{&image.Sizes, &image.Formats}, &image.CreatedAt, &image.UpdatedAt, &image.DeletedAt,
+		&image.AuthorId, &image.Tags, &image.BlurHash, &image.Lqip, &image.DominantColor, &image.Metadata,
+		hashColumn{&image.PerceptualHash},
+	}
+	err := row.Scan(append(columns, extra...)...)
+
+	return image, err
+}
+
+func (repo *ImageRepo) GetOne(ctx context.Context, imageId string) (storage.Image, error) {
+	query := "SELECT " + imageColumns + " FROM images WHERE id = $1 AND deleted_at IS NULL LIMIT 1"
+
+	image, err := scanImage(repo.database.dbPool.QueryRow(ctx, query, imageId))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return storage.Image{}, storage.NotFound{}
@@ -90,7 +267,37 @@ LIMIT 1
 }
 
 func (repo *ImageRepo) GetOneByName(ctx context.Context, name string) (storage.Image, error) {
-	return storage.Image{}, nil
+	query := "SELECT " + imageColumns + " FROM images WHERE name = $1 AND deleted_at IS NULL LIMIT 1"
+
+	image, err := scanImage(repo.database.dbPool.QueryRow(ctx, query, name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.Image{}, storage.NotFound{Msg: "Image not found by name " + name}
+		}
+		return storage.Image{}, err
+	}
+
+	return image, nil
+}
+
+// GetCurrentName returns the name of the image that was previously called by the former name
+func (repo *ImageRepo) GetCurrentName(ctx context.Context, formerName string) (string, error) {
+	query := `SELECT images.name
+FROM slug_history
+JOIN images ON images.id = slug_history.image_id
+WHERE slug_history.name = $1 AND images.deleted_at IS NULL
+LIMIT 1`
+
+	var name string
+	err := repo.database.dbPool.QueryRow(ctx, query, formerName).Scan(&name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", storage.NotFound{Msg: "No image was named " + formerName}
+		}
+		return "", err
+	}
+
+	return name, nil
 }
 
 func (repo *ImageRepo) DoesImageExist(ctx context.Context, name string) (bool, error) {
@@ -110,17 +317,29 @@ func (repo *ImageRepo) DoesImageExist(ctx context.Context, name string) (bool, e
 
 func (repo *ImageRepo) Create(ctx context.Context, image storage.Image) (storage.Image, error) {
 	query := `INSERT INTO
- images ("name", "format", "original", "domain", "path", "sizes", "author_id")
- VALUES ($1, $2, $3, $4, $5, $6, $7)
- RETURNING id, name, format, original, domain, path, sizes, created_at, updated_at, author_id
-`
-	data, err := json.Marshal(image.Sizes)
+ images ("name", "format", "original", "domain", "path", "sizes", "author_id", "blur_hash", "lqip", "dominant_color",
+ "metadata", "perceptual_hash")
+ VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11, $12)
+ RETURNING id, name, format, original, domain, path, sizes, created_at, updated_at, author_id,
+ ` + placeholderColumns + `, metadata, perceptual_hash`
+	data, err := marshalSizes(image.Sizes, image.Formats)
+	if err != nil {
+		return storage.Image{}, err
+	}
+	metadata, err := marshalMetadata(image.Metadata)
+	if err != nil {
+		return storage.Image{}, err
+	}
+	hash, err := hashValue(image.PerceptualHash)
 	if err != nil {
 		return storage.Image{}, err
 	}
 
-	var id, name, format, original, domain, path, sizes, authorId string
+	var id, name, format, original, domain, path, authorId, blurHash, lqip, dominantColor, createdHash string
+	var sizes storage.ImageSizes
+	var formats storage.ImageVariantFormats
 	var createdAt, updatedAt *time.Time
+	var createdMetadata *storage.ImageMetadata
 
 	err = repo.database.dbPool.QueryRow(
 		ctx,
@@ -130,44 +349,301 @@ func (repo *ImageRepo) Create(ctx context.Context, image storage.Image) (storage
 		image.Original,
 		image.Domain,
 		image.Path,
-		string(data),
+		data,
 		image.AuthorId,
+		image.BlurHash,
+		image.Lqip,
+		image.DominantColor,
+		metadata,
+		hash,
 	).Scan(
-		&id, &name, &format, &original, &domain, &path, &sizes, &createdAt, &updatedAt, &authorId,
+		&id, &name, &format, &original, &domain, &path, sizesColumn{&sizes, &formats}, &createdAt, &updatedAt, &authorId,
+		&blurHash, &lqip, &dominantColor, &createdMetadata, hashColumn{&createdHash},
 	)
 
-	var sizesConverted storage.ImageSizes
-	if len(sizes) > 0 {
-		err = json.Unmarshal([]byte(sizes), &sizesConverted)
-		if err != nil {
-			return storage.Image{}, err
-		}
-	}
-
 	createdImage := storage.Image{
-		Id:        id,
-		Name:      name,
-		Format:    storage.ImageFormat(format),
-		Original:  original,
-		Domain:    domain,
-		Path:      path,
-		Sizes:     sizesConverted,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-		AuthorId:  authorId,
+		Id:             id,
+		Name:           name,
+		Format:         storage.ImageFormat(format),
+		Original:       original,
+		Domain:         domain,
+		Path:           path,
+		Sizes:          sizes,
+		Formats:        formats,
+		CreatedAt:      createdAt,
+		UpdatedAt:      updatedAt,
+		AuthorId:       authorId,
+		Tags:           storage.TagList{},
+		BlurHash:       blurHash,
+		Lqip:           lqip,
+		DominantColor:  dominantColor,
+		Metadata:       createdMetadata,
+		PerceptualHash: createdHash,
 	}
 
 	return createdImage, err
 }
 
+// SetNameById renames the image and records the previous name in the slug history within one transaction
 func (repo *ImageRepo) SetNameById(ctx context.Context, imageId, newName string) (storage.Image, error) {
-	return storage.Image{}, nil
+	tx, err := repo.database.dbPool.Begin(ctx)
+	if err != nil {
+		return storage.Image{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = renameImage(ctx, tx, imageId, newName); err != nil {
+		return storage.Image{}, err
+	}
+
+	query := "SELECT " + imageColumns + " FROM images WHERE id = $1"
+	image, err := scanImage(tx.QueryRow(ctx, query, imageId))
+	if err != nil {
+		return storage.Image{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return storage.Image{}, err
+	}
+
+	return image, nil
 }
 
+// renameImage changes the name of the image and keeps the old one in slug_history. The new name is removed from the
+// history, as it now belongs to the image and shouldn't redirect anymore.
+func renameImage(ctx context.Context, tx pgx.Tx, imageId, newName string) error {
+	var oldName string
+	err := tx.QueryRow(ctx, "SELECT name FROM images WHERE id = $1 FOR UPDATE", imageId).Scan(&oldName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.NotFound{Msg: "Image not found by id " + imageId}
+		}
+		return err
+	}
+	if oldName == newName {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE images SET name = $2, updated_at = now() WHERE id = $1", imageId, newName)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return storage.ErrDuplicate
+		}
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM slug_history WHERE name = $1", newName); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO slug_history (image_id, name) VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET image_id = excluded.image_id, created_at = now()`, imageId, oldName)
+
+	return err
+}
+
+// UpdateOne replaces the name, files, sizes, placeholders, metadata and perceptual hash of the image with the id of
+// updates. A changed name is recorded in the slug history, same as in SetNameById.
 func (repo *ImageRepo) UpdateOne(ctx context.Context, updates storage.Image) error {
+	data, err := marshalSizes(updates.Sizes, updates.Formats)
+	if err != nil {
+		return err
+	}
+	metadata, err := marshalMetadata(updates.Metadata)
+	if err != nil {
+		return err
+	}
+	hash, err := hashValue(updates.PerceptualHash)
+	if err != nil {
+		return err
+	}
+
+	tx, err := repo.database.dbPool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = renameImage(ctx, tx, updates.Id, updates.Name); err != nil {
+		return err
+	}
+
+	query := `UPDATE images
+SET format = $2, original = $3, domain = $4, path = $5, sizes = $6,
+ blur_hash = NULLIF($7, ''), lqip = NULLIF($8, ''), dominant_color = NULLIF($9, ''), metadata = $10,
+ perceptual_hash = $11, updated_at = now()
+WHERE id = $1`
+	_, err = tx.Exec(
+		ctx,
+		query,
+		updates.Id,
+		updates.Format,
+		updates.Original,
+		updates.Domain,
+		updates.Path,
+		data,
+		updates.BlurHash,
+		updates.Lqip,
+		updates.DominantColor,
+		metadata,
+		hash,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetWithoutPlaceholder returns the images whose placeholders weren't computed yet ordered by id, starting after
+// the id so images that keep failing are skipped by the following batches
+func (repo *ImageRepo) GetWithoutPlaceholder(
+	ctx context.Context, afterId string, limit int,
+) (storage.ImageList, error) {
+	query := `SELECT ` + imageColumns + `
+FROM images
+WHERE blur_hash IS NULL AND deleted_at IS NULL AND ($1 = '' OR id > NULLIF($1, '')::uuid)
+ORDER BY id ASC
+LIMIT $2
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying images without placeholder: %w", err)
+	}
+	defer rows.Close()
+
+	images := storage.ImageList{}
+	for rows.Next() {
+		image, scanErr := scanImage(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("failed scaning images: %w", scanErr)
+		}
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
+// SetPlaceholder stores the placeholders of the image without changing its update time, they don't change its files
+func (repo *ImageRepo) SetPlaceholder(ctx context.Context, img storage.Image) error {
+	query := `UPDATE images
+SET blur_hash = NULLIF($2, ''), lqip = NULLIF($3, ''), dominant_color = NULLIF($4, '')
+WHERE id = $1`
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, img.Id, img.BlurHash, img.Lqip, img.DominantColor)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Image not found by id " + img.Id}
+	}
+
 	return nil
 }
 
+func (repo *ImageRepo) GetWithoutHash(
+	ctx context.Context, afterId string, limit int,
+) (storage.ImageList, error) {
+	query := `SELECT ` + imageColumns + `
+FROM images
+WHERE perceptual_hash IS NULL AND deleted_at IS NULL AND ($1 = '' OR id > NULLIF($1, '')::uuid)
+ORDER BY id ASC
+LIMIT $2
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying images without perceptual hash: %w", err)
+	}
+	defer rows.Close()
+
+	images := storage.ImageList{}
+	for rows.Next() {
+		image, scanErr := scanImage(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("failed scaning images: %w", scanErr)
+		}
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
+// SetPerceptualHash stores the perceptual hash of the image without changing its update time, like its placeholders
+func (repo *ImageRepo) SetPerceptualHash(ctx context.Context, img storage.Image) error {
+	hash, err := hashValue(img.PerceptualHash)
+	if err != nil {
+		return err
+	}
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, "UPDATE images SET perceptual_hash = $2 WHERE id = $1", img.Id, hash)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Image not found by id " + img.Id}
+	}
+
+	return nil
+}
+
+// Trash hides the image from every read until it's restored or purged
+func (repo *ImageRepo) Trash(ctx context.Context, imageId string) error {
+	query := "UPDATE images SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL"
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, imageId)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Image not found by id " + imageId}
+	}
+
+	return nil
+}
+
+func (repo *ImageRepo) Restore(ctx context.Context, imageId string) (storage.Image, error) {
+	query := `UPDATE images SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING ` + imageColumns
+
+	image, err := scanImage(repo.database.dbPool.QueryRow(ctx, query, imageId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.Image{}, storage.NotFound{Msg: "Image not found in trash by id " + imageId}
+		}
+		return storage.Image{}, err
+	}
+
+	return image, nil
+}
+
+func (repo *ImageRepo) GetTrashedBefore(
+	ctx context.Context, before time.Time, limit int,
+) (storage.ImageList, error) {
+	query := `SELECT ` + imageColumns + `
+FROM images
+WHERE deleted_at < $1
+ORDER BY deleted_at ASC
+LIMIT $2
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying trashed images: %w", err)
+	}
+	defer rows.Close()
+
+	images := storage.ImageList{}
+	for rows.Next() {
+		image, scanErr := scanImage(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("failed scaning images: %w", scanErr)
+		}
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
 func (repo *ImageRepo) DeleteOne(ctx context.Context, imageId string) error {
 	query := "DELETE FROM images WHERE id = $1"
 
@@ -4,8 +4,11 @@ import (
 	"api/storage"
 	"api/test"
 	"context"
+	"errors"
 	"fmt"
+	"math/bits"
 	"testing"
+	"time"
 )
 
 func setupImageRepo(ctx context.Context) (*ImageRepo, error) {
@@ -66,8 +69,10 @@ func insertDummyData(repo *ImageRepo, userRepo *UserRepo) error {
 			AuthorId: createdUser.Id,
 			Sizes: storage.ImageSizes{
 				Original: storage.Dimensions{Width: 300, Height: 400},
-				Xs:       &storage.Dimensions{Width: 100, Height: 150},
-				S:        &storage.Dimensions{Width: 200, Height: 250},
+				Variants: map[string]storage.Dimensions{
+					"xs": {Width: 100, Height: 150},
+					"s":  {Width: 200, Height: 250},
+				},
 			},
 		},
 	}
@@ -96,7 +101,10 @@ func TestImageRepository_Get(t *testing.T) {
 		t.Error(fmt.Errorf("error inserting images %w", err))
 	}
 
-	imageList, err := repo.Get(context.Background(), 10, 0, storage.OrderDescending)
+	imageList, err := repo.Get(context.Background(), storage.ImageFilter{}, storage.Pagination{
+		Limit: 10,
+		Order: storage.OrderDescending,
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -131,8 +139,10 @@ func TestImageRepository_Get(t *testing.T) {
 			AuthorId: "user-1",
 			Sizes: storage.ImageSizes{
 				Original: storage.Dimensions{Width: 300, Height: 400},
-				Xs:       &storage.Dimensions{Width: 100, Height: 150},
-				S:        &storage.Dimensions{Width: 200, Height: 250},
+				Variants: map[string]storage.Dimensions{
+					"xs": {Width: 100, Height: 150},
+					"s":  {Width: 200, Height: 250},
+				},
 			},
 		},
 	}
@@ -145,6 +155,133 @@ func TestImageRepository_Get(t *testing.T) {
 	}
 }
 
+func TestImageRepository_GetByAuthor(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	if err = insertDummyData(repo, userRepo); err != nil {
+		t.Fatal(fmt.Errorf("error inserting images %w", err))
+	}
+	pagination := storage.Pagination{Limit: 10, Order: storage.OrderDescending}
+	all, err := repo.Get(ctx, storage.ImageFilter{}, pagination)
+	if err != nil || len(all) == 0 {
+		t.Fatalf("Expected images, got %v", err)
+	}
+
+	images, err := repo.Get(ctx, storage.ImageFilter{AuthorId: all[0].AuthorId}, pagination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 images of the author, got %d", len(images))
+	}
+
+	count, err := repo.Count(ctx, storage.ImageFilter{AuthorId: "c0a6f5b4-2a77-4e4b-9a53-6d7f0f4b8f11"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no images of another author, got %d", count)
+	}
+}
+
+// maxUuid sorts after every image id, so a cursor with it points past every image with the same key
+const maxUuid = "ffffffff-ffff-ffff-ffff-ffffffffffff"
+
+func TestImageRepository_GetFilteredAndSorted(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	if err = insertDummyData(repo, userRepo); err != nil {
+		t.Fatal(fmt.Errorf("error inserting images %w", err))
+	}
+
+	data := []struct {
+		testName   string
+		filter     storage.ImageFilter
+		pagination storage.Pagination
+		expected   []string
+	}{
+		{
+			testName:   "By format",
+			filter:     storage.ImageFilter{Format: storage.PngFormat},
+			pagination: storage.Pagination{Limit: 10},
+			expected:   []string{"testing-image-one"},
+		},
+		{
+			testName:   "By size variant",
+			filter:     storage.ImageFilter{HasSize: "xs"},
+			pagination: storage.Pagination{Limit: 10},
+			expected:   []string{"testing-image-two"},
+		},
+		{
+			testName:   "By minimum dimensions",
+			filter:     storage.ImageFilter{MinWidth: 200, MinHeight: 400},
+			pagination: storage.Pagination{Limit: 10},
+			expected:   []string{"testing-image-two"},
+		},
+		{
+			testName:   "Sorted by width",
+			pagination: storage.Pagination{Limit: 10, Order: storage.OrderAscending, Sort: storage.ImageSortWidth},
+			expected:   []string{"testing-image-one", "testing-image-two"},
+		},
+		{
+			testName:   "Sorted by name",
+			pagination: storage.Pagination{Limit: 10, Order: storage.OrderDescending, Sort: storage.ImageSortName},
+			expected:   []string{"testing-image-two", "testing-image-one"},
+		},
+		{
+			testName: "After name cursor",
+			pagination: storage.Pagination{
+				Limit:  10,
+				Order:  storage.OrderAscending,
+				Sort:   storage.ImageSortName,
+				Cursor: &storage.Cursor{Id: maxUuid, Sort: storage.ImageSortName, Key: "testing-image-one"},
+			},
+			expected: []string{"testing-image-two"},
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.testName, func(t *testing.T) {
+			images, err := repo.Get(ctx, d.filter, d.pagination)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(images) != len(d.expected) {
+				t.Fatalf("Expected %d images, got %d", len(d.expected), len(images))
+			}
+			for i, name := range d.expected {
+				if images[i].Name != name {
+					t.Fatalf("Expected image %d to be %s, got %s", i, name, images[i].Name)
+				}
+			}
+		})
+	}
+}
+
 func TestImageRepo_GetOne(t *testing.T) {
 	test.SkipIfNotIntegrationTesting(t)
 	ctx := context.Background()
@@ -165,7 +302,10 @@ func TestImageRepo_GetOne(t *testing.T) {
 		t.Error(fmt.Errorf("error inserting images %w", err))
 	}
 
-	imageList, err := repo.Get(context.Background(), 10, 0, storage.OrderDescending)
+	imageList, err := repo.Get(context.Background(), storage.ImageFilter{}, storage.Pagination{
+		Limit: 10,
+		Order: storage.OrderDescending,
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -191,7 +331,7 @@ func TestImageRepo_GetOne(t *testing.T) {
 	if secondImage.Name != img.Name {
 		t.Fatal("failed Name assertion")
 	}
-	if secondImage.Sizes != img.Sizes {
+	if !secondImage.Sizes.IsEqualTo(img.Sizes) {
 		t.Fatal("failed Sizes assertion")
 	}
 	if secondImage.AuthorId != img.AuthorId {
@@ -274,7 +414,7 @@ func TestImageRepository_Create(t *testing.T) {
 	if createdImage.AuthorId != newUser.Id {
 		t.Fatal("failed asserting Id")
 	}
-	if createdImage.Sizes != img.Sizes {
+	if !createdImage.Sizes.IsEqualTo(img.Sizes) {
 		t.Fatal("failed asserting Sizes")
 	}
 }
@@ -315,3 +455,487 @@ func TestImageRepository_DoesImageExist(t *testing.T) {
 		t.Fatal("image unknown should not exist")
 	}
 }
+
+func TestImageRepository_SetNameById(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	err = insertDummyData(repo, userRepo)
+	if err != nil {
+		t.Error(fmt.Errorf("error inserting images %w", err))
+	}
+
+	img, err := repo.GetOneByName(ctx, "testing-image-one")
+	if err != nil {
+		t.Fatal("[GetOneByName]: ", err)
+	}
+
+	renamed, err := repo.SetNameById(ctx, img.Id, "testing-image-renamed")
+	if err != nil {
+		t.Fatal("[SetNameById]: ", err)
+	}
+	if renamed.Name != "testing-image-renamed" || renamed.UpdatedAt == nil {
+		t.Fatalf("expected renamed image with updatedAt, got %+v", renamed)
+	}
+
+	currentName, err := repo.GetCurrentName(ctx, "testing-image-one")
+	if err != nil {
+		t.Fatal("[GetCurrentName]: ", err)
+	}
+	if currentName != "testing-image-renamed" {
+		t.Fatalf("expected current name testing-image-renamed, got %s", currentName)
+	}
+
+	// Renaming back makes the old name current again, so it's no longer a redirect
+	if _, err = repo.SetNameById(ctx, img.Id, "testing-image-one"); err != nil {
+		t.Fatal("[SetNameById back]: ", err)
+	}
+	_, err = repo.GetCurrentName(ctx, "testing-image-one")
+	if !errors.As(err, &storage.NotFound{}) {
+		t.Fatalf("expected NotFound for the current name, got %v", err)
+	}
+
+	if _, err = repo.SetNameById(ctx, img.Id, "testing-image-two"); !errors.Is(err, storage.ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate for a taken name, got %v", err)
+	}
+}
+
+func TestImageRepository_UpdateOne(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	err = insertDummyData(repo, userRepo)
+	if err != nil {
+		t.Error(fmt.Errorf("error inserting images %w", err))
+	}
+
+	img, err := repo.GetOneByName(ctx, "testing-image-one")
+	if err != nil {
+		t.Fatal("[GetOneByName]: ", err)
+	}
+
+	updates := img
+	updates.Name = "testing-image-updated"
+	updates.Format = "webp"
+	updates.Original = "images/testing-image-updated.webp"
+	updates.Sizes = storage.ImageSizes{
+		Original: storage.Dimensions{Width: 600, Height: 400},
+		Variants: map[string]storage.Dimensions{"s": {Width: 300, Height: 200}},
+	}
+	if err = repo.UpdateOne(ctx, updates); err != nil {
+		t.Fatal("[UpdateOne]: ", err)
+	}
+
+	updated, err := repo.GetOne(ctx, img.Id)
+	if err != nil {
+		t.Fatal("[GetOne]: ", err)
+	}
+	if !updated.IsEqualTo(updates) {
+		t.Fatalf("expected %+v, got %+v", updates, updated)
+	}
+	if updated.UpdatedAt == nil {
+		t.Fatal("expected updatedAt to be set")
+	}
+
+	currentName, err := repo.GetCurrentName(ctx, "testing-image-one")
+	if err != nil || currentName != "testing-image-updated" {
+		t.Fatalf("expected the old name to redirect, got %s %v", currentName, err)
+	}
+}
+
+func TestImageRepository_TrashAndRestore(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	err = insertDummyData(repo, userRepo)
+	if err != nil {
+		t.Error(fmt.Errorf("error inserting images %w", err))
+	}
+
+	img, err := repo.GetOneByName(ctx, "testing-image-one")
+	if err != nil {
+		t.Fatal("[GetOneByName]: ", err)
+	}
+
+	if err = repo.Trash(ctx, img.Id); err != nil {
+		t.Fatal("[Trash]: ", err)
+	}
+	if _, err = repo.GetOne(ctx, img.Id); !errors.As(err, &storage.NotFound{}) {
+		t.Fatalf("expected trashed image to be hidden, got %v", err)
+	}
+
+	trashed, err := repo.Get(ctx, storage.ImageFilter{Trashed: true}, storage.Pagination{
+		Limit: 10,
+		Order: storage.OrderDescending,
+	})
+	if err != nil {
+		t.Fatal("[Get trashed]: ", err)
+	}
+	if len(trashed) != 1 || trashed[0].Id != img.Id || trashed[0].DeletedAt == nil {
+		t.Fatalf("expected the trashed image, got %+v", trashed)
+	}
+
+	purgeable, err := repo.GetTrashedBefore(ctx, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal("[GetTrashedBefore]: ", err)
+	}
+	if len(purgeable) != 1 {
+		t.Fatalf("expected 1 purgeable image, got %d", len(purgeable))
+	}
+
+	restored, err := repo.Restore(ctx, img.Id)
+	if err != nil {
+		t.Fatal("[Restore]: ", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Fatal("expected restored image to have no deletedAt")
+	}
+	if _, err = repo.Restore(ctx, img.Id); !errors.As(err, &storage.NotFound{}) {
+		t.Fatalf("expected NotFound restoring an active image, got %v", err)
+	}
+}
+
+func TestImageRepository_SetPlaceholder(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	err = insertDummyData(repo, userRepo)
+	if err != nil {
+		t.Error(fmt.Errorf("error inserting images %w", err))
+	}
+
+	missing, err := repo.GetWithoutPlaceholder(ctx, "", 10)
+	if err != nil {
+		t.Fatal("[GetWithoutPlaceholder]: ", err)
+	}
+	if len(missing) != 2 {
+		t.Fatalf("expected 2 images without placeholder, got %d", len(missing))
+	}
+
+	img := missing[0]
+	img.BlurHash = "LEHV6nWB2yk8pyo0adR*.7kCMdnj"
+	img.Lqip = "data:image/jpeg;base64,/9j/"
+	img.DominantColor = "#a1b2c3"
+	if err = repo.SetPlaceholder(ctx, img); err != nil {
+		t.Fatal("[SetPlaceholder]: ", err)
+	}
+
+	updated, err := repo.GetOne(ctx, img.Id)
+	if err != nil {
+		t.Fatal("[GetOne]: ", err)
+	}
+	if !updated.IsEqualTo(img) || updated.UpdatedAt != nil {
+		t.Fatalf("expected %+v without an update time, got %+v", img, updated)
+	}
+
+	missing, err = repo.GetWithoutPlaceholder(ctx, "", 10)
+	if err != nil {
+		t.Fatal("[GetWithoutPlaceholder]: ", err)
+	}
+	if len(missing) != 1 || missing[0].Id == img.Id {
+		t.Fatalf("expected only the other image without placeholder, got %+v", missing)
+	}
+	if after, _ := repo.GetWithoutPlaceholder(ctx, missing[0].Id, 10); len(after) != 0 {
+		t.Fatalf("expected no images after the last one, got %+v", after)
+	}
+}
+
+func TestImageRepository_SetPerceptualHash(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	err = insertDummyData(repo, userRepo)
+	if err != nil {
+		t.Error(fmt.Errorf("error inserting images %w", err))
+	}
+
+	missing, err := repo.GetWithoutHash(ctx, "", 10)
+	if err != nil {
+		t.Fatal("[GetWithoutHash]: ", err)
+	}
+	if len(missing) != 2 {
+		t.Fatalf("expected 2 images without perceptual hash, got %d", len(missing))
+	}
+
+	img := missing[0]
+	img.PerceptualHash = "f0f0f0f0f0f0f0f0"
+	if err = repo.SetPerceptualHash(ctx, img); err != nil {
+		t.Fatal("[SetPerceptualHash]: ", err)
+	}
+
+	updated, err := repo.GetOne(ctx, img.Id)
+	if err != nil {
+		t.Fatal("[GetOne]: ", err)
+	}
+	if updated.PerceptualHash != img.PerceptualHash || updated.UpdatedAt != nil {
+		t.Fatalf("expected %+v without an update time, got %+v", img, updated)
+	}
+
+	missing, err = repo.GetWithoutHash(ctx, "", 10)
+	if err != nil {
+		t.Fatal("[GetWithoutHash]: ", err)
+	}
+	if len(missing) != 1 || missing[0].Id == img.Id {
+		t.Fatalf("expected only the other image without perceptual hash, got %+v", missing)
+	}
+}
+
+func TestImageRepository_Metadata(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	err = insertDummyData(repo, userRepo)
+	if err != nil {
+		t.Error(fmt.Errorf("error inserting images %w", err))
+	}
+
+	images, err := repo.GetWithoutPlaceholder(ctx, "", 10)
+	if err != nil {
+		t.Fatal("[GetWithoutPlaceholder]: ", err)
+	}
+	if len(images) == 0 || images[0].Metadata != nil {
+		t.Fatalf("expected images without metadata, got %+v", images)
+	}
+
+	capturedAt := time.Date(2021, 6, 21, 16, 30, 0, 0, time.UTC)
+	altitude := 170.5
+	img := images[0]
+	img.Metadata = &storage.ImageMetadata{
+		Camera:      "Canon EOS R5",
+		CapturedAt:  &capturedAt,
+		Orientation: 6,
+		Gps:         &storage.Coordinates{Latitude: 45.75, Longitude: -4.5, Altitude: &altitude},
+	}
+	if err = repo.UpdateOne(ctx, img); err != nil {
+		t.Fatal("[UpdateOne]: ", err)
+	}
+
+	updated, err := repo.GetOne(ctx, img.Id)
+	if err != nil {
+		t.Fatal("[GetOne]: ", err)
+	}
+	if !updated.Metadata.IsEqualTo(img.Metadata) {
+		t.Fatalf("expected the metadata %+v, got %+v", img.Metadata, updated.Metadata)
+	}
+
+	img.Metadata = nil
+	if err = repo.UpdateOne(ctx, img); err != nil {
+		t.Fatal("[UpdateOne]: ", err)
+	}
+	if updated, _ = repo.GetOne(ctx, img.Id); updated.Metadata != nil {
+		t.Fatalf("expected the metadata to be cleared, got %+v", updated.Metadata)
+	}
+}
+
+func TestImageRepository_GetSimilar(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	err = insertDummyData(repo, userRepo)
+	if err != nil {
+		t.Error(fmt.Errorf("error inserting images %w", err))
+	}
+
+	images, err := repo.GetWithoutPlaceholder(ctx, "", 10)
+	if err != nil {
+		t.Fatal("[GetWithoutPlaceholder]: ", err)
+	}
+	if len(images) == 0 || images[0].PerceptualHash != "" {
+		t.Fatalf("expected images without perceptual hash, got %+v", images)
+	}
+
+	// the hashes differ from the first one in 0, 3, 9 and 4 bits, the third one in its sign bit too and the last
+	// one in every band. The first is updated, the others are created with theirs.
+	hashes := []string{"f0f0f0f0f0f0f0f0", "f0f0f0f0f0f0f0f7", "0ff0f0f0f0f0f0f1", "f0f1f0f1f0f1f0f1"}
+	images[0].PerceptualHash = hashes[0]
+	if err = repo.UpdateOne(ctx, images[0]); err != nil {
+		t.Fatal("[UpdateOne]: ", err)
+	}
+	images = images[:1]
+	for i, hash := range hashes[1:] {
+		created, createErr := repo.Create(ctx, storage.Image{
+			Name:           fmt.Sprintf("similar-image-%d", i),
+			Format:         "png",
+			Original:       fmt.Sprintf("images/similar-image-%d.png", i),
+			AuthorId:       images[0].AuthorId,
+			PerceptualHash: hash,
+		})
+		if createErr != nil {
+			t.Fatal("[Create]: ", createErr)
+		}
+		images = append(images, created)
+	}
+
+	updated, err := repo.GetOne(ctx, images[0].Id)
+	if err != nil {
+		t.Fatal("[GetOne]: ", err)
+	}
+	if updated.PerceptualHash != hashes[0] {
+		t.Fatalf("expected the perceptual hash %s, got %s", hashes[0], updated.PerceptualHash)
+	}
+
+	similar, err := repo.GetSimilar(ctx, hashes[0], images[0].Id, 5, 10)
+	if err != nil {
+		t.Fatal("[GetSimilar]: ", err)
+	}
+	if len(similar) != 2 || similar[0].Image.Id != images[1].Id || similar[1].Image.Id != images[3].Id {
+		t.Fatalf("expected the images 3 and 4 bits away, got %+v", similar)
+	}
+
+	similar, err = repo.GetSimilar(ctx, hashes[0], "", 64, 10)
+	if err != nil {
+		t.Fatal("[GetSimilar]: ", err)
+	}
+	if len(similar) != 4 || similar[0].Distance != 0 || similar[3].Distance != 9 {
+		t.Fatalf("expected the hashed images nearest first, got %+v", similar)
+	}
+}
+
+func TestBandProbes(t *testing.T) {
+	probes := bandProbes(0xf0f0, 0, 2, nil)
+	if len(probes) != 1+16+120 {
+		t.Fatalf("expected the band and its values 1 and 2 bits away, got %d probes", len(probes))
+	}
+
+	seen := map[int32]bool{}
+	for _, probe := range probes {
+		if seen[probe] || bits.OnesCount16(uint16(probe)^0xf0f0) > 2 {
+			t.Fatalf("unexpected probe %016b", probe)
+		}
+		seen[probe] = true
+	}
+}
+
+func TestImageRepository_Get_TagSynonyms(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagRepo, err := setupTagRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+	defer cleanTagRepo(t, tagRepo)
+
+	if err = insertDummyData(repo, userRepo); err != nil {
+		t.Fatal(fmt.Errorf("error inserting images %w", err))
+	}
+	pagination := storage.Pagination{Limit: 10, Order: storage.OrderDescending}
+	all, err := repo.Get(ctx, storage.ImageFilter{}, pagination)
+	if err != nil || len(all) == 0 {
+		t.Fatalf("Expected images, got %v", err)
+	}
+
+	// the image is tagged before aircraft becomes a synonym of plane
+	plane, err := tagRepo.Create(ctx, storage.Tag{Value: "plane"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aircraft, err := tagRepo.Create(ctx, storage.Tag{Value: "aircraft"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tagRepo.AttachToImage(ctx, all[0].Id, aircraft.Id); err != nil {
+		t.Fatal(err)
+	}
+	aircraft.CanonicalId = &plane.Id
+	if _, err = tagRepo.UpdateOne(ctx, aircraft); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range []string{"plane", "aircraft"} {
+		images, err := repo.Get(ctx, storage.ImageFilter{Tag: value}, pagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(images) != 1 || images[0].Id != all[0].Id {
+			t.Fatalf("Expected the image tagged with the synonym for %s, got %d images", value, len(images))
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package postgresql
+
+import (
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
+type ImageRevisionRepo struct {
+	database *Database
+}
+
+func NewImageRevisionRepository(db *Database) *ImageRevisionRepo {
+	return &ImageRevisionRepo{database: db}
+}
+
+const imageRevisionColumns = `id, image_id, revision, name, format, original, domain, path, sizes, author_id,
+ created_at, COALESCE(blur_hash, ''), COALESCE(lqip, ''), COALESCE(dominant_color, '')`
+
+func scanImageRevision(row pgx.Row) (storage.ImageRevision, error) {
+	var revision storage.ImageRevision
+	err := row.Scan(
+		&revision.Id,
+		&revision.ImageId,
+		&revision.Revision,
+		&revision.Name,
+		&revision.Format,
+		&revision.Original,
+		&revision.Domain,
+		&revision.Path,
+		sizesColumn{&revision.Sizes, &revision.Formats},
+		&revision.AuthorId,
+		&revision.CreatedAt,
+		&revision.BlurHash,
+		&revision.Lqip,
+		&revision.DominantColor,
+	)
+	return revision, err
+}
+
+func (repo *ImageRevisionRepo) Get(ctx context.Context, imageId string) (storage.ImageRevisionList, error) {
+	query := `SELECT ` + imageRevisionColumns + `
+FROM image_revisions
+WHERE image_id = $1
+ORDER BY revision DESC
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, imageId)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying image revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := storage.ImageRevisionList{}
+	for rows.Next() {
+		revision, scanErr := scanImageRevision(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (repo *ImageRevisionRepo) GetOne(
+	ctx context.Context, imageId string, revision int,
+) (storage.ImageRevision, error) {
+	query := `SELECT ` + imageRevisionColumns + ` FROM image_revisions WHERE image_id = $1 AND revision = $2`
+
+	found, err := scanImageRevision(repo.database.dbPool.QueryRow(ctx, query, imageId, revision))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ImageRevision{}, storage.NotFound{
+				Msg: fmt.Sprintf("Revision %d of image %s not found", revision, imageId),
+			}
+		}
+		return storage.ImageRevision{}, err
+	}
+
+	return found, nil
+}
+
+func (repo *ImageRevisionRepo) NextRevision(ctx context.Context, imageId string) (int, error) {
+	query := "SELECT COALESCE(MAX(revision), 0) + 1 FROM image_revisions WHERE image_id = $1"
+
+	var next int
+	if err := repo.database.dbPool.QueryRow(ctx, query, imageId).Scan(&next); err != nil {
+		return 0, err
+	}
+
+	return next, nil
+}
+
+func (repo *ImageRevisionRepo) Create(
+	ctx context.Context, revision storage.ImageRevision,
+) (storage.ImageRevision, error) {
+	query := `INSERT INTO image_revisions
+ ("image_id", "revision", "name", "format", "original", "domain", "path", "sizes", "author_id",
+ "blur_hash", "lqip", "dominant_color")
+ VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''))
+ RETURNING ` + imageRevisionColumns
+
+	data, err := marshalSizes(revision.Sizes, revision.Formats)
+	if err != nil {
+		return storage.ImageRevision{}, err
+	}
+
+	created, err := scanImageRevision(repo.database.dbPool.QueryRow(
+		ctx,
+		query,
+		revision.ImageId,
+		revision.Revision,
+		revision.Name,
+		revision.Format,
+		revision.Original,
+		revision.Domain,
+		revision.Path,
+		data,
+		revision.AuthorId,
+		revision.BlurHash,
+		revision.Lqip,
+		revision.DominantColor,
+	))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return storage.ImageRevision{}, storage.ErrDuplicate
+		}
+		return storage.ImageRevision{}, err
+	}
+
+	return created, nil
+}
+
+func (repo *ImageRevisionRepo) DeleteOne(ctx context.Context, imageId string, revision int) error {
+	query := "DELETE FROM image_revisions WHERE image_id = $1 AND revision = $2"
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, imageId, revision)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: fmt.Sprintf("Revision %d of image %s not found", revision, imageId)}
+	}
+
+	return nil
+}
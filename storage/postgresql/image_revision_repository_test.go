@@ -0,0 +1,82 @@
+package postgresql
+
+import (
+	"api/storage"
+	"api/test"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestImageRevisionRepo(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	imageRepo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, imageRepo)
+
+	if err = insertDummyData(imageRepo, userRepo); err != nil {
+		t.Error(fmt.Errorf("error inserting images %w", err))
+	}
+	img, err := imageRepo.GetOneByName(ctx, "testing-image-two")
+	if err != nil {
+		t.Fatal("[GetOneByName]: ", err)
+	}
+
+	repo := NewImageRevisionRepository(imageRepo.database)
+
+	next, err := repo.NextRevision(ctx, img.Id)
+	if err != nil || next != 1 {
+		t.Fatalf("expected first revision number 1, got %d %v", next, err)
+	}
+
+	revision := storage.ImageRevision{
+		ImageId:  img.Id,
+		Revision: next,
+		Name:     img.Name,
+		Format:   img.Format,
+		Original: "images/revision.jpg",
+		Domain:   img.Domain,
+		Path:     img.Path,
+		Sizes:    img.Sizes,
+		AuthorId: &img.AuthorId,
+		BlurHash: "LEHV6nWB2yk8",
+	}
+	created, err := repo.Create(ctx, revision)
+	if err != nil {
+		t.Fatal("[Create]: ", err)
+	}
+	if created.Id == "" || created.CreatedAt == nil || !created.Sizes.IsEqualTo(img.Sizes) ||
+		created.BlurHash != "LEHV6nWB2yk8" || created.Lqip != "" {
+		t.Fatalf("unexpected created revision %+v", created)
+	}
+	if _, err = repo.Create(ctx, revision); !errors.Is(err, storage.ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate for the same revision number, got %v", err)
+	}
+
+	next, err = repo.NextRevision(ctx, img.Id)
+	if err != nil || next != 2 {
+		t.Fatalf("expected next revision number 2, got %d %v", next, err)
+	}
+
+	revisions, err := repo.Get(ctx, img.Id)
+	if err != nil || len(revisions) != 1 {
+		t.Fatalf("expected 1 revision, got %d %v", len(revisions), err)
+	}
+
+	if err = repo.DeleteOne(ctx, img.Id, 1); err != nil {
+		t.Fatal("[DeleteOne]: ", err)
+	}
+	if _, err = repo.GetOne(ctx, img.Id, 1); !errors.As(err, &storage.NotFound{}) {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package postgresql
+
+import (
+	"api/storage"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// searchConfig is the text search configuration used for the images.search_vector column
+const searchConfig = "english"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the LIKE wildcards so the value is matched literally
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// Search ranks images by full text match on the name and tags, including tag synonyms, combined with the trigram
+// similarity of the name so that misspelled queries still find results
+func (repo *ImageRepo) Search(
+	ctx context.Context, query string, limit, offset int,
+) (storage.ImageSearchResultList, error) {
+	sqlQuery := `WITH search AS (
+  SELECT websearch_to_tsquery('` + searchConfig + `', $1) AS query
+ )
+ SELECT
+  images.id, images.name, images.format, images.original, images.domain, images.path, images.sizes,
+  images.created_at, images.updated_at, images.author_id, ` + imageTagsColumn + `, ` + placeholderColumns + `,
+  images.metadata, images.perceptual_hash,
+  ts_rank(images.search_vector || tag_document.vector, search.query)
+   + similarity(replace(images.name, '-', ' '), $1) AS rank,
+  ts_headline(
+   '` + searchConfig + `', replace(images.name, '-', ' '), search.query,
+   'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'
+  ) AS highlight,
+  tag_document.matched
+ FROM images
+ CROSS JOIN search
+ CROSS JOIN LATERAL (
+  SELECT
+   to_tsvector('` + searchConfig + `', COALESCE(string_agg(t.value, ' '), '')) AS vector,
+   COALESCE(
+    array_agg(DISTINCT t.value) FILTER (
+     WHERE to_tsvector('` + searchConfig + `', t.value) @@ search.query OR t.value % $1
+    ),
+    '{}'
+   ) AS matched
+  FROM images_tags it
+  JOIN tags t ON t.id = it.tag_id OR t.canonical_id = it.tag_id
+  WHERE it.image_id = images.id
+ ) tag_document
+ WHERE images.deleted_at IS NULL AND (
+  images.search_vector @@ search.query
+  OR tag_document.vector @@ search.query
+  OR images.name % $1
+  OR cardinality(tag_document.matched) > 0
+ )
+ ORDER BY rank DESC, images.created_at DESC
+ LIMIT $2
+ OFFSET $3
+`
+	rows, err := repo.database.dbPool.Query(ctx, sqlQuery, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed searching images: %w", err)
+	}
+	defer rows.Close()
+
+	results := storage.ImageSearchResultList{}
+	for rows.Next() {
+		var result storage.ImageSearchResult
+		img := &result.Image
+
+		err = rows.Scan(
+			&img.Id, &img.Name, &img.Format, &img.Original, &img.Domain, &img.Path,
+			sizesColumn{&img.Sizes, &img.Formats}, &img.CreatedAt, &img.UpdatedAt, &img.AuthorId, &img.Tags,
+			&img.BlurHash, &img.Lqip, &img.DominantColor, &img.Metadata, hashColumn{&img.PerceptualHash}, &result.Rank,
+			&result.Highlight, &result.MatchedTags,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scaning search results: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// Suggest returns image names and tags for autocompletion, prefix matches come first followed by similar values
+func (repo *ImageRepo) Suggest(ctx context.Context, prefix string, limit int) (storage.SuggestionList, error) {
+	query := `SELECT value, kind FROM (
+  SELECT replace(name, '-', ' ') AS value, 'image' AS kind, similarity(replace(name, '-', ' '), $1) AS score,
+   replace(name, '-', ' ') ILIKE $2 AS is_prefix
+  FROM images
+  WHERE deleted_at IS NULL AND (replace(name, '-', ' ') ILIKE $2 OR name % $1)
+  UNION ALL
+  SELECT value, 'tag' AS kind, similarity(value, $1) AS score, value ILIKE $2 AS is_prefix
+  FROM tags
+  WHERE value ILIKE $2 OR value % $1
+ ) suggestions
+ ORDER BY is_prefix DESC, score DESC, value ASC
+ LIMIT $3
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, prefix, escapeLike(prefix)+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := storage.SuggestionList{}
+	for rows.Next() {
+		var suggestion storage.Suggestion
+		if err = rows.Scan(&suggestion.Value, &suggestion.Kind); err != nil {
+			return nil, fmt.Errorf("failed scaning suggestions: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, rows.Err()
+}
@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"api/test"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEscapeLike(t *testing.T) {
+	data := []struct {
+		value    string
+		expected string
+	}{
+		{value: "plane", expected: "plane"},
+		{value: "100%", expected: `100\%`},
+		{value: "my_image", expected: `my\_image`},
+		{value: `back\slash`, expected: `back\\slash`},
+	}
+
+	for _, d := range data {
+		result := escapeLike(d.value)
+		if result != d.expected {
+			t.Errorf("Expected '%s' to be escaped as '%s', got '%s'", d.value, d.expected, result)
+		}
+	}
+}
+
+func TestImageRepository_Search(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupImageRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	defer cleanImageRepo(t, repo)
+
+	if err = insertDummyData(repo, userRepo); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := repo.Search(ctx, "testing image two", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].Image.Name != "testing-image-two" {
+		t.Fatalf("Expected the exact name to rank first, got %+v", results)
+	}
+	if !strings.Contains(results[0].Highlight, "<mark>two</mark>") {
+		t.Fatalf("Unexpected highlight %s", results[0].Highlight)
+	}
+
+	// a misspelled name is only matched by the trigram similarity of the name, dashes are compared as spaces
+	results, err = repo.Search(ctx, "testng imag one", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].Image.Name != "testing-image-one" {
+		t.Fatalf("Expected the similar name to be found, got %+v", results)
+	}
+
+	suggestions, err := repo.Suggest(ctx, "testing image t", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) == 0 || suggestions[0].Value != "testing image two" {
+		t.Fatalf("Expected the prefix match first, got %+v", suggestions)
+	}
+}
@@ -0,0 +1,121 @@
+package postgresql
+
+import (
+	"api/storage"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// hammingDistance counts the bits the perceptual hash of images differs in from the hash of the first parameter
+const hammingDistance = `length(replace((perceptual_hash # $1::bigint)::bit(64)::text, '0', ''))`
+
+const (
+	// hashBands are the 16 bit quarters of the perceptual hash, stored in indexed columns
+	hashBands    = 4
+	hashBandBits = 16
+	// maxProbedBandDistance bounds the bits the probed values of a band differ in, beyond it the probes would be
+	// too many and every hash is compared
+	maxProbedBandDistance = 2
+)
+
+// hashValue converts the hex perceptual hash to the bits of the bigint column, images without one keep it null
+func hashValue(hash string) (*int64, error) {
+	if hash == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseUint(hash, 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid perceptual hash %s: %w", hash, err)
+	}
+	bits := int64(value)
+
+	return &bits, nil
+}
+
+// hashColumn scans the bigint perceptual hash into the hex hash it points to
+type hashColumn struct {
+	hash *string
+}
+
+func (column hashColumn) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*column.hash = ""
+	case int64:
+		*column.hash = fmt.Sprintf("%016x", uint64(value))
+	default:
+		return fmt.Errorf("can't scan perceptual hash from %T", src)
+	}
+
+	return nil
+}
+
+// probeBands adds the condition that a band of the hashes is within the distance of the same band of the hash to
+// the query. Hashes within a hamming distance of d differ in at most d/4 bits in one of their bands, so the
+// condition keeps every near duplicate and is looked up in the band indexes.
+func probeBands(hash uint64, bandDistance int, args []interface{}) (string, []interface{}) {
+	conditions := make([]string, hashBands)
+	for i := range conditions {
+		band := uint16(hash >> (hashBandBits * (hashBands - 1 - i)))
+		args = append(args, bandProbes(band, 0, bandDistance, nil))
+		conditions[i] = fmt.Sprintf("perceptual_hash_band%d = ANY($%d)", i, len(args))
+	}
+
+	return " AND (" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// bandProbes appends the values of the band that differ from it in at most distance of its bits from the first one
+func bandProbes(band uint16, firstBit int, distance int, probes []int32) []int32 {
+	probes = append(probes, int32(band))
+	if distance == 0 {
+		return probes
+	}
+	for bit := firstBit; bit < hashBandBits; bit++ {
+		probes = bandProbes(band^1<<bit, bit+1, distance-1, probes)
+	}
+
+	return probes
+}
+
+func (repo *ImageRepo) GetSimilar(
+	ctx context.Context, hash string, excludedId string, maxDistance int, limit int,
+) (storage.SimilarImageList, error) {
+	bits, err := hashValue(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{bits, excludedId, maxDistance, limit}
+	bandsCondition := ""
+	if bits != nil && maxDistance/hashBands <= maxProbedBandDistance {
+		bandsCondition, args = probeBands(uint64(*bits), maxDistance/hashBands, args)
+	}
+
+	query := `SELECT ` + imageColumns + `, ` + hammingDistance + ` AS distance
+FROM images
+WHERE perceptual_hash IS NOT NULL AND deleted_at IS NULL AND ($2 = '' OR id <> NULLIF($2, '')::uuid)
+` + bandsCondition + ` AND ` + hammingDistance + ` <= $3
+ORDER BY distance ASC, created_at DESC
+LIMIT $4
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying similar images: %w", err)
+	}
+	defer rows.Close()
+
+	similar := storage.SimilarImageList{}
+	for rows.Next() {
+		var distance int
+		image, scanErr := scanImage(rows, &distance)
+		if scanErr != nil {
+			return nil, fmt.Errorf("failed scaning similar images: %w", scanErr)
+		}
+		similar = append(similar, storage.SimilarImage{Image: image, Distance: distance})
+	}
+
+	return similar, rows.Err()
+}
@@ -0,0 +1,59 @@
+package postgresql
+
+import (
+	"api/storage"
+	"encoding/json"
+	"fmt"
+)
+
+// sizesDocument is how storage.ImageSizes are kept in the sizes jsonb with the variant formats of the image, variants
+// are nested under their profile names so a profile can't collide with the original or the formats
+type sizesDocument struct {
+	Original storage.Dimensions            `json:"original"`
+	Variants map[string]storage.Dimensions `json:"variants"`
+	Formats  storage.ImageVariantFormats   `json:"formats,omitempty"`
+}
+
+func marshalSizes(sizes storage.ImageSizes, formats storage.ImageVariantFormats) (string, error) {
+	document := sizesDocument{Original: sizes.Original, Variants: sizes.Variants, Formats: formats}
+	if document.Variants == nil {
+		document.Variants = map[string]storage.Dimensions{}
+	}
+
+	data, err := json.Marshal(document)
+	return string(data), err
+}
+
+// sizesColumn scans the sizes jsonb into the sizes and the variant formats it points to
+type sizesColumn struct {
+	sizes   *storage.ImageSizes
+	formats *storage.ImageVariantFormats
+}
+
+func (column sizesColumn) Scan(src interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		*column.sizes = storage.ImageSizes{}
+		*column.formats = nil
+		return nil
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		return fmt.Errorf("can't scan sizes from %T", src)
+	}
+
+	var document sizesDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("failed scanning sizes: %w", err)
+	}
+	*column.sizes = storage.ImageSizes{
+		Original: document.Original,
+		Variants: document.Variants,
+	}
+	*column.formats = document.Formats
+
+	return nil
+}
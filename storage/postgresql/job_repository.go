@@ -0,0 +1,140 @@
+package postgresql
+
+import (
+	"api/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+type JobRepo struct {
+	database *Database
+}
+
+func NewJobRepository(db *Database) *JobRepo {
+	return &JobRepo{database: db}
+}
+
+const jobColumns = "id, author_id, status, payload, image_id, error, attempts, locked_until, created_at, updated_at"
+
+func scanJob(row pgx.Row) (storage.Job, error) {
+	var job storage.Job
+	err := row.Scan(
+		&job.Id,
+		&job.AuthorId,
+		&job.Status,
+		&job.Payload,
+		&job.ImageId,
+		&job.Error,
+		&job.Attempts,
+		&job.LockedUntil,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	return job, err
+}
+
+func (repo *JobRepo) Create(ctx context.Context, job storage.Job) (storage.Job, error) {
+	query := `INSERT INTO jobs ("author_id", "payload")
+VALUES ($1, $2)
+RETURNING ` + jobColumns
+
+	data, err := json.Marshal(job.Payload)
+	if err != nil {
+		return storage.Job{}, err
+	}
+
+	return scanJob(repo.database.dbPool.QueryRow(ctx, query, job.AuthorId, string(data)))
+}
+
+func (repo *JobRepo) GetOne(ctx context.Context, jobId string) (storage.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+
+	job, err := scanJob(repo.database.dbPool.QueryRow(ctx, query, jobId))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.Job{}, storage.NotFound{Msg: "Job not found by id " + jobId}
+	}
+
+	return job, err
+}
+
+func (repo *JobRepo) ClaimNext(ctx context.Context, lockedUntil time.Time) (storage.Job, bool, error) {
+	query := `UPDATE jobs
+SET status = 'running', attempts = attempts + 1, locked_until = $1, updated_at = now()
+WHERE id = (
+    SELECT id
+    FROM jobs
+    WHERE status = 'queued' OR (status IN ('running', 'waiting') AND locked_until < now())
+    ORDER BY created_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + jobColumns
+
+	job, err := scanJob(repo.database.dbPool.QueryRow(ctx, query, lockedUntil.UTC()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.Job{}, false, nil
+	}
+	if err != nil {
+		return storage.Job{}, false, err
+	}
+
+	return job, true, nil
+}
+
+func (repo *JobRepo) Wait(ctx context.Context, jobId string, lockedUntil time.Time) error {
+	query := `UPDATE jobs SET status = 'waiting', locked_until = $2, updated_at = now()
+WHERE id = $1 AND status = 'running'`
+
+	return repo.exec(ctx, query, jobId, lockedUntil.UTC())
+}
+
+func (repo *JobRepo) Resume(ctx context.Context, jobId string, lockedUntil time.Time) (bool, error) {
+	query := `UPDATE jobs SET status = 'running', locked_until = $2, updated_at = now()
+WHERE id = $1 AND status = 'waiting'`
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, jobId, lockedUntil.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+func (repo *JobRepo) Succeed(ctx context.Context, jobId string, imageId string) error {
+	query := `UPDATE jobs SET status = 'succeeded', image_id = $2, locked_until = NULL, updated_at = now()
+WHERE id = $1 AND status IN ('running', 'waiting')`
+
+	return repo.exec(ctx, query, jobId, imageId)
+}
+
+func (repo *JobRepo) Fail(ctx context.Context, jobId string, reason string) error {
+	query := `UPDATE jobs SET status = 'failed', error = $2, locked_until = NULL, updated_at = now()
+WHERE id = $1 AND status IN ('queued', 'running', 'waiting')`
+
+	return repo.exec(ctx, query, jobId, reason)
+}
+
+// exec runs the update of an unfinished job, NotFound is returned when there is none
+func (repo *JobRepo) exec(ctx context.Context, query string, jobId string, args ...interface{}) error {
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, append([]interface{}{jobId}, args...)...)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Unfinished job not found by id " + jobId}
+	}
+
+	return nil
+}
+
+func (repo *JobRepo) DeleteAll(ctx context.Context) (rowsAffected int64, err error) {
+	cmdTag, err := repo.database.dbPool.Exec(ctx, "DELETE FROM jobs")
+	if err != nil {
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
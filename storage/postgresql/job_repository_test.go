@@ -0,0 +1,74 @@
+package postgresql
+
+import (
+	"api/storage"
+	"api/test"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJobRepo(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	insertUserDummyData(t, userRepo)
+	user, err := userRepo.GetByUsername(ctx, "what-ever-username123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewJobRepository(userRepo.db)
+	defer func() {
+		if _, err := repo.DeleteAll(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	job, err := repo.Create(ctx, storage.Job{
+		AuthorId: user.Id,
+		Payload:  storage.JobPayload{Name: "my plane", Format: "png", OriginalFile: "o.png", CroppedFile: "c.png"},
+	})
+	if err != nil {
+		t.Fatal("[Create]: ", err)
+	}
+	if job.Status != storage.JobQueued || job.Payload.CroppedFile != "c.png" {
+		t.Fatalf("unexpected created job %+v", job)
+	}
+
+	claimed, isClaimed, err := repo.ClaimNext(ctx, time.Now().Add(time.Hour))
+	if err != nil || !isClaimed || claimed.Id != job.Id || claimed.Status != storage.JobRunning || claimed.Attempts != 1 {
+		t.Fatalf("expected the job to be claimed, got %+v %v", claimed, err)
+	}
+	if _, isClaimed, err = repo.ClaimNext(ctx, time.Now().Add(time.Hour)); err != nil || isClaimed {
+		t.Fatalf("expected a leased job not to be claimed again, got %v", err)
+	}
+
+	if err = repo.Wait(ctx, job.Id, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal("[Wait]: ", err)
+	}
+	claimed, isClaimed, err = repo.ClaimNext(ctx, time.Now().Add(time.Hour))
+	if err != nil || !isClaimed || claimed.Attempts != 2 {
+		t.Fatalf("expected a job with an expired lease to be claimed again, got %+v %v", claimed, err)
+	}
+	if isResumed, err := repo.Resume(ctx, job.Id, time.Now().Add(time.Hour)); err != nil || isResumed {
+		t.Fatalf("expected a running job not to resume, got %v", err)
+	}
+
+	if err = repo.Fail(ctx, job.Id, "failed resizing"); err != nil {
+		t.Fatal("[Fail]: ", err)
+	}
+	if err = repo.Succeed(ctx, job.Id, job.Id); !errors.As(err, &storage.NotFound{}) {
+		t.Fatalf("expected a finished job not to change, got %v", err)
+	}
+	found, err := repo.GetOne(ctx, job.Id)
+	if err != nil || found.Status != storage.JobFailed || found.Error == nil || *found.Error != "failed resizing" {
+		t.Fatalf("unexpected failed job %+v %v", found, err)
+	}
+}
@@ -0,0 +1,119 @@
+package postgresql
+
+import (
+	"api/storage"
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+type PendingOperationRepo struct {
+	database *Database
+}
+
+func NewPendingOperationRepository(db *Database) *PendingOperationRepo {
+	return &PendingOperationRepo{database: db}
+}
+
+const pendingOperationColumns = "id, kind, image_id, payload, created_at, updated_at"
+
+func scanPendingOperation(row pgx.Row) (storage.PendingOperation, error) {
+	var operation storage.PendingOperation
+	err := row.Scan(
+		&operation.Id,
+		&operation.Kind,
+		&operation.ImageId,
+		&operation.Payload,
+		&operation.CreatedAt,
+		&operation.UpdatedAt,
+	)
+	return operation, err
+}
+
+func (repo *PendingOperationRepo) GetStartedBefore(
+	ctx context.Context, before time.Time,
+) (storage.PendingOperationList, error) {
+	query := `SELECT ` + pendingOperationColumns + `
+FROM pending_operations
+WHERE created_at < $1
+ORDER BY created_at ASC
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	operations := storage.PendingOperationList{}
+	for rows.Next() {
+		operation, scanErr := scanPendingOperation(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		operations = append(operations, operation)
+	}
+
+	return operations, rows.Err()
+}
+
+func (repo *PendingOperationRepo) Create(
+	ctx context.Context, operation storage.PendingOperation,
+) (storage.PendingOperation, error) {
+	query := `INSERT INTO pending_operations ("kind", "image_id", "payload")
+VALUES ($1, $2, $3)
+RETURNING ` + pendingOperationColumns
+
+	data, err := json.Marshal(operation.Payload)
+	if err != nil {
+		return storage.PendingOperation{}, err
+	}
+
+	return scanPendingOperation(repo.database.dbPool.QueryRow(
+		ctx, query, operation.Kind, operation.ImageId, string(data),
+	))
+}
+
+func (repo *PendingOperationRepo) UpdatePayload(
+	ctx context.Context, operationId string, payload storage.PendingOperationPayload,
+) error {
+	query := "UPDATE pending_operations SET payload = $2, updated_at = now() WHERE id = $1"
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, operationId, string(data))
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Pending operation not found by id " + operationId}
+	}
+
+	return nil
+}
+
+func (repo *PendingOperationRepo) DeleteOne(ctx context.Context, operationId string) error {
+	query := "DELETE FROM pending_operations WHERE id = $1"
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, operationId)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Pending operation not found by id " + operationId}
+	}
+
+	return nil
+}
+
+func (repo *PendingOperationRepo) DeleteAll(ctx context.Context) (rowsAffected int64, err error) {
+	cmdTag, err := repo.database.dbPool.Exec(ctx, "DELETE FROM pending_operations")
+	if err != nil {
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
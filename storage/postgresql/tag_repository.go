@@ -0,0 +1,183 @@
+package postgresql
+
+import (
+	"api/storage"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
+type TagRepo struct {
+	database *Database
+}
+
+func NewTagRepository(db *Database) *TagRepo {
+	return &TagRepo{database: db}
+}
+
+const tagColumns = "id, value, parent_id, canonical_id, created_at, updated_at, author_id"
+
+func scanTag(row pgx.Row) (storage.Tag, error) {
+	var tag storage.Tag
+	err := row.Scan(
+		&tag.Id,
+		&tag.Value,
+		&tag.ParentId,
+		&tag.CanonicalId,
+		&tag.CreatedAt,
+		&tag.UpdatedAt,
+		&tag.AuthorId,
+	)
+	return tag, err
+}
+
+func (repo *TagRepo) Get(ctx context.Context, limit, offset int) (storage.TagList, error) {
+	query := `SELECT ` + tagColumns + `
+FROM tags
+ORDER BY value ASC
+LIMIT $1
+OFFSET $2
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := storage.TagList{}
+	for rows.Next() {
+		tag, scanErr := scanTag(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (repo *TagRepo) GetOne(ctx context.Context, tagId string) (storage.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE id = $1 LIMIT 1`
+
+	tag, err := scanTag(repo.database.dbPool.QueryRow(ctx, query, tagId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.Tag{}, storage.NotFound{Msg: "Tag not found by id " + tagId}
+		}
+		return storage.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+func (repo *TagRepo) GetOneByValue(ctx context.Context, value string) (storage.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE value = $1 LIMIT 1`
+
+	tag, err := scanTag(repo.database.dbPool.QueryRow(ctx, query, value))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.Tag{}, storage.NotFound{Msg: "Tag not found by value " + value}
+		}
+		return storage.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+func (repo *TagRepo) Create(ctx context.Context, tag storage.Tag) (storage.Tag, error) {
+	query := `INSERT INTO tags ("value", "parent_id", "canonical_id", "author_id")
+VALUES ($1, $2, $3, $4)
+RETURNING ` + tagColumns
+
+	created, err := scanTag(repo.database.dbPool.QueryRow(
+		ctx, query, tag.Value, tag.ParentId, tag.CanonicalId, tag.AuthorId,
+	))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return storage.Tag{}, storage.ErrDuplicate
+		}
+		return storage.Tag{}, err
+	}
+
+	return created, nil
+}
+
+func (repo *TagRepo) UpdateOne(ctx context.Context, tag storage.Tag) (storage.Tag, error) {
+	query := `UPDATE tags
+SET value = $2, parent_id = $3, canonical_id = $4, updated_at = now()
+WHERE id = $1
+RETURNING ` + tagColumns
+
+	updated, err := scanTag(repo.database.dbPool.QueryRow(
+		ctx, query, tag.Id, tag.Value, tag.ParentId, tag.CanonicalId,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.Tag{}, storage.NotFound{Msg: "Tag not found by id " + tag.Id}
+		}
+		if strings.Contains(err.Error(), "duplicate") {
+			return storage.Tag{}, storage.ErrDuplicate
+		}
+		return storage.Tag{}, err
+	}
+
+	return updated, nil
+}
+
+func (repo *TagRepo) DeleteOne(ctx context.Context, tagId string) error {
+	query := "DELETE FROM tags WHERE id = $1"
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, tagId)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Tag not found by id " + tagId}
+	}
+
+	return nil
+}
+
+func (repo *TagRepo) HasSynonyms(ctx context.Context, tagId string) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM tags WHERE canonical_id = $1)"
+
+	var hasSynonyms bool
+	err := repo.database.dbPool.QueryRow(ctx, query, tagId).Scan(&hasSynonyms)
+
+	return hasSynonyms, err
+}
+
+func (repo *TagRepo) AttachToImage(ctx context.Context, imageId, tagId string) error {
+	query := `INSERT INTO images_tags ("image_id", "tag_id")
+VALUES ($1, $2)
+ON CONFLICT (image_id, tag_id) DO NOTHING`
+
+	_, err := repo.database.dbPool.Exec(ctx, query, imageId, tagId)
+	return err
+}
+
+func (repo *TagRepo) DetachFromImage(ctx context.Context, imageId, tagId string) error {
+	query := "DELETE FROM images_tags WHERE image_id = $1 AND tag_id = $2"
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, imageId, tagId)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Tag " + tagId + " is not attached to image " + imageId}
+	}
+
+	return nil
+}
+
+func (repo *TagRepo) DeleteAll(ctx context.Context) (rowsAffected int64, err error) {
+	query := "DELETE FROM tags"
+	cmdTag, err := repo.database.dbPool.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected = cmdTag.RowsAffected()
+	return
+}
@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"api/storage"
+	"api/test"
+	"context"
+	"errors"
+	"testing"
+)
+
+func setupTagRepo(ctx context.Context) (*TagRepo, error) {
+	db, err := setupDb(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTagRepository(db), nil
+}
+
+func cleanTagRepo(t *testing.T, repo *TagRepo) {
+	defer repo.database.Close()
+
+	_, err := repo.DeleteAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTagRepo_Create(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupTagRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanTagRepo(t, repo)
+
+	plane, err := repo.Create(ctx, storage.Tag{Value: "plane"})
+	if err != nil {
+		t.Fatalf("failed creating tag: %v", err)
+	}
+	aircraft, err := repo.Create(ctx, storage.Tag{Value: "aircraft", CanonicalId: &plane.Id})
+	if err != nil {
+		t.Fatalf("failed creating synonym tag: %v", err)
+	}
+
+	if aircraft.CanonicalId == nil || *aircraft.CanonicalId != plane.Id {
+		t.Fatal("failed asserting CanonicalId")
+	}
+	if !aircraft.IsSynonym() {
+		t.Fatal("expected tag to be a synonym")
+	}
+
+	_, err = repo.Create(ctx, storage.Tag{Value: "plane"})
+	if !errors.Is(err, storage.ErrDuplicate) {
+		t.Fatalf("expected error duplicate, got %v", err)
+	}
+}
+
+func TestTagRepo_GetOneByValue(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupTagRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanTagRepo(t, repo)
+
+	created, err := repo.Create(ctx, storage.Tag{Value: "world war 2"})
+	if err != nil {
+		t.Fatalf("failed creating tag: %v", err)
+	}
+
+	tag, err := repo.GetOneByValue(ctx, "world war 2")
+	if err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+	if tag.Id != created.Id {
+		t.Fatal("failed asserting Id")
+	}
+
+	_, err = repo.GetOneByValue(ctx, "unknown")
+	if !errors.As(err, &storage.NotFound{}) {
+		t.Fatal("expected error of type not found")
+	}
+}
+
+func TestTagRepo_HasSynonyms(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	repo, err := setupTagRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanTagRepo(t, repo)
+
+	canonical, err := repo.Create(ctx, storage.Tag{Value: "plane"})
+	if err != nil {
+		t.Fatalf("failed creating tag: %v", err)
+	}
+	synonym, err := repo.Create(ctx, storage.Tag{Value: "aircraft", CanonicalId: &canonical.Id})
+	if err != nil {
+		t.Fatalf("failed creating tag: %v", err)
+	}
+
+	if hasSynonyms, err := repo.HasSynonyms(ctx, canonical.Id); err != nil || !hasSynonyms {
+		t.Fatalf("expected the canonical tag to have synonyms, got %v and error %v", hasSynonyms, err)
+	}
+	if hasSynonyms, err := repo.HasSynonyms(ctx, synonym.Id); err != nil || hasSynonyms {
+		t.Fatalf("expected the synonym to have no synonyms, got %v and error %v", hasSynonyms, err)
+	}
+}
@@ -0,0 +1,109 @@
+package postgresql
+
+import (
+	"api/storage"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+type UploadIntentRepo struct {
+	database *Database
+}
+
+func NewUploadIntentRepository(db *Database) *UploadIntentRepo {
+	return &UploadIntentRepo{database: db}
+}
+
+const uploadIntentColumns = "id, author_id, name, format, original_file, cropped_file, expires_at, created_at"
+
+func scanUploadIntent(row pgx.Row) (storage.UploadIntent, error) {
+	var intent storage.UploadIntent
+	err := row.Scan(
+		&intent.Id,
+		&intent.AuthorId,
+		&intent.Name,
+		&intent.Format,
+		&intent.OriginalFile,
+		&intent.CroppedFile,
+		&intent.ExpiresAt,
+		&intent.CreatedAt,
+	)
+	return intent, err
+}
+
+func (repo *UploadIntentRepo) Create(ctx context.Context, intent storage.UploadIntent) (storage.UploadIntent, error) {
+	query := `INSERT INTO upload_intents ("author_id", "name", "format", "original_file", "cropped_file", "expires_at")
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING ` + uploadIntentColumns
+
+	return scanUploadIntent(repo.database.dbPool.QueryRow(
+		ctx,
+		query,
+		intent.AuthorId,
+		intent.Name,
+		intent.Format,
+		intent.OriginalFile,
+		intent.CroppedFile,
+		intent.ExpiresAt.UTC(),
+	))
+}
+
+func (repo *UploadIntentRepo) GetOne(ctx context.Context, intentId string) (storage.UploadIntent, error) {
+	query := `SELECT ` + uploadIntentColumns + ` FROM upload_intents WHERE id = $1`
+
+	intent, err := scanUploadIntent(repo.database.dbPool.QueryRow(ctx, query, intentId))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.UploadIntent{}, storage.NotFound{Msg: "Upload intent not found by id " + intentId}
+	}
+
+	return intent, err
+}
+
+func (repo *UploadIntentRepo) GetExpired(ctx context.Context, before time.Time) (storage.UploadIntentList, error) {
+	query := `SELECT ` + uploadIntentColumns + `
+FROM upload_intents
+WHERE expires_at < $1
+ORDER BY expires_at ASC
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	intents := storage.UploadIntentList{}
+	for rows.Next() {
+		intent, scanErr := scanUploadIntent(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		intents = append(intents, intent)
+	}
+
+	return intents, rows.Err()
+}
+
+func (repo *UploadIntentRepo) DeleteOne(ctx context.Context, intentId string) error {
+	query := "DELETE FROM upload_intents WHERE id = $1"
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, intentId)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Upload intent not found by id " + intentId}
+	}
+
+	return nil
+}
+
+func (repo *UploadIntentRepo) DeleteAll(ctx context.Context) (rowsAffected int64, err error) {
+	cmdTag, err := repo.database.dbPool.Exec(ctx, "DELETE FROM upload_intents")
+	if err != nil {
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,64 @@
+package postgresql
+
+import (
+	"api/storage"
+	"api/test"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUploadIntentRepo(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	insertUserDummyData(t, userRepo)
+	user, err := userRepo.GetByUsername(ctx, "what-ever-username123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewUploadIntentRepository(userRepo.db)
+	defer func() {
+		if _, err := repo.DeleteAll(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	expiresAt := time.Now().Add(time.Hour)
+	intent, err := repo.Create(ctx, storage.UploadIntent{
+		AuthorId:     user.Id,
+		Name:         "my plane",
+		Format:       "png",
+		OriginalFile: "uploads/original.png",
+		CroppedFile:  "uploads/cropped.png",
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		t.Fatal("[Create]: ", err)
+	}
+	found, err := repo.GetOne(ctx, intent.Id)
+	if err != nil || found.Name != "my plane" || found.CroppedFile != "uploads/cropped.png" {
+		t.Fatalf("unexpected intent %+v %v", found, err)
+	}
+
+	if expired, err := repo.GetExpired(ctx, time.Now()); err != nil || len(expired) != 0 {
+		t.Fatalf("expected no expired intents, got %d %v", len(expired), err)
+	}
+	if expired, err := repo.GetExpired(ctx, expiresAt.Add(time.Minute)); err != nil || len(expired) != 1 {
+		t.Fatalf("expected the intent to expire, got %d %v", len(expired), err)
+	}
+
+	if err = repo.DeleteOne(ctx, intent.Id); err != nil {
+		t.Fatal("[DeleteOne]: ", err)
+	}
+	if err = repo.DeleteOne(ctx, intent.Id); !errors.As(err, &storage.NotFound{}) {
+		t.Fatalf("expected a deleted intent not to be deleted again, got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package postgresql
+
+import (
+	"api/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"strings"
+	"time"
+)
+
+type UploadRepo struct {
+	database *Database
+}
+
+func NewUploadRepository(db *Database) *UploadRepo {
+	return &UploadRepo{database: db}
+}
+
+const uploadColumns = `id, author_id, original_id, length, upload_offset, metadata, chunks, image_id, expires_at,
+created_at, updated_at`
+
+func scanUpload(row pgx.Row) (storage.Upload, error) {
+	var upload storage.Upload
+	err := row.Scan(
+		&upload.Id,
+		&upload.AuthorId,
+		&upload.OriginalId,
+		&upload.Length,
+		&upload.Offset,
+		&upload.Metadata,
+		&upload.Chunks,
+		&upload.ImageId,
+		&upload.ExpiresAt,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+	)
+	return upload, err
+}
+
+func (repo *UploadRepo) Create(ctx context.Context, upload storage.Upload) (storage.Upload, error) {
+	query := `INSERT INTO uploads ("author_id", "original_id", "length", "metadata", "expires_at")
+VALUES ($1, $2, $3, $4, $5)
+RETURNING ` + uploadColumns
+
+	data, err := json.Marshal(upload.Metadata)
+	if err != nil {
+		return storage.Upload{}, err
+	}
+
+	created, err := scanUpload(repo.database.dbPool.QueryRow(
+		ctx, query, upload.AuthorId, upload.OriginalId, upload.Length, string(data), upload.ExpiresAt.UTC(),
+	))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return storage.Upload{}, storage.ErrDuplicate
+		}
+		return storage.Upload{}, err
+	}
+
+	return created, nil
+}
+
+func (repo *UploadRepo) GetOne(ctx context.Context, uploadId string) (storage.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM uploads WHERE id = $1`
+
+	upload, err := scanUpload(repo.database.dbPool.QueryRow(ctx, query, uploadId))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.Upload{}, storage.NotFound{Msg: "Upload not found by id " + uploadId}
+	}
+
+	return upload, err
+}
+
+func (repo *UploadRepo) GetCropped(ctx context.Context, originalId string) (storage.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM uploads WHERE original_id = $1`
+
+	upload, err := scanUpload(repo.database.dbPool.QueryRow(ctx, query, originalId))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.Upload{}, storage.NotFound{Msg: "Cropped upload not found by original id " + originalId}
+	}
+
+	return upload, err
+}
+
+func (repo *UploadRepo) Advance(
+	ctx context.Context, uploadId string, offset int64, chunk string, size int64, expiresAt time.Time,
+) (bool, error) {
+	query := `UPDATE uploads
+SET upload_offset = upload_offset + $4, chunks = array_append(chunks, $3), expires_at = $5, updated_at = now()
+WHERE id = $1 AND upload_offset = $2`
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, uploadId, offset, chunk, size, expiresAt.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+func (repo *UploadRepo) Claim(ctx context.Context, originalId string) (bool, error) {
+	query := `UPDATE uploads SET finishing = true, updated_at = now()
+WHERE id = $1 AND NOT finishing AND image_id IS NULL`
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, originalId)
+	if err != nil {
+		return false, err
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+func (repo *UploadRepo) Release(ctx context.Context, originalId string) error {
+	query := "UPDATE uploads SET finishing = false, updated_at = now() WHERE id = $1"
+
+	_, err := repo.database.dbPool.Exec(ctx, query, originalId)
+
+	return err
+}
+
+func (repo *UploadRepo) SetImage(ctx context.Context, originalId string, imageId string) error {
+	query := `UPDATE uploads SET image_id = $2, finishing = false, updated_at = now()
+WHERE id = $1 OR original_id = $1`
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, originalId, imageId)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Upload not found by id " + originalId}
+	}
+
+	return nil
+}
+
+func (repo *UploadRepo) GetExpired(ctx context.Context, before time.Time) (storage.UploadList, error) {
+	query := `SELECT ` + uploadColumns + `
+FROM uploads
+WHERE expires_at < $1
+ORDER BY expires_at ASC
+`
+	rows, err := repo.database.dbPool.Query(ctx, query, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uploads := storage.UploadList{}
+	for rows.Next() {
+		upload, scanErr := scanUpload(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, rows.Err()
+}
+
+func (repo *UploadRepo) DeleteOne(ctx context.Context, uploadId string) error {
+	query := "DELETE FROM uploads WHERE id = $1"
+
+	commandTag, err := repo.database.dbPool.Exec(ctx, query, uploadId)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return storage.NotFound{Msg: "Upload not found by id " + uploadId}
+	}
+
+	return nil
+}
+
+func (repo *UploadRepo) DeleteAll(ctx context.Context) (rowsAffected int64, err error) {
+	cmdTag, err := repo.database.dbPool.Exec(ctx, "DELETE FROM uploads")
+	if err != nil {
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,95 @@
+package postgresql
+
+import (
+	"api/storage"
+	"api/test"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUploadRepo(t *testing.T) {
+	test.SkipIfNotIntegrationTesting(t)
+	ctx := context.Background()
+
+	userRepo, err := setupUserRepo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUserRepo(userRepo)
+	insertUserDummyData(t, userRepo)
+	user, err := userRepo.GetByUsername(ctx, "what-ever-username123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewUploadRepository(userRepo.db)
+	defer func() {
+		if _, err := repo.DeleteAll(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	expiresAt := time.Now().Add(time.Hour)
+	original, err := repo.Create(ctx, storage.Upload{
+		AuthorId:  user.Id,
+		Length:    10,
+		Metadata:  map[string]string{"name": "my plane", "format": "png"},
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatal("[Create]: ", err)
+	}
+	if original.Id == "" || original.Offset != 0 || original.Metadata["name"] != "my plane" {
+		t.Fatalf("unexpected created upload %+v", original)
+	}
+
+	cropped := storage.Upload{AuthorId: user.Id, OriginalId: &original.Id, Length: 5, ExpiresAt: expiresAt}
+	if cropped, err = repo.Create(ctx, cropped); err != nil {
+		t.Fatal("[Create]: ", err)
+	}
+	if _, err = repo.Create(ctx, cropped); !errors.Is(err, storage.ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate for a second cropped upload, got %v", err)
+	}
+	if found, err := repo.GetCropped(ctx, original.Id); err != nil || found.Id != cropped.Id {
+		t.Fatalf("expected the cropped upload, got %+v %v", found, err)
+	}
+
+	isAdvanced, err := repo.Advance(ctx, original.Id, 0, "tus/chunk-0", 4, expiresAt)
+	if err != nil || !isAdvanced {
+		t.Fatalf("expected the upload to advance, got %v", err)
+	}
+	if isAdvanced, err = repo.Advance(ctx, original.Id, 0, "tus/chunk-1", 4, expiresAt); err != nil || isAdvanced {
+		t.Fatalf("expected an outdated offset not to advance, got %v", err)
+	}
+	found, err := repo.GetOne(ctx, original.Id)
+	if err != nil || found.Offset != 4 || len(found.Chunks) != 1 || found.Chunks[0] != "tus/chunk-0" {
+		t.Fatalf("unexpected advanced upload %+v %v", found, err)
+	}
+
+	if isClaimed, err := repo.Claim(ctx, original.Id); err != nil || !isClaimed {
+		t.Fatalf("expected the pair to be claimed, got %v", err)
+	}
+	if isClaimed, err := repo.Claim(ctx, original.Id); err != nil || isClaimed {
+		t.Fatalf("expected a claimed pair not to be claimed again, got %v", err)
+	}
+	if err = repo.Release(ctx, original.Id); err != nil {
+		t.Fatal("[Release]: ", err)
+	}
+
+	expired, err := repo.GetExpired(ctx, expiresAt.Add(time.Minute))
+	if err != nil || len(expired) != 2 {
+		t.Fatalf("expected both uploads to expire, got %d %v", len(expired), err)
+	}
+
+	if err = repo.DeleteOne(ctx, original.Id); err != nil {
+		t.Fatal("[DeleteOne]: ", err)
+	}
+	if _, err = repo.GetOne(ctx, original.Id); !errors.As(err, &storage.NotFound{}) {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	if found, err = repo.GetOne(ctx, cropped.Id); err != nil || found.OriginalId != nil {
+		t.Fatalf("expected the cropped upload to be unpaired, got %+v %v", found, err)
+	}
+}
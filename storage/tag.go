@@ -0,0 +1,21 @@
+package storage
+
+import "time"
+
+// Tag represents a label that can be attached to images. Tags can form a hierarchy through ParentId, while
+// CanonicalId marks the tag as a synonym of another one, e.g. "aircraft" is a synonym of "plane".
+type Tag struct {
+	Id          string     `json:"id"`
+	Value       string     `json:"value"`
+	ParentId    *string    `json:"parentId"`
+	CanonicalId *string    `json:"canonicalId,omitempty"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+	AuthorId    *string    `json:"authorId,omitempty"`
+}
+
+func (tag Tag) IsSynonym() bool {
+	return tag.CanonicalId != nil
+}
+
+type TagList []Tag
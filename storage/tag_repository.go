@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+)
+
+type TagRepository interface {
+	Get(ctx context.Context, limit, offset int) (TagList, error)
+	GetOne(ctx context.Context, tagId string) (Tag, error)
+	GetOneByValue(ctx context.Context, value string) (Tag, error)
+	Create(ctx context.Context, tag Tag) (Tag, error)
+	UpdateOne(ctx context.Context, tag Tag) (Tag, error)
+	DeleteOne(ctx context.Context, tagId string) error
+	// HasSynonyms is true when other tags have the tag as their canonical tag
+	HasSynonyms(ctx context.Context, tagId string) (bool, error)
+	AttachToImage(ctx context.Context, imageId, tagId string) error
+	DetachFromImage(ctx context.Context, imageId, tagId string) error
+}
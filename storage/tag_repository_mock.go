@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+)
+
+type TagRepoMock struct {
+}
+
+func (repo TagRepoMock) Get(_ context.Context, _, _ int) (TagList, error) {
+	return TagList{}, nil
+}
+
+func (repo TagRepoMock) GetOne(_ context.Context, _ string) (Tag, error) {
+	return Tag{}, nil
+}
+
+func (repo TagRepoMock) GetOneByValue(_ context.Context, _ string) (Tag, error) {
+	return Tag{}, nil
+}
+
+func (repo TagRepoMock) Create(_ context.Context, tag Tag) (Tag, error) {
+	return tag, nil
+}
+
+func (repo TagRepoMock) UpdateOne(_ context.Context, tag Tag) (Tag, error) {
+	return tag, nil
+}
+
+func (repo TagRepoMock) DeleteOne(_ context.Context, _ string) error {
+	return nil
+}
+
+func (repo TagRepoMock) HasSynonyms(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func (repo TagRepoMock) AttachToImage(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (repo TagRepoMock) DetachFromImage(_ context.Context, _, _ string) error {
+	return nil
+}
@@ -0,0 +1,27 @@
+package storage
+
+import "time"
+
+// Upload is a resumable upload of one file of an image, its content is received in chunks until Offset reaches
+// Length. The upload of a cropped file refers to the upload of its original file by OriginalId.
+type Upload struct {
+	Id         string            `json:"id"`
+	AuthorId   string            `json:"authorId"`
+	OriginalId *string           `json:"originalId"`
+	Length     int64             `json:"length"`
+	Offset     int64             `json:"offset"`
+	Metadata   map[string]string `json:"metadata"`
+	// Chunks are the object keys of the received content in order
+	Chunks []string `json:"chunks"`
+	// ImageId is the image created once both files of the pair were received
+	ImageId   *string    `json:"imageId"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+func (upload Upload) IsComplete() bool {
+	return upload.Offset == upload.Length
+}
+
+type UploadList []Upload
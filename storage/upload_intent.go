@@ -0,0 +1,18 @@
+package storage
+
+import "time"
+
+// UploadIntent is a pair of signed urls handed out for a client to upload both files of an image directly to
+type UploadIntent struct {
+	Id       string `json:"id"`
+	AuthorId string `json:"authorId"`
+	Name     string `json:"name"`
+	Format   string `json:"format"`
+	// OriginalFile and CroppedFile are the remote paths the signed urls upload to
+	OriginalFile string    `json:"originalFile"`
+	CroppedFile  string    `json:"croppedFile"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type UploadIntentList []UploadIntent
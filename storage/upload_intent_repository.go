@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type UploadIntentRepository interface {
+	Create(ctx context.Context, intent UploadIntent) (UploadIntent, error)
+	GetOne(ctx context.Context, intentId string) (UploadIntent, error)
+	GetExpired(ctx context.Context, before time.Time) (UploadIntentList, error)
+	// DeleteOne returns NotFound when the intent was already deleted, so only one request gets to complete it
+	DeleteOne(ctx context.Context, intentId string) error
+}
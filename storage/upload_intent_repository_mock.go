@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type UploadIntentRepoMock struct {
+}
+
+func (repo UploadIntentRepoMock) Create(_ context.Context, intent UploadIntent) (UploadIntent, error) {
+	intent.Id = "9a1e3c5b-7d2f-4e6a-8b0c-1f3d5e7a9b2c"
+	return intent, nil
+}
+
+func (repo UploadIntentRepoMock) GetOne(_ context.Context, intentId string) (UploadIntent, error) {
+	return UploadIntent{}, NotFound{Msg: "Upload intent not found by id " + intentId}
+}
+
+func (repo UploadIntentRepoMock) GetExpired(_ context.Context, _ time.Time) (UploadIntentList, error) {
+	return UploadIntentList{}, nil
+}
+
+func (repo UploadIntentRepoMock) DeleteOne(_ context.Context, _ string) error {
+	return nil
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type UploadRepository interface {
+	// Create returns ErrDuplicate when the original upload is already paired with another cropped upload
+	Create(ctx context.Context, upload Upload) (Upload, error)
+	GetOne(ctx context.Context, uploadId string) (Upload, error)
+	// GetCropped returns the upload of the cropped file paired with the original upload
+	GetCropped(ctx context.Context, originalId string) (Upload, error)
+	// Advance appends the chunk of size bytes received at offset and extends the expiry of the upload, false is
+	// returned when the offset was moved by another request in the meantime
+	Advance(
+		ctx context.Context, uploadId string, offset int64, chunk string, size int64, expiresAt time.Time,
+	) (bool, error)
+	// Claim marks the pair of the original upload as being finished, false is returned when it already is or was
+	Claim(ctx context.Context, originalId string) (bool, error)
+	// Release unmarks a pair whose finishing failed, so that it can be retried
+	Release(ctx context.Context, originalId string) error
+	// SetImage saves the image created from the pair of the original upload
+	SetImage(ctx context.Context, originalId string, imageId string) error
+	GetExpired(ctx context.Context, before time.Time) (UploadList, error)
+	DeleteOne(ctx context.Context, uploadId string) error
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type UploadRepoMock struct {
+}
+
+func (repo UploadRepoMock) Create(_ context.Context, upload Upload) (Upload, error) {
+	upload.Id = "5d7f1c2a-4b8e-4f3a-9c6d-2e1b0a9f8c7d"
+	return upload, nil
+}
+
+func (repo UploadRepoMock) GetOne(_ context.Context, uploadId string) (Upload, error) {
+	return Upload{}, NotFound{Msg: "Upload not found by id " + uploadId}
+}
+
+func (repo UploadRepoMock) GetCropped(_ context.Context, originalId string) (Upload, error) {
+	return Upload{}, NotFound{Msg: "Cropped upload not found by original id " + originalId}
+}
+
+func (repo UploadRepoMock) Advance(
+	_ context.Context, _ string, _ int64, _ string, _ int64, _ time.Time,
+) (bool, error) {
+	return true, nil
+}
+
+func (repo UploadRepoMock) Claim(_ context.Context, _ string) (bool, error) {
+	return true, nil
+}
+
+func (repo UploadRepoMock) Release(_ context.Context, _ string) error {
+	return nil
+}
+
+func (repo UploadRepoMock) SetImage(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+func (repo UploadRepoMock) GetExpired(_ context.Context, _ time.Time) (UploadList, error) {
+	return UploadList{}, nil
+}
+
+func (repo UploadRepoMock) DeleteOne(_ context.Context, _ string) error {
+	return nil
+}
@@ -28,13 +28,20 @@ type FormFilesParams struct {
 	FileName  string
 }
 
-// CreateMultipartFormData creates multipart form data with multiple files and fields
+// CreateMultipartFormData creates multipart form data with the fields preceding the files, as uploads are streamed
 func CreateMultipartFormData(
 	formFileParams []FormFilesParams, params FormParams,
 ) (*bytes.Buffer, string, error) {
 	body := new(bytes.Buffer)
 	writer := multipart.NewWriter(body)
 
+	for name, value := range params {
+		err := writer.WriteField(name, value)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
 	for _, formFileParam := range formFileParams {
 		file, err := os.Open(formFileParam.FilePath)
 		if err != nil {
@@ -58,13 +65,6 @@ func CreateMultipartFormData(
 		}
 	}
 
-	for name, value := range params {
-		err := writer.WriteField(name, value)
-		if err != nil {
-			return nil, "", err
-		}
-	}
-
 	contentType := writer.FormDataContentType()
 
 	err := writer.Close()
@@ -8,7 +8,7 @@ import (
 	"api/auth/cognito"
 	"api/core"
 	"api/image"
-	"api/image/resize"
+	"api/image/local"
 	"api/storage"
 	"api/storage/postgresql"
 	"github.com/google/wire"
@@ -19,20 +19,52 @@ var DatabaseSet = wire.NewSet(
 	postgresql.NewDatabase,
 	postgresql.NewImageRepository,
 	postgresql.NewUserRepo,
+	postgresql.NewTagRepository,
+	postgresql.NewPendingOperationRepository,
+	postgresql.NewImageRevisionRepository,
+	postgresql.NewUploadRepository,
+	postgresql.NewUploadIntentRepository,
+	postgresql.NewJobRepository,
 	wire.Bind(new(storage.Storage), new(*postgresql.Database)),
 	wire.Bind(new(storage.ImagesRepository), new(*postgresql.ImageRepo)),
 	wire.Bind(new(storage.UserRepository), new(*postgresql.UserRepo)),
+	wire.Bind(new(storage.TagRepository), new(*postgresql.TagRepo)),
+	wire.Bind(new(storage.PendingOperationRepository), new(*postgresql.PendingOperationRepo)),
+	wire.Bind(new(storage.ImageRevisionRepository), new(*postgresql.ImageRevisionRepo)),
+	wire.Bind(new(storage.UploadRepository), new(*postgresql.UploadRepo)),
+	wire.Bind(new(storage.UploadIntentRepository), new(*postgresql.UploadIntentRepo)),
+	wire.Bind(new(storage.JobRepository), new(*postgresql.JobRepo)),
 )
 
 func InitializeApp(logger *zerolog.Logger) (*core.App, error) {
 	wire.Build(
 		core.NewConfigFromEnv,
 		DatabaseSet,
-		resize.NewClient,
-		wire.Bind(new(image.Resizer), new(*resize.Client)),
+		NewObjectSigner,
+		NewObjectStore,
+		NewResizer,
+		local.NewTransformer,
+		wire.Bind(new(image.Transformer), new(*local.Transformer)),
+		local.NewPlaceholderGenerator,
+		wire.Bind(new(image.PlaceholderGenerator), new(*local.PlaceholderGenerator)),
+		NewMetadataProcessor,
+		local.NewPerceptualHasher,
+		wire.Bind(new(image.PerceptualHasher), new(*local.PerceptualHasher)),
+		core.NewDuplicateDetector,
 		cognito.NewCognitoAuthService,
 		wire.Bind(new(auth.Authenticator), new(*cognito.AuthService)),
+		core.NewUrlBuilder,
 		core.NewImagesService,
+		core.NewTagsService,
+		core.NewUsersService,
+		core.NewTrashPurger,
+		core.NewUploadsService,
+		core.NewUploadPurger,
+		core.NewJobsService,
+		core.NewJobWorker,
+		core.NewTransformsService,
+		core.NewPlaceholderBackfill,
+		core.NewHashBackfill,
 		core.NewApp,
 	)
 
@@ -43,11 +75,31 @@ func InitializeAppForTesting(logger *zerolog.Logger) (*core.App, error) {
 	wire.Build(
 		core.NewConfigFromEnv,
 		DatabaseSet,
-		resize.NewClient,
-		wire.Bind(new(image.Resizer), new(*resize.Client)),
+		NewObjectSigner,
+		NewObjectStore,
+		NewResizer,
+		local.NewTransformer,
+		wire.Bind(new(image.Transformer), new(*local.Transformer)),
+		local.NewPlaceholderGenerator,
+		wire.Bind(new(image.PlaceholderGenerator), new(*local.PlaceholderGenerator)),
+		NewMetadataProcessor,
+		local.NewPerceptualHasher,
+		wire.Bind(new(image.PerceptualHasher), new(*local.PerceptualHasher)),
+		core.NewDuplicateDetector,
 		cognito.NewCognitoAuthService,
 		wire.Bind(new(auth.Authenticator), new(*cognito.AuthService)),
+		core.NewUrlBuilder,
 		core.NewImagesService,
+		core.NewTagsService,
+		core.NewUsersService,
+		core.NewTrashPurger,
+		core.NewUploadsService,
+		core.NewUploadPurger,
+		core.NewJobsService,
+		core.NewJobWorker,
+		core.NewTransformsService,
+		core.NewPlaceholderBackfill,
+		core.NewHashBackfill,
 		core.NewApp,
 	)
 
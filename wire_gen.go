@@ -9,7 +9,7 @@ package api
 import (
 	"api/auth/cognito"
 	"api/core"
-	"api/image/resize"
+	"api/image/local"
 	"api/storage"
 	"api/storage/postgresql"
 	"github.com/google/wire"
@@ -26,10 +26,40 @@ func InitializeApp(logger *zerolog.Logger) (*core.App, error) {
 	database := postgresql.NewDatabase(logger)
 	userRepo := postgresql.NewUserRepo(database)
 	authService := cognito.NewCognitoAuthService(config, userRepo, logger)
-	client := resize.NewClient(config, logger)
+	signer, err := NewObjectSigner(config, logger)
+	if err != nil {
+		return nil, err
+	}
+	objectStore, err := NewObjectStore(config, signer)
+	if err != nil {
+		return nil, err
+	}
+	resizer := NewResizer(config, objectStore, logger)
 	imageRepo := postgresql.NewImageRepository(database)
-	imagesService := core.NewImagesService(client, imageRepo, authService, logger)
-	app := core.NewApp(config, database, authService, imagesService)
+	imageRevisionRepo := postgresql.NewImageRevisionRepository(database)
+	pendingOperationRepo := postgresql.NewPendingOperationRepository(database)
+	placeholderGenerator := local.NewPlaceholderGenerator()
+	metadataProcessor := NewMetadataProcessor(config)
+	perceptualHasher := local.NewPerceptualHasher()
+	duplicateDetector := core.NewDuplicateDetector(config, perceptualHasher, imageRepo, logger)
+	urlBuilder := core.NewUrlBuilder(config)
+	imagesService := core.NewImagesService(resizer, imageRepo, imageRevisionRepo, pendingOperationRepo, placeholderGenerator, metadataProcessor, duplicateDetector, authService, urlBuilder, logger)
+	tagRepo := postgresql.NewTagRepository(database)
+	tagsService := core.NewTagsService(tagRepo, imageRepo, authService, urlBuilder, logger)
+	usersService := core.NewUsersService(authService)
+	trashPurger := core.NewTrashPurger(config, imagesService, logger)
+	uploadRepo := postgresql.NewUploadRepository(database)
+	uploadIntentRepo := postgresql.NewUploadIntentRepository(database)
+	uploadsService := core.NewUploadsService(config, uploadRepo, uploadIntentRepo, objectStore, imagesService, authService, logger)
+	uploadPurger := core.NewUploadPurger(config, uploadsService, logger)
+	jobRepo := postgresql.NewJobRepository(database)
+	jobsService := core.NewJobsService(config, jobRepo, imagesService, authService, logger)
+	jobWorker := core.NewJobWorker(config, jobsService, logger)
+	transformer := local.NewTransformer()
+	transformsService := core.NewTransformsService(config, imagesService, objectStore, transformer, logger)
+	placeholderBackfill := core.NewPlaceholderBackfill(config, imageRepo, objectStore, placeholderGenerator, logger)
+	hashBackfill := core.NewHashBackfill(config, imageRepo, objectStore, perceptualHasher, logger)
+	app := core.NewApp(config, database, authService, imagesService, tagsService, usersService, trashPurger, uploadsService, uploadPurger, jobsService, jobWorker, transformsService, placeholderBackfill, hashBackfill, objectStore, signer)
 	return app, nil
 }
 
@@ -41,13 +71,43 @@ func InitializeAppForTesting(logger *zerolog.Logger) (*core.App, error) {
 	database := postgresql.NewDatabase(logger)
 	userRepo := postgresql.NewUserRepo(database)
 	authService := cognito.NewCognitoAuthService(config, userRepo, logger)
-	client := resize.NewClient(config, logger)
+	signer, err := NewObjectSigner(config, logger)
+	if err != nil {
+		return nil, err
+	}
+	objectStore, err := NewObjectStore(config, signer)
+	if err != nil {
+		return nil, err
+	}
+	resizer := NewResizer(config, objectStore, logger)
 	imageRepo := postgresql.NewImageRepository(database)
-	imagesService := core.NewImagesService(client, imageRepo, authService, logger)
-	app := core.NewApp(config, database, authService, imagesService)
+	imageRevisionRepo := postgresql.NewImageRevisionRepository(database)
+	pendingOperationRepo := postgresql.NewPendingOperationRepository(database)
+	placeholderGenerator := local.NewPlaceholderGenerator()
+	metadataProcessor := NewMetadataProcessor(config)
+	perceptualHasher := local.NewPerceptualHasher()
+	duplicateDetector := core.NewDuplicateDetector(config, perceptualHasher, imageRepo, logger)
+	urlBuilder := core.NewUrlBuilder(config)
+	imagesService := core.NewImagesService(resizer, imageRepo, imageRevisionRepo, pendingOperationRepo, placeholderGenerator, metadataProcessor, duplicateDetector, authService, urlBuilder, logger)
+	tagRepo := postgresql.NewTagRepository(database)
+	tagsService := core.NewTagsService(tagRepo, imageRepo, authService, urlBuilder, logger)
+	usersService := core.NewUsersService(authService)
+	trashPurger := core.NewTrashPurger(config, imagesService, logger)
+	uploadRepo := postgresql.NewUploadRepository(database)
+	uploadIntentRepo := postgresql.NewUploadIntentRepository(database)
+	uploadsService := core.NewUploadsService(config, uploadRepo, uploadIntentRepo, objectStore, imagesService, authService, logger)
+	uploadPurger := core.NewUploadPurger(config, uploadsService, logger)
+	jobRepo := postgresql.NewJobRepository(database)
+	jobsService := core.NewJobsService(config, jobRepo, imagesService, authService, logger)
+	jobWorker := core.NewJobWorker(config, jobsService, logger)
+	transformer := local.NewTransformer()
+	transformsService := core.NewTransformsService(config, imagesService, objectStore, transformer, logger)
+	placeholderBackfill := core.NewPlaceholderBackfill(config, imageRepo, objectStore, placeholderGenerator, logger)
+	hashBackfill := core.NewHashBackfill(config, imageRepo, objectStore, perceptualHasher, logger)
+	app := core.NewApp(config, database, authService, imagesService, tagsService, usersService, trashPurger, uploadsService, uploadPurger, jobsService, jobWorker, transformsService, placeholderBackfill, hashBackfill, objectStore, signer)
 	return app, nil
 }
 
 // wire.go:
 
-var DatabaseSet = wire.NewSet(postgresql.NewDatabase, postgresql.NewImageRepository, postgresql.NewUserRepo, wire.Bind(new(storage.Storage), new(*postgresql.Database)), wire.Bind(new(storage.ImagesRepository), new(*postgresql.ImageRepo)), wire.Bind(new(storage.UserRepository), new(*postgresql.UserRepo)))
+var DatabaseSet = wire.NewSet(postgresql.NewDatabase, postgresql.NewImageRepository, postgresql.NewUserRepo, postgresql.NewTagRepository, postgresql.NewPendingOperationRepository, postgresql.NewImageRevisionRepository, postgresql.NewUploadRepository, postgresql.NewUploadIntentRepository, postgresql.NewJobRepository, wire.Bind(new(storage.Storage), new(*postgresql.Database)), wire.Bind(new(storage.ImagesRepository), new(*postgresql.ImageRepo)), wire.Bind(new(storage.UserRepository), new(*postgresql.UserRepo)), wire.Bind(new(storage.TagRepository), new(*postgresql.TagRepo)), wire.Bind(new(storage.PendingOperationRepository), new(*postgresql.PendingOperationRepo)), wire.Bind(new(storage.ImageRevisionRepository), new(*postgresql.ImageRevisionRepo)), wire.Bind(new(storage.UploadRepository), new(*postgresql.UploadRepo)), wire.Bind(new(storage.UploadIntentRepository), new(*postgresql.UploadIntentRepo)), wire.Bind(new(storage.JobRepository), new(*postgresql.JobRepo)))